@@ -10,13 +10,18 @@ type AgentSpec struct {
 	// +kubebuilder:validation:Required
 	LLMRef LocalObjectReference `json:"llmRef"`
 
+	// RouterRef references a ModelRouter that picks the LLM per turn.
+	// LLMRef is used for turns no route matches.
+	// +optional
+	RouterRef *LocalObjectReference `json:"routerRef,omitempty"`
+
 	// Tools is a list of tools this agent can use
 	// +optional
 	Tools []LocalObjectReference `json:"tools,omitempty"`
 
 	// MCPServers is a list of MCP servers this agent can use
 	// +optional
-	MCPServers []LocalObjectReference `json:"mcpServers,omitempty"`
+	MCPServers []AgentMCPServer `json:"mcpServers,omitempty"`
 
 	// System is the system prompt for the agent
 	// +kubebuilder:validation:Required
@@ -24,6 +29,25 @@ type AgentSpec struct {
 	System string `json:"system"`
 }
 
+// AgentMCPServer references an MCP server and narrows down which of its
+// tools the agent may use
+type AgentMCPServer struct {
+	// Name of the MCPServer
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// IncludeTools limits the agent to the tools matching one of these names
+	// or glob patterns (e.g. "get_*"). All tools are included if empty.
+	// +optional
+	IncludeTools []string `json:"includeTools,omitempty"`
+
+	// ExcludeTools hides the tools matching one of these names or glob
+	// patterns. Exclusions win over inclusions.
+	// +optional
+	ExcludeTools []string `json:"excludeTools,omitempty"`
+}
+
 // LocalObjectReference contains enough information to locate the referenced resource in the same namespace
 type LocalObjectReference struct {
 	// Name of the referent
@@ -51,6 +75,10 @@ type AgentStatus struct {
 	// ValidMCPServers is the list of MCP servers that were successfully validated
 	// +optional
 	ValidMCPServers []ResolvedMCPServer `json:"validMCPServers,omitempty"`
+
+	// CostSummary aggregates the token usage and estimated cost of all TaskRuns of this Agent
+	// +optional
+	CostSummary *UsageSummary `json:"costSummary,omitempty"`
 }
 
 type ResolvedTool struct {
@@ -78,6 +106,7 @@ type ResolvedMCPServer struct {
 // +kubebuilder:printcolumn:name="Ready",type="boolean",JSONPath=".status.ready"
 // +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.status"
 // +kubebuilder:printcolumn:name="Detail",type="string",JSONPath=".status.statusDetail",priority=1
+// +kubebuilder:printcolumn:name="Cost",type="string",JSONPath=".status.costSummary.estimatedCost",priority=1
 // +kubebuilder:resource:scope=Namespaced
 
 // Agent is the Schema for the agents API
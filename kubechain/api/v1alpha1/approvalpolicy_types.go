@@ -0,0 +1,104 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ApprovalAction is what an approval policy decides for a tool call
+// +kubebuilder:validation:Enum=Approve;Deny;RequireApproval
+type ApprovalAction string
+
+const (
+	// ApprovalActionApprove runs the tool call without asking anyone
+	ApprovalActionApprove ApprovalAction = "Approve"
+	// ApprovalActionDeny rejects the tool call without asking anyone
+	ApprovalActionDeny ApprovalAction = "Deny"
+	// ApprovalActionRequireApproval runs the tool call once a human approved it
+	ApprovalActionRequireApproval ApprovalAction = "RequireApproval"
+)
+
+// ApprovalPolicy decides which tool calls a human has to approve. Its rules
+// are tried in order, the first that matches a call decides.
+type ApprovalPolicy struct {
+	// Rules are tried in order, the first that matches a call decides
+	// +kubebuilder:validation:MinItems=1
+	Rules []ApprovalRule `json:"rules"`
+
+	// DefaultAction applies to calls no rule matches. Defaults to
+	// RequireApproval on MCPServers and Tools with an approvalContactChannel,
+	// and to Approve otherwise.
+	// +optional
+	DefaultAction ApprovalAction `json:"defaultAction,omitempty"`
+
+	// Timeouts bound how long calls wait for approval. They replace the
+	// approvalTimeouts of the contact channel.
+	// +optional
+	Timeouts *ApprovalTimeouts `json:"timeouts,omitempty"`
+}
+
+// ApprovalRule matches tool calls by tool name and arguments
+type ApprovalRule struct {
+	// Tools are glob patterns of the tool names the rule matches, e.g.
+	// "read_*". A rule without tools matches all tools.
+	// +optional
+	Tools []string `json:"tools,omitempty"`
+
+	// Condition is a CEL expression the call's arguments must satisfy, e.g.
+	// "args.path.startsWith('/prod')". It sees the arguments as args and the
+	// tool name as tool. A rule without a condition matches all arguments.
+	// +optional
+	Condition string `json:"condition,omitempty"`
+
+	// Action is what happens to the calls the rule matches
+	Action ApprovalAction `json:"action"`
+
+	// ContactChannel approves the calls the rule requires approval for.
+	// Defaults to the approvalContactChannel of the MCPServer or Tool.
+	// +optional
+	ContactChannel *LocalObjectReference `json:"contactChannel,omitempty"`
+}
+
+// ApprovalTimeoutAction is what happens to a call no one approved in time
+// +kubebuilder:validation:Enum=Reject;Approve;Fail
+type ApprovalTimeoutAction string
+
+const (
+	// ApprovalTimeoutActionReject rejects the call, the LLM is told so
+	ApprovalTimeoutActionReject ApprovalTimeoutAction = "Reject"
+	// ApprovalTimeoutActionApprove runs the call
+	ApprovalTimeoutActionApprove ApprovalTimeoutAction = "Approve"
+	// ApprovalTimeoutActionFail fails the call
+	ApprovalTimeoutActionFail ApprovalTimeoutAction = "Fail"
+)
+
+// ApprovalTimeouts bound how long a tool call waits for a human's approval.
+// All durations count from when approval was first requested.
+type ApprovalTimeouts struct {
+	// Timeout is how long a call waits for an answer before OnTimeout applies.
+	// Calls wait indefinitely without it.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// OnTimeout is what happens to a call no one answered within Timeout
+	// +kubebuilder:default=Reject
+	// +optional
+	OnTimeout ApprovalTimeoutAction `json:"onTimeout,omitempty"`
+
+	// ReminderInterval asks again this often while no one answered
+	// +optional
+	ReminderInterval *metav1.Duration `json:"reminderInterval,omitempty"`
+
+	// Escalation asks a secondary contact channel once no one answered for a while
+	// +optional
+	Escalation *ApprovalEscalation `json:"escalation,omitempty"`
+}
+
+// ApprovalEscalation asks a secondary contact channel for approval. An answer
+// on either channel counts.
+type ApprovalEscalation struct {
+	// After is how long to wait for an answer before escalating
+	After metav1.Duration `json:"after"`
+
+	// ContactChannel is the secondary contact channel
+	ContactChannel LocalObjectReference `json:"contactChannel"`
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2025 the Kubechain Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ApprovalRequestType is what an ApprovalRequest asks a human for
+// +kubebuilder:validation:Enum=FunctionCall;HumanContact
+type ApprovalRequestType string
+
+const (
+	// ApprovalRequestTypeFunctionCall asks to approve or reject a function call
+	ApprovalRequestTypeFunctionCall ApprovalRequestType = "FunctionCall"
+	// ApprovalRequestTypeHumanContact asks for a free-text response to a message
+	ApprovalRequestTypeHumanContact ApprovalRequestType = "HumanContact"
+)
+
+// ApprovalRequestPhase is where an ApprovalRequest stands
+// +kubebuilder:validation:Enum=Pending;Approved;Rejected;Responded
+type ApprovalRequestPhase string
+
+const (
+	ApprovalRequestPhasePending   ApprovalRequestPhase = "Pending"
+	ApprovalRequestPhaseApproved  ApprovalRequestPhase = "Approved"
+	ApprovalRequestPhaseRejected  ApprovalRequestPhase = "Rejected"
+	ApprovalRequestPhaseResponded ApprovalRequestPhase = "Responded"
+)
+
+// ApprovalRequestCallIDLabel holds the call ID of an ApprovalRequest, to
+// select requests by call ID with kubectl
+const ApprovalRequestCallIDLabel = "kubechain.humanlayer.dev/call-id"
+
+// ApprovalRequestSpec defines what a human is asked
+type ApprovalRequestSpec struct {
+	// Type is whether a function call is to be approved or a message answered
+	Type ApprovalRequestType `json:"type"`
+
+	// ContactChannelRef is the inCluster ContactChannel the request was sent to
+	ContactChannelRef LocalObjectReference `json:"contactChannelRef"`
+
+	// RunID is the TaskRunToolCall the request was made for
+	RunID string `json:"runID,omitempty"`
+
+	// CallID identifies the request
+	CallID string `json:"callID"`
+
+	// FunctionName is the function to approve, for FunctionCall requests
+	// +optional
+	FunctionName string `json:"functionName,omitempty"`
+
+	// Arguments are the JSON encoded arguments of the function to approve,
+	// for FunctionCall requests
+	// +optional
+	Arguments string `json:"arguments,omitempty"`
+
+	// Message is the message to respond to, for HumanContact requests
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// ApprovalRequestStatus holds the human's answer. Approvers set it with
+// kubectl --subresource=status or the approval API.
+type ApprovalRequestStatus struct {
+	// Phase is Pending until a human approved, rejected or responded
+	Phase ApprovalRequestPhase `json:"phase,omitempty"`
+
+	// Comment is the reason given for approving or rejecting a function call
+	// +optional
+	Comment string `json:"comment,omitempty"`
+
+	// Response is the human's answer to a HumanContact request
+	// +optional
+	Response string `json:"response,omitempty"`
+
+	// RequestedAt is when the request was made
+	// +optional
+	RequestedAt *metav1.Time `json:"requestedAt,omitempty"`
+
+	// RespondedAt is when a human answered
+	// +optional
+	RespondedAt *metav1.Time `json:"respondedAt,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Type",type="string",JSONPath=".spec.type"
+// +kubebuilder:printcolumn:name="Function",type="string",JSONPath=".spec.functionName"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="RunID",type="string",JSONPath=".spec.runID",priority=1
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:resource:scope=Namespaced
+
+// ApprovalRequest is the Schema for the approvalrequests API.
+// It is an approval or human contact sent to an inCluster ContactChannel.
+type ApprovalRequest struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ApprovalRequestSpec   `json:"spec,omitempty"`
+	Status ApprovalRequestStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ApprovalRequestList contains a list of ApprovalRequest
+type ApprovalRequestList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ApprovalRequest `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ApprovalRequest{}, &ApprovalRequestList{})
+}
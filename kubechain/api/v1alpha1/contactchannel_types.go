@@ -25,6 +25,16 @@ type ContactChannelType string
 const (
 	ContactChannelTypeSlack ContactChannelType = "slack"
 	ContactChannelTypeEmail ContactChannelType = "email"
+
+	// ContactChannelTypeSMS sends messages to a phone number via SMS
+	ContactChannelTypeSMS ContactChannelType = "sms"
+
+	// ContactChannelTypeWhatsApp sends messages to a phone number via WhatsApp
+	ContactChannelTypeWhatsApp ContactChannelType = "whatsapp"
+
+	// ContactChannelTypeInCluster keeps approvals and human contacts in the
+	// cluster as ApprovalRequests instead of sending them to HumanLayer
+	ContactChannelTypeInCluster ContactChannelType = "inCluster"
 )
 
 // SlackChannelConfig defines configuration specific to Slack channels
@@ -55,19 +65,42 @@ type EmailChannelConfig struct {
 	Subject string `json:"subject,omitempty"`
 }
 
+// SMSChannelConfig defines configuration specific to SMS channels
+type SMSChannelConfig struct {
+	// PhoneNumber is the recipient phone number in E.164 format, e.g. +14155552671
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^\+[1-9][0-9]{1,14}$`
+	PhoneNumber string `json:"phoneNumber"`
+
+	// ContextAboutUser provides context for the LLM about the recipient
+	ContextAboutUser string `json:"contextAboutUser,omitempty"`
+}
+
+// WhatsAppChannelConfig defines configuration specific to WhatsApp channels
+type WhatsAppChannelConfig struct {
+	// PhoneNumber is the recipient phone number in E.164 format, e.g. +14155552671
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^\+[1-9][0-9]{1,14}$`
+	PhoneNumber string `json:"phoneNumber"`
+
+	// ContextAboutUser provides context for the LLM about the recipient
+	ContextAboutUser string `json:"contextAboutUser,omitempty"`
+}
+
 // ContactChannelSpec defines the desired state of ContactChannel.
 type ContactChannelSpec struct {
 	// Type is the type of channel (e.g. "slack", "email")
 	// TODO(4) - consider removing this, HumanLayer ContactChannel models don't include it
 
-	// Type is the type of channel (e.g. "slack", "email")
+	// Type is the type of channel (e.g. "slack", "email", "sms", "whatsapp", "inCluster")
 	// +kubebuilder:validation:Required
-	// +kubebuilder:validation:Enum=slack;email
+	// +kubebuilder:validation:Enum=slack;email;sms;whatsapp;inCluster
 	Type ContactChannelType `json:"type"`
 
-	// APIKeyFrom references the secret containing the API key or token
-	// +kubebuilder:validation:Required
-	APIKeyFrom APIKeySource `json:"apiKeyFrom"`
+	// APIKeyFrom references the secret containing the HumanLayer API key,
+	// required for all channel types but inCluster
+	// +optional
+	APIKeyFrom *APIKeySource `json:"apiKeyFrom,omitempty"`
 
 	// Slack holds configuration specific to Slack channels
 	// +optional
@@ -76,6 +109,19 @@ type ContactChannelSpec struct {
 	// Email holds configuration specific to Email channels
 	// +optional
 	Email *EmailChannelConfig `json:"email,omitempty"`
+
+	// SMS holds configuration specific to SMS channels
+	// +optional
+	SMS *SMSChannelConfig `json:"sms,omitempty"`
+
+	// WhatsApp holds configuration specific to WhatsApp channels
+	// +optional
+	WhatsApp *WhatsAppChannelConfig `json:"whatsapp,omitempty"`
+
+	// ApprovalTimeouts bound how long tool calls wait for approval via the
+	// channel, unless the approval policy has timeouts of its own
+	// +optional
+	ApprovalTimeouts *ApprovalTimeouts `json:"approvalTimeouts,omitempty"`
 }
 
 // ContactChannelStatus defines the observed state of ContactChannel.
@@ -68,6 +68,19 @@ type BaseConfig struct {
 	// PresencePenalty reduces repetition by penalizing tokens that appear at all
 	// +kubebuilder:validation:Pattern=^-?[0-2](\.[0-9]+)?$
 	PresencePenalty string `json:"presencePenalty,omitempty"`
+
+	// Reasoning enables extended thinking for models that support it
+	// +optional
+	Reasoning *ReasoningConfig `json:"reasoning,omitempty"`
+}
+
+// ReasoningConfig configures the model's reasoning ("thinking") before it answers
+type ReasoningConfig struct {
+	// BudgetTokens is the maximum number of tokens the model may spend on
+	// reasoning per turn. Currently honored by the anthropic provider.
+	// +kubebuilder:validation:Minimum=1024
+	// +kubebuilder:validation:Required
+	BudgetTokens int `json:"budgetTokens"`
 }
 
 // OpenAIConfig for OpenAI-specific options
@@ -170,6 +183,28 @@ type LLMSpec struct {
 	// Google provider-specific configuration
 	// +optional
 	Google *GoogleConfig `json:"google,omitempty"`
+
+	// HealthCheck configures the periodic health probe of the provider
+	// +optional
+	HealthCheck *LLMHealthCheckSpec `json:"healthCheck,omitempty"`
+}
+
+// LLMHealthCheckSpec configures the periodic health probe of an LLM provider.
+// Where the provider offers a models list endpoint it is used for the probe,
+// otherwise a 1-token completion is sent.
+type LLMHealthCheckSpec struct {
+	// Interval between health probes. Defaults to 5m.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// Timeout for a single probe. Defaults to 30s.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// DryRun skips all calls to the provider. Only the configuration and the
+	// credentials secret are validated, and the LLM is reported ready.
+	// +optional
+	DryRun bool `json:"dryRun,omitempty"`
 }
 
 // LLMStatus defines the observed state of LLM
@@ -183,6 +218,50 @@ type LLMStatus struct {
 
 	// StatusDetail provides additional details about the current status
 	StatusDetail string `json:"statusDetail,omitempty"`
+
+	// Conditions hold the latest health observations of the LLM:
+	// CredentialsValid, Reachable and ModelAvailable
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// LastProbeTime is when the provider was last probed
+	// +optional
+	LastProbeTime *metav1.Time `json:"lastProbeTime,omitempty"`
+
+	// ConsecutiveFailures counts the failed probes since the last successful one
+	// +optional
+	ConsecutiveFailures int `json:"consecutiveFailures,omitempty"`
+
+	// Latency holds statistics about the probe round trips
+	// +optional
+	Latency *LLMLatencyStats `json:"latency,omitempty"`
+}
+
+// LLM condition types
+const (
+	// LLMConditionCredentialsValid indicates the provider accepted the configured credentials
+	LLMConditionCredentialsValid = "CredentialsValid"
+	// LLMConditionReachable indicates the provider API could be reached
+	LLMConditionReachable = "Reachable"
+	// LLMConditionModelAvailable indicates the configured model is offered by the provider
+	LLMConditionModelAvailable = "ModelAvailable"
+)
+
+// LLMLatencyStats summarizes the latency of health probes
+type LLMLatencyStats struct {
+	// LastMillis is the latency of the most recent probe in milliseconds
+	LastMillis int64 `json:"lastMillis"`
+
+	// AverageMillis is the mean probe latency in milliseconds
+	AverageMillis int64 `json:"averageMillis"`
+
+	// MaxMillis is the highest observed probe latency in milliseconds
+	MaxMillis int64 `json:"maxMillis"`
+
+	// Samples is the number of probes the statistics are based on
+	Samples int64 `json:"samples"`
 }
 
 // +kubebuilder:object:root=true
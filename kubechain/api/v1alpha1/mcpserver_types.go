@@ -1,6 +1,7 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -8,8 +9,9 @@ import (
 
 // MCPServerSpec defines the desired state of MCPServer
 type MCPServerSpec struct {
-	// Transport specifies the transport type for the MCP server
-	// +kubebuilder:validation:Enum=stdio;http
+	// Transport specifies the transport type for the MCP server: stdio,
+	// http for the SSE transport, or streamable-http
+	// +kubebuilder:validation:Enum=stdio;http;streamable-http
 	// +kubebuilder:validation:Required
 	Transport string `json:"transport"`
 
@@ -29,6 +31,18 @@ type MCPServerSpec struct {
 	// +optional
 	URL string `json:"url,omitempty"`
 
+	// Headers are added to every request to HTTP MCP servers
+	// +optional
+	Headers []HTTPHeader `json:"headers,omitempty"`
+
+	// Auth configures how the operator authenticates to HTTP MCP servers
+	// +optional
+	Auth *MCPServerAuth `json:"auth,omitempty"`
+
+	// TLS configures the TLS connection to HTTP MCP servers
+	// +optional
+	TLS *MCPServerTLS `json:"tls,omitempty"`
+
 	// ResourceRequirements defines CPU/Memory resources requests/limits
 	// +optional
 	Resources ResourceRequirements `json:"resources,omitempty"`
@@ -36,6 +50,146 @@ type MCPServerSpec struct {
 	// ApprovalContactChannel is the contact channel for approval
 	// +optional
 	ApprovalContactChannel *LocalObjectReference `json:"approvalContactChannel,omitempty"`
+
+	// ApprovalPolicy decides per tool call whether it runs, is rejected, or
+	// waits for a human's approval. Without a policy every call waits for
+	// ApprovalContactChannel, if there is one.
+	// +optional
+	ApprovalPolicy *ApprovalPolicy `json:"approvalPolicy,omitempty"`
+
+	// ElicitationContactChannel is asked for the input the server requests
+	// while it runs a tool call. Defaults to ApprovalContactChannel, servers
+	// with neither can't elicit input.
+	// +optional
+	ElicitationContactChannel *LocalObjectReference `json:"elicitationContactChannel,omitempty"`
+
+	// Deployment runs a stdio MCP server in its own Deployment instead of as a
+	// subprocess of the operator. Command, Args, Env and Resources apply to the
+	// server container.
+	// +optional
+	Deployment *MCPServerDeployment `json:"deployment,omitempty"`
+
+	// Sampling lets the server request LLM completions while it runs a tool
+	// call, they are answered by the LLM of the calling Agent
+	// +optional
+	Sampling *MCPServerSampling `json:"sampling,omitempty"`
+}
+
+// ElicitationChannel is the contact channel asked for the input an MCP server
+// elicits, if any
+func (s *MCPServerSpec) ElicitationChannel() *LocalObjectReference {
+	if s.ElicitationContactChannel != nil {
+		return s.ElicitationContactChannel
+	}
+	return s.ApprovalContactChannel
+}
+
+// MCPServerSampling configures the sampling requests an MCP server may make
+type MCPServerSampling struct {
+	// MaxTokens caps the tokens of each completion, lower requests are kept
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxTokens *int `json:"maxTokens,omitempty"`
+
+	// ApprovalContactChannel is asked to approve each completion before the
+	// LLM is called
+	// +optional
+	ApprovalContactChannel *LocalObjectReference `json:"approvalContactChannel,omitempty"`
+}
+
+// MCPServerDeployment configures the Deployment of a stdio MCP server
+type MCPServerDeployment struct {
+	// Image is the container image that provides the server's command
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Image string `json:"image"`
+
+	// ImagePullPolicy of the server container
+	// +kubebuilder:validation:Enum=Always;Never;IfNotPresent
+	// +optional
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+
+	// SecurityContext of the server container
+	// +optional
+	SecurityContext *corev1.SecurityContext `json:"securityContext,omitempty"`
+
+	// PodSecurityContext of the server Pod
+	// +optional
+	PodSecurityContext *corev1.PodSecurityContext `json:"podSecurityContext,omitempty"`
+
+	// ServiceAccountName is the service account the server Pod runs as
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+}
+
+// HTTPHeader is a header sent to an HTTP MCP server
+type HTTPHeader struct {
+	// Name of the header
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Value of the header (direct literal value)
+	// +optional
+	Value string `json:"value,omitempty"`
+
+	// ValueFrom represents a source for the value of the header
+	// +optional
+	ValueFrom *EnvVarSource `json:"valueFrom,omitempty"`
+}
+
+// MCPServerAuth configures authentication to an HTTP MCP server.
+// At most one of BearerTokenFrom and OAuth may be set.
+type MCPServerAuth struct {
+	// BearerTokenFrom is a secret key holding a static bearer token
+	// +optional
+	BearerTokenFrom *SecretKeySelector `json:"bearerTokenFrom,omitempty"`
+
+	// OAuth acquires bearer tokens with the OAuth client credentials flow
+	// +optional
+	OAuth *OAuthClientCredentials `json:"oauth,omitempty"`
+}
+
+// OAuthClientCredentials configures the OAuth 2.0 client credentials flow
+type OAuthClientCredentials struct {
+	// TokenURL is the token endpoint of the authorization server
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	TokenURL string `json:"tokenURL"`
+
+	// ClientID identifies the operator to the authorization server
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	ClientID string `json:"clientID"`
+
+	// ClientSecretFrom is a secret key holding the client secret
+	// +kubebuilder:validation:Required
+	ClientSecretFrom SecretKeySelector `json:"clientSecretFrom"`
+
+	// Scopes requested for the token
+	// +optional
+	Scopes []string `json:"scopes,omitempty"`
+
+	// Audience is sent as the audience parameter, as some authorization servers require
+	// +optional
+	Audience string `json:"audience,omitempty"`
+}
+
+// MCPServerTLS configures TLS for an HTTP MCP server
+type MCPServerTLS struct {
+	// CABundleFrom is a secret key holding PEM encoded CA certificates to
+	// trust in addition to the system roots
+	// +optional
+	CABundleFrom *SecretKeySelector `json:"caBundleFrom,omitempty"`
+
+	// ClientCertificateSecret is a kubernetes.io/tls Secret whose tls.crt and
+	// tls.key are presented as the client certificate for mTLS
+	// +optional
+	ClientCertificateSecret *LocalObjectReference `json:"clientCertificateSecret,omitempty"`
+
+	// InsecureSkipVerify disables server certificate verification
+	// +optional
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
 }
 
 // EnvVar represents an environment variable
@@ -102,6 +256,55 @@ type MCPTool struct {
 	InputSchema runtime.RawExtension `json:"inputSchema,omitempty"`
 }
 
+// MCPResource describes a resource provided by an MCP server
+type MCPResource struct {
+	// URI of the resource
+	// +kubebuilder:validation:Required
+	URI string `json:"uri"`
+
+	// Name of the resource
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Description of the resource
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// MimeType of the resource, if known
+	// +optional
+	MimeType string `json:"mimeType,omitempty"`
+}
+
+// MCPPrompt describes a prompt template provided by an MCP server
+type MCPPrompt struct {
+	// Name of the prompt
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Description of the prompt
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// Arguments the prompt is templated with
+	// +optional
+	Arguments []MCPPromptArgument `json:"arguments,omitempty"`
+}
+
+// MCPPromptArgument describes an argument of an MCP prompt
+type MCPPromptArgument struct {
+	// Name of the argument
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Description of the argument
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// Required is whether the argument must be provided
+	// +optional
+	Required bool `json:"required,omitempty"`
+}
+
 // MCPServerStatus defines the observed state of MCPServer
 type MCPServerStatus struct {
 	// Connected indicates if the MCP server is currently connected and operational
@@ -117,12 +320,43 @@ type MCPServerStatus struct {
 	// Tools is the list of tools provided by this MCP server
 	// +optional
 	Tools []MCPTool `json:"tools,omitempty"`
+
+	// Resources is the list of resources provided by this MCP server
+	// +optional
+	Resources []MCPResource `json:"resources,omitempty"`
+
+	// Prompts is the list of prompts provided by this MCP server
+	// +optional
+	Prompts []MCPPrompt `json:"prompts,omitempty"`
+
+	// RestartCount is how many times the connection was lost and re-established
+	// +optional
+	RestartCount int32 `json:"restartCount,omitempty"`
+
+	// LastFailure is why the connection last failed
+	// +optional
+	LastFailure string `json:"lastFailure,omitempty"`
+
+	// LastFailureTime is when the connection last failed
+	// +optional
+	LastFailureTime *metav1.Time `json:"lastFailureTime,omitempty"`
+
+	// ConsecutiveFailures counts the failed health checks and connection
+	// attempts since the server was last healthy, it drives the reconnect backoff
+	// +optional
+	ConsecutiveFailures int32 `json:"consecutiveFailures,omitempty"`
+
+	// FailedConfigHash identifies the spec and secrets ConsecutiveFailures
+	// were counted for. Changing them resets the backoff.
+	// +optional
+	FailedConfigHash string `json:"failedConfigHash,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="Connected",type="boolean",JSONPath=".status.connected"
 // +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.status"
+// +kubebuilder:printcolumn:name="Restarts",type="integer",JSONPath=".status.restartCount"
 // +kubebuilder:printcolumn:name="Detail",type="string",JSONPath=".status.statusDetail",priority=1
 // +kubebuilder:resource:scope=Namespaced
 
@@ -0,0 +1,121 @@
+/*
+Copyright 2025 the Kubechain Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ModelRouteMatch describes the conditions under which a route applies.
+// All conditions that are set must hold; an empty match always applies.
+type ModelRouteMatch struct {
+	// MinContextTokens matches turns whose estimated context window size
+	// is at least this many tokens
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinContextTokens *int64 `json:"minContextTokens,omitempty"`
+
+	// MaxContextTokens matches turns whose estimated context window size
+	// is at most this many tokens
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxContextTokens *int64 `json:"maxContextTokens,omitempty"`
+
+	// HasTools matches on whether any tools are offered to the LLM in this turn
+	// +optional
+	HasTools *bool `json:"hasTools,omitempty"`
+
+	// Tiers matches the tier declared by the Task
+	// +optional
+	Tiers []string `json:"tiers,omitempty"`
+
+	// ClassifierLabels matches the label picked by the router's classifier.
+	// The classifier is only called when a route with this condition is evaluated.
+	// +optional
+	ClassifierLabels []string `json:"classifierLabels,omitempty"`
+}
+
+// ModelRoute sends matching turns to an LLM
+type ModelRoute struct {
+	// Name identifies the route in events and logs
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// LLMRef references the LLM to use when the route matches
+	// +kubebuilder:validation:Required
+	LLMRef LocalObjectReference `json:"llmRef"`
+
+	// Match holds the conditions of the route
+	// +optional
+	Match ModelRouteMatch `json:"match,omitempty"`
+}
+
+// ModelRouterClassifier configures a cheap LLM call that labels a turn
+// before routes are evaluated
+type ModelRouterClassifier struct {
+	// LLMRef references the LLM used for classification
+	// +kubebuilder:validation:Required
+	LLMRef LocalObjectReference `json:"llmRef"`
+
+	// Labels the classifier chooses from
+	// +kubebuilder:validation:MinItems=1
+	Labels []string `json:"labels"`
+
+	// Instructions replace the default classification prompt. The list of
+	// labels is always appended.
+	// +optional
+	Instructions string `json:"instructions,omitempty"`
+}
+
+// ModelRouterSpec defines the desired state of ModelRouter
+type ModelRouterSpec struct {
+	// Routes are evaluated in order for every LLM turn and the first matching
+	// route picks the LLM. If no route matches, the Agent's LLMRef is used.
+	// +kubebuilder:validation:MinItems=1
+	Routes []ModelRoute `json:"routes"`
+
+	// Classifier configures the classifier used by ClassifierLabels matches
+	// +optional
+	Classifier *ModelRouterClassifier `json:"classifier,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Namespaced
+
+// ModelRouter is the Schema for the modelrouters API.
+// Agents reference a ModelRouter to pick an LLM per turn instead of always
+// using their LLMRef.
+type ModelRouter struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ModelRouterSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ModelRouterList contains a list of ModelRouter
+type ModelRouterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ModelRouter `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ModelRouter{}, &ModelRouterList{})
+}
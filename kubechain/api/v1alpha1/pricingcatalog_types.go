@@ -0,0 +1,84 @@
+/*
+Copyright 2025 the Kubechain Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ModelPrice defines the token prices of a provider's model
+type ModelPrice struct {
+	// Provider is the LLM provider the price applies to
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=openai;anthropic;mistral;google;vertex
+	Provider string `json:"provider"`
+
+	// Model is the model name. Glob patterns such as "gpt-4o*" or "*" are
+	// allowed; an exact match always wins over a pattern.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Model string `json:"model"`
+
+	// InputPerMillion is the price of one million input (prompt) tokens
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+	InputPerMillion string `json:"inputPerMillion"`
+
+	// OutputPerMillion is the price of one million output (completion) tokens
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+	OutputPerMillion string `json:"outputPerMillion"`
+}
+
+// PricingCatalogSpec defines the desired state of PricingCatalog
+type PricingCatalogSpec struct {
+	// Currency of all prices in this catalog
+	// +kubebuilder:default=USD
+	// +optional
+	Currency string `json:"currency,omitempty"`
+
+	// Prices lists the token prices per provider and model
+	// +kubebuilder:validation:MinItems=1
+	Prices []ModelPrice `json:"prices"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Currency",type="string",JSONPath=".spec.currency"
+// +kubebuilder:resource:scope=Namespaced
+
+// PricingCatalog is the Schema for the pricingcatalogs API.
+// The TaskRun controller uses the catalogs in a TaskRun's namespace
+// to estimate the cost of each LLM turn.
+type PricingCatalog struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec PricingCatalogSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PricingCatalogList contains a list of PricingCatalog
+type PricingCatalogList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PricingCatalog `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PricingCatalog{}, &PricingCatalogList{})
+}
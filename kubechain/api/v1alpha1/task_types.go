@@ -18,20 +18,65 @@ type TaskSpec struct {
 	// +kubebuilder:validation:Required
 	AgentRef LocalObjectReference `json:"agentRef"`
 
-	// Message is the input prompt or request for the task
-	// +kubebuilder:validation:Required
-	// +kubebuilder:validation:MinLength=1
-	Message string `json:"message"`
+	// Message is the input prompt or request for the task.
+	// Required unless the message comes from an MCP prompt.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Prompt renders the task's messages from an MCP prompt template
+	// instead of Message
+	// +optional
+	Prompt *MCPPromptRef `json:"prompt,omitempty"`
+
+	// Resources are MCP resources read when a TaskRun starts and given to
+	// the agent as context
+	// +optional
+	Resources []MCPResourceRef `json:"resources,omitempty"`
 
 	// Goal is the goal of the task
 	// +optional
 	Goal string `json:"goal,omitempty"`
 
+	// Tier declares how demanding the task is, e.g. "simple" or "complex".
+	// ModelRouters can route on it.
+	// +optional
+	Tier string `json:"tier,omitempty"`
+
 	// EverythingThatHappenedSoFar is a list of all the things that have happened so far
 	// +optional
 	EverythingThatHappenedSoFar []string `json:"everythingThatHappenedSoFar,omitempty"`
 }
 
+// MCPPromptRef references a prompt of an MCP server
+type MCPPromptRef struct {
+	// MCPServer is the name of the MCPServer providing the prompt
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	MCPServer string `json:"mcpServer"`
+
+	// Name of the prompt
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Arguments to render the prompt with
+	// +optional
+	Arguments map[string]string `json:"arguments,omitempty"`
+}
+
+// MCPResourceRef references a resource of an MCP server
+type MCPResourceRef struct {
+	// MCPServer is the name of the MCPServer providing the resource
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	MCPServer string `json:"mcpServer"`
+
+	// URI of the resource
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	URI string `json:"uri"`
+}
+
 // TaskStatus defines the observed state of Task
 type TaskStatus struct {
 	// Ready indicates if the task is ready to be executed
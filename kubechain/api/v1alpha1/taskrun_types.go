@@ -45,6 +45,125 @@ type Message struct {
 	// Name is the name of the tool that was called
 	// +optional
 	Name string `json:"name,omitempty"`
+
+	// Reasoning holds the model's reasoning behind this message. It is kept
+	// apart from Content and never shown as part of the answer. Only
+	// Anthropic thinking blocks are passed back to the provider.
+	// +optional
+	Reasoning []ReasoningBlock `json:"reasoning,omitempty"`
+
+	// LLM is the name of the LLM resource that produced this message
+	// +optional
+	LLM string `json:"llm,omitempty"`
+
+	// Model is the model that produced this message
+	// +optional
+	Model string `json:"model,omitempty"`
+
+	// Usage holds the token usage and estimated cost of the LLM turn that produced this message
+	// +optional
+	Usage *TokenUsage `json:"usage,omitempty"`
+
+	// Attachments are the non-text contents of a tool result
+	// +optional
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// Attachment is a non-text content of a tool result, like an image. Its data
+// is kept in a ConfigMap rather than in the status.
+type Attachment struct {
+	// Type is the kind of content
+	// +kubebuilder:validation:Enum=image;audio;resource
+	// +kubebuilder:validation:Required
+	Type string `json:"type"`
+
+	// MimeType of the data
+	// +optional
+	MimeType string `json:"mimeType,omitempty"`
+
+	// URI of an embedded resource
+	// +optional
+	URI string `json:"uri,omitempty"`
+
+	// Size of the data in bytes
+	// +optional
+	Size int64 `json:"size,omitempty"`
+
+	// DataFrom references the ConfigMap key holding the data. It is unset
+	// when the data was too large to keep.
+	// +optional
+	DataFrom *ConfigMapKeySelector `json:"dataFrom,omitempty"`
+}
+
+// ConfigMapKeySelector selects a key of a ConfigMap
+type ConfigMapKeySelector struct {
+	// Name of the ConfigMap
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Key within the ConfigMap
+	// +kubebuilder:validation:Required
+	Key string `json:"key"`
+}
+
+// ReasoningBlock is a piece of a model's reasoning as returned by the provider
+type ReasoningBlock struct {
+	// Type is the provider's kind of block, e.g. thinking or redacted_thinking
+	// +kubebuilder:validation:Required
+	Type string `json:"type"`
+
+	// Text is the reasoning text, empty for redacted blocks
+	// +optional
+	Text string `json:"text,omitempty"`
+
+	// Signature lets the provider verify the block when it is passed back on a later turn
+	// +optional
+	Signature string `json:"signature,omitempty"`
+
+	// Data holds the encrypted reasoning of redacted blocks
+	// +optional
+	Data string `json:"data,omitempty"`
+}
+
+// TokenUsage holds the token usage of a single LLM turn
+type TokenUsage struct {
+	// InputTokens is the number of prompt tokens sent to the LLM
+	InputTokens int64 `json:"inputTokens"`
+
+	// OutputTokens is the number of tokens generated by the LLM
+	OutputTokens int64 `json:"outputTokens"`
+
+	// ReasoningTokens is the part of OutputTokens spent on reasoning, if the provider reports it
+	// +optional
+	ReasoningTokens int64 `json:"reasoningTokens,omitempty"`
+
+	// EstimatedCost is the estimated cost of the turn, empty if no price is known
+	// +optional
+	EstimatedCost string `json:"estimatedCost,omitempty"`
+}
+
+// UsageSummary aggregates token usage and estimated cost over several LLM turns
+type UsageSummary struct {
+	// Turns is the number of LLM turns included in the summary
+	Turns int64 `json:"turns"`
+
+	// InputTokens is the total number of prompt tokens
+	InputTokens int64 `json:"inputTokens"`
+
+	// OutputTokens is the total number of generated tokens
+	OutputTokens int64 `json:"outputTokens"`
+
+	// EstimatedCost is the total estimated cost of the priced turns
+	// +optional
+	EstimatedCost string `json:"estimatedCost,omitempty"`
+
+	// Currency of EstimatedCost
+	// +optional
+	Currency string `json:"currency,omitempty"`
+
+	// UnpricedTurns counts the turns for which no price was found in any PricingCatalog
+	// +optional
+	UnpricedTurns int64 `json:"unpricedTurns,omitempty"`
 }
 
 // ToolCall represents a request to call a tool
@@ -129,6 +248,10 @@ type TaskRunStatus struct {
 	// ToolCallRequestID uniquely identifies a set of tool calls from a single LLM response
 	// +optional
 	ToolCallRequestID string `json:"toolCallRequestId,omitempty"`
+
+	// Usage is the running token usage and estimated cost of this TaskRun
+	// +optional
+	Usage *UsageSummary `json:"usage,omitempty"`
 }
 
 type TaskRunStatusStatus string
@@ -176,6 +299,7 @@ const (
 // +kubebuilder:printcolumn:name="Error",type="string",JSONPath=".status.error",priority=1
 // +kubebuilder:printcolumn:name="Started",type="date",JSONPath=".status.startTime",priority=1
 // +kubebuilder:printcolumn:name="Completed",type="date",JSONPath=".status.completionTime",priority=1
+// +kubebuilder:printcolumn:name="Cost",type="string",JSONPath=".status.usage.estimatedCost",priority=1
 // +kubebuilder:resource:scope=Namespaced
 
 // TaskRun is the Schema for the taskruns API
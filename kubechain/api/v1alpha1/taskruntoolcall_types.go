@@ -52,10 +52,23 @@ type TaskRunToolCallStatus struct {
 	// ExternalCallID is the unique identifier for this function call in external services
 	ExternalCallID string `json:"externalCallID"`
 
+	// Approval tracks the human approval the tool call waits or waited for
+	// +optional
+	Approval *ToolCallApproval `json:"approval,omitempty"`
+
 	// Result contains the result of the tool call if completed
 	// +optional
 	Result string `json:"result,omitempty"`
 
+	// Attachments are the non-text contents of the result, like images
+	// +optional
+	Attachments []Attachment `json:"attachments,omitempty"`
+
+	// Progress is the latest progress an MCP server reported while running
+	// the tool
+	// +optional
+	Progress *ToolCallProgress `json:"progress,omitempty"`
+
 	// Error message if the tool call failed
 	// +optional
 	Error string `json:"error,omitempty"`
@@ -73,6 +86,68 @@ type TaskRunToolCallStatus struct {
 	SpanContext *SpanContext `json:"spanContext,omitempty"`
 }
 
+// ApprovalStage is how far a request for approval has gone
+// +kubebuilder:validation:Enum=Requested;Reminded;Escalated;Answered;TimedOut
+type ApprovalStage string
+
+const (
+	// ApprovalStageRequested waits for the first answer
+	ApprovalStageRequested ApprovalStage = "Requested"
+	// ApprovalStageReminded asked again after no one answered
+	ApprovalStageReminded ApprovalStage = "Reminded"
+	// ApprovalStageEscalated asked the escalation contact channel
+	ApprovalStageEscalated ApprovalStage = "Escalated"
+	// ApprovalStageAnswered got an answer from a human
+	ApprovalStageAnswered ApprovalStage = "Answered"
+	// ApprovalStageTimedOut got no answer in time
+	ApprovalStageTimedOut ApprovalStage = "TimedOut"
+)
+
+// ToolCallApproval tracks a request for human approval of a tool call
+type ToolCallApproval struct {
+	// Stage is how far the request has gone
+	Stage ApprovalStage `json:"stage"`
+
+	// RequestedAt is when approval was first requested
+	RequestedAt metav1.Time `json:"requestedAt"`
+
+	// Reminders is how many times approval was asked for again
+	// +optional
+	Reminders int32 `json:"reminders,omitempty"`
+
+	// Calls are the HumanLayer calls that ask for approval, oldest first.
+	// An answer to any of them counts.
+	Calls []ApprovalCall `json:"calls"`
+}
+
+// ApprovalCall is a HumanLayer call that asks a contact channel for approval
+type ApprovalCall struct {
+	// CallID identifies the call
+	CallID string `json:"callID"`
+
+	// ContactChannel is the contact channel asked
+	ContactChannel string `json:"contactChannel"`
+
+	// RequestedAt is when the call was made
+	RequestedAt metav1.Time `json:"requestedAt"`
+}
+
+// ToolCallProgress is the progress of a running tool call
+type ToolCallProgress struct {
+	// Percentage is how much of the work is done, if the server knows the total
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	Percentage *int32 `json:"percentage,omitempty"`
+
+	// Message describes what the tool is doing
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// LastUpdateTime is when the server last reported progress
+	LastUpdateTime metav1.Time `json:"lastUpdateTime"`
+}
+
 // TaskRunToolCallPhase represents the phase of a TaskRunToolCall
 // +kubebuilder:validation:Enum=Pending;Running;Succeeded;Failed;AwaitingHumanInput;AwaitingSubAgent;AwaitingHumanApproval;ReadyToExecuteApprovedTool;ErrorRequestingHumanApproval;ToolCallRejected
 type TaskRunToolCallPhase string
@@ -105,6 +180,7 @@ const (
 // +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
 // +kubebuilder:printcolumn:name="TaskRun",type="string",JSONPath=".spec.taskRunRef.name"
 // +kubebuilder:printcolumn:name="Tool",type="string",JSONPath=".spec.toolRef.name"
+// +kubebuilder:printcolumn:name="Approval",type="string",JSONPath=".status.approval.stage",priority=1
 // +kubebuilder:printcolumn:name="Started",type="date",JSONPath=".status.startTime",priority=1
 // +kubebuilder:printcolumn:name="Completed",type="date",JSONPath=".status.completionTime",priority=1
 // +kubebuilder:printcolumn:name="Error",type="string",JSONPath=".status.error",priority=1
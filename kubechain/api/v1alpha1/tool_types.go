@@ -23,7 +23,7 @@ type ToolSpec struct {
 	Arguments runtime.RawExtension `json:"arguments,omitempty"`
 
 	// ToolType represents the type of tool; e.g. "function", "delegateToAgent", "externalAPI" etc.
-	// +kubebuilder:validation:Enum=function;delegateToAgent;externalAPI
+	// +kubebuilder:validation:Enum=function;delegateToAgent;externalAPI;humanContact
 	ToolType string `json:"toolType,omitempty"`
 
 	// Execute defines how the tool should be executed.
@@ -31,6 +31,17 @@ type ToolSpec struct {
 
 	// AgentRef is used for delegation-type tools.
 	AgentRef *AgentReference `json:"agentRef,omitempty"`
+
+	// ApprovalContactChannel approves calls of the tool. With it set, every
+	// call waits for a human's approval unless ApprovalPolicy decides
+	// otherwise.
+	// +optional
+	ApprovalContactChannel *LocalObjectReference `json:"approvalContactChannel,omitempty"`
+
+	// ApprovalPolicy decides per call whether the tool runs, is rejected, or
+	// waits for a human's approval
+	// +optional
+	ApprovalPolicy *ApprovalPolicy `json:"approvalPolicy,omitempty"`
 }
 
 type ToolExecute struct {
@@ -39,6 +50,9 @@ type ToolExecute struct {
 
 	// ExternalAPI represents an external API call
 	ExternalAPI *ExternalAPISpec `json:"externalAPI,omitempty"`
+
+	// HumanContact asks a human the LLM's question, their reply is the result
+	HumanContact *HumanContactToolSpec `json:"humanContact,omitempty"`
 }
 
 // NameReference contains a name reference to another resource
@@ -59,6 +73,12 @@ type BuiltinToolSpec struct {
 	Name string `json:"name,omitempty"`
 }
 
+// HumanContactToolSpec defines the parameters for asking a human.
+type HumanContactToolSpec struct {
+	// ContactChannel is where the question is sent
+	ContactChannel LocalObjectReference `json:"contactChannel"`
+}
+
 type ExternalAPISpec struct {
 	// URL for the API endpoint
 	URL string `json:"url,omitempty"`
@@ -66,7 +86,8 @@ type ExternalAPISpec struct {
 	// Method specifies the HTTP method to use (GET, POST, etc.)
 	Method string `json:"method,omitempty"`
 
-	// RequiresApproval indicates if this API call needs explicit approval
+	// RequiresApproval indicates if this API call needs explicit approval,
+	// via the Tool's approvalContactChannel
 	RequiresApproval bool `json:"requiresApproval,omitempty"`
 
 	// Credentials reference for API authentication
@@ -21,6 +21,8 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -99,6 +101,31 @@ func (in *AgentList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AgentMCPServer) DeepCopyInto(out *AgentMCPServer) {
+	*out = *in
+	if in.IncludeTools != nil {
+		in, out := &in.IncludeTools, &out.IncludeTools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludeTools != nil {
+		in, out := &in.ExcludeTools, &out.ExcludeTools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AgentMCPServer.
+func (in *AgentMCPServer) DeepCopy() *AgentMCPServer {
+	if in == nil {
+		return nil
+	}
+	out := new(AgentMCPServer)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AgentReference) DeepCopyInto(out *AgentReference) {
 	*out = *in
@@ -118,6 +145,11 @@ func (in *AgentReference) DeepCopy() *AgentReference {
 func (in *AgentSpec) DeepCopyInto(out *AgentSpec) {
 	*out = *in
 	out.LLMRef = in.LLMRef
+	if in.RouterRef != nil {
+		in, out := &in.RouterRef, &out.RouterRef
+		*out = new(LocalObjectReference)
+		**out = **in
+	}
 	if in.Tools != nil {
 		in, out := &in.Tools, &out.Tools
 		*out = make([]LocalObjectReference, len(*in))
@@ -125,8 +157,10 @@ func (in *AgentSpec) DeepCopyInto(out *AgentSpec) {
 	}
 	if in.MCPServers != nil {
 		in, out := &in.MCPServers, &out.MCPServers
-		*out = make([]LocalObjectReference, len(*in))
-		copy(*out, *in)
+		*out = make([]AgentMCPServer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
@@ -155,6 +189,11 @@ func (in *AgentStatus) DeepCopyInto(out *AgentStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.CostSummary != nil {
+		in, out := &in.CostSummary, &out.CostSummary
+		*out = new(UsageSummary)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AgentStatus.
@@ -182,6 +221,239 @@ func (in *AnthropicConfig) DeepCopy() *AnthropicConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApprovalCall) DeepCopyInto(out *ApprovalCall) {
+	*out = *in
+	in.RequestedAt.DeepCopyInto(&out.RequestedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApprovalCall.
+func (in *ApprovalCall) DeepCopy() *ApprovalCall {
+	if in == nil {
+		return nil
+	}
+	out := new(ApprovalCall)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApprovalEscalation) DeepCopyInto(out *ApprovalEscalation) {
+	*out = *in
+	out.After = in.After
+	out.ContactChannel = in.ContactChannel
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApprovalEscalation.
+func (in *ApprovalEscalation) DeepCopy() *ApprovalEscalation {
+	if in == nil {
+		return nil
+	}
+	out := new(ApprovalEscalation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApprovalPolicy) DeepCopyInto(out *ApprovalPolicy) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]ApprovalRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Timeouts != nil {
+		in, out := &in.Timeouts, &out.Timeouts
+		*out = new(ApprovalTimeouts)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApprovalPolicy.
+func (in *ApprovalPolicy) DeepCopy() *ApprovalPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ApprovalPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApprovalRequest) DeepCopyInto(out *ApprovalRequest) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApprovalRequest.
+func (in *ApprovalRequest) DeepCopy() *ApprovalRequest {
+	if in == nil {
+		return nil
+	}
+	out := new(ApprovalRequest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ApprovalRequest) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApprovalRequestList) DeepCopyInto(out *ApprovalRequestList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ApprovalRequest, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApprovalRequestList.
+func (in *ApprovalRequestList) DeepCopy() *ApprovalRequestList {
+	if in == nil {
+		return nil
+	}
+	out := new(ApprovalRequestList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ApprovalRequestList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApprovalRequestSpec) DeepCopyInto(out *ApprovalRequestSpec) {
+	*out = *in
+	out.ContactChannelRef = in.ContactChannelRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApprovalRequestSpec.
+func (in *ApprovalRequestSpec) DeepCopy() *ApprovalRequestSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ApprovalRequestSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApprovalRequestStatus) DeepCopyInto(out *ApprovalRequestStatus) {
+	*out = *in
+	if in.RequestedAt != nil {
+		in, out := &in.RequestedAt, &out.RequestedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.RespondedAt != nil {
+		in, out := &in.RespondedAt, &out.RespondedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApprovalRequestStatus.
+func (in *ApprovalRequestStatus) DeepCopy() *ApprovalRequestStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ApprovalRequestStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApprovalRule) DeepCopyInto(out *ApprovalRule) {
+	*out = *in
+	if in.Tools != nil {
+		in, out := &in.Tools, &out.Tools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ContactChannel != nil {
+		in, out := &in.ContactChannel, &out.ContactChannel
+		*out = new(LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApprovalRule.
+func (in *ApprovalRule) DeepCopy() *ApprovalRule {
+	if in == nil {
+		return nil
+	}
+	out := new(ApprovalRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApprovalTimeouts) DeepCopyInto(out *ApprovalTimeouts) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ReminderInterval != nil {
+		in, out := &in.ReminderInterval, &out.ReminderInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Escalation != nil {
+		in, out := &in.Escalation, &out.Escalation
+		*out = new(ApprovalEscalation)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApprovalTimeouts.
+func (in *ApprovalTimeouts) DeepCopy() *ApprovalTimeouts {
+	if in == nil {
+		return nil
+	}
+	out := new(ApprovalTimeouts)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Attachment) DeepCopyInto(out *Attachment) {
+	*out = *in
+	if in.DataFrom != nil {
+		in, out := &in.DataFrom, &out.DataFrom
+		*out = new(ConfigMapKeySelector)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Attachment.
+func (in *Attachment) DeepCopy() *Attachment {
+	if in == nil {
+		return nil
+	}
+	out := new(Attachment)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BaseConfig) DeepCopyInto(out *BaseConfig) {
 	*out = *in
@@ -195,6 +467,11 @@ func (in *BaseConfig) DeepCopyInto(out *BaseConfig) {
 		*out = new(int)
 		**out = **in
 	}
+	if in.Reasoning != nil {
+		in, out := &in.Reasoning, &out.Reasoning
+		*out = new(ReasoningConfig)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BaseConfig.
@@ -222,6 +499,21 @@ func (in *BuiltinToolSpec) DeepCopy() *BuiltinToolSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigMapKeySelector) DeepCopyInto(out *ConfigMapKeySelector) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigMapKeySelector.
+func (in *ConfigMapKeySelector) DeepCopy() *ConfigMapKeySelector {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigMapKeySelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ContactChannel) DeepCopyInto(out *ContactChannel) {
 	*out = *in
@@ -284,7 +576,11 @@ func (in *ContactChannelList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ContactChannelSpec) DeepCopyInto(out *ContactChannelSpec) {
 	*out = *in
-	out.APIKeyFrom = in.APIKeyFrom
+	if in.APIKeyFrom != nil {
+		in, out := &in.APIKeyFrom, &out.APIKeyFrom
+		*out = new(APIKeySource)
+		**out = **in
+	}
 	if in.Slack != nil {
 		in, out := &in.Slack, &out.Slack
 		*out = new(SlackChannelConfig)
@@ -295,6 +591,21 @@ func (in *ContactChannelSpec) DeepCopyInto(out *ContactChannelSpec) {
 		*out = new(EmailChannelConfig)
 		**out = **in
 	}
+	if in.SMS != nil {
+		in, out := &in.SMS, &out.SMS
+		*out = new(SMSChannelConfig)
+		**out = **in
+	}
+	if in.WhatsApp != nil {
+		in, out := &in.WhatsApp, &out.WhatsApp
+		*out = new(WhatsAppChannelConfig)
+		**out = **in
+	}
+	if in.ApprovalTimeouts != nil {
+		in, out := &in.ApprovalTimeouts, &out.ApprovalTimeouts
+		*out = new(ApprovalTimeouts)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContactChannelSpec.
@@ -412,13 +723,49 @@ func (in *GoogleConfig) DeepCopy() *GoogleConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPHeader) DeepCopyInto(out *HTTPHeader) {
+	*out = *in
+	if in.ValueFrom != nil {
+		in, out := &in.ValueFrom, &out.ValueFrom
+		*out = new(EnvVarSource)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPHeader.
+func (in *HTTPHeader) DeepCopy() *HTTPHeader {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPHeader)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HumanContactToolSpec) DeepCopyInto(out *HumanContactToolSpec) {
+	*out = *in
+	out.ContactChannel = in.ContactChannel
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HumanContactToolSpec.
+func (in *HumanContactToolSpec) DeepCopy() *HumanContactToolSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HumanContactToolSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LLM) DeepCopyInto(out *LLM) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LLM.
@@ -440,19 +787,59 @@ func (in *LLM) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *LLMList) DeepCopyInto(out *LLMList) {
+func (in *LLMHealthCheckSpec) DeepCopyInto(out *LLMHealthCheckSpec) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]LLM, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
 	}
-}
-
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LLMHealthCheckSpec.
+func (in *LLMHealthCheckSpec) DeepCopy() *LLMHealthCheckSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LLMHealthCheckSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LLMLatencyStats) DeepCopyInto(out *LLMLatencyStats) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LLMLatencyStats.
+func (in *LLMLatencyStats) DeepCopy() *LLMLatencyStats {
+	if in == nil {
+		return nil
+	}
+	out := new(LLMLatencyStats)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LLMList) DeepCopyInto(out *LLMList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]LLM, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LLMList.
 func (in *LLMList) DeepCopy() *LLMList {
 	if in == nil {
@@ -505,6 +892,11 @@ func (in *LLMSpec) DeepCopyInto(out *LLMSpec) {
 		*out = new(GoogleConfig)
 		**out = **in
 	}
+	if in.HealthCheck != nil {
+		in, out := &in.HealthCheck, &out.HealthCheck
+		*out = new(LLMHealthCheckSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LLMSpec.
@@ -520,6 +912,22 @@ func (in *LLMSpec) DeepCopy() *LLMSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LLMStatus) DeepCopyInto(out *LLMStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastProbeTime != nil {
+		in, out := &in.LastProbeTime, &out.LastProbeTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Latency != nil {
+		in, out := &in.Latency, &out.Latency
+		*out = new(LLMLatencyStats)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LLMStatus.
@@ -537,37 +945,545 @@ func (in *LocalObjectReference) DeepCopyInto(out *LocalObjectReference) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalObjectReference.
-func (in *LocalObjectReference) DeepCopy() *LocalObjectReference {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalObjectReference.
+func (in *LocalObjectReference) DeepCopy() *LocalObjectReference {
+	if in == nil {
+		return nil
+	}
+	out := new(LocalObjectReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MCPPrompt) DeepCopyInto(out *MCPPrompt) {
+	*out = *in
+	if in.Arguments != nil {
+		in, out := &in.Arguments, &out.Arguments
+		*out = make([]MCPPromptArgument, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MCPPrompt.
+func (in *MCPPrompt) DeepCopy() *MCPPrompt {
+	if in == nil {
+		return nil
+	}
+	out := new(MCPPrompt)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MCPPromptArgument) DeepCopyInto(out *MCPPromptArgument) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MCPPromptArgument.
+func (in *MCPPromptArgument) DeepCopy() *MCPPromptArgument {
+	if in == nil {
+		return nil
+	}
+	out := new(MCPPromptArgument)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MCPPromptRef) DeepCopyInto(out *MCPPromptRef) {
+	*out = *in
+	if in.Arguments != nil {
+		in, out := &in.Arguments, &out.Arguments
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MCPPromptRef.
+func (in *MCPPromptRef) DeepCopy() *MCPPromptRef {
+	if in == nil {
+		return nil
+	}
+	out := new(MCPPromptRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MCPResource) DeepCopyInto(out *MCPResource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MCPResource.
+func (in *MCPResource) DeepCopy() *MCPResource {
+	if in == nil {
+		return nil
+	}
+	out := new(MCPResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MCPResourceRef) DeepCopyInto(out *MCPResourceRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MCPResourceRef.
+func (in *MCPResourceRef) DeepCopy() *MCPResourceRef {
+	if in == nil {
+		return nil
+	}
+	out := new(MCPResourceRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MCPServer) DeepCopyInto(out *MCPServer) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MCPServer.
+func (in *MCPServer) DeepCopy() *MCPServer {
+	if in == nil {
+		return nil
+	}
+	out := new(MCPServer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MCPServer) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MCPServerAuth) DeepCopyInto(out *MCPServerAuth) {
+	*out = *in
+	if in.BearerTokenFrom != nil {
+		in, out := &in.BearerTokenFrom, &out.BearerTokenFrom
+		*out = new(SecretKeySelector)
+		**out = **in
+	}
+	if in.OAuth != nil {
+		in, out := &in.OAuth, &out.OAuth
+		*out = new(OAuthClientCredentials)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MCPServerAuth.
+func (in *MCPServerAuth) DeepCopy() *MCPServerAuth {
+	if in == nil {
+		return nil
+	}
+	out := new(MCPServerAuth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MCPServerDeployment) DeepCopyInto(out *MCPServerDeployment) {
+	*out = *in
+	if in.SecurityContext != nil {
+		in, out := &in.SecurityContext, &out.SecurityContext
+		*out = new(corev1.SecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodSecurityContext != nil {
+		in, out := &in.PodSecurityContext, &out.PodSecurityContext
+		*out = new(corev1.PodSecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MCPServerDeployment.
+func (in *MCPServerDeployment) DeepCopy() *MCPServerDeployment {
+	if in == nil {
+		return nil
+	}
+	out := new(MCPServerDeployment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MCPServerList) DeepCopyInto(out *MCPServerList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MCPServer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MCPServerList.
+func (in *MCPServerList) DeepCopy() *MCPServerList {
+	if in == nil {
+		return nil
+	}
+	out := new(MCPServerList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MCPServerList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MCPServerSampling) DeepCopyInto(out *MCPServerSampling) {
+	*out = *in
+	if in.MaxTokens != nil {
+		in, out := &in.MaxTokens, &out.MaxTokens
+		*out = new(int)
+		**out = **in
+	}
+	if in.ApprovalContactChannel != nil {
+		in, out := &in.ApprovalContactChannel, &out.ApprovalContactChannel
+		*out = new(LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MCPServerSampling.
+func (in *MCPServerSampling) DeepCopy() *MCPServerSampling {
+	if in == nil {
+		return nil
+	}
+	out := new(MCPServerSampling)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MCPServerSpec) DeepCopyInto(out *MCPServerSpec) {
+	*out = *in
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make([]HTTPHeader, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Auth != nil {
+		in, out := &in.Auth, &out.Auth
+		*out = new(MCPServerAuth)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(MCPServerTLS)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+	if in.ApprovalContactChannel != nil {
+		in, out := &in.ApprovalContactChannel, &out.ApprovalContactChannel
+		*out = new(LocalObjectReference)
+		**out = **in
+	}
+	if in.ApprovalPolicy != nil {
+		in, out := &in.ApprovalPolicy, &out.ApprovalPolicy
+		*out = new(ApprovalPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ElicitationContactChannel != nil {
+		in, out := &in.ElicitationContactChannel, &out.ElicitationContactChannel
+		*out = new(LocalObjectReference)
+		**out = **in
+	}
+	if in.Deployment != nil {
+		in, out := &in.Deployment, &out.Deployment
+		*out = new(MCPServerDeployment)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Sampling != nil {
+		in, out := &in.Sampling, &out.Sampling
+		*out = new(MCPServerSampling)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MCPServerSpec.
+func (in *MCPServerSpec) DeepCopy() *MCPServerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MCPServerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MCPServerStatus) DeepCopyInto(out *MCPServerStatus) {
+	*out = *in
+	if in.Tools != nil {
+		in, out := &in.Tools, &out.Tools
+		*out = make([]MCPTool, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]MCPResource, len(*in))
+		copy(*out, *in)
+	}
+	if in.Prompts != nil {
+		in, out := &in.Prompts, &out.Prompts
+		*out = make([]MCPPrompt, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastFailureTime != nil {
+		in, out := &in.LastFailureTime, &out.LastFailureTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MCPServerStatus.
+func (in *MCPServerStatus) DeepCopy() *MCPServerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MCPServerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MCPServerTLS) DeepCopyInto(out *MCPServerTLS) {
+	*out = *in
+	if in.CABundleFrom != nil {
+		in, out := &in.CABundleFrom, &out.CABundleFrom
+		*out = new(SecretKeySelector)
+		**out = **in
+	}
+	if in.ClientCertificateSecret != nil {
+		in, out := &in.ClientCertificateSecret, &out.ClientCertificateSecret
+		*out = new(LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MCPServerTLS.
+func (in *MCPServerTLS) DeepCopy() *MCPServerTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(MCPServerTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MCPTool) DeepCopyInto(out *MCPTool) {
+	*out = *in
+	in.InputSchema.DeepCopyInto(&out.InputSchema)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MCPTool.
+func (in *MCPTool) DeepCopy() *MCPTool {
+	if in == nil {
+		return nil
+	}
+	out := new(MCPTool)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Message) DeepCopyInto(out *Message) {
+	*out = *in
+	if in.ToolCalls != nil {
+		in, out := &in.ToolCalls, &out.ToolCalls
+		*out = make([]ToolCall, len(*in))
+		copy(*out, *in)
+	}
+	if in.Reasoning != nil {
+		in, out := &in.Reasoning, &out.Reasoning
+		*out = make([]ReasoningBlock, len(*in))
+		copy(*out, *in)
+	}
+	if in.Usage != nil {
+		in, out := &in.Usage, &out.Usage
+		*out = new(TokenUsage)
+		**out = **in
+	}
+	if in.Attachments != nil {
+		in, out := &in.Attachments, &out.Attachments
+		*out = make([]Attachment, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Message.
+func (in *Message) DeepCopy() *Message {
+	if in == nil {
+		return nil
+	}
+	out := new(Message)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MistralConfig) DeepCopyInto(out *MistralConfig) {
+	*out = *in
+	if in.MaxRetries != nil {
+		in, out := &in.MaxRetries, &out.MaxRetries
+		*out = new(int)
+		**out = **in
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(int)
+		**out = **in
+	}
+	if in.RandomSeed != nil {
+		in, out := &in.RandomSeed, &out.RandomSeed
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MistralConfig.
+func (in *MistralConfig) DeepCopy() *MistralConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(MistralConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ModelPrice) DeepCopyInto(out *ModelPrice) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModelPrice.
+func (in *ModelPrice) DeepCopy() *ModelPrice {
+	if in == nil {
+		return nil
+	}
+	out := new(ModelPrice)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ModelRoute) DeepCopyInto(out *ModelRoute) {
+	*out = *in
+	out.LLMRef = in.LLMRef
+	in.Match.DeepCopyInto(&out.Match)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModelRoute.
+func (in *ModelRoute) DeepCopy() *ModelRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(ModelRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ModelRouteMatch) DeepCopyInto(out *ModelRouteMatch) {
+	*out = *in
+	if in.MinContextTokens != nil {
+		in, out := &in.MinContextTokens, &out.MinContextTokens
+		*out = new(int64)
+		**out = **in
+	}
+	if in.MaxContextTokens != nil {
+		in, out := &in.MaxContextTokens, &out.MaxContextTokens
+		*out = new(int64)
+		**out = **in
+	}
+	if in.HasTools != nil {
+		in, out := &in.HasTools, &out.HasTools
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Tiers != nil {
+		in, out := &in.Tiers, &out.Tiers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ClassifierLabels != nil {
+		in, out := &in.ClassifierLabels, &out.ClassifierLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModelRouteMatch.
+func (in *ModelRouteMatch) DeepCopy() *ModelRouteMatch {
 	if in == nil {
 		return nil
 	}
-	out := new(LocalObjectReference)
+	out := new(ModelRouteMatch)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MCPServer) DeepCopyInto(out *MCPServer) {
+func (in *ModelRouter) DeepCopyInto(out *ModelRouter) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MCPServer.
-func (in *MCPServer) DeepCopy() *MCPServer {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModelRouter.
+func (in *ModelRouter) DeepCopy() *ModelRouter {
 	if in == nil {
 		return nil
 	}
-	out := new(MCPServer)
+	out := new(ModelRouter)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *MCPServer) DeepCopyObject() runtime.Object {
+func (in *ModelRouter) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -575,31 +1491,52 @@ func (in *MCPServer) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MCPServerList) DeepCopyInto(out *MCPServerList) {
+func (in *ModelRouterClassifier) DeepCopyInto(out *ModelRouterClassifier) {
+	*out = *in
+	out.LLMRef = in.LLMRef
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModelRouterClassifier.
+func (in *ModelRouterClassifier) DeepCopy() *ModelRouterClassifier {
+	if in == nil {
+		return nil
+	}
+	out := new(ModelRouterClassifier)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ModelRouterList) DeepCopyInto(out *ModelRouterList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]MCPServer, len(*in))
+		*out = make([]ModelRouter, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MCPServerList.
-func (in *MCPServerList) DeepCopy() *MCPServerList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModelRouterList.
+func (in *ModelRouterList) DeepCopy() *ModelRouterList {
 	if in == nil {
 		return nil
 	}
-	out := new(MCPServerList)
+	out := new(ModelRouterList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *MCPServerList) DeepCopyObject() runtime.Object {
+func (in *ModelRouterList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -607,152 +1544,157 @@ func (in *MCPServerList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MCPServerSpec) DeepCopyInto(out *MCPServerSpec) {
+func (in *ModelRouterSpec) DeepCopyInto(out *ModelRouterSpec) {
 	*out = *in
-	if in.Args != nil {
-		in, out := &in.Args, &out.Args
-		*out = make([]string, len(*in))
-		copy(*out, *in)
-	}
-	if in.Env != nil {
-		in, out := &in.Env, &out.Env
-		*out = make([]EnvVar, len(*in))
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = make([]ModelRoute, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	in.Resources.DeepCopyInto(&out.Resources)
-	if in.ApprovalContactChannel != nil {
-		in, out := &in.ApprovalContactChannel, &out.ApprovalContactChannel
-		*out = new(LocalObjectReference)
-		**out = **in
+	if in.Classifier != nil {
+		in, out := &in.Classifier, &out.Classifier
+		*out = new(ModelRouterClassifier)
+		(*in).DeepCopyInto(*out)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MCPServerSpec.
-func (in *MCPServerSpec) DeepCopy() *MCPServerSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModelRouterSpec.
+func (in *ModelRouterSpec) DeepCopy() *ModelRouterSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(MCPServerSpec)
+	out := new(ModelRouterSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MCPServerStatus) DeepCopyInto(out *MCPServerStatus) {
+func (in *NameReference) DeepCopyInto(out *NameReference) {
 	*out = *in
-	if in.Tools != nil {
-		in, out := &in.Tools, &out.Tools
-		*out = make([]MCPTool, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MCPServerStatus.
-func (in *MCPServerStatus) DeepCopy() *MCPServerStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NameReference.
+func (in *NameReference) DeepCopy() *NameReference {
 	if in == nil {
 		return nil
 	}
-	out := new(MCPServerStatus)
+	out := new(NameReference)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MCPTool) DeepCopyInto(out *MCPTool) {
+func (in *OAuthClientCredentials) DeepCopyInto(out *OAuthClientCredentials) {
 	*out = *in
-	in.InputSchema.DeepCopyInto(&out.InputSchema)
+	out.ClientSecretFrom = in.ClientSecretFrom
+	if in.Scopes != nil {
+		in, out := &in.Scopes, &out.Scopes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MCPTool.
-func (in *MCPTool) DeepCopy() *MCPTool {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OAuthClientCredentials.
+func (in *OAuthClientCredentials) DeepCopy() *OAuthClientCredentials {
 	if in == nil {
 		return nil
 	}
-	out := new(MCPTool)
+	out := new(OAuthClientCredentials)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Message) DeepCopyInto(out *Message) {
+func (in *OpenAIConfig) DeepCopyInto(out *OpenAIConfig) {
 	*out = *in
-	if in.ToolCalls != nil {
-		in, out := &in.ToolCalls, &out.ToolCalls
-		*out = make([]ToolCall, len(*in))
-		copy(*out, *in)
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Message.
-func (in *Message) DeepCopy() *Message {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenAIConfig.
+func (in *OpenAIConfig) DeepCopy() *OpenAIConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(Message)
+	out := new(OpenAIConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MistralConfig) DeepCopyInto(out *MistralConfig) {
+func (in *PricingCatalog) DeepCopyInto(out *PricingCatalog) {
 	*out = *in
-	if in.MaxRetries != nil {
-		in, out := &in.MaxRetries, &out.MaxRetries
-		*out = new(int)
-		**out = **in
-	}
-	if in.Timeout != nil {
-		in, out := &in.Timeout, &out.Timeout
-		*out = new(int)
-		**out = **in
-	}
-	if in.RandomSeed != nil {
-		in, out := &in.RandomSeed, &out.RandomSeed
-		*out = new(int)
-		**out = **in
-	}
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MistralConfig.
-func (in *MistralConfig) DeepCopy() *MistralConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PricingCatalog.
+func (in *PricingCatalog) DeepCopy() *PricingCatalog {
 	if in == nil {
 		return nil
 	}
-	out := new(MistralConfig)
+	out := new(PricingCatalog)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PricingCatalog) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NameReference) DeepCopyInto(out *NameReference) {
+func (in *PricingCatalogList) DeepCopyInto(out *PricingCatalogList) {
 	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PricingCatalog, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NameReference.
-func (in *NameReference) DeepCopy() *NameReference {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PricingCatalogList.
+func (in *PricingCatalogList) DeepCopy() *PricingCatalogList {
 	if in == nil {
 		return nil
 	}
-	out := new(NameReference)
+	out := new(PricingCatalogList)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PricingCatalogList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OpenAIConfig) DeepCopyInto(out *OpenAIConfig) {
+func (in *PricingCatalogSpec) DeepCopyInto(out *PricingCatalogSpec) {
 	*out = *in
+	if in.Prices != nil {
+		in, out := &in.Prices, &out.Prices
+		*out = make([]ModelPrice, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenAIConfig.
-func (in *OpenAIConfig) DeepCopy() *OpenAIConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PricingCatalogSpec.
+func (in *PricingCatalogSpec) DeepCopy() *PricingCatalogSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(OpenAIConfig)
+	out := new(PricingCatalogSpec)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -797,6 +1739,36 @@ func (in *ProviderConfig) DeepCopy() *ProviderConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReasoningBlock) DeepCopyInto(out *ReasoningBlock) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReasoningBlock.
+func (in *ReasoningBlock) DeepCopy() *ReasoningBlock {
+	if in == nil {
+		return nil
+	}
+	out := new(ReasoningBlock)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReasoningConfig) DeepCopyInto(out *ReasoningConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReasoningConfig.
+func (in *ReasoningConfig) DeepCopy() *ReasoningConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ReasoningConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResolvedMCPServer) DeepCopyInto(out *ResolvedMCPServer) {
 	*out = *in
@@ -882,6 +1854,21 @@ func (in *ResourceRequirements) DeepCopy() *ResourceRequirements {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SMSChannelConfig) DeepCopyInto(out *SMSChannelConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SMSChannelConfig.
+func (in *SMSChannelConfig) DeepCopy() *SMSChannelConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(SMSChannelConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecretKeyRef) DeepCopyInto(out *SecretKeyRef) {
 	*out = *in
@@ -1118,6 +2105,11 @@ func (in *TaskRunStatus) DeepCopyInto(out *TaskRunStatus) {
 		*out = new(SpanContext)
 		**out = **in
 	}
+	if in.Usage != nil {
+		in, out := &in.Usage, &out.Usage
+		*out = new(UsageSummary)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskRunStatus.
@@ -1209,6 +2201,23 @@ func (in *TaskRunToolCallSpec) DeepCopy() *TaskRunToolCallSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TaskRunToolCallStatus) DeepCopyInto(out *TaskRunToolCallStatus) {
 	*out = *in
+	if in.Approval != nil {
+		in, out := &in.Approval, &out.Approval
+		*out = new(ToolCallApproval)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Attachments != nil {
+		in, out := &in.Attachments, &out.Attachments
+		*out = make([]Attachment, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Progress != nil {
+		in, out := &in.Progress, &out.Progress
+		*out = new(ToolCallProgress)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.StartTime != nil {
 		in, out := &in.StartTime, &out.StartTime
 		*out = (*in).DeepCopy()
@@ -1238,6 +2247,16 @@ func (in *TaskRunToolCallStatus) DeepCopy() *TaskRunToolCallStatus {
 func (in *TaskSpec) DeepCopyInto(out *TaskSpec) {
 	*out = *in
 	out.AgentRef = in.AgentRef
+	if in.Prompt != nil {
+		in, out := &in.Prompt, &out.Prompt
+		*out = new(MCPPromptRef)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]MCPResourceRef, len(*in))
+		copy(*out, *in)
+	}
 	if in.EverythingThatHappenedSoFar != nil {
 		in, out := &in.EverythingThatHappenedSoFar, &out.EverythingThatHappenedSoFar
 		*out = make([]string, len(*in))
@@ -1270,6 +2289,21 @@ func (in *TaskStatus) DeepCopy() *TaskStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TokenUsage) DeepCopyInto(out *TokenUsage) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TokenUsage.
+func (in *TokenUsage) DeepCopy() *TokenUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(TokenUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Tool) DeepCopyInto(out *Tool) {
 	*out = *in
@@ -1313,6 +2347,29 @@ func (in *ToolCall) DeepCopy() *ToolCall {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ToolCallApproval) DeepCopyInto(out *ToolCallApproval) {
+	*out = *in
+	in.RequestedAt.DeepCopyInto(&out.RequestedAt)
+	if in.Calls != nil {
+		in, out := &in.Calls, &out.Calls
+		*out = make([]ApprovalCall, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ToolCallApproval.
+func (in *ToolCallApproval) DeepCopy() *ToolCallApproval {
+	if in == nil {
+		return nil
+	}
+	out := new(ToolCallApproval)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ToolCallFunction) DeepCopyInto(out *ToolCallFunction) {
 	*out = *in
@@ -1328,6 +2385,27 @@ func (in *ToolCallFunction) DeepCopy() *ToolCallFunction {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ToolCallProgress) DeepCopyInto(out *ToolCallProgress) {
+	*out = *in
+	if in.Percentage != nil {
+		in, out := &in.Percentage, &out.Percentage
+		*out = new(int32)
+		**out = **in
+	}
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ToolCallProgress.
+func (in *ToolCallProgress) DeepCopy() *ToolCallProgress {
+	if in == nil {
+		return nil
+	}
+	out := new(ToolCallProgress)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ToolExecute) DeepCopyInto(out *ToolExecute) {
 	*out = *in
@@ -1341,6 +2419,11 @@ func (in *ToolExecute) DeepCopyInto(out *ToolExecute) {
 		*out = new(ExternalAPISpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.HumanContact != nil {
+		in, out := &in.HumanContact, &out.HumanContact
+		*out = new(HumanContactToolSpec)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ToolExecute.
@@ -1396,6 +2479,16 @@ func (in *ToolSpec) DeepCopyInto(out *ToolSpec) {
 		*out = new(AgentReference)
 		**out = **in
 	}
+	if in.ApprovalContactChannel != nil {
+		in, out := &in.ApprovalContactChannel, &out.ApprovalContactChannel
+		*out = new(LocalObjectReference)
+		**out = **in
+	}
+	if in.ApprovalPolicy != nil {
+		in, out := &in.ApprovalPolicy, &out.ApprovalPolicy
+		*out = new(ApprovalPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ToolSpec.
@@ -1423,6 +2516,21 @@ func (in *ToolStatus) DeepCopy() *ToolStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UsageSummary) DeepCopyInto(out *UsageSummary) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UsageSummary.
+func (in *UsageSummary) DeepCopy() *UsageSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(UsageSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VertexConfig) DeepCopyInto(out *VertexConfig) {
 	*out = *in
@@ -1437,3 +2545,18 @@ func (in *VertexConfig) DeepCopy() *VertexConfig {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WhatsAppChannelConfig) DeepCopyInto(out *WhatsAppChannelConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WhatsAppChannelConfig.
+func (in *WhatsAppChannelConfig) DeepCopy() *WhatsAppChannelConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(WhatsAppChannelConfig)
+	in.DeepCopyInto(out)
+	return out
+}
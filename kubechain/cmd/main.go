@@ -23,6 +23,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/humanlayer/smallchain/kubechain/internal/approval"
 	"github.com/humanlayer/smallchain/kubechain/internal/controller/agent"
 	"github.com/humanlayer/smallchain/kubechain/internal/controller/contactchannel"
 	"github.com/humanlayer/smallchain/kubechain/internal/controller/llm"
@@ -31,12 +32,14 @@ import (
 	"github.com/humanlayer/smallchain/kubechain/internal/controller/taskrun"
 	"github.com/humanlayer/smallchain/kubechain/internal/controller/taskruntoolcall"
 	"github.com/humanlayer/smallchain/kubechain/internal/controller/tool"
+	"github.com/humanlayer/smallchain/kubechain/internal/humanlayer"
 	"github.com/humanlayer/smallchain/kubechain/internal/mcpmanager"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -74,6 +77,12 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var mcpBridgeImage string
+	var managerNamespace, managerPodLabels string
+	var approvalAddr string
+	var toolCallConcurrency int
+	var humanLayerWebhookAddr, humanLayerWebhookSecret string
+	var humanLayerWebhookCertPath, humanLayerWebhookCertName, humanLayerWebhookCertKey string
 	var tlsOpts []func(*tls.Config)
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
@@ -92,6 +101,30 @@ func main() {
 	flag.StringVar(&metricsCertKey, "metrics-cert-key", "tls.key", "The name of the metrics server key file.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.StringVar(&mcpBridgeImage, "mcp-bridge-image", os.Getenv("MCP_BRIDGE_IMAGE"),
+		"The image that provides the MCP bridge for MCPServers that run as Deployments, usually the operator image.")
+	flag.StringVar(&managerNamespace, "manager-namespace", os.Getenv("POD_NAMESPACE"),
+		"The namespace the manager runs in, defaults to the POD_NAMESPACE environment variable. The NetworkPolicies "+
+			"of MCPServers that run as Deployments admit only the manager Pods, none are created if it is empty.")
+	flag.StringVar(&managerPodLabels, "manager-pod-labels", "control-plane=controller-manager",
+		"The labels of the manager Pods, as comma separated key=value pairs.")
+	flag.IntVar(&toolCallConcurrency, "tool-call-concurrency", taskruntoolcall.DefaultMaxConcurrentReconciles,
+		"How many TaskRunToolCalls are reconciled at once. An MCP tool call holds one until it returns, "+
+			"also while a human is asked to approve sampling or to provide input.")
+	flag.StringVar(&approvalAddr, "approval-bind-address", "0", "The address the approval API and UI for inCluster "+
+		"contact channels binds to, or leave as 0 to disable it. Callers need a Kubernetes token whose user may "+
+		"update approvalrequests/status.")
+	flag.StringVar(&humanLayerWebhookAddr, "humanlayer-webhook-bind-address", "0",
+		"The address the endpoint for HumanLayer webhooks binds to, or leave as 0 to disable it. It only listens on "+
+			"the leader, so with more than one replica the Service must target the leader only.")
+	flag.StringVar(&humanLayerWebhookSecret, "humanlayer-webhook-secret", os.Getenv("HUMANLAYER_WEBHOOK_SECRET"),
+		"The secret HumanLayer webhooks are signed with, defaults to the HUMANLAYER_WEBHOOK_SECRET environment variable.")
+	flag.StringVar(&humanLayerWebhookCertPath, "humanlayer-webhook-cert-path", "",
+		"The directory that contains the HumanLayer webhook server certificate, served over plain HTTP if unset.")
+	flag.StringVar(&humanLayerWebhookCertName, "humanlayer-webhook-cert-name", "tls.crt",
+		"The name of the HumanLayer webhook server certificate file.")
+	flag.StringVar(&humanLayerWebhookCertKey, "humanlayer-webhook-cert-key", "tls.key",
+		"The name of the HumanLayer webhook server key file.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -244,8 +277,13 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Create a shared MCPManager that all controllers will use
+	// Create a shared MCPManager that all controllers will use. It runs with
+	// the manager, so only the leader connects to MCP servers.
 	mcpManagerInstance := mcpmanager.NewMCPServerManagerWithClient(mgr.GetClient())
+	if err := mcpManagerInstance.SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to add MCP manager to manager")
+		os.Exit(1)
+	}
 
 	if err = (&agent.AgentReconciler{
 		Client:     mgr.GetClient(),
@@ -274,19 +312,29 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err = (&taskruntoolcall.TaskRunToolCallReconciler{
-		Client:     mgr.GetClient(),
-		Scheme:     mgr.GetScheme(),
-		MCPManager: mcpManagerInstance,
-	}).SetupWithManager(mgr); err != nil {
+	taskRunToolCallReconciler := &taskruntoolcall.TaskRunToolCallReconciler{
+		Client:                  mgr.GetClient(),
+		Scheme:                  mgr.GetScheme(),
+		MCPManager:              mcpManagerInstance,
+		MaxConcurrentReconciles: toolCallConcurrency,
+	}
+	if err = taskRunToolCallReconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "TaskRunToolCall")
 		os.Exit(1)
 	}
 
+	managerLabels, err := labels.ConvertSelectorToLabelsMap(managerPodLabels)
+	if err != nil {
+		setupLog.Error(err, "invalid --manager-pod-labels")
+		os.Exit(1)
+	}
 	if err = (&mcpserver.MCPServerReconciler{
-		Client:     mgr.GetClient(),
-		Scheme:     mgr.GetScheme(),
-		MCPManager: mcpManagerInstance,
+		Client:           mgr.GetClient(),
+		Scheme:           mgr.GetScheme(),
+		MCPManager:       mcpManagerInstance,
+		BridgeImage:      mcpBridgeImage,
+		ManagerNamespace: managerNamespace,
+		ManagerPodLabels: managerLabels,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "MCPServer")
 		os.Exit(1)
@@ -300,6 +348,57 @@ func main() {
 		os.Exit(1)
 	}
 
+	if humanLayerWebhookAddr != "0" {
+		if humanLayerWebhookSecret == "" {
+			setupLog.Error(nil, "--humanlayer-webhook-bind-address needs --humanlayer-webhook-secret")
+			os.Exit(1)
+		}
+
+		var humanLayerWebhookTLS *tls.Config
+		if len(humanLayerWebhookCertPath) > 0 {
+			setupLog.Info("Initializing HumanLayer webhook certificate watcher using provided certificates",
+				"humanlayer-webhook-cert-path", humanLayerWebhookCertPath)
+
+			humanLayerWebhookCertWatcher, err := certwatcher.New(
+				filepath.Join(humanLayerWebhookCertPath, humanLayerWebhookCertName),
+				filepath.Join(humanLayerWebhookCertPath, humanLayerWebhookCertKey),
+			)
+			if err != nil {
+				setupLog.Error(err, "Failed to initialize HumanLayer webhook certificate watcher")
+				os.Exit(1)
+			}
+			if err := mgr.Add(humanLayerWebhookCertWatcher); err != nil {
+				setupLog.Error(err, "unable to add HumanLayer webhook certificate watcher to manager")
+				os.Exit(1)
+			}
+
+			humanLayerWebhookTLS = &tls.Config{
+				GetCertificate: humanLayerWebhookCertWatcher.GetCertificate,
+				MinVersion:     tls.VersionTLS12,
+			}
+			for _, opt := range tlsOpts {
+				opt(humanLayerWebhookTLS)
+			}
+		}
+
+		if err := mgr.Add(&humanlayer.WebhookServer{
+			Addr:      humanLayerWebhookAddr,
+			TLSConfig: humanLayerWebhookTLS,
+			Secret:    []byte(humanLayerWebhookSecret),
+			Route:     taskRunToolCallReconciler.RouteWebhook,
+		}); err != nil {
+			setupLog.Error(err, "unable to add HumanLayer webhook server to manager")
+			os.Exit(1)
+		}
+	}
+
+	if approvalAddr != "0" {
+		if err := mgr.Add(approval.NewServer(mgr.GetClient(), approvalAddr)); err != nil {
+			setupLog.Error(err, "unable to add approval server to manager")
+			os.Exit(1)
+		}
+	}
+
 	if metricsCertWatcher != nil {
 		setupLog.Info("Adding metrics certificate watcher to manager")
 		if err := mgr.Add(metricsCertWatcher); err != nil {
@@ -0,0 +1,85 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// mcp-bridge runs a stdio MCP server and exposes it over TCP. It is copied
+// into the Pods of MCPServers that run as Deployments.
+//
+// Usage: mcp-bridge [--listen :3100] [--health-listen :3101] -- command [args...]
+//
+// Clients authenticate with the token in the KUBECHAIN_BRIDGE_TOKEN
+// environment variable, which the server process doesn't inherit.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/humanlayer/smallchain/kubechain/internal/mcpbridge"
+)
+
+func main() {
+	var listenAddr, healthAddr string
+	flag.StringVar(&listenAddr, "listen", fmt.Sprintf(":%d", mcpbridge.Port), "The address the bridge listens on.")
+	flag.StringVar(&healthAddr, "health-listen", fmt.Sprintf(":%d", mcpbridge.HealthPort),
+		"The address the health checks are served on. Set to 0 to disable them.")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: mcp-bridge [--listen addr] [--health-listen addr] -- command [args...]")
+		os.Exit(2)
+	}
+
+	token := os.Getenv(mcpbridge.TokenEnv)
+	if token == "" {
+		fmt.Fprintf(os.Stderr, "mcp-bridge: %s is not set\n", mcpbridge.TokenEnv)
+		os.Exit(2)
+	}
+	// the server process inherits the environment, but not the token
+	_ = os.Unsetenv(mcpbridge.TokenEnv)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	listener, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "mcp-bridge: failed to listen on %s: %v\n", listenAddr, err)
+		os.Exit(1)
+	}
+
+	bridge := &mcpbridge.Bridge{Command: flag.Arg(0), Args: flag.Args()[1:], Token: token}
+	if healthAddr != "0" {
+		health := &http.Server{Addr: healthAddr, Handler: bridge.HealthHandler(), ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			if err := health.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				fmt.Fprintf(os.Stderr, "mcp-bridge: health checks failed: %v\n", err)
+				stop()
+			}
+		}()
+		defer func() { _ = health.Close() }()
+	}
+
+	if err := bridge.Serve(ctx, listener); err != nil {
+		fmt.Fprintf(os.Stderr, "mcp-bridge: %v\n", err)
+		os.Exit(1)
+	}
+}
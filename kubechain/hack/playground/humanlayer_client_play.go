@@ -28,6 +28,16 @@ func requestApproval(
 			Address:          os.Getenv("HL_EXAMPLE_CONTACT_EMAIL"),
 			ContextAboutUser: "Primary approver for web fetch operations",
 		})
+	case kubechainv1alpha1.ContactChannelTypeSMS:
+		client.SetSMSConfig(&kubechainv1alpha1.SMSChannelConfig{
+			PhoneNumber:      os.Getenv("HL_EXAMPLE_CONTACT_PHONE"),
+			ContextAboutUser: "Primary approver for web fetch operations",
+		})
+	case kubechainv1alpha1.ContactChannelTypeWhatsApp:
+		client.SetWhatsAppConfig(&kubechainv1alpha1.WhatsAppChannelConfig{
+			PhoneNumber:      os.Getenv("HL_EXAMPLE_CONTACT_PHONE"),
+			ContextAboutUser: "Primary approver for web fetch operations",
+		})
 	default:
 		panic("Unsupported channel type: " + channelType)
 	}
@@ -62,7 +72,7 @@ func getFunctionCallStatus(client humanlayer.HumanLayerClientWrapper) *humanlaye
 func main() {
 	// Define command line flags
 	callIDFlag := flag.String("call-id", "", "Existing call ID to check status for")
-	typeFlag := flag.String("channel", "slack", "Channel type (slack or email)")
+	typeFlag := flag.String("channel", "slack", "Channel type (slack, email, sms or whatsapp)")
 	flag.Parse()
 
 	factory, _ := humanlayer.NewHumanLayerClientFactory("")
@@ -1,36 +1,38 @@
 package adapters
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
 	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
 	"github.com/humanlayer/smallchain/kubechain/internal/llmclient"
+	"github.com/humanlayer/smallchain/kubechain/internal/mcpmanager"
 )
 
-// ConvertMCPToolsToLLMClientTools converts KubeChain MCPTool objects to LLM client tool format
-func ConvertMCPToolsToLLMClientTools(mcpTools []kubechainv1alpha1.MCPTool, serverName string) []llmclient.Tool {
+// ConvertMCPToolsToLLMClientTools converts KubeChain MCPTool objects to LLM client tool format.
+// Tools whose input schema can't be read are left out, the LLM would call
+// them without the arguments they need.
+func ConvertMCPToolsToLLMClientTools(ctx context.Context, mcpTools []kubechainv1alpha1.MCPTool, serverName string) []llmclient.Tool {
 	var clientTools = make([]llmclient.Tool, 0, len(mcpTools))
 
 	for _, tool := range mcpTools {
 		// Create a function definition
 		toolFunction := llmclient.ToolFunction{
-			Name:        fmt.Sprintf("%s__%s", serverName, tool.Name),
+			Name:        mcpmanager.ToolName(serverName, tool.Name),
 			Description: tool.Description,
 		}
 
 		// Convert the input schema if available
 		if tool.InputSchema.Raw != nil {
 			var params llmclient.ToolFunctionParameters
-			if err := json.Unmarshal(tool.InputSchema.Raw, &params); err == nil {
-				toolFunction.Parameters = params
-			} else {
-				// Default to a simple object schema if none provided
-				toolFunction.Parameters = llmclient.ToolFunctionParameters{
-					Type:       "object",
-					Properties: map[string]llmclient.ToolFunctionParameter{},
-				}
+			if err := json.Unmarshal(tool.InputSchema.Raw, &params); err != nil {
+				log.FromContext(ctx).Error(err, "Skipping MCP tool with an invalid input schema", "server", serverName, "tool", tool.Name)
+				continue
 			}
+			toolFunction.Parameters = params
 		} else {
 			// Default to a simple object schema if none provided
 			toolFunction.Parameters = llmclient.ToolFunctionParameters{
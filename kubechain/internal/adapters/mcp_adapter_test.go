@@ -0,0 +1,51 @@
+package adapters
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+var _ = Describe("MCP adapter", func() {
+	Context("ConvertMCPToolsToLLMClientTools", func() {
+		It("passes the input schema through", func() {
+			tools := ConvertMCPToolsToLLMClientTools(context.Background(), []kubechainv1alpha1.MCPTool{{
+				Name:        "fetch",
+				Description: "Fetches a URL",
+				InputSchema: runtime.RawExtension{Raw: []byte(`{"type":"object","properties":{"url":{"type":"string"}},"required":["url"]}`)},
+			}}, "web")
+
+			Expect(tools).To(HaveLen(1))
+			Expect(tools[0].Function.Name).To(Equal("web__fetch"))
+			Expect(tools[0].Function.Parameters.Properties).To(HaveKey("url"))
+			Expect(tools[0].Function.Parameters.Required).To(Equal([]string{"url"}))
+		})
+
+		It("gives tools without a schema an empty object schema", func() {
+			tools := ConvertMCPToolsToLLMClientTools(context.Background(), []kubechainv1alpha1.MCPTool{{Name: "ping"}}, "web")
+
+			Expect(tools).To(HaveLen(1))
+			Expect(tools[0].Function.Parameters.Type).To(Equal("object"))
+		})
+
+		It("leaves out tools whose schema can't be read", func() {
+			tools := ConvertMCPToolsToLLMClientTools(context.Background(), []kubechainv1alpha1.MCPTool{
+				{Name: "broken", InputSchema: runtime.RawExtension{Raw: []byte(`["not", "a", "schema"]`)}},
+				{Name: "ping"},
+			}, "web")
+
+			Expect(tools).To(HaveLen(1))
+			Expect(tools[0].Function.Name).To(Equal("web__ping"))
+		})
+	})
+})
+
+func TestAdapters(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Adapters Suite")
+}
@@ -0,0 +1,131 @@
+package approval
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+// +kubebuilder:rbac:groups=authentication.k8s.io,resources=tokenreviews,verbs=create
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+const (
+	// tokenCookie carries the token of a UI session, set by the login form
+	tokenCookie = "kubechain-approval-token"
+
+	// csrfField is the form field of the UI's CSRF token
+	csrfField = "csrf"
+)
+
+var (
+	errUnauthenticated = errors.New("a valid bearer token is required")
+	errForbidden       = errors.New("forbidden")
+	errInvalidCSRF     = errors.New("invalid or missing CSRF token")
+)
+
+// caller is who made a request, as the API server knows them
+type caller struct {
+	user authenticationv1.UserInfo
+	// token is the bearer token the caller presented
+	token string
+	// fromCookie is set when the token came with the UI's cookie, which a
+	// browser sends along on any site's form
+	fromCookie bool
+}
+
+// authenticate looks up the caller of a request by its bearer token with a
+// TokenReview. Browsers can't send a bearer token, the UI sends it as a
+// cookie instead.
+func (s *Server) authenticate(req *http.Request) (*caller, error) {
+	c := &caller{}
+	if token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer "); ok {
+		c.token = strings.TrimSpace(token)
+	} else if cookie, err := req.Cookie(tokenCookie); err == nil {
+		c.token, c.fromCookie = cookie.Value, true
+	}
+	if c.token == "" {
+		return nil, errUnauthenticated
+	}
+
+	user, err := s.reviewToken(req.Context(), c.token)
+	if err != nil {
+		return nil, err
+	}
+	c.user = user
+	return c, nil
+}
+
+// reviewToken returns the user a token belongs to
+func (s *Server) reviewToken(ctx context.Context, token string) (authenticationv1.UserInfo, error) {
+	review := &authenticationv1.TokenReview{Spec: authenticationv1.TokenReviewSpec{Token: token}}
+	if err := s.client.Create(ctx, review); err != nil {
+		return authenticationv1.UserInfo{}, fmt.Errorf("failed to review token: %w", err)
+	}
+	if !review.Status.Authenticated {
+		return authenticationv1.UserInfo{}, errUnauthenticated
+	}
+	return review.Status.User, nil
+}
+
+// authorize checks with a SubjectAccessReview that the caller may do verb on
+// the approval requests, or their subresource, of a namespace, or of all
+// namespaces if it is empty
+func (s *Server) authorize(ctx context.Context, c *caller, verb, subresource, namespace, name string) error {
+	extra := make(map[string]authorizationv1.ExtraValue, len(c.user.Extra))
+	for key, values := range c.user.Extra {
+		extra[key] = authorizationv1.ExtraValue(values)
+	}
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   c.user.Username,
+			UID:    c.user.UID,
+			Groups: c.user.Groups,
+			Extra:  extra,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace:   namespace,
+				Verb:        verb,
+				Group:       kubechainv1alpha1.GroupVersion.Group,
+				Version:     kubechainv1alpha1.GroupVersion.Version,
+				Resource:    "approvalrequests",
+				Subresource: subresource,
+				Name:        name,
+			},
+		},
+	}
+	if err := s.client.Create(ctx, review); err != nil {
+		return fmt.Errorf("failed to review access: %w", err)
+	}
+	if !review.Status.Allowed {
+		return fmt.Errorf("%w: user %q may not %s approvalrequests", errForbidden, c.user.Username, verb)
+	}
+	return nil
+}
+
+// csrfToken is the CSRF token of the UI's forms, bound to the caller's token
+func (s *Server) csrfToken(c *caller) string {
+	mac := hmac.New(sha256.New, s.csrfKey)
+	mac.Write([]byte(c.token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// checkCSRF checks the CSRF token of a form the caller's browser posted.
+// Requests with a bearer token don't need one, no other site can send it.
+func (s *Server) checkCSRF(req *http.Request, c *caller) error {
+	if !c.fromCookie {
+		return nil
+	}
+	if !hmac.Equal([]byte(req.FormValue(csrfField)), []byte(s.csrfToken(c))) {
+		return errInvalidCSRF
+	}
+	return nil
+}
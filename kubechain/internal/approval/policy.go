@@ -0,0 +1,152 @@
+package approval
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"k8s.io/utils/lru"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+	"github.com/humanlayer/smallchain/kubechain/internal/mcpmanager"
+)
+
+// Decision is what a policy decided for a tool call
+type Decision struct {
+	Action kubechainv1alpha1.ApprovalAction
+	// ContactChannel is the channel of the matching rule, if it names one
+	ContactChannel *kubechainv1alpha1.LocalObjectReference
+	// Reason says which rule decided, for events and status details
+	Reason string
+}
+
+// env is the CEL environment conditions are compiled in
+var env = sync.OnceValues(func() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.Variable("args", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("tool", cel.StringType),
+	)
+})
+
+// Conditions come from users and run in the controllers, these bound what
+// evaluating one may cost
+const (
+	// conditionCostLimit caps the CEL runtime cost of a single evaluation
+	conditionCostLimit = 1_000_000
+	// conditionTimeout caps the time a single evaluation may take
+	conditionTimeout = 100 * time.Millisecond
+	// interruptCheckFrequency is how many comprehension iterations run
+	// between checks of the evaluation deadline
+	interruptCheckFrequency = 100
+	// maxCachedPrograms bounds the compiled conditions kept around, policies
+	// that are edited or removed leave theirs behind
+	maxCachedPrograms = 512
+)
+
+// programs caches compiled conditions by expression
+var programs = lru.New(maxCachedPrograms)
+
+// compile compiles a condition, it must evaluate to a bool
+func compile(condition string) (cel.Program, error) {
+	if program, ok := programs.Get(condition); ok {
+		return program.(cel.Program), nil
+	}
+	env, err := env()
+	if err != nil {
+		return nil, err
+	}
+	ast, issues := env.Compile(condition)
+	if issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	if ast.OutputType() != cel.BoolType {
+		return nil, fmt.Errorf("condition must be a bool, not %s", ast.OutputType())
+	}
+	program, err := env.Program(ast,
+		cel.CostLimit(conditionCostLimit),
+		cel.InterruptCheckFrequency(interruptCheckFrequency),
+	)
+	if err != nil {
+		return nil, err
+	}
+	programs.Add(condition, program)
+	return program, nil
+}
+
+// Validate checks the tool patterns and conditions of a policy
+func Validate(policy *kubechainv1alpha1.ApprovalPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	for i, rule := range policy.Rules {
+		if err := mcpmanager.ValidateToolPatterns(rule.Tools); err != nil {
+			return fmt.Errorf("approval rule %d: %w", i, err)
+		}
+		if rule.Condition != "" {
+			if _, err := compile(rule.Condition); err != nil {
+				return fmt.Errorf("approval rule %d: invalid condition: %w", i, err)
+			}
+		}
+	}
+	return ValidateTimeouts(policy.Timeouts)
+}
+
+// Decide decides on a call of a tool with args. Calls that no rule matches
+// get the policy's default action, or defaultAction if it has none. A
+// condition that fails to evaluate, e.g. on an argument the call lacks, is an
+// error: a call is never let through by a broken rule, nor by one that
+// exceeds its cost limit or deadline.
+func Decide(ctx context.Context, policy *kubechainv1alpha1.ApprovalPolicy, defaultAction kubechainv1alpha1.ApprovalAction, toolName string, args map[string]interface{}) (Decision, error) {
+	if policy == nil {
+		return Decision{Action: defaultAction, Reason: "no approval policy"}, nil
+	}
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+
+	for i, rule := range policy.Rules {
+		if len(rule.Tools) > 0 && !mcpmanager.MatchesAny(rule.Tools, toolName) {
+			continue
+		}
+		if rule.Condition != "" {
+			matched, err := evaluate(ctx, rule.Condition, toolName, args)
+			if err != nil {
+				return Decision{}, fmt.Errorf("approval rule %d: %w", i, err)
+			}
+			if !matched {
+				continue
+			}
+		}
+		return Decision{
+			Action:         rule.Action,
+			ContactChannel: rule.ContactChannel,
+			Reason:         fmt.Sprintf("approval rule %d", i),
+		}, nil
+	}
+
+	if policy.DefaultAction != "" {
+		defaultAction = policy.DefaultAction
+	}
+	return Decision{Action: defaultAction, Reason: "no approval rule matched"}, nil
+}
+
+// evaluate evaluates a condition for a call
+func evaluate(ctx context.Context, condition, toolName string, args map[string]interface{}) (bool, error) {
+	program, err := compile(condition)
+	if err != nil {
+		return false, fmt.Errorf("invalid condition: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, conditionTimeout)
+	defer cancel()
+	out, _, err := program.ContextEval(ctx, map[string]interface{}{"args": args, "tool": toolName})
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate condition: %w", err)
+	}
+	matched, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("condition evaluated to %v, not a bool", out.Value())
+	}
+	return matched, nil
+}
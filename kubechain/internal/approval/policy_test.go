@@ -0,0 +1,132 @@
+package approval
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+var _ = Describe("Approval policies", func() {
+	policy := &kubechainv1alpha1.ApprovalPolicy{
+		Rules: []kubechainv1alpha1.ApprovalRule{
+			{Tools: []string{"delete_*"}, Action: kubechainv1alpha1.ApprovalActionDeny},
+			{
+				Tools:          []string{"write_file"},
+				Condition:      "args.path.startsWith('/prod')",
+				Action:         kubechainv1alpha1.ApprovalActionRequireApproval,
+				ContactChannel: &kubechainv1alpha1.LocalObjectReference{Name: "prod-approvers"},
+			},
+			{Tools: []string{"read_*", "list_*"}, Action: kubechainv1alpha1.ApprovalActionApprove},
+		},
+	}
+
+	Context("Decide", func() {
+		It("decides with the first matching rule", func() {
+			decision, err := Decide(context.Background(), policy, kubechainv1alpha1.ApprovalActionRequireApproval, "delete_file", nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decision.Action).To(Equal(kubechainv1alpha1.ApprovalActionDeny))
+			Expect(decision.Reason).To(Equal("approval rule 0"))
+
+			decision, err = Decide(context.Background(), policy, kubechainv1alpha1.ApprovalActionRequireApproval, "read_file", map[string]interface{}{"path": "/prod/config"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decision.Action).To(Equal(kubechainv1alpha1.ApprovalActionApprove))
+		})
+
+		It("matches rules on their condition", func() {
+			decision, err := Decide(context.Background(), policy, kubechainv1alpha1.ApprovalActionApprove, "write_file", map[string]interface{}{"path": "/prod/config"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decision.Action).To(Equal(kubechainv1alpha1.ApprovalActionRequireApproval))
+			Expect(decision.ContactChannel.Name).To(Equal("prod-approvers"))
+
+			decision, err = Decide(context.Background(), policy, kubechainv1alpha1.ApprovalActionApprove, "write_file", map[string]interface{}{"path": "/tmp/scratch"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decision.Action).To(Equal(kubechainv1alpha1.ApprovalActionApprove))
+			Expect(decision.Reason).To(Equal("no approval rule matched"))
+		})
+
+		It("gives calls no rule matches the default action", func() {
+			decision, err := Decide(context.Background(), policy, kubechainv1alpha1.ApprovalActionRequireApproval, "send_email", nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decision.Action).To(Equal(kubechainv1alpha1.ApprovalActionRequireApproval))
+
+			withDefault := policy.DeepCopy()
+			withDefault.DefaultAction = kubechainv1alpha1.ApprovalActionDeny
+			decision, err = Decide(context.Background(), withDefault, kubechainv1alpha1.ApprovalActionRequireApproval, "send_email", nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decision.Action).To(Equal(kubechainv1alpha1.ApprovalActionDeny))
+
+			decision, err = Decide(context.Background(), nil, kubechainv1alpha1.ApprovalActionApprove, "send_email", nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decision.Action).To(Equal(kubechainv1alpha1.ApprovalActionApprove))
+		})
+
+		It("fails calls whose condition can't be evaluated", func() {
+			_, err := Decide(context.Background(), policy, kubechainv1alpha1.ApprovalActionApprove, "write_file", map[string]interface{}{"content": "hello"})
+			Expect(err).To(MatchError(ContainSubstring("approval rule 1")))
+		})
+
+		Context("with a condition that loops over its arguments", func() {
+			expensive := &kubechainv1alpha1.ApprovalPolicy{Rules: []kubechainv1alpha1.ApprovalRule{{
+				Condition: "args.items.all(x, args.items.all(y, args.items.all(z, x + y + z >= 0)))",
+				Action:    kubechainv1alpha1.ApprovalActionApprove,
+			}}}
+			items := make([]interface{}, 200)
+			for i := range items {
+				items[i] = i
+			}
+
+			It("fails calls that exceed the cost limit", func() {
+				_, err := Decide(context.Background(), expensive, kubechainv1alpha1.ApprovalActionRequireApproval, "batch", map[string]interface{}{"items": items})
+				Expect(err).To(MatchError(ContainSubstring("cost limit")))
+			})
+
+			It("fails calls whose evaluation is cancelled", func() {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				_, err := Decide(ctx, expensive, kubechainv1alpha1.ApprovalActionRequireApproval, "batch", map[string]interface{}{"items": items[:20]})
+				Expect(err).To(MatchError(ContainSubstring("interrupted")))
+			})
+		})
+	})
+
+	Context("compile", func() {
+		It("keeps a bounded number of programs", func() {
+			for i := 0; i < maxCachedPrograms+10; i++ {
+				_, err := compile(fmt.Sprintf("tool == 'tool_%d'", i))
+				Expect(err).NotTo(HaveOccurred())
+			}
+			Expect(programs.Len()).To(Equal(maxCachedPrograms))
+		})
+	})
+
+	Context("Validate", func() {
+		It("accepts valid policies", func() {
+			Expect(Validate(policy)).To(Succeed())
+			Expect(Validate(nil)).To(Succeed())
+		})
+
+		It("rejects invalid patterns and conditions", func() {
+			Expect(Validate(&kubechainv1alpha1.ApprovalPolicy{Rules: []kubechainv1alpha1.ApprovalRule{
+				{Tools: []string{"read_["}, Action: kubechainv1alpha1.ApprovalActionApprove},
+			}})).To(MatchError(ContainSubstring("invalid tool pattern")))
+
+			Expect(Validate(&kubechainv1alpha1.ApprovalPolicy{Rules: []kubechainv1alpha1.ApprovalRule{
+				{Condition: "args.path.startsWith(", Action: kubechainv1alpha1.ApprovalActionApprove},
+			}})).To(MatchError(ContainSubstring("invalid condition")))
+
+			Expect(Validate(&kubechainv1alpha1.ApprovalPolicy{Rules: []kubechainv1alpha1.ApprovalRule{
+				{Condition: "args.path", Action: kubechainv1alpha1.ApprovalActionApprove},
+			}})).To(MatchError(ContainSubstring("must be a bool")))
+		})
+	})
+})
+
+func TestApproval(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Approval Suite")
+}
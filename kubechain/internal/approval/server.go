@@ -0,0 +1,333 @@
+package approval
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+// Server is a small HTTP API and UI for answering the ApprovalRequests of
+// inCluster contact channels:
+//
+//	GET  /                                                       pending requests, as a page
+//	POST /login                                                  signs the page in with a token
+//	GET  /api/v1/approvalrequests                                pending requests, as JSON
+//	POST /api/v1/namespaces/{namespace}/approvalrequests/{name}/{approve,reject,respond}
+//
+// The POST endpoints take the form values comment (approve, reject) and
+// response (respond). Requests carry a Kubernetes bearer token; its user
+// needs to list approvalrequests to see them and to update
+// approvalrequests/status to answer them, as with kubectl.
+type Server struct {
+	client client.Client
+	addr   string
+	// csrfKey signs the CSRF tokens of the page's forms
+	csrfKey []byte
+}
+
+// NewServer returns a Server listening on addr, to be added to a manager
+func NewServer(c client.Client, addr string) *Server {
+	return &Server{client: c, addr: addr, csrfKey: []byte(rand.Text())}
+}
+
+// Start implements manager.Runnable
+func (s *Server) Start(ctx context.Context) error {
+	server := &http.Server{
+		Addr:              s.addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		log.FromContext(ctx).Info("Starting approval server", "addr", s.addr)
+		errs <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return server.Shutdown(shutdownCtx)
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, every replica
+// can answer requests
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// Handler routes the API and UI
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.page)
+	mux.HandleFunc("POST /login", s.login)
+	mux.HandleFunc("GET /api/v1/approvalrequests", s.list)
+	mux.HandleFunc("POST /api/v1/namespaces/{namespace}/approvalrequests/{name}/{action}", s.answer)
+	return mux
+}
+
+// pending returns the requests no one answered yet, oldest first
+func (s *Server) pending(ctx context.Context, namespace string) ([]kubechainv1alpha1.ApprovalRequest, error) {
+	var requests kubechainv1alpha1.ApprovalRequestList
+	if err := s.client.List(ctx, &requests, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	pending := slices.DeleteFunc(requests.Items, func(request kubechainv1alpha1.ApprovalRequest) bool {
+		return request.Status.Phase != "" && request.Status.Phase != kubechainv1alpha1.ApprovalRequestPhasePending
+	})
+	slices.SortFunc(pending, func(a, b kubechainv1alpha1.ApprovalRequest) int {
+		return a.CreationTimestamp.Compare(b.CreationTimestamp.Time)
+	})
+	return pending, nil
+}
+
+func (s *Server) list(w http.ResponseWriter, req *http.Request) {
+	namespace := req.URL.Query().Get("namespace")
+	c, err := s.authenticate(req)
+	if err == nil {
+		err = s.authorize(req.Context(), c, "list", "", namespace, "")
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	pending, err := s.pending(req.Context(), namespace)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(pending); err != nil {
+		log.FromContext(req.Context()).Error(err, "Failed to write approval requests")
+	}
+}
+
+func (s *Server) page(w http.ResponseWriter, req *http.Request) {
+	namespace := req.URL.Query().Get("namespace")
+	c, err := s.authenticate(req)
+	if errors.Is(err, errUnauthenticated) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusUnauthorized)
+		if err := loginTemplate.Execute(w, nil); err != nil {
+			log.FromContext(req.Context()).Error(err, "Failed to render login")
+		}
+		return
+	}
+	if err == nil {
+		err = s.authorize(req.Context(), c, "list", "", namespace, "")
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	pending, err := s.pending(req.Context(), namespace)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	data := struct {
+		Requests []kubechainv1alpha1.ApprovalRequest
+		CSRF     string
+	}{pending, s.csrfToken(c)}
+	if err := pageTemplate.Execute(w, data); err != nil {
+		log.FromContext(req.Context()).Error(err, "Failed to render approval requests")
+	}
+}
+
+// login keeps the token of the login form in a cookie for the page's forms,
+// once the API server knows it
+func (s *Server) login(w http.ResponseWriter, req *http.Request) {
+	token := strings.TrimSpace(req.FormValue("token"))
+	if _, err := s.reviewToken(req.Context(), token); err != nil {
+		writeError(w, err)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     tokenCookie,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   req.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+	http.Redirect(w, req, "/", http.StatusSeeOther)
+}
+
+var errAnswered = errors.New("approval request was already answered")
+
+func (s *Server) answer(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	key := client.ObjectKey{Namespace: req.PathValue("namespace"), Name: req.PathValue("name")}
+
+	c, err := s.authenticate(req)
+	if err == nil {
+		err = s.checkCSRF(req, c)
+	}
+	if err == nil {
+		err = s.authorize(ctx, c, "update", "status", key.Namespace, key.Name)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var request kubechainv1alpha1.ApprovalRequest
+	if err := s.client.Get(ctx, key, &request); err != nil {
+		http.Error(w, err.Error(), statusCode(err))
+		return
+	}
+
+	if err := answerRequest(&request, req.PathValue("action"), req.FormValue("comment"), req.FormValue("response")); err != nil {
+		http.Error(w, err.Error(), statusCode(err))
+		return
+	}
+	if err := s.client.Status().Update(ctx, &request); err != nil {
+		http.Error(w, err.Error(), statusCode(err))
+		return
+	}
+	log.FromContext(ctx).Info("Approval request answered", "approvalRequest", key, "phase", request.Status.Phase,
+		"user", c.user.Username)
+
+	// forms of the UI go back to the list, API clients get the request
+	if req.URL.Query().Has("ui") {
+		http.Redirect(w, req, "/", http.StatusSeeOther)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(request); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to write approval request")
+	}
+}
+
+// answerRequest records a human's answer to a pending request
+func answerRequest(request *kubechainv1alpha1.ApprovalRequest, action, comment, response string) error {
+	if request.Status.Phase != "" && request.Status.Phase != kubechainv1alpha1.ApprovalRequestPhasePending {
+		return errAnswered
+	}
+
+	functionCall := request.Spec.Type == kubechainv1alpha1.ApprovalRequestTypeFunctionCall
+	switch {
+	case action == "approve" && functionCall:
+		request.Status.Phase = kubechainv1alpha1.ApprovalRequestPhaseApproved
+		request.Status.Comment = comment
+	case action == "reject" && functionCall:
+		request.Status.Phase = kubechainv1alpha1.ApprovalRequestPhaseRejected
+		request.Status.Comment = comment
+	case action == "respond" && !functionCall:
+		if response == "" {
+			return badRequestError("response is required")
+		}
+		request.Status.Phase = kubechainv1alpha1.ApprovalRequestPhaseResponded
+		request.Status.Response = response
+	default:
+		return badRequestError("cannot " + action + " a " + string(request.Spec.Type) + " request")
+	}
+
+	now := metav1.Now()
+	request.Status.RespondedAt = &now
+	return nil
+}
+
+type badRequestError string
+
+func (e badRequestError) Error() string {
+	return string(e)
+}
+
+// writeError answers a request with an error
+func writeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errUnauthenticated) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="kubechain-approvals"`)
+	}
+	http.Error(w, err.Error(), statusCode(err))
+}
+
+func statusCode(err error) int {
+	var badRequest badRequestError
+	switch {
+	case errors.As(err, &badRequest):
+		return http.StatusBadRequest
+	case errors.Is(err, errUnauthenticated):
+		return http.StatusUnauthorized
+	case errors.Is(err, errForbidden), errors.Is(err, errInvalidCSRF):
+		return http.StatusForbidden
+	case errors.Is(err, errAnswered), apierrors.IsConflict(err):
+		return http.StatusConflict
+	case apierrors.IsNotFound(err):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+var pageTemplate = template.Must(template.New("approvals").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Kubechain approvals</title></head>
+<body>
+<h1>Pending approvals</h1>
+{{- $csrf := .CSRF}}
+{{- if not .Requests}}
+<p>Nothing to approve.</p>
+{{- end}}
+{{- range .Requests}}
+<section>
+<h2>{{.Namespace}}/{{.Name}}</h2>
+<p>Channel {{.Spec.ContactChannelRef.Name}}, TaskRunToolCall {{.Spec.RunID}}, requested {{.CreationTimestamp}}</p>
+{{- if eq .Spec.Type "FunctionCall"}}
+<pre>{{.Spec.FunctionName}}({{.Spec.Arguments}})</pre>
+<form method="post" action="/api/v1/namespaces/{{.Namespace}}/approvalrequests/{{.Name}}/approve?ui">
+<input type="hidden" name="csrf" value="{{$csrf}}">
+<input name="comment" placeholder="comment">
+<button>Approve</button>
+<button formaction="/api/v1/namespaces/{{.Namespace}}/approvalrequests/{{.Name}}/reject?ui">Reject</button>
+</form>
+{{- else}}
+<pre>{{.Spec.Message}}</pre>
+<form method="post" action="/api/v1/namespaces/{{.Namespace}}/approvalrequests/{{.Name}}/respond?ui">
+<input type="hidden" name="csrf" value="{{$csrf}}">
+<textarea name="response" required></textarea>
+<button>Respond</button>
+</form>
+{{- end}}
+</section>
+{{- end}}
+</body>
+</html>
+`))
+
+var loginTemplate = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Kubechain approvals</title></head>
+<body>
+<h1>Sign in</h1>
+<p>Paste a Kubernetes token, e.g. from <code>kubectl create token</code>, of a user who may answer approval requests.</p>
+<form method="post" action="/login">
+<input type="password" name="token" required>
+<button>Sign in</button>
+</form>
+</body>
+</html>
+`))
@@ -0,0 +1,202 @@
+package approval
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+var _ = Describe("Approval server", func() {
+	var (
+		k8s     client.Client
+		handler http.Handler
+	)
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(kubechainv1alpha1.AddToScheme(scheme)).To(Succeed())
+		pending := kubechainv1alpha1.ApprovalRequestStatus{Phase: kubechainv1alpha1.ApprovalRequestPhasePending}
+		k8s = fake.NewClientBuilder().WithScheme(scheme).
+			WithStatusSubresource(&kubechainv1alpha1.ApprovalRequest{}).
+			WithObjects(
+				&kubechainv1alpha1.ApprovalRequest{
+					ObjectMeta: metav1.ObjectMeta{Name: "ec-1", Namespace: "default"},
+					Spec: kubechainv1alpha1.ApprovalRequestSpec{
+						Type:         kubechainv1alpha1.ApprovalRequestTypeFunctionCall,
+						FunctionName: "delete_file",
+					},
+					Status: pending,
+				},
+				&kubechainv1alpha1.ApprovalRequest{
+					ObjectMeta: metav1.ObjectMeta{Name: "hc-1", Namespace: "default"},
+					Spec: kubechainv1alpha1.ApprovalRequestSpec{
+						Type:    kubechainv1alpha1.ApprovalRequestTypeHumanContact,
+						Message: "Can I restart the billing service?",
+					},
+					Status: pending,
+				},
+			).
+			WithInterceptorFuncs(interceptor.Funcs{Create: review}).
+			Build()
+		handler = NewServer(k8s, ":0").Handler()
+	})
+
+	send := func(method, path, token string, form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	post := func(path string, form url.Values) *httptest.ResponseRecorder {
+		return send(http.MethodPost, path, approverToken, form)
+	}
+
+	get := func(name string) kubechainv1alpha1.ApprovalRequest {
+		var request kubechainv1alpha1.ApprovalRequest
+		Expect(k8s.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: name}, &request)).To(Succeed())
+		return request
+	}
+
+	It("lists pending requests", func() {
+		rec := send(http.MethodGet, "/", approverToken, nil)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(ContainSubstring("delete_file"))
+		Expect(rec.Body.String()).To(ContainSubstring("Can I restart the billing service?"))
+	})
+
+	It("approves function calls once", func() {
+		rec := post("/api/v1/namespaces/default/approvalrequests/ec-1/approve", url.Values{"comment": {"fine"}})
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		request := get("ec-1")
+		Expect(request.Status.Phase).To(Equal(kubechainv1alpha1.ApprovalRequestPhaseApproved))
+		Expect(request.Status.Comment).To(Equal("fine"))
+		Expect(request.Status.RespondedAt).NotTo(BeNil())
+
+		rec = post("/api/v1/namespaces/default/approvalrequests/ec-1/reject", nil)
+		Expect(rec.Code).To(Equal(http.StatusConflict))
+	})
+
+	It("records responses to human contacts", func() {
+		rec := post("/api/v1/namespaces/default/approvalrequests/hc-1/approve", nil)
+		Expect(rec.Code).To(Equal(http.StatusBadRequest))
+
+		rec = post("/api/v1/namespaces/default/approvalrequests/hc-1/respond?ui", url.Values{"response": {"Yes"}})
+		Expect(rec.Code).To(Equal(http.StatusSeeOther))
+		request := get("hc-1")
+		Expect(request.Status.Phase).To(Equal(kubechainv1alpha1.ApprovalRequestPhaseResponded))
+		Expect(request.Status.Response).To(Equal("Yes"))
+	})
+
+	It("returns not found for unknown requests", func() {
+		rec := post("/api/v1/namespaces/default/approvalrequests/nope/approve", nil)
+		Expect(rec.Code).To(Equal(http.StatusNotFound))
+	})
+
+	It("requires a token the API server knows", func() {
+		rec := send(http.MethodGet, "/api/v1/approvalrequests", "", nil)
+		Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+		Expect(rec.Header().Get("WWW-Authenticate")).To(HavePrefix("Bearer"))
+
+		rec = send(http.MethodPost, "/api/v1/namespaces/default/approvalrequests/ec-1/approve", "stolen", nil)
+		Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+		Expect(get("ec-1").Status.Phase).To(Equal(kubechainv1alpha1.ApprovalRequestPhasePending))
+
+		rec = send(http.MethodGet, "/", "", nil)
+		Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+		Expect(rec.Body.String()).To(ContainSubstring(`action="/login"`))
+	})
+
+	It("lets only users who may update the status answer", func() {
+		rec := send(http.MethodGet, "/api/v1/approvalrequests", viewerToken, nil)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(ContainSubstring("delete_file"))
+
+		rec = send(http.MethodPost, "/api/v1/namespaces/default/approvalrequests/ec-1/approve", viewerToken, nil)
+		Expect(rec.Code).To(Equal(http.StatusForbidden))
+		Expect(get("ec-1").Status.Phase).To(Equal(kubechainv1alpha1.ApprovalRequestPhasePending))
+	})
+
+	It("needs the CSRF token of the page for forms signed in with the cookie", func() {
+		rec := send(http.MethodPost, "/login", "", url.Values{"token": {approverToken}})
+		Expect(rec.Code).To(Equal(http.StatusSeeOther))
+		cookies := rec.Result().Cookies()
+		Expect(cookies).To(HaveLen(1))
+		Expect(cookies[0].HttpOnly).To(BeTrue())
+
+		browse := func(method, path string, form url.Values) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.AddCookie(cookies[0])
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			return rec
+		}
+
+		rec = browse(http.MethodGet, "/", nil)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		match := regexp.MustCompile(`name="csrf" value="([0-9a-f]+)"`).FindStringSubmatch(rec.Body.String())
+		Expect(match).To(HaveLen(2))
+
+		rec = browse(http.MethodPost, "/api/v1/namespaces/default/approvalrequests/ec-1/approve?ui", nil)
+		Expect(rec.Code).To(Equal(http.StatusForbidden))
+		Expect(get("ec-1").Status.Phase).To(Equal(kubechainv1alpha1.ApprovalRequestPhasePending))
+
+		rec = browse(http.MethodPost, "/api/v1/namespaces/default/approvalrequests/ec-1/approve?ui", url.Values{"csrf": {match[1]}})
+		Expect(rec.Code).To(Equal(http.StatusSeeOther))
+		Expect(get("ec-1").Status.Phase).To(Equal(kubechainv1alpha1.ApprovalRequestPhaseApproved))
+	})
+
+	It("refuses to sign in with an unknown token", func() {
+		rec := send(http.MethodPost, "/login", "", url.Values{"token": {"stolen"}})
+		Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+		Expect(rec.Result().Cookies()).To(BeEmpty())
+	})
+})
+
+const (
+	approverToken = "approver-token"
+	viewerToken   = "viewer-token"
+)
+
+// review answers TokenReviews and SubjectAccessReviews like an API server
+// that knows an approver, who may answer requests, and a viewer, who may
+// only list them
+func review(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+	switch review := obj.(type) {
+	case *authenticationv1.TokenReview:
+		users := map[string]string{approverToken: "approver", viewerToken: "viewer"}
+		if user, ok := users[review.Spec.Token]; ok {
+			review.Status.Authenticated = true
+			review.Status.User = authenticationv1.UserInfo{Username: user}
+		}
+		return nil
+	case *authorizationv1.SubjectAccessReview:
+		attributes := review.Spec.ResourceAttributes
+		answers := attributes.Verb == "update" && attributes.Subresource == "status"
+		lists := attributes.Verb == "list" && attributes.Subresource == ""
+		review.Status.Allowed = attributes.Resource == "approvalrequests" &&
+			(review.Spec.User == "approver" && (answers || lists) || review.Spec.User == "viewer" && lists)
+		return nil
+	}
+	return c.Create(ctx, obj, opts...)
+}
@@ -0,0 +1,76 @@
+package approval
+
+import (
+	"fmt"
+	"time"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+// Step is what a call that still waits for approval needs next
+type Step string
+
+const (
+	// StepWait keeps waiting for an answer
+	StepWait Step = "Wait"
+	// StepRemind asks the contact channel asked last again
+	StepRemind Step = "Remind"
+	// StepEscalate asks the escalation contact channel
+	StepEscalate Step = "Escalate"
+	// StepTimeOut gives up waiting, the timeout's OnTimeout applies
+	StepTimeOut Step = "TimeOut"
+)
+
+// ValidateTimeouts checks that the durations of approval timeouts are
+// positive and that escalation happens before the timeout
+func ValidateTimeouts(timeouts *kubechainv1alpha1.ApprovalTimeouts) error {
+	if timeouts == nil {
+		return nil
+	}
+	if timeouts.Timeout != nil && timeouts.Timeout.Duration <= 0 {
+		return fmt.Errorf("approval timeout must be positive")
+	}
+	if timeouts.ReminderInterval != nil && timeouts.ReminderInterval.Duration <= 0 {
+		return fmt.Errorf("approval reminder interval must be positive")
+	}
+	if escalation := timeouts.Escalation; escalation != nil {
+		if escalation.After.Duration <= 0 {
+			return fmt.Errorf("approval escalation must happen after a positive duration")
+		}
+		if timeouts.Timeout != nil && escalation.After.Duration >= timeouts.Timeout.Duration {
+			return fmt.Errorf("approval escalation after %s never happens with a timeout of %s",
+				escalation.After.Duration, timeouts.Timeout.Duration)
+		}
+	}
+	return nil
+}
+
+// OnTimeout is what happens to a call no one approved in time
+func OnTimeout(timeouts *kubechainv1alpha1.ApprovalTimeouts) kubechainv1alpha1.ApprovalTimeoutAction {
+	if timeouts == nil || timeouts.OnTimeout == "" {
+		return kubechainv1alpha1.ApprovalTimeoutActionReject
+	}
+	return timeouts.OnTimeout
+}
+
+// NextStep decides what a call that still waits for approval needs at now.
+// Timing out comes before escalating, and escalating before reminding.
+func NextStep(timeouts *kubechainv1alpha1.ApprovalTimeouts, approval *kubechainv1alpha1.ToolCallApproval, now time.Time) Step {
+	if timeouts == nil || approval == nil || len(approval.Calls) == 0 {
+		return StepWait
+	}
+	waited := now.Sub(approval.RequestedAt.Time)
+
+	if timeouts.Timeout != nil && waited >= timeouts.Timeout.Duration {
+		return StepTimeOut
+	}
+	if timeouts.Escalation != nil && approval.Stage != kubechainv1alpha1.ApprovalStageEscalated &&
+		waited >= timeouts.Escalation.After.Duration {
+		return StepEscalate
+	}
+	lastAsked := approval.Calls[len(approval.Calls)-1].RequestedAt.Time
+	if timeouts.ReminderInterval != nil && now.Sub(lastAsked) >= timeouts.ReminderInterval.Duration {
+		return StepRemind
+	}
+	return StepWait
+}
@@ -0,0 +1,93 @@
+package approval
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+var _ = Describe("Approval timeouts", func() {
+	timeouts := &kubechainv1alpha1.ApprovalTimeouts{
+		Timeout:          &metav1.Duration{Duration: time.Hour},
+		ReminderInterval: &metav1.Duration{Duration: 10 * time.Minute},
+		Escalation: &kubechainv1alpha1.ApprovalEscalation{
+			After:          metav1.Duration{Duration: 30 * time.Minute},
+			ContactChannel: kubechainv1alpha1.LocalObjectReference{Name: "oncall"},
+		},
+	}
+	requestedAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// waiting returns an approval first asked for at requestedAt, and asked
+	// for again at each of the given offsets
+	waiting := func(stage kubechainv1alpha1.ApprovalStage, askedAgain ...time.Duration) *kubechainv1alpha1.ToolCallApproval {
+		approval := &kubechainv1alpha1.ToolCallApproval{
+			Stage:       stage,
+			RequestedAt: metav1.NewTime(requestedAt),
+			Calls:       []kubechainv1alpha1.ApprovalCall{{CallID: "call-0", RequestedAt: metav1.NewTime(requestedAt)}},
+		}
+		for _, offset := range askedAgain {
+			approval.Calls = append(approval.Calls, kubechainv1alpha1.ApprovalCall{RequestedAt: metav1.NewTime(requestedAt.Add(offset))})
+		}
+		return approval
+	}
+
+	Context("NextStep", func() {
+		It("reminds the last contact channel asked once the interval passed", func() {
+			Expect(NextStep(timeouts, waiting(kubechainv1alpha1.ApprovalStageRequested), requestedAt.Add(5*time.Minute))).To(Equal(StepWait))
+			Expect(NextStep(timeouts, waiting(kubechainv1alpha1.ApprovalStageRequested), requestedAt.Add(10*time.Minute))).To(Equal(StepRemind))
+			Expect(NextStep(timeouts, waiting(kubechainv1alpha1.ApprovalStageReminded, 10*time.Minute), requestedAt.Add(15*time.Minute))).To(Equal(StepWait))
+		})
+
+		It("escalates once, before reminding", func() {
+			Expect(NextStep(timeouts, waiting(kubechainv1alpha1.ApprovalStageRequested), requestedAt.Add(30*time.Minute))).To(Equal(StepEscalate))
+			Expect(NextStep(timeouts, waiting(kubechainv1alpha1.ApprovalStageEscalated, 30*time.Minute), requestedAt.Add(35*time.Minute))).To(Equal(StepWait))
+			Expect(NextStep(timeouts, waiting(kubechainv1alpha1.ApprovalStageEscalated, 30*time.Minute), requestedAt.Add(40*time.Minute))).To(Equal(StepRemind))
+		})
+
+		It("times out before anything else", func() {
+			Expect(NextStep(timeouts, waiting(kubechainv1alpha1.ApprovalStageRequested), requestedAt.Add(2*time.Hour))).To(Equal(StepTimeOut))
+		})
+
+		It("waits without timeouts", func() {
+			Expect(NextStep(nil, waiting(kubechainv1alpha1.ApprovalStageRequested), requestedAt.Add(24*time.Hour))).To(Equal(StepWait))
+			Expect(NextStep(timeouts, nil, requestedAt.Add(24*time.Hour))).To(Equal(StepWait))
+		})
+	})
+
+	Context("OnTimeout", func() {
+		It("rejects by default", func() {
+			Expect(OnTimeout(nil)).To(Equal(kubechainv1alpha1.ApprovalTimeoutActionReject))
+			Expect(OnTimeout(timeouts)).To(Equal(kubechainv1alpha1.ApprovalTimeoutActionReject))
+			Expect(OnTimeout(&kubechainv1alpha1.ApprovalTimeouts{OnTimeout: kubechainv1alpha1.ApprovalTimeoutActionFail})).
+				To(Equal(kubechainv1alpha1.ApprovalTimeoutActionFail))
+		})
+	})
+
+	Context("ValidateTimeouts", func() {
+		It("accepts valid timeouts", func() {
+			Expect(ValidateTimeouts(timeouts)).To(Succeed())
+			Expect(ValidateTimeouts(nil)).To(Succeed())
+		})
+
+		It("rejects durations that are not positive", func() {
+			Expect(ValidateTimeouts(&kubechainv1alpha1.ApprovalTimeouts{
+				Timeout: &metav1.Duration{},
+			})).To(MatchError(ContainSubstring("timeout must be positive")))
+
+			Expect(ValidateTimeouts(&kubechainv1alpha1.ApprovalTimeouts{
+				ReminderInterval: &metav1.Duration{Duration: -time.Minute},
+			})).To(MatchError(ContainSubstring("reminder interval must be positive")))
+		})
+
+		It("rejects escalating after the timeout", func() {
+			late := timeouts.DeepCopy()
+			late.Escalation.After.Duration = 2 * time.Hour
+			Expect(ValidateTimeouts(late)).To(MatchError(ContainSubstring("never happens")))
+			Expect(Validate(&kubechainv1alpha1.ApprovalPolicy{Timeouts: late})).To(MatchError(ContainSubstring("never happens")))
+		})
+	})
+})
@@ -5,11 +5,17 @@ import (
 	"fmt"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
 	"github.com/humanlayer/smallchain/kubechain/internal/mcpmanager"
@@ -25,6 +31,7 @@ const (
 // +kubebuilder:rbac:groups=kubechain.humanlayer.dev,resources=llms,verbs=get;list;watch
 // +kubebuilder:rbac:groups=kubechain.humanlayer.dev,resources=tools,verbs=get;list;watch
 // +kubebuilder:rbac:groups=kubechain.humanlayer.dev,resources=mcpservers,verbs=get;list;watch
+// +kubebuilder:rbac:groups=kubechain.humanlayer.dev,resources=modelrouters,verbs=get;list;watch
 
 // AgentReconciler reconciles a Agent object
 type AgentReconciler struct {
@@ -36,22 +43,64 @@ type AgentReconciler struct {
 
 // validateLLM checks if the referenced LLM exists and is ready
 func (r *AgentReconciler) validateLLM(ctx context.Context, agent *kubechainv1alpha1.Agent) error {
+	return r.validateLLMRef(ctx, agent.Namespace, agent.Spec.LLMRef.Name)
+}
+
+// validateLLMRef checks if an LLM exists and is ready
+func (r *AgentReconciler) validateLLMRef(ctx context.Context, namespace, name string) error {
 	llm := &kubechainv1alpha1.LLM{}
 	err := r.Get(ctx, client.ObjectKey{
-		Namespace: agent.Namespace,
-		Name:      agent.Spec.LLMRef.Name,
+		Namespace: namespace,
+		Name:      name,
 	}, llm)
 	if err != nil {
-		return fmt.Errorf("failed to get LLM %q: %w", agent.Spec.LLMRef.Name, err)
+		return fmt.Errorf("failed to get LLM %q: %w", name, err)
 	}
 
 	if llm.Status.Status != StatusReady {
-		return fmt.Errorf("LLM %q is not ready", agent.Spec.LLMRef.Name)
+		return fmt.Errorf("LLM %q is not ready", name)
+	}
+
+	return nil
+}
+
+// validateRouter checks if the referenced ModelRouter exists and all LLMs it
+// can route to are ready
+func (r *AgentReconciler) validateRouter(ctx context.Context, agent *kubechainv1alpha1.Agent) error {
+	if agent.Spec.RouterRef == nil {
+		return nil
+	}
+
+	router := &kubechainv1alpha1.ModelRouter{}
+	err := r.Get(ctx, client.ObjectKey{
+		Namespace: agent.Namespace,
+		Name:      agent.Spec.RouterRef.Name,
+	}, router)
+	if err != nil {
+		return fmt.Errorf("failed to get ModelRouter %q: %w", agent.Spec.RouterRef.Name, err)
+	}
+
+	for _, name := range routerLLMs(router) {
+		if err := r.validateLLMRef(ctx, agent.Namespace, name); err != nil {
+			return fmt.Errorf("ModelRouter %q: %w", router.Name, err)
+		}
 	}
 
 	return nil
 }
 
+// routerLLMs lists the names of all LLMs a ModelRouter references
+func routerLLMs(router *kubechainv1alpha1.ModelRouter) []string {
+	names := make([]string, 0, len(router.Spec.Routes)+1)
+	for _, route := range router.Spec.Routes {
+		names = append(names, route.LLMRef.Name)
+	}
+	if router.Spec.Classifier != nil {
+		names = append(names, router.Spec.Classifier.LLMRef.Name)
+	}
+	return names
+}
+
 // validateTools checks if all referenced tools exist and are ready
 func (r *AgentReconciler) validateTools(ctx context.Context, agent *kubechainv1alpha1.Agent) ([]kubechainv1alpha1.ResolvedTool, error) {
 	validTools := make([]kubechainv1alpha1.ResolvedTool, 0, len(agent.Spec.Tools))
@@ -88,6 +137,10 @@ func (r *AgentReconciler) validateMCPServers(ctx context.Context, agent *kubecha
 	validMCPServers := make([]kubechainv1alpha1.ResolvedMCPServer, 0, len(agent.Spec.MCPServers))
 
 	for _, serverRef := range agent.Spec.MCPServers {
+		if err := mcpmanager.ValidateToolFilter(serverRef); err != nil {
+			return validMCPServers, fmt.Errorf("MCPServer %q: %w", serverRef.Name, err)
+		}
+
 		mcpServer := &kubechainv1alpha1.MCPServer{}
 		err := r.Get(ctx, client.ObjectKey{
 			Namespace: agent.Namespace,
@@ -101,12 +154,13 @@ func (r *AgentReconciler) validateMCPServers(ctx context.Context, agent *kubecha
 			return validMCPServers, fmt.Errorf("MCPServer %q is not connected", serverRef.Name)
 		}
 
-		tools, exists := r.MCPManager.GetTools(mcpServer.Name)
+		tools, exists := r.MCPManager.GetTools(mcpServer.Namespace, mcpServer.Name)
 		if !exists {
 			return validMCPServers, fmt.Errorf("failed to get tools for MCPServer %q", mcpServer.Name)
 		}
 
-		// Create list of tool names
+		// Create list of the names of the tools the agent may use
+		tools = mcpmanager.FilterTools(serverRef, tools)
 		toolNames := make([]string, 0, len(tools))
 		for _, tool := range tools {
 			toolNames = append(toolNames, tool.Name)
@@ -162,6 +216,22 @@ func (r *AgentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 		return ctrl.Result{}, err // requeue
 	}
 
+	// Validate the ModelRouter reference, if any
+	if err := r.validateRouter(ctx, &agent); err != nil {
+		logger.Error(err, "ModelRouter validation failed")
+		statusUpdate.Status.Ready = false
+		statusUpdate.Status.Status = StatusError
+		statusUpdate.Status.StatusDetail = err.Error()
+		statusUpdate.Status.ValidTools = validTools
+		statusUpdate.Status.ValidMCPServers = validMCPServers
+		r.recorder.Event(&agent, corev1.EventTypeWarning, "ValidationFailed", err.Error())
+		if updateErr := r.Status().Update(ctx, statusUpdate); updateErr != nil {
+			logger.Error(updateErr, "Failed to update Agent status")
+			return ctrl.Result{}, fmt.Errorf("failed to update agent status: %v", err)
+		}
+		return ctrl.Result{}, err // requeue
+	}
+
 	// Validate Tool references
 	validTools, err := r.validateTools(ctx, &agent)
 	if err != nil {
@@ -220,16 +290,116 @@ func (r *AgentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 	return ctrl.Result{}, nil
 }
 
+// agentsForLLM maps an LLM to the agents that reference it, directly or
+// through their ModelRouter, so agents follow their LLMs' health
+func (r *AgentReconciler) agentsForLLM(ctx context.Context, obj client.Object) []reconcile.Request {
+	logger := log.FromContext(ctx)
+
+	var agents kubechainv1alpha1.AgentList
+	if err := r.List(ctx, &agents, client.InNamespace(obj.GetNamespace())); err != nil {
+		logger.Error(err, "Failed to list agents for LLM", "llm", obj.GetName())
+		return nil
+	}
+
+	// routers that route to this LLM
+	routers := map[string]bool{}
+	var routerList kubechainv1alpha1.ModelRouterList
+	if err := r.List(ctx, &routerList, client.InNamespace(obj.GetNamespace())); err != nil {
+		logger.Error(err, "Failed to list model routers for LLM", "llm", obj.GetName())
+	}
+	for i := range routerList.Items {
+		for _, name := range routerLLMs(&routerList.Items[i]) {
+			if name == obj.GetName() {
+				routers[routerList.Items[i].Name] = true
+			}
+		}
+	}
+
+	var requests []reconcile.Request
+	for _, agent := range agents.Items {
+		if agent.Spec.LLMRef.Name == obj.GetName() ||
+			(agent.Spec.RouterRef != nil && routers[agent.Spec.RouterRef.Name]) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&agent)})
+		}
+	}
+	return requests
+}
+
+// agentsForModelRouter maps a ModelRouter to the agents that reference it
+func (r *AgentReconciler) agentsForModelRouter(ctx context.Context, obj client.Object) []reconcile.Request {
+	var agents kubechainv1alpha1.AgentList
+	if err := r.List(ctx, &agents, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list agents for ModelRouter", "router", obj.GetName())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, agent := range agents.Items {
+		if agent.Spec.RouterRef != nil && agent.Spec.RouterRef.Name == obj.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&agent)})
+		}
+	}
+	return requests
+}
+
+// llmReadinessChanged filters LLM updates down to readiness transitions,
+// ignoring the status churn of periodic health probes
+var llmReadinessChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldLLM, okOld := e.ObjectOld.(*kubechainv1alpha1.LLM)
+		newLLM, okNew := e.ObjectNew.(*kubechainv1alpha1.LLM)
+		if !okOld || !okNew {
+			return false
+		}
+		return oldLLM.Status.Ready != newLLM.Status.Ready || oldLLM.Status.Status != newLLM.Status.Status
+	},
+}
+
+// agentsForMCPServer maps an MCPServer to the agents that use it, so agents
+// pick up its changed tools
+func (r *AgentReconciler) agentsForMCPServer(ctx context.Context, obj client.Object) []reconcile.Request {
+	var agents kubechainv1alpha1.AgentList
+	if err := r.List(ctx, &agents, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list agents for MCPServer", "mcpServer", obj.GetName())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, agent := range agents.Items {
+		if _, referenced := mcpmanager.AgentServerRef(&agent, obj.GetName()); referenced {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&agent)})
+		}
+	}
+	return requests
+}
+
+// mcpServerToolsChanged filters MCPServer updates down to changes of its
+// connection or tools, ignoring the status churn of health checks
+var mcpServerToolsChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldServer, okOld := e.ObjectOld.(*kubechainv1alpha1.MCPServer)
+		newServer, okNew := e.ObjectNew.(*kubechainv1alpha1.MCPServer)
+		if !okOld || !okNew {
+			return false
+		}
+		return oldServer.Status.Connected != newServer.Status.Connected ||
+			!equality.Semantic.DeepEqual(oldServer.Status.Tools, newServer.Status.Tools)
+	},
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *AgentReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.recorder = mgr.GetEventRecorderFor("agent-controller")
 
-	// Initialize MCPManager if not already set
-	if r.MCPManager == nil {
-		r.MCPManager = mcpmanager.NewMCPServerManager()
-	}
-
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&kubechainv1alpha1.Agent{}).
+		Watches(&kubechainv1alpha1.LLM{},
+			handler.EnqueueRequestsFromMapFunc(r.agentsForLLM),
+			builder.WithPredicates(llmReadinessChanged)).
+		Watches(&kubechainv1alpha1.ModelRouter{},
+			handler.EnqueueRequestsFromMapFunc(r.agentsForModelRouter)).
+		Watches(&kubechainv1alpha1.MCPServer{},
+			handler.EnqueueRequestsFromMapFunc(r.agentsForMCPServer),
+			builder.WithPredicates(mcpServerToolsChanged)).
 		Complete(r)
 }
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/mail"
+	"regexp"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -32,6 +33,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+	"github.com/humanlayer/smallchain/kubechain/internal/approval"
 )
 
 var (
@@ -112,8 +114,23 @@ func (r *ContactChannelReconciler) validateEmailAddress(email string) error {
 	return nil
 }
 
+// e164Pattern matches phone numbers in E.164 format, a + and up to 15 digits
+var e164Pattern = regexp.MustCompile(`^\+[1-9]\d{1,14}$`)
+
+// validatePhoneNumber checks if the phone number is in E.164 format
+func (r *ContactChannelReconciler) validatePhoneNumber(phoneNumber string) error {
+	if !e164Pattern.MatchString(phoneNumber) {
+		return fmt.Errorf("invalid phone number %q: must be in E.164 format, e.g. +14155552671", phoneNumber)
+	}
+	return nil
+}
+
 // validateChannelConfig validates the channel configuration based on channel type
 func (r *ContactChannelReconciler) validateChannelConfig(channel *kubechainv1alpha1.ContactChannel) error {
+	if err := approval.ValidateTimeouts(channel.Spec.ApprovalTimeouts); err != nil {
+		return err
+	}
+
 	switch channel.Spec.Type {
 	case kubechainv1alpha1.ContactChannelTypeSlack:
 		if channel.Spec.Slack == nil {
@@ -128,6 +145,22 @@ func (r *ContactChannelReconciler) validateChannelConfig(channel *kubechainv1alp
 		}
 		return r.validateEmailAddress(channel.Spec.Email.Address)
 
+	case kubechainv1alpha1.ContactChannelTypeSMS:
+		if channel.Spec.SMS == nil {
+			return fmt.Errorf("sms is required for sms channel type")
+		}
+		return r.validatePhoneNumber(channel.Spec.SMS.PhoneNumber)
+
+	case kubechainv1alpha1.ContactChannelTypeWhatsApp:
+		if channel.Spec.WhatsApp == nil {
+			return fmt.Errorf("whatsapp is required for whatsapp channel type")
+		}
+		return r.validatePhoneNumber(channel.Spec.WhatsApp.PhoneNumber)
+
+	case kubechainv1alpha1.ContactChannelTypeInCluster:
+		// approvals are ApprovalRequests in the channel's namespace
+		return nil
+
 	default:
 		return fmt.Errorf("unsupported channel type: %s", channel.Spec.Type)
 	}
@@ -135,6 +168,10 @@ func (r *ContactChannelReconciler) validateChannelConfig(channel *kubechainv1alp
 
 // validateSecret validates the secret and the API key
 func (r *ContactChannelReconciler) validateSecret(ctx context.Context, channel *kubechainv1alpha1.ContactChannel) error {
+	if channel.Spec.APIKeyFrom == nil {
+		return fmt.Errorf("apiKeyFrom is required for %s channel type", channel.Spec.Type)
+	}
+
 	secret := &corev1.Secret{}
 	err := r.Get(ctx, types.NamespacedName{
 		Name:      channel.Spec.APIKeyFrom.SecretKeyRef.Name,
@@ -172,8 +209,8 @@ func (r *ContactChannelReconciler) validateSecret(ctx context.Context, channel *
 		// This would depend on how HumanLayer handles the integration
 		return nil
 
-	case kubechainv1alpha1.ContactChannelTypeEmail:
-		// Email validation doesn't require additional API key validation
+	case kubechainv1alpha1.ContactChannelTypeEmail, kubechainv1alpha1.ContactChannelTypeSMS, kubechainv1alpha1.ContactChannelTypeWhatsApp:
+		// Email, SMS and WhatsApp validation doesn't require additional API key validation
 		return nil
 
 	default:
@@ -228,8 +265,13 @@ func (r *ContactChannelReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		return ctrl.Result{}, nil
 	}
 
-	// Validate secret and API key
-	if err := r.validateSecret(ctx, &channel); err != nil {
+	// inCluster channels never talk to HumanLayer, there is no API key to validate
+	if channel.Spec.Type == kubechainv1alpha1.ContactChannelTypeInCluster {
+		statusUpdate.Status.Ready = true
+		statusUpdate.Status.Status = statusReady
+		statusUpdate.Status.StatusDetail = "In-cluster channel ready, approve with ApprovalRequests"
+		r.recorder.Event(&channel, corev1.EventTypeNormal, eventReasonValidationSucceeded, statusUpdate.Status.StatusDetail)
+	} else if err := r.validateSecret(ctx, &channel); err != nil {
 		log.Error(err, "Secret validation failed")
 		statusUpdate.Status.Ready = false
 		statusUpdate.Status.Status = statusError
@@ -102,7 +102,7 @@ var _ = Describe("ContactChannel Controller", func() {
 				},
 				Spec: kubechainv1alpha1.ContactChannelSpec{
 					Type: "slack",
-					APIKeyFrom: kubechainv1alpha1.APIKeySource{
+					APIKeyFrom: &kubechainv1alpha1.APIKeySource{
 						SecretKeyRef: kubechainv1alpha1.SecretKeyRef{
 							Name: secretName,
 							Key:  secretKey,
@@ -138,6 +138,41 @@ var _ = Describe("ContactChannel Controller", func() {
 			Expect(updatedChannel.Status.StatusDetail).To(ContainSubstring("validated successfully"))
 		})
 
+		It("should mark an inCluster channel ready without an API key", func() {
+			By("Creating a ContactChannel resource for in-cluster approvals")
+			channel := &kubechainv1alpha1.ContactChannel{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: kubechainv1alpha1.ContactChannelSpec{
+					Type: kubechainv1alpha1.ContactChannelTypeInCluster,
+				},
+			}
+			Expect(k8sClient.Create(ctx, channel)).To(Succeed())
+
+			By("Reconciling the resource")
+			eventRecorder := record.NewFakeRecorder(10)
+			controllerReconciler := &ContactChannelReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				recorder: eventRecorder,
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking the resource status")
+			updatedChannel := &kubechainv1alpha1.ContactChannel{}
+			err = k8sClient.Get(ctx, typeNamespacedName, updatedChannel)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updatedChannel.Status.Ready).To(BeTrue())
+			Expect(updatedChannel.Status.Status).To(Equal(statusReady))
+			Expect(updatedChannel.Status.HumanLayerProject).To(BeEmpty())
+		})
+
 		It("should successfully validate an Email channel with valid config", func() {
 			By("Creating a secret with valid API key")
 			secret := &corev1.Secret{
@@ -159,7 +194,7 @@ var _ = Describe("ContactChannel Controller", func() {
 				},
 				Spec: kubechainv1alpha1.ContactChannelSpec{
 					Type: "email",
-					APIKeyFrom: kubechainv1alpha1.APIKeySource{
+					APIKeyFrom: &kubechainv1alpha1.APIKeySource{
 						SecretKeyRef: kubechainv1alpha1.SecretKeyRef{
 							Name: secretName,
 							Key:  secretKey,
@@ -196,6 +231,86 @@ var _ = Describe("ContactChannel Controller", func() {
 			Expect(updatedChannel.Status.StatusDetail).To(ContainSubstring("validated successfully"))
 		})
 
+		It("should successfully validate an SMS channel with valid config", func() {
+			By("Creating a secret with valid API key")
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      secretName,
+					Namespace: "default",
+				},
+				Data: map[string][]byte{
+					secretKey: []byte("valid-humanlayer-key"),
+				},
+			}
+			Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+
+			By("Creating a ContactChannel resource for SMS")
+			channel := &kubechainv1alpha1.ContactChannel{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: kubechainv1alpha1.ContactChannelSpec{
+					Type: "sms",
+					APIKeyFrom: &kubechainv1alpha1.APIKeySource{
+						SecretKeyRef: kubechainv1alpha1.SecretKeyRef{
+							Name: secretName,
+							Key:  secretKey,
+						},
+					},
+					SMS: &kubechainv1alpha1.SMSChannelConfig{
+						PhoneNumber:      "+14155552671",
+						ContextAboutUser: "The on-call engineer",
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, channel)).To(Succeed())
+
+			By("Reconciling the resource")
+			eventRecorder := record.NewFakeRecorder(10)
+			controllerReconciler := &ContactChannelReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				recorder: eventRecorder,
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking the resource status")
+			updatedChannel := &kubechainv1alpha1.ContactChannel{}
+			err = k8sClient.Get(ctx, typeNamespacedName, updatedChannel)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updatedChannel.Status.Ready).To(BeTrue())
+			Expect(updatedChannel.Status.Status).To(Equal(statusReady))
+			Expect(updatedChannel.Status.StatusDetail).To(ContainSubstring("validated successfully"))
+		})
+
+		It("should reject a WhatsApp channel whose phone number is not in E.164 format", func() {
+			By("Creating a ContactChannel resource with a local phone number")
+			channel := &kubechainv1alpha1.ContactChannel{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: kubechainv1alpha1.ContactChannelSpec{
+					Type: "whatsapp",
+					APIKeyFrom: &kubechainv1alpha1.APIKeySource{
+						SecretKeyRef: kubechainv1alpha1.SecretKeyRef{
+							Name: secretName,
+							Key:  secretKey,
+						},
+					},
+					WhatsApp: &kubechainv1alpha1.WhatsAppChannelConfig{
+						PhoneNumber: "0415 555 2671",
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, channel)).To(MatchError(ContainSubstring("spec.whatsapp.phoneNumber")))
+		})
+
 		It("should fail validation with invalid configuration", func() {
 			By("Creating a secret with valid API key")
 			secret := &corev1.Secret{
@@ -217,7 +332,7 @@ var _ = Describe("ContactChannel Controller", func() {
 				},
 				Spec: kubechainv1alpha1.ContactChannelSpec{
 					Type: "slack",
-					APIKeyFrom: kubechainv1alpha1.APIKeySource{
+					APIKeyFrom: &kubechainv1alpha1.APIKeySource{
 						SecretKeyRef: kubechainv1alpha1.SecretKeyRef{
 							Name: secretName,
 							Key:  secretKey,
@@ -250,6 +365,55 @@ var _ = Describe("ContactChannel Controller", func() {
 			Expect(updatedChannel.Status.StatusDetail).To(ContainSubstring("slackConfig"))
 		})
 
+		It("should name the missing sms field of an SMS channel", func() {
+			By("Creating a secret with valid API key")
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      secretName,
+					Namespace: "default",
+				},
+				Data: map[string][]byte{
+					secretKey: []byte("valid-humanlayer-key"),
+				},
+			}
+			Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+
+			By("Creating an SMS ContactChannel without its sms config")
+			channel := &kubechainv1alpha1.ContactChannel{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: kubechainv1alpha1.ContactChannelSpec{
+					Type: "sms",
+					APIKeyFrom: &kubechainv1alpha1.APIKeySource{
+						SecretKeyRef: kubechainv1alpha1.SecretKeyRef{
+							Name: secretName,
+							Key:  secretKey,
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, channel)).To(Succeed())
+
+			By("Reconciling the resource")
+			controllerReconciler := &ContactChannelReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				recorder: record.NewFakeRecorder(10),
+			}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking the resource status")
+			updatedChannel := &kubechainv1alpha1.ContactChannel{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, updatedChannel)).To(Succeed())
+			Expect(updatedChannel.Status.Ready).To(BeFalse())
+			Expect(updatedChannel.Status.StatusDetail).To(ContainSubstring("sms is required for sms channel type"))
+		})
+
 		It("should fail validation with invalid API key", func() {
 			By("Creating a secret with invalid API key")
 			secret := &corev1.Secret{
@@ -271,7 +435,7 @@ var _ = Describe("ContactChannel Controller", func() {
 				},
 				Spec: kubechainv1alpha1.ContactChannelSpec{
 					Type: "slack",
-					APIKeyFrom: kubechainv1alpha1.APIKeySource{
+					APIKeyFrom: &kubechainv1alpha1.APIKeySource{
 						SecretKeyRef: kubechainv1alpha1.SecretKeyRef{
 							Name: secretName,
 							Key:  secretKey,
@@ -315,7 +479,7 @@ var _ = Describe("ContactChannel Controller", func() {
 				},
 				Spec: kubechainv1alpha1.ContactChannelSpec{
 					Type: "slack",
-					APIKeyFrom: kubechainv1alpha1.APIKeySource{
+					APIKeyFrom: &kubechainv1alpha1.APIKeySource{
 						SecretKeyRef: kubechainv1alpha1.SecretKeyRef{
 							Name: "nonexistent-secret",
 							Key:  secretKey,
@@ -371,7 +535,7 @@ var _ = Describe("ContactChannel Controller", func() {
 				},
 				Spec: kubechainv1alpha1.ContactChannelSpec{
 					Type: "email",
-					APIKeyFrom: kubechainv1alpha1.APIKeySource{
+					APIKeyFrom: &kubechainv1alpha1.APIKeySource{
 						SecretKeyRef: kubechainv1alpha1.SecretKeyRef{
 							Name: secretName,
 							Key:  secretKey,
@@ -18,7 +18,9 @@ package llm
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/tmc/langchaingo/llms"
@@ -28,12 +30,16 @@ import (
 	"github.com/tmc/langchaingo/llms/mistral"
 	"github.com/tmc/langchaingo/llms/openai"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
 )
@@ -45,8 +51,9 @@ import (
 // LLMReconciler reconciles a LLM object
 type LLMReconciler struct {
 	client.Client
-	Scheme   *runtime.Scheme
-	recorder record.EventRecorder
+	Scheme     *runtime.Scheme
+	recorder   record.EventRecorder
+	httpClient *http.Client
 }
 
 //
@@ -321,8 +328,85 @@ func (r *LLMReconciler) validateSecret(ctx context.Context, llm *kubechainv1alph
 	return string(apiKey), nil
 }
 
+// validateStaticConfig checks the parts of the configuration that can be
+// validated without talking to the provider
+func validateStaticConfig(llm *kubechainv1alpha1.LLM) error {
+	switch llm.Spec.Provider {
+	case "openai", "anthropic", "mistral", "google":
+		return nil
+	case "vertex":
+		if llm.Spec.Vertex == nil {
+			return fmt.Errorf("vertex configuration is required for vertex provider")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported provider: %s. Supported providers are: openai, anthropic, mistral, google, vertex", llm.Spec.Provider)
+	}
+}
+
+// setCondition records a condition on the LLM status
+func setCondition(llm *kubechainv1alpha1.LLM, conditionType string, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&llm.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: llm.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// applyProbeResult translates a probe result into conditions, latency stats and the overall status
+func applyProbeResult(llm *kubechainv1alpha1.LLM, result probeResult) {
+	now := metav1.Now()
+	llm.Status.LastProbeTime = &now
+	if result.latency > 0 {
+		llm.Status.Latency = recordLatency(llm.Status.Latency, result.latency)
+	}
+
+	if result.err == nil {
+		llm.Status.ConsecutiveFailures = 0
+		setCondition(llm, kubechainv1alpha1.LLMConditionCredentialsValid, metav1.ConditionTrue, "Accepted", "Provider accepted the credentials")
+		setCondition(llm, kubechainv1alpha1.LLMConditionReachable, metav1.ConditionTrue, "Reachable", "Provider API responded")
+		if result.modelChecked {
+			setCondition(llm, kubechainv1alpha1.LLMConditionModelAvailable, metav1.ConditionTrue, "Available", "Model is available")
+		} else {
+			setCondition(llm, kubechainv1alpha1.LLMConditionModelAvailable, metav1.ConditionUnknown, "NotChecked", "Provider did not list its models")
+		}
+		llm.Status.Ready = true
+		llm.Status.Status = "Ready"
+		llm.Status.StatusDetail = fmt.Sprintf("%s provider validated successfully", llm.Spec.Provider)
+		return
+	}
+
+	llm.Status.ConsecutiveFailures++
+	llm.Status.Ready = false
+	llm.Status.Status = "Error"
+	llm.Status.StatusDetail = result.err.Error()
+
+	var pe *probeError
+	if !errors.As(result.err, &pe) {
+		pe = &probeError{failure: probeFailureReachability, reason: "ProbeFailed", err: result.err}
+	}
+
+	switch pe.failure {
+	case probeFailureCredentials:
+		setCondition(llm, kubechainv1alpha1.LLMConditionCredentialsValid, metav1.ConditionFalse, pe.reason, pe.Error())
+		setCondition(llm, kubechainv1alpha1.LLMConditionReachable, metav1.ConditionTrue, "Reachable", "Provider API responded")
+		setCondition(llm, kubechainv1alpha1.LLMConditionModelAvailable, metav1.ConditionUnknown, "NotChecked", "Credentials were rejected")
+	case probeFailureModel:
+		setCondition(llm, kubechainv1alpha1.LLMConditionCredentialsValid, metav1.ConditionTrue, "Accepted", "Provider accepted the credentials")
+		setCondition(llm, kubechainv1alpha1.LLMConditionReachable, metav1.ConditionTrue, "Reachable", "Provider API responded")
+		setCondition(llm, kubechainv1alpha1.LLMConditionModelAvailable, metav1.ConditionFalse, pe.reason, pe.Error())
+	default:
+		setCondition(llm, kubechainv1alpha1.LLMConditionCredentialsValid, metav1.ConditionUnknown, "NotChecked", "Provider could not be probed")
+		setCondition(llm, kubechainv1alpha1.LLMConditionReachable, metav1.ConditionFalse, pe.reason, pe.Error())
+		setCondition(llm, kubechainv1alpha1.LLMConditionModelAvailable, metav1.ConditionUnknown, "NotChecked", "Provider could not be probed")
+	}
+}
+
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
+// The provider is re-probed every healthCheck interval.
 func (r *LLMReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := log.FromContext(ctx)
 
@@ -332,6 +416,12 @@ func (r *LLMReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
+	// Resyncs and other events that don't change the spec must not probe the
+	// provider before the interval passed, that would spend its quota
+	if wait := untilNextProbe(&llm, time.Now()); wait > 0 {
+		return ctrl.Result{RequeueAfter: wait}, nil
+	}
+
 	log.Info("Starting reconciliation", "namespacedName", req.NamespacedName, "provider", llm.Spec.Provider)
 
 	// Create a copy for status update
@@ -344,6 +434,9 @@ func (r *LLMReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 		r.recorder.Event(&llm, corev1.EventTypeNormal, "Initializing", "Starting validation")
 	}
 
+	dryRun := llm.Spec.HealthCheck != nil && llm.Spec.HealthCheck.DryRun
+	failureReason := "ValidationFailed"
+
 	// Validate secret and get API key (if applicable)
 	// TODO: Will this work with amazon bedrock? Probably not?? If so we should look at adding tests for this specifically.
 	apiKey, err := r.validateSecret(ctx, &llm)
@@ -352,21 +445,41 @@ func (r *LLMReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 		statusUpdate.Status.Ready = false
 		statusUpdate.Status.Status = "Error"
 		statusUpdate.Status.StatusDetail = err.Error()
-		r.recorder.Event(&llm, corev1.EventTypeWarning, "SecretValidationFailed", err.Error())
-	} else {
-		// Validate provider with API key
-		err := r.validateProviderConfig(ctx, &llm, apiKey)
-		if err != nil {
-			log.Error(err, "Provider validation failed")
+		setCondition(statusUpdate, kubechainv1alpha1.LLMConditionCredentialsValid, metav1.ConditionFalse, "SecretInvalid", err.Error())
+		setCondition(statusUpdate, kubechainv1alpha1.LLMConditionReachable, metav1.ConditionUnknown, "NotChecked", "Credentials are missing")
+		setCondition(statusUpdate, kubechainv1alpha1.LLMConditionModelAvailable, metav1.ConditionUnknown, "NotChecked", "Credentials are missing")
+		failureReason = "SecretValidationFailed"
+	} else if dryRun {
+		if err := validateStaticConfig(&llm); err != nil {
 			statusUpdate.Status.Ready = false
 			statusUpdate.Status.Status = "Error"
 			statusUpdate.Status.StatusDetail = err.Error()
-			r.recorder.Event(&llm, corev1.EventTypeWarning, "ValidationFailed", err.Error())
 		} else {
 			statusUpdate.Status.Ready = true
 			statusUpdate.Status.Status = "Ready"
-			statusUpdate.Status.StatusDetail = fmt.Sprintf("%s provider validated successfully", llm.Spec.Provider)
+			statusUpdate.Status.StatusDetail = fmt.Sprintf("%s provider configuration validated (dry run)", llm.Spec.Provider)
+		}
+		for _, conditionType := range []string{
+			kubechainv1alpha1.LLMConditionCredentialsValid,
+			kubechainv1alpha1.LLMConditionReachable,
+			kubechainv1alpha1.LLMConditionModelAvailable,
+		} {
+			setCondition(statusUpdate, conditionType, metav1.ConditionUnknown, "DryRun", "Provider calls are skipped in dry run mode")
+		}
+	} else {
+		// Probe the provider with the API key
+		applyProbeResult(statusUpdate, r.probe(ctx, &llm, apiKey))
+		if !statusUpdate.Status.Ready {
+			log.Error(errors.New(statusUpdate.Status.StatusDetail), "Provider validation failed")
+		}
+	}
+
+	// Only emit events on transitions, the periodic probes would flood them otherwise
+	if llm.Status.Status != statusUpdate.Status.Status || llm.Status.StatusDetail != statusUpdate.Status.StatusDetail {
+		if statusUpdate.Status.Ready {
 			r.recorder.Event(&llm, corev1.EventTypeNormal, "ValidationSucceeded", statusUpdate.Status.StatusDetail)
+		} else {
+			r.recorder.Event(&llm, corev1.EventTypeWarning, failureReason, statusUpdate.Status.StatusDetail)
 		}
 	}
 
@@ -381,14 +494,15 @@ func (r *LLMReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 		"ready", statusUpdate.Status.Ready,
 		"status", statusUpdate.Status.Status,
 		"statusDetail", statusUpdate.Status.StatusDetail)
-	return ctrl.Result{}, nil
+	return ctrl.Result{RequeueAfter: healthCheckInterval(&llm)}, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *LLMReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.recorder = mgr.GetEventRecorderFor("llm-controller")
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&kubechainv1alpha1.LLM{}).
+		// status patches with the probe results must not trigger another probe
+		For(&kubechainv1alpha1.LLM{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		Named("llm").
 		Complete(r)
 }
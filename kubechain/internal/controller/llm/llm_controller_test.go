@@ -20,14 +20,20 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
@@ -270,6 +276,12 @@ var _ = Describe("LLM Controller", func() {
 				w.Header().Set("Content-Type", "application/json")
 				w.WriteHeader(http.StatusOK)
 
+				// Health probes list the models
+				if strings.HasSuffix(r.URL.Path, "/models") {
+					_, _ = w.Write([]byte(`{"data":[{"id":"test-model"},{"id":"gpt-4"}]}`))
+					return
+				}
+
 				// Return appropriate responses based on the provider being tested
 				_, err := w.Write([]byte(`{"id":"test-id","choices":[{"message":{"content":"test"}}]}`))
 				if err != nil {
@@ -638,5 +650,132 @@ var _ = Describe("LLM Controller", func() {
 			By("checking that a success event was created")
 			utils.ExpectRecorder(eventRecorder).ToEmitEventContaining("ValidationSucceeded")
 		})
+
+		It("should report an unavailable model from the models list", func() {
+			By("Creating test resources with a model the provider doesn't offer")
+			fixture := NewLLMTestFixture("openai", resourceName, secretName, secretKey, "test-key", mockServer.URL)
+			llm, _, err := fixture.Setup(ctx, k8sClient)
+			Expect(err).NotTo(HaveOccurred())
+			llm.Spec.Parameters.Model = "not-a-model"
+			Expect(k8sClient.Update(ctx, llm)).To(Succeed())
+
+			By("Reconciling the resource")
+			reconciler, eventRecorder := getReconciler()
+			result, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(5 * time.Minute))
+
+			By("Checking the resource status")
+			updatedLLM := &kubechainv1alpha1.LLM{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, updatedLLM)).To(Succeed())
+			Expect(updatedLLM.Status.Ready).To(BeFalse())
+			Expect(updatedLLM.Status.Status).To(Equal("Error"))
+			Expect(updatedLLM.Status.ConsecutiveFailures).To(Equal(1))
+			Expect(meta.IsStatusConditionTrue(updatedLLM.Status.Conditions, kubechainv1alpha1.LLMConditionCredentialsValid)).To(BeTrue())
+			Expect(meta.IsStatusConditionTrue(updatedLLM.Status.Conditions, kubechainv1alpha1.LLMConditionReachable)).To(BeTrue())
+			Expect(meta.IsStatusConditionFalse(updatedLLM.Status.Conditions, kubechainv1alpha1.LLMConditionModelAvailable)).To(BeTrue())
+			Expect(updatedLLM.Status.Latency).NotTo(BeNil())
+			Expect(updatedLLM.Status.Latency.Samples).To(Equal(int64(1)))
+
+			utils.ExpectRecorder(eventRecorder).ToEmitEventContaining("ValidationFailed")
+		})
+
+		It("should report rejected credentials", func() {
+			By("Pointing the LLM at a server that rejects the key")
+			unauthorized := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+			}))
+			defer unauthorized.Close()
+
+			fixture := NewLLMTestFixture("openai", resourceName, secretName, secretKey, "bad-key", unauthorized.URL)
+			_, _, err := fixture.Setup(ctx, k8sClient)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Reconciling the resource")
+			reconciler, _ := getReconciler()
+			_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking the resource status")
+			updatedLLM := &kubechainv1alpha1.LLM{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, updatedLLM)).To(Succeed())
+			Expect(updatedLLM.Status.Ready).To(BeFalse())
+			Expect(meta.IsStatusConditionFalse(updatedLLM.Status.Conditions, kubechainv1alpha1.LLMConditionCredentialsValid)).To(BeTrue())
+		})
+
+		It("should skip provider calls in dry run mode", func() {
+			By("Creating an LLM in dry run mode pointing at a server that fails every call")
+			var providerCalls atomic.Int32
+			failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				providerCalls.Add(1)
+				w.WriteHeader(http.StatusInternalServerError)
+			}))
+			defer failing.Close()
+
+			fixture := NewLLMTestFixture("openai", resourceName, secretName, secretKey, "test-key", failing.URL)
+			llm, _, err := fixture.Setup(ctx, k8sClient)
+			Expect(err).NotTo(HaveOccurred())
+			llm.Spec.HealthCheck = &kubechainv1alpha1.LLMHealthCheckSpec{
+				DryRun:   true,
+				Interval: &metav1.Duration{Duration: time.Minute},
+			}
+			Expect(k8sClient.Update(ctx, llm)).To(Succeed())
+
+			By("Reconciling the resource")
+			reconciler, _ := getReconciler()
+			result, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(time.Minute))
+			Expect(providerCalls.Load()).To(BeZero())
+
+			By("Checking the resource status")
+			updatedLLM := &kubechainv1alpha1.LLM{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, updatedLLM)).To(Succeed())
+			Expect(updatedLLM.Status.Ready).To(BeTrue())
+			Expect(updatedLLM.Status.StatusDetail).To(ContainSubstring("dry run"))
+			Expect(updatedLLM.Status.LastProbeTime).To(BeNil())
+			condition := meta.FindStatusCondition(updatedLLM.Status.Conditions, kubechainv1alpha1.LLMConditionReachable)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Reason).To(Equal("DryRun"))
+		})
+
+		It("should not probe again after a status-only update", func() {
+			By("Counting the calls to the provider")
+			var providerCalls atomic.Int32
+			counting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				providerCalls.Add(1)
+				mockServer.Config.Handler.ServeHTTP(w, r)
+			}))
+			defer counting.Close()
+
+			fixture := NewLLMTestFixture("openai", resourceName, secretName, secretKey, "test-key", counting.URL)
+			_, _, err := fixture.Setup(ctx, k8sClient)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Probing the provider once")
+			reconciler, _ := getReconciler()
+			_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			probes := providerCalls.Load()
+			Expect(probes).NotTo(BeZero())
+
+			By("Updating only the status")
+			updatedLLM := &kubechainv1alpha1.LLM{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, updatedLLM)).To(Succeed())
+			oldLLM := updatedLLM.DeepCopy()
+			updatedLLM.Status.StatusDetail = "touched"
+			Expect(k8sClient.Status().Update(ctx, updatedLLM)).To(Succeed())
+			Expect(predicate.GenerationChangedPredicate{}.Update(event.UpdateEvent{
+				ObjectOld: oldLLM,
+				ObjectNew: updatedLLM,
+			})).To(BeFalse())
+
+			By("Reconciling again within the interval")
+			result, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(providerCalls.Load()).To(Equal(probes))
+			Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+			Expect(result.RequeueAfter).To(BeNumerically("<=", 5*time.Minute))
+		})
 	})
 })
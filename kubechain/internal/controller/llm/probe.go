@@ -0,0 +1,319 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+const (
+	defaultHealthCheckInterval = 5 * time.Minute
+	defaultHealthCheckTimeout  = 30 * time.Second
+
+	openAIDefaultBaseURL    = "https://api.openai.com/v1"
+	anthropicDefaultBaseURL = "https://api.anthropic.com/v1"
+	anthropicAPIVersion     = "2023-06-01"
+	mistralDefaultBaseURL   = "https://api.mistral.ai"
+	googleModelsURL         = "https://generativelanguage.googleapis.com/v1beta/models"
+)
+
+// probeFailure classifies why a health probe failed, so it can be mapped
+// onto the matching condition
+type probeFailure string
+
+const (
+	probeFailureCredentials  probeFailure = kubechainv1alpha1.LLMConditionCredentialsValid
+	probeFailureReachability probeFailure = kubechainv1alpha1.LLMConditionReachable
+	probeFailureModel        probeFailure = kubechainv1alpha1.LLMConditionModelAvailable
+)
+
+// probeError is returned when a health probe fails
+type probeError struct {
+	failure probeFailure
+	reason  string
+	err     error
+}
+
+func (e *probeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *probeError) Unwrap() error {
+	return e.err
+}
+
+// probeResult is the outcome of a health probe
+type probeResult struct {
+	// latency is the round trip of the probe, zero if no response was received
+	latency time.Duration
+	// modelChecked is false when the probe couldn't tell whether the model exists
+	modelChecked bool
+	err          error
+}
+
+// modelsRequest describes the models list endpoint of a provider
+type modelsRequest struct {
+	url     string
+	headers map[string]string
+	// parse extracts the model IDs from a page of the response and returns the next page URL, if any
+	parse func(body []byte) (models []string, next string, err error)
+}
+
+// modelsListRequest returns the models list endpoint for the LLM's provider,
+// or false if the provider has none we can use
+func modelsListRequest(llm *kubechainv1alpha1.LLM, apiKey string) (*modelsRequest, bool) {
+	baseURL := strings.TrimSuffix(llm.Spec.Parameters.BaseURL, "/")
+
+	switch llm.Spec.Provider {
+	case "openai":
+		// Azure deployments don't expose a compatible models list
+		if llm.Spec.OpenAI != nil && llm.Spec.OpenAI.APIType != "" && llm.Spec.OpenAI.APIType != "OPEN_AI" {
+			return nil, false
+		}
+		if baseURL == "" {
+			baseURL = openAIDefaultBaseURL
+		}
+		headers := map[string]string{"Authorization": "Bearer " + apiKey}
+		if llm.Spec.OpenAI != nil && llm.Spec.OpenAI.Organization != "" {
+			headers["OpenAI-Organization"] = llm.Spec.OpenAI.Organization
+		}
+		return &modelsRequest{url: baseURL + "/models", headers: headers, parse: parseDataModelIDs}, true
+
+	case "anthropic":
+		if baseURL == "" {
+			baseURL = anthropicDefaultBaseURL
+		}
+		headers := map[string]string{
+			"x-api-key":         apiKey,
+			"anthropic-version": anthropicAPIVersion,
+		}
+		return &modelsRequest{url: baseURL + "/models?limit=1000", headers: headers, parse: parseDataModelIDs}, true
+
+	case "mistral":
+		if baseURL == "" {
+			baseURL = mistralDefaultBaseURL
+		}
+		headers := map[string]string{"Authorization": "Bearer " + apiKey}
+		return &modelsRequest{url: baseURL + "/v1/models", headers: headers, parse: parseDataModelIDs}, true
+
+	case "google":
+		query := url.Values{"key": {apiKey}, "pageSize": {"1000"}}
+		return &modelsRequest{
+			url:     googleModelsURL + "?" + query.Encode(),
+			headers: map[string]string{},
+			parse:   parseGoogleModels(query),
+		}, true
+	}
+
+	return nil, false
+}
+
+// parseDataModelIDs parses the OpenAI-style {"data": [{"id": ...}]} models list
+func parseDataModelIDs(body []byte) ([]string, string, error) {
+	var resp struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, "", fmt.Errorf("failed to parse models list: %w", err)
+	}
+
+	models := make([]string, 0, len(resp.Data))
+	for _, m := range resp.Data {
+		models = append(models, m.ID)
+	}
+	return models, "", nil
+}
+
+// parseGoogleModels parses the paginated Gemini models list
+func parseGoogleModels(query url.Values) func(body []byte) ([]string, string, error) {
+	return func(body []byte) ([]string, string, error) {
+		var resp struct {
+			Models []struct {
+				Name string `json:"name"`
+			} `json:"models"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return nil, "", fmt.Errorf("failed to parse models list: %w", err)
+		}
+
+		models := make([]string, 0, len(resp.Models))
+		for _, m := range resp.Models {
+			models = append(models, strings.TrimPrefix(m.Name, "models/"))
+		}
+
+		next := ""
+		if resp.NextPageToken != "" {
+			nextQuery := url.Values{}
+			for k, v := range query {
+				nextQuery[k] = v
+			}
+			nextQuery.Set("pageToken", resp.NextPageToken)
+			next = googleModelsURL + "?" + nextQuery.Encode()
+		}
+		return models, next, nil
+	}
+}
+
+// probeModelsList probes the provider through its models list endpoint.
+// It returns false if the endpoint isn't implemented (e.g. by an OpenAI-compatible
+// proxy), in which case the caller should fall back to a completion probe.
+func (r *LLMReconciler) probeModelsList(ctx context.Context, llm *kubechainv1alpha1.LLM, req *modelsRequest) (probeResult, bool) {
+	httpClient := r.httpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	var models []string
+	var latency time.Duration
+	next := req.url
+	for next != "" {
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, next, nil)
+		if err != nil {
+			return probeResult{err: &probeError{failure: probeFailureReachability, reason: "InvalidEndpoint", err: err}}, true
+		}
+		for k, v := range req.headers {
+			httpReq.Header.Set(k, v)
+		}
+
+		start := time.Now()
+		resp, err := httpClient.Do(httpReq)
+		if err != nil {
+			return probeResult{err: &probeError{failure: probeFailureReachability, reason: "ConnectionFailed", err: fmt.Errorf("%s API unreachable: %w", llm.Spec.Provider, err)}}, true
+		}
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		// only the first page counts towards latency
+		if latency == 0 {
+			latency = time.Since(start)
+		}
+		if err != nil {
+			return probeResult{latency: latency, err: &probeError{failure: probeFailureReachability, reason: "ConnectionFailed", err: fmt.Errorf("failed to read %s models list: %w", llm.Spec.Provider, err)}}, true
+		}
+
+		switch {
+		case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+			return probeResult{latency: latency, err: &probeError{
+				failure: probeFailureCredentials,
+				reason:  "Unauthorized",
+				err:     fmt.Errorf("%s API rejected the credentials with status %d", llm.Spec.Provider, resp.StatusCode),
+			}}, true
+		case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed:
+			return probeResult{}, false
+		case resp.StatusCode == http.StatusTooManyRequests:
+			// the provider answered and accepted the key, it just won't tell us more right now
+			return probeResult{latency: latency}, true
+		case resp.StatusCode >= 300:
+			return probeResult{latency: latency, err: &probeError{
+				failure: probeFailureReachability,
+				reason:  "UnexpectedStatus",
+				err:     fmt.Errorf("%s models list failed with status %d: %s", llm.Spec.Provider, resp.StatusCode, truncate(string(body), 200)),
+			}}, true
+		}
+
+		page, nextURL, err := req.parse(body)
+		if err != nil {
+			// a 200 we can't parse: most likely a proxy without a real models list
+			return probeResult{}, false
+		}
+		models = append(models, page...)
+		next = nextURL
+	}
+
+	model := llm.Spec.Parameters.Model
+	if model == "" {
+		return probeResult{latency: latency, modelChecked: true}, true
+	}
+	for _, m := range models {
+		if m == model {
+			return probeResult{latency: latency, modelChecked: true}, true
+		}
+	}
+	return probeResult{latency: latency, modelChecked: true, err: &probeError{
+		failure: probeFailureModel,
+		reason:  "ModelNotFound",
+		err:     fmt.Errorf("model %q is not offered by %s", model, llm.Spec.Provider),
+	}}, true
+}
+
+// probe runs a health probe against the provider, preferring the models list
+// and falling back to a 1-token completion
+func (r *LLMReconciler) probe(ctx context.Context, llm *kubechainv1alpha1.LLM, apiKey string) probeResult {
+	timeout := defaultHealthCheckTimeout
+	if llm.Spec.HealthCheck != nil && llm.Spec.HealthCheck.Timeout != nil {
+		timeout = llm.Spec.HealthCheck.Timeout.Duration
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if req, ok := modelsListRequest(llm, apiKey); ok {
+		if result, ok := r.probeModelsList(ctx, llm, req); ok {
+			return result
+		}
+	}
+
+	start := time.Now()
+	if err := r.validateProviderConfig(ctx, llm, apiKey); err != nil {
+		return probeResult{err: &probeError{failure: probeFailureReachability, reason: "ValidationFailed", err: err}}
+	}
+	return probeResult{latency: time.Since(start), modelChecked: true}
+}
+
+// healthCheckInterval returns how often the LLM should be probed
+func healthCheckInterval(llm *kubechainv1alpha1.LLM) time.Duration {
+	if llm.Spec.HealthCheck != nil && llm.Spec.HealthCheck.Interval != nil && llm.Spec.HealthCheck.Interval.Duration > 0 {
+		return llm.Spec.HealthCheck.Interval.Duration
+	}
+	return defaultHealthCheckInterval
+}
+
+// untilNextProbe returns how long to wait before the LLM is due for another
+// probe, zero when it is due: it was never probed, its spec changed since, or
+// the health check interval passed
+func untilNextProbe(llm *kubechainv1alpha1.LLM, now time.Time) time.Duration {
+	if llm.Status.LastProbeTime == nil {
+		return 0
+	}
+	condition := meta.FindStatusCondition(llm.Status.Conditions, kubechainv1alpha1.LLMConditionReachable)
+	if condition == nil || condition.ObservedGeneration != llm.Generation {
+		return 0
+	}
+	if wait := llm.Status.LastProbeTime.Add(healthCheckInterval(llm)).Sub(now); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// recordLatency folds a probe latency into the running statistics
+func recordLatency(stats *kubechainv1alpha1.LLMLatencyStats, latency time.Duration) *kubechainv1alpha1.LLMLatencyStats {
+	if stats == nil {
+		stats = &kubechainv1alpha1.LLMLatencyStats{}
+	}
+	millis := latency.Milliseconds()
+	stats.AverageMillis = (stats.AverageMillis*stats.Samples + millis) / (stats.Samples + 1)
+	stats.Samples++
+	stats.LastMillis = millis
+	if millis > stats.MaxMillis {
+		stats.MaxMillis = millis
+	}
+	return stats
+}
+
+// truncate shortens s to at most n bytes
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
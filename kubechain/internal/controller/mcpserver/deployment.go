@@ -0,0 +1,318 @@
+package mcpserver
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+	"github.com/humanlayer/smallchain/kubechain/internal/mcpbridge"
+	"github.com/humanlayer/smallchain/kubechain/internal/mcpmanager"
+)
+
+const (
+	// mcpServerLabel selects the Pods of an MCPServer's Deployment
+	mcpServerLabel = "kubechain.humanlayer.dev/mcpserver"
+
+	// bridgeVolumeName is the volume the bridge binary is copied to
+	bridgeVolumeName = "mcp-bridge"
+
+	// bridgeImagePath is where the bridge binary lives in the bridge image
+	bridgeImagePath = "/mcp-bridge"
+
+	// configHashAnnotation rolls the server Pod when the spec or its secrets change
+	configHashAnnotation = "kubechain.humanlayer.dev/config-hash"
+
+	// tokenHashAnnotation rolls the server Pod when its bridge token changes
+	tokenHashAnnotation = "kubechain.humanlayer.dev/bridge-token-hash"
+
+	// namespaceNameLabel is set on every namespace by Kubernetes
+	namespaceNameLabel = "kubernetes.io/metadata.name"
+)
+
+// reconcileDeployment makes sure the bridge token, Deployment, Service and
+// NetworkPolicy of a deployed stdio server exist and match the spec. It
+// returns whether the server is available to connect to.
+func (r *MCPServerReconciler) reconcileDeployment(ctx context.Context, mcpServer *kubechainv1alpha1.MCPServer) (bool, error) {
+	// secret values are read by the kubelet, so a changed secret needs a new Pod
+	configHash, err := mcpmanager.ConfigHash(ctx, r.Client, mcpServer)
+	if err != nil {
+		return false, err
+	}
+
+	token, err := r.reconcileBridgeToken(ctx, mcpServer)
+	if err != nil {
+		return false, err
+	}
+
+	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+		Name:      mcpbridge.ResourceName(mcpServer),
+		Namespace: mcpServer.Namespace,
+	}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, deployment, func() error {
+		r.buildDeployment(mcpServer, configHash, token, deployment)
+		return controllerutil.SetControllerReference(mcpServer, deployment, r.Scheme)
+	}); err != nil {
+		return false, fmt.Errorf("failed to apply Deployment: %w", err)
+	}
+
+	service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{
+		Name:      mcpbridge.ResourceName(mcpServer),
+		Namespace: mcpServer.Namespace,
+	}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, service, func() error {
+		service.Labels = map[string]string{mcpServerLabel: mcpServer.Name}
+		service.Spec.Selector = map[string]string{mcpServerLabel: mcpServer.Name}
+		service.Spec.Ports = []corev1.ServicePort{{
+			Name:       "mcp",
+			Port:       mcpbridge.Port,
+			TargetPort: intstr.FromInt32(mcpbridge.Port),
+			Protocol:   corev1.ProtocolTCP,
+		}}
+		return controllerutil.SetControllerReference(mcpServer, service, r.Scheme)
+	}); err != nil {
+		return false, fmt.Errorf("failed to apply Service: %w", err)
+	}
+
+	if err := r.reconcileNetworkPolicy(ctx, mcpServer); err != nil {
+		return false, err
+	}
+
+	// wait for the rollout of a changed spec so that we don't connect to the old Pod
+	rolledOut := deployment.Status.ObservedGeneration >= deployment.Generation && deployment.Status.UpdatedReplicas > 0
+	return rolledOut && deployment.Status.AvailableReplicas > 0, nil
+}
+
+// reconcileBridgeToken makes sure the Secret with the token that the
+// operator presents to the server's bridge exists, and returns the token
+func (r *MCPServerReconciler) reconcileBridgeToken(ctx context.Context, mcpServer *kubechainv1alpha1.MCPServer) ([]byte, error) {
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name:      mcpbridge.TokenSecretName(mcpServer),
+		Namespace: mcpServer.Namespace,
+	}}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(secret), secret); err != nil && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get bridge token Secret: %w", err)
+	} else if err == nil && !metav1.IsControlledBy(secret, mcpServer) {
+		// never hand out or overwrite a token someone else manages
+		return nil, fmt.Errorf("secret %s exists and is not owned by the MCPServer", secret.Name)
+	}
+
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		secret.Labels = map[string]string{mcpServerLabel: mcpServer.Name}
+		if len(secret.Data[mcpbridge.TokenKey]) == 0 {
+			token := make([]byte, 32)
+			if _, err := rand.Read(token); err != nil {
+				return fmt.Errorf("failed to generate bridge token: %w", err)
+			}
+			secret.Data = map[string][]byte{mcpbridge.TokenKey: []byte(hex.EncodeToString(token))}
+		}
+		return controllerutil.SetControllerReference(mcpServer, secret, r.Scheme)
+	}); err != nil {
+		return nil, fmt.Errorf("failed to apply bridge token Secret: %w", err)
+	}
+	return secret.Data[mcpbridge.TokenKey], nil
+}
+
+// reconcileNetworkPolicy admits only the manager to the server's bridge. It
+// needs to know the manager's namespace, without it the token alone guards
+// the bridge.
+func (r *MCPServerReconciler) reconcileNetworkPolicy(ctx context.Context, mcpServer *kubechainv1alpha1.MCPServer) error {
+	if r.ManagerNamespace == "" {
+		return nil
+	}
+
+	policy := &networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{
+		Name:      mcpbridge.ResourceName(mcpServer),
+		Namespace: mcpServer.Namespace,
+	}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, policy, func() error {
+		labels := map[string]string{mcpServerLabel: mcpServer.Name}
+		policy.Labels = labels
+		policy.Spec = networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: labels},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{{
+				From: []networkingv1.NetworkPolicyPeer{{
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{namespaceNameLabel: r.ManagerNamespace}},
+					PodSelector:       &metav1.LabelSelector{MatchLabels: r.ManagerPodLabels},
+				}},
+				Ports: []networkingv1.NetworkPolicyPort{{
+					Protocol: ptr.To(corev1.ProtocolTCP),
+					Port:     ptr.To(intstr.FromInt32(mcpbridge.Port)),
+				}},
+			}, {
+				// the readiness probe, the health port serves nothing else
+				Ports: []networkingv1.NetworkPolicyPort{{
+					Protocol: ptr.To(corev1.ProtocolTCP),
+					Port:     ptr.To(intstr.FromInt32(mcpbridge.HealthPort)),
+				}},
+			}},
+		}
+		return controllerutil.SetControllerReference(mcpServer, policy, r.Scheme)
+	}); err != nil {
+		return fmt.Errorf("failed to apply NetworkPolicy: %w", err)
+	}
+	return nil
+}
+
+// buildDeployment sets the desired state of an MCPServer's Deployment. The
+// bridge binary is copied from the bridge image by an init container and
+// wraps the server's command.
+func (r *MCPServerReconciler) buildDeployment(mcpServer *kubechainv1alpha1.MCPServer, configHash string, token []byte, deployment *appsv1.Deployment) {
+	spec := mcpServer.Spec.Deployment
+	labels := map[string]string{mcpServerLabel: mcpServer.Name}
+
+	command := []string{mcpbridge.BinaryPath, fmt.Sprintf("--listen=:%d", mcpbridge.Port),
+		fmt.Sprintf("--health-listen=:%d", mcpbridge.HealthPort), "--", mcpServer.Spec.Command}
+	command = append(command, mcpServer.Spec.Args...)
+
+	deployment.Labels = labels
+	deployment.Spec.Replicas = ptr.To(int32(1))
+	deployment.Spec.Selector = &metav1.LabelSelector{MatchLabels: labels}
+	// the bridge serves one session at a time, never run two servers side by side
+	deployment.Spec.Strategy = appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType}
+	deployment.Spec.Template = corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: labels,
+			Annotations: map[string]string{
+				configHashAnnotation: configHash,
+				tokenHashAnnotation:  tokenHash(token),
+			},
+		},
+		Spec: corev1.PodSpec{
+			ServiceAccountName: spec.ServiceAccountName,
+			SecurityContext:    spec.PodSecurityContext,
+			InitContainers: []corev1.Container{{
+				Name:            "install-bridge",
+				Image:           r.BridgeImage,
+				Command:         []string{"cp", bridgeImagePath, mcpbridge.BinaryPath},
+				SecurityContext: spec.SecurityContext,
+				VolumeMounts:    []corev1.VolumeMount{{Name: bridgeVolumeName, MountPath: mcpbridge.VolumeMountPath}},
+			}},
+			Containers: []corev1.Container{{
+				Name:            "server",
+				Image:           spec.Image,
+				ImagePullPolicy: spec.ImagePullPolicy,
+				Command:         command,
+				Env:             append(convertEnv(mcpServer.Spec.Env), bridgeTokenEnv(mcpServer)),
+				Resources:       convertResources(mcpServer.Spec.Resources),
+				SecurityContext: spec.SecurityContext,
+				Ports: []corev1.ContainerPort{{
+					Name:          "mcp",
+					ContainerPort: mcpbridge.Port,
+					Protocol:      corev1.ProtocolTCP,
+				}, {
+					Name:          "health",
+					ContainerPort: mcpbridge.HealthPort,
+					Protocol:      corev1.ProtocolTCP,
+				}},
+				// probing the MCP port would take over the operator's session
+				ReadinessProbe: &corev1.Probe{
+					ProbeHandler: corev1.ProbeHandler{
+						HTTPGet: &corev1.HTTPGetAction{Path: mcpbridge.HealthPath, Port: intstr.FromInt32(mcpbridge.HealthPort)},
+					},
+				},
+				VolumeMounts: []corev1.VolumeMount{{Name: bridgeVolumeName, MountPath: mcpbridge.VolumeMountPath, ReadOnly: true}},
+			}},
+			Volumes: []corev1.Volume{{
+				Name:         bridgeVolumeName,
+				VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+			}},
+		},
+	}
+}
+
+// bridgeTokenEnv passes the bridge its token
+func bridgeTokenEnv(mcpServer *kubechainv1alpha1.MCPServer) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: mcpbridge.TokenEnv,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: mcpbridge.TokenSecretName(mcpServer)},
+				Key:                  mcpbridge.TokenKey,
+			},
+		},
+	}
+}
+
+// tokenHash identifies a token without revealing it
+func tokenHash(token []byte) string {
+	sum := sha256.Sum256(token)
+	return hex.EncodeToString(sum[:8])
+}
+
+// deleteDeployment removes the Deployment, Service, NetworkPolicy and bridge
+// token of a server that no longer runs as a Deployment
+func (r *MCPServerReconciler) deleteDeployment(ctx context.Context, mcpServer *kubechainv1alpha1.MCPServer) error {
+	objects := []client.Object{
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: mcpbridge.ResourceName(mcpServer), Namespace: mcpServer.Namespace}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: mcpbridge.ResourceName(mcpServer), Namespace: mcpServer.Namespace}},
+		&networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: mcpbridge.ResourceName(mcpServer), Namespace: mcpServer.Namespace}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: mcpbridge.TokenSecretName(mcpServer), Namespace: mcpServer.Namespace}},
+	}
+	for _, obj := range objects {
+		if err := r.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		// only remove what this MCPServer created
+		if !metav1.IsControlledBy(obj, mcpServer) {
+			continue
+		}
+		if err := client.IgnoreNotFound(r.Delete(ctx, obj)); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", obj.GetName(), err)
+		}
+	}
+	return nil
+}
+
+// convertEnv converts MCPServer environment variables to container ones
+func convertEnv(env []kubechainv1alpha1.EnvVar) []corev1.EnvVar {
+	result := make([]corev1.EnvVar, 0, len(env))
+	for _, e := range env {
+		envVar := corev1.EnvVar{Name: e.Name, Value: e.Value}
+		if e.ValueFrom != nil && e.ValueFrom.SecretKeyRef != nil {
+			envVar.Value = ""
+			envVar.ValueFrom = &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: e.ValueFrom.SecretKeyRef.Name},
+					Key:                  e.ValueFrom.SecretKeyRef.Key,
+				},
+			}
+		}
+		result = append(result, envVar)
+	}
+	return result
+}
+
+// convertResources converts MCPServer resource requirements to container ones
+func convertResources(resources kubechainv1alpha1.ResourceRequirements) corev1.ResourceRequirements {
+	convert := func(list kubechainv1alpha1.ResourceList) corev1.ResourceList {
+		if len(list) == 0 {
+			return nil
+		}
+		result := corev1.ResourceList{}
+		for name, quantity := range list {
+			result[corev1.ResourceName(name)] = quantity
+		}
+		return result
+	}
+	return corev1.ResourceRequirements{
+		Limits:   convert(resources.Limits),
+		Requests: convert(resources.Requests),
+	}
+}
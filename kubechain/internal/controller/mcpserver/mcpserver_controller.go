@@ -5,15 +5,22 @@ import (
 	"fmt"
 	"time"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+	"github.com/humanlayer/smallchain/kubechain/internal/approval"
 	"github.com/humanlayer/smallchain/kubechain/internal/mcpmanager"
 )
 
@@ -23,20 +30,39 @@ const (
 	StatusReady   = "Ready"
 )
 
+const (
+	// healthCheckInterval is how often a connected server is pinged
+	healthCheckInterval = 30 * time.Second
+
+	// reconnectBackoffBase and reconnectBackoffMax bound the exponential
+	// backoff between reconnection attempts
+	reconnectBackoffBase = 5 * time.Second
+	reconnectBackoffMax  = 5 * time.Minute
+)
+
 // MCPServerManagerInterface defines the interface for MCP server management
 type MCPServerManagerInterface interface {
 	ConnectServer(ctx context.Context, mcpServer *kubechainv1alpha1.MCPServer) error
-	GetTools(serverName string) ([]kubechainv1alpha1.MCPTool, bool)
-	GetConnection(serverName string) (*mcpmanager.MCPConnection, bool)
-	DisconnectServer(serverName string)
+	GetTools(namespace, serverName string) ([]kubechainv1alpha1.MCPTool, bool)
+	GetResources(namespace, serverName string) ([]kubechainv1alpha1.MCPResource, bool)
+	GetPrompts(namespace, serverName string) ([]kubechainv1alpha1.MCPPrompt, bool)
+	GetConnection(namespace, serverName string) (*mcpmanager.MCPConnection, bool)
+	DisconnectServer(namespace, serverName string)
 	GetToolsForAgent(agent *kubechainv1alpha1.Agent) []kubechainv1alpha1.MCPTool
-	CallTool(ctx context.Context, serverName, toolName string, arguments map[string]interface{}) (string, error)
-	FindServerForTool(fullToolName string) (serverName string, toolName string, found bool)
+	CallTool(ctx context.Context, namespace, serverName, toolName string, arguments map[string]interface{}) (string, error)
+	FindServerForTool(namespace, fullToolName string) (serverName string, toolName string, found bool)
+	CheckHealth(ctx context.Context, namespace, serverName string) error
+	ConnectionFailures() <-chan event.GenericEvent
+	ListChanges() <-chan event.GenericEvent
 	Close()
 }
 
 // +kubebuilder:rbac:groups=kubechain.humanlayer.dev,resources=mcpservers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=kubechain.humanlayer.dev,resources=mcpservers/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
 
 // MCPServerReconciler reconciles a MCPServer object
 type MCPServerReconciler struct {
@@ -44,6 +70,13 @@ type MCPServerReconciler struct {
 	Scheme     *runtime.Scheme
 	recorder   record.EventRecorder
 	MCPManager MCPServerManagerInterface
+	// BridgeImage provides the bridge binary for MCPServers that run as Deployments
+	BridgeImage string
+	// ManagerNamespace and ManagerPodLabels select the manager Pods, the
+	// NetworkPolicy of a deployed server admits only them. Without a
+	// namespace no NetworkPolicy is created.
+	ManagerNamespace string
+	ManagerPodLabels map[string]string
 }
 
 // updateStatus updates the status of the MCPServer resource with the latest version
@@ -62,6 +95,13 @@ func (r *MCPServerReconciler) updateStatus(ctx context.Context, req ctrl.Request
 	latestMCPServer.Status.Status = statusUpdate.Status.Status
 	latestMCPServer.Status.StatusDetail = statusUpdate.Status.StatusDetail
 	latestMCPServer.Status.Tools = statusUpdate.Status.Tools
+	latestMCPServer.Status.Resources = statusUpdate.Status.Resources
+	latestMCPServer.Status.Prompts = statusUpdate.Status.Prompts
+	latestMCPServer.Status.RestartCount = statusUpdate.Status.RestartCount
+	latestMCPServer.Status.LastFailure = statusUpdate.Status.LastFailure
+	latestMCPServer.Status.LastFailureTime = statusUpdate.Status.LastFailureTime
+	latestMCPServer.Status.ConsecutiveFailures = statusUpdate.Status.ConsecutiveFailures
+	latestMCPServer.Status.FailedConfigHash = statusUpdate.Status.FailedConfigHash
 
 	// Update the status
 	if err := r.Status().Update(ctx, &latestMCPServer); err != nil {
@@ -87,27 +127,34 @@ func (r *MCPServerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 	// Create a status update copy
 	statusUpdate := mcpServer.DeepCopy()
 
-	if statusUpdate.Spec.ApprovalContactChannel != nil {
-		// validate the approval contact channel
-		approvalContactChannel := &kubechainv1alpha1.ContactChannel{}
-		err := r.Get(ctx, types.NamespacedName{Name: statusUpdate.Spec.ApprovalContactChannel.Name, Namespace: statusUpdate.Namespace}, approvalContactChannel)
+	// validate the contact channels
+	refs := []*kubechainv1alpha1.LocalObjectReference{statusUpdate.Spec.ApprovalContactChannel, statusUpdate.Spec.ElicitationContactChannel}
+	if policy := statusUpdate.Spec.ApprovalPolicy; policy != nil && policy.Timeouts != nil && policy.Timeouts.Escalation != nil {
+		refs = append(refs, &policy.Timeouts.Escalation.ContactChannel)
+	}
+	for _, ref := range refs {
+		if ref == nil {
+			continue
+		}
+		contactChannel := &kubechainv1alpha1.ContactChannel{}
+		err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: statusUpdate.Namespace}, contactChannel)
 		if err != nil {
 			statusUpdate.Status.Connected = false
 			statusUpdate.Status.Status = StatusError
 			// todo handle other types of error, not just "not found"
-			statusUpdate.Status.StatusDetail = fmt.Sprintf("ContactChannel %q not found", statusUpdate.Spec.ApprovalContactChannel.Name)
-			r.recorder.Event(&mcpServer, corev1.EventTypeWarning, "ContactChannelNotFound", fmt.Sprintf("ContactChannel %q not found", statusUpdate.Spec.ApprovalContactChannel.Name))
+			statusUpdate.Status.StatusDetail = fmt.Sprintf("ContactChannel %q not found", ref.Name)
+			r.recorder.Event(&mcpServer, corev1.EventTypeWarning, "ContactChannelNotFound", fmt.Sprintf("ContactChannel %q not found", ref.Name))
 			if err := r.updateStatus(ctx, req, statusUpdate); err != nil {
 				return ctrl.Result{}, err
 			}
 			return ctrl.Result{}, err
 		}
 
-		if !approvalContactChannel.Status.Ready {
+		if !contactChannel.Status.Ready {
 			statusUpdate.Status.Connected = false
 			statusUpdate.Status.Status = StatusPending
-			statusUpdate.Status.StatusDetail = fmt.Sprintf("ContactChannel %q is not ready", statusUpdate.Spec.ApprovalContactChannel.Name)
-			r.recorder.Event(&mcpServer, corev1.EventTypeWarning, "ContactChannelNotReady", fmt.Sprintf("ContactChannel %q is not ready", statusUpdate.Spec.ApprovalContactChannel.Name))
+			statusUpdate.Status.StatusDetail = fmt.Sprintf("ContactChannel %q is not ready", ref.Name)
+			r.recorder.Event(&mcpServer, corev1.EventTypeWarning, "ContactChannelNotReady", fmt.Sprintf("ContactChannel %q is not ready", ref.Name))
 			if err := r.updateStatus(ctx, req, statusUpdate); err != nil {
 				return ctrl.Result{}, err
 			}
@@ -128,9 +175,70 @@ func (r *MCPServerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return ctrl.Result{}, err
 	}
 
+	// Run the server in its own Deployment if requested, and wait for it to be available
+	if mcpServer.Spec.Deployment != nil {
+		available, err := r.reconcileDeployment(ctx, &mcpServer)
+		if err != nil {
+			statusUpdate.Status.Connected = false
+			statusUpdate.Status.Status = StatusError
+			statusUpdate.Status.StatusDetail = fmt.Sprintf("Deployment failed: %v", err)
+			r.recorder.Event(&mcpServer, corev1.EventTypeWarning, "DeploymentFailed", err.Error())
+			if updateErr := r.updateStatus(ctx, req, statusUpdate); updateErr != nil {
+				return ctrl.Result{}, updateErr
+			}
+			return ctrl.Result{}, err
+		}
+		if !available {
+			statusUpdate.Status.Connected = false
+			statusUpdate.Status.Status = StatusPending
+			statusUpdate.Status.StatusDetail = "Waiting for the MCP server deployment to become available"
+			if updateErr := r.updateStatus(ctx, req, statusUpdate); updateErr != nil {
+				return ctrl.Result{}, updateErr
+			}
+			return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+		}
+	} else if err := r.deleteDeployment(ctx, &mcpServer); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// A changed spec or secret may fix what failed, it is connected right away.
+	// Secrets that are missing fail the connection attempt, see ConnectServer.
+	configHash, _ := mcpmanager.ConfigHash(ctx, r.Client, &mcpServer)
+	if statusUpdate.Status.ConsecutiveFailures > 0 && configHash != statusUpdate.Status.FailedConfigHash {
+		statusUpdate.Status.ConsecutiveFailures = 0
+	}
+
+	// Check an existing connection, a dead one is dropped and re-established after a backoff
+	_, connected := r.MCPManager.GetConnection(mcpServer.Namespace, mcpServer.Name)
+	if connected {
+		if err := r.MCPManager.CheckHealth(ctx, mcpServer.Namespace, mcpServer.Name); err != nil {
+			r.MCPManager.DisconnectServer(mcpServer.Namespace, mcpServer.Name)
+			recordFailure(statusUpdate, configHash, err)
+			statusUpdate.Status.RestartCount++
+			statusUpdate.Status.Connected = false
+			statusUpdate.Status.Status = StatusError
+			statusUpdate.Status.StatusDetail = fmt.Sprintf("Connection lost: %v", err)
+			r.recorder.Event(&mcpServer, corev1.EventTypeWarning, "ConnectionLost", err.Error())
+
+			if updateErr := r.updateStatus(ctx, req, statusUpdate); updateErr != nil {
+				return ctrl.Result{}, updateErr
+			}
+			return ctrl.Result{RequeueAfter: reconnectBackoff(statusUpdate.Status.ConsecutiveFailures)}, nil
+		}
+	}
+
+	// Our own status writes and the watches requeue right away, a crash-looping
+	// server must not be reconnected before its backoff passed
+	if !connected {
+		if wait := untilReconnect(statusUpdate, time.Now()); wait > 0 {
+			return ctrl.Result{RequeueAfter: wait}, nil
+		}
+	}
+
 	// Try to connect to the MCP server
 	err := r.MCPManager.ConnectServer(ctx, &mcpServer)
 	if err != nil {
+		recordFailure(statusUpdate, configHash, err)
 		statusUpdate.Status.Connected = false
 		statusUpdate.Status.Status = StatusError
 		statusUpdate.Status.StatusDetail = fmt.Sprintf("Connection failed: %v", err)
@@ -139,11 +247,11 @@ func (r *MCPServerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		if updateErr := r.updateStatus(ctx, req, statusUpdate); updateErr != nil {
 			return ctrl.Result{}, updateErr
 		}
-		return ctrl.Result{RequeueAfter: time.Second * 30}, nil // Retry after 30 seconds
+		return ctrl.Result{RequeueAfter: reconnectBackoff(statusUpdate.Status.ConsecutiveFailures)}, nil
 	}
 
 	// Get tools from the manager
-	tools, exists := r.MCPManager.GetTools(mcpServer.Name)
+	tools, exists := r.MCPManager.GetTools(mcpServer.Namespace, mcpServer.Name)
 	if !exists {
 		statusUpdate.Status.Connected = false
 		statusUpdate.Status.Status = StatusError
@@ -161,7 +269,13 @@ func (r *MCPServerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 	statusUpdate.Status.Status = "Ready"
 	statusUpdate.Status.StatusDetail = fmt.Sprintf("Connected successfully with %d tools", len(tools))
 	statusUpdate.Status.Tools = tools
-	r.recorder.Event(&mcpServer, corev1.EventTypeNormal, "Connected", "MCP server connected successfully")
+	statusUpdate.Status.Resources, _ = r.MCPManager.GetResources(mcpServer.Namespace, mcpServer.Name)
+	statusUpdate.Status.Prompts, _ = r.MCPManager.GetPrompts(mcpServer.Namespace, mcpServer.Name)
+	statusUpdate.Status.ConsecutiveFailures = 0
+	// only report the transition, the periodic health checks would flood the events otherwise
+	if !mcpServer.Status.Connected {
+		r.recorder.Event(&mcpServer, corev1.EventTypeNormal, "Connected", "MCP server connected successfully")
+	}
 
 	// Update status
 	if updateErr := r.updateStatus(ctx, req, statusUpdate); updateErr != nil {
@@ -173,14 +287,46 @@ func (r *MCPServerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		"connected", statusUpdate.Status.Connected,
 		"toolCount", len(statusUpdate.Status.Tools))
 
-	// Schedule periodic reconciliation to refresh tool list
-	return ctrl.Result{RequeueAfter: time.Minute * 10}, nil
+	// Schedule the next health check
+	return ctrl.Result{RequeueAfter: healthCheckInterval}, nil
+}
+
+// recordFailure notes a failed health check or connection attempt of the
+// given configuration in the status
+func recordFailure(mcpServer *kubechainv1alpha1.MCPServer, configHash string, err error) {
+	now := metav1.Now()
+	mcpServer.Status.ConsecutiveFailures++
+	mcpServer.Status.FailedConfigHash = configHash
+	mcpServer.Status.LastFailure = err.Error()
+	mcpServer.Status.LastFailureTime = &now
+}
+
+// reconnectBackoff is the delay before reconnecting after the given number of consecutive failures
+func reconnectBackoff(failures int32) time.Duration {
+	backoff := reconnectBackoffBase
+	for i := int32(1); i < failures && backoff < reconnectBackoffMax; i++ {
+		backoff *= 2
+	}
+	return min(backoff, reconnectBackoffMax)
+}
+
+// untilReconnect returns how long to wait before reconnecting to a server
+// whose last connection failed, zero when the backoff passed
+func untilReconnect(mcpServer *kubechainv1alpha1.MCPServer, now time.Time) time.Duration {
+	if mcpServer.Status.ConsecutiveFailures == 0 || mcpServer.Status.LastFailureTime == nil {
+		return 0
+	}
+	backoff := reconnectBackoff(mcpServer.Status.ConsecutiveFailures)
+	if wait := mcpServer.Status.LastFailureTime.Add(backoff).Sub(now); wait > 0 {
+		return wait
+	}
+	return 0
 }
 
 // validateMCPServer performs basic validation on the MCPServer spec
 func (r *MCPServerReconciler) validateMCPServer(mcpServer *kubechainv1alpha1.MCPServer) error {
 	// Check server transport type
-	if mcpServer.Spec.Transport != "stdio" && mcpServer.Spec.Transport != "http" {
+	if mcpServer.Spec.Transport != "stdio" && !isHTTPTransport(mcpServer.Spec.Transport) {
 		return fmt.Errorf("invalid server transport: %s", mcpServer.Spec.Transport)
 	}
 
@@ -192,27 +338,88 @@ func (r *MCPServerReconciler) validateMCPServer(mcpServer *kubechainv1alpha1.MCP
 		// Other validations as needed
 	}
 
-	// Validate http transport
-	if mcpServer.Spec.Transport == "http" {
+	// Validate deployment mode
+	if mcpServer.Spec.Deployment != nil {
+		if mcpServer.Spec.Transport != "stdio" {
+			return fmt.Errorf("deployment is only supported for stdio servers")
+		}
+		if r.BridgeImage == "" {
+			return fmt.Errorf("deployment requires the operator to be started with --mcp-bridge-image")
+		}
+	}
+
+	// Validate http transports
+	if isHTTPTransport(mcpServer.Spec.Transport) {
 		if mcpServer.Spec.URL == "" {
 			return fmt.Errorf("url is required for http servers")
 		}
-		// Other validations as needed
+		if auth := mcpServer.Spec.Auth; auth != nil && auth.BearerTokenFrom != nil && auth.OAuth != nil {
+			return fmt.Errorf("auth can use either bearerTokenFrom or oauth, not both")
+		}
+		for _, header := range mcpServer.Spec.Headers {
+			if header.Value == "" && (header.ValueFrom == nil || header.ValueFrom.SecretKeyRef == nil) {
+				return fmt.Errorf("header %s needs a value or valueFrom", header.Name)
+			}
+		}
+	} else if len(mcpServer.Spec.Headers) > 0 || mcpServer.Spec.Auth != nil || mcpServer.Spec.TLS != nil {
+		return fmt.Errorf("headers, auth and tls are only supported for http servers")
+	}
+
+	if err := approval.Validate(mcpServer.Spec.ApprovalPolicy); err != nil {
+		return err
 	}
 
 	return nil
 }
 
+// isHTTPTransport reports whether a transport talks to the server over HTTP
+func isHTTPTransport(transport string) bool {
+	return transport == "http" || transport == "streamable-http"
+}
+
+// mcpServersForSecret maps a Secret to the MCPServers whose connection depends on it
+func (r *MCPServerReconciler) mcpServersForSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	var mcpServers kubechainv1alpha1.MCPServerList
+	if err := r.List(ctx, &mcpServers, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list MCPServers for Secret", "secret", obj.GetName())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, mcpServer := range mcpServers.Items {
+		for _, name := range mcpmanager.ReferencedSecrets(&mcpServer) {
+			if name == obj.GetName() {
+				requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&mcpServer)})
+				break
+			}
+		}
+	}
+	return requests
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *MCPServerReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.recorder = mgr.GetEventRecorderFor("mcpserver-controller")
 
-	// Initialize the MCP manager if not already set
+	// the manager is shared with the other controllers, see cmd/main.go
 	if r.MCPManager == nil {
-		r.MCPManager = mcpmanager.NewMCPServerManagerWithClient(r.Client)
+		return fmt.Errorf("MCPManager is required")
 	}
 
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&kubechainv1alpha1.MCPServer{}).
-		Complete(r)
+		Owns(&appsv1.Deployment{}).
+		Watches(&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(r.mcpServersForSecret))
+
+	// Reconnect as soon as a connection is lost instead of waiting for the next health check
+	if failures := r.MCPManager.ConnectionFailures(); failures != nil {
+		b = b.WatchesRawSource(source.Channel(failures, &handler.EnqueueRequestForObject{}))
+	}
+	// Update the status as soon as a server's tools change instead of waiting for the next health check
+	if changes := r.MCPManager.ListChanges(); changes != nil {
+		b = b.WatchesRawSource(source.Channel(changes, &handler.EnqueueRequestForObject{}))
+	}
+
+	return b.Complete(r)
 }
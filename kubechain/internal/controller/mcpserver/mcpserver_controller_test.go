@@ -2,15 +2,20 @@ package mcpserver
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 
 	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
 	"github.com/humanlayer/smallchain/kubechain/internal/mcpmanager"
@@ -29,6 +34,10 @@ func teardownContactChannel(ctx context.Context, contactChannel *kubechainv1alph
 type MockMCPServerManager struct {
 	ConnectServerFunc func(ctx context.Context, mcpServer *kubechainv1alpha1.MCPServer) error
 	GetToolsFunc      func(serverName string) ([]kubechainv1alpha1.MCPTool, bool)
+	CheckHealthFunc   func(ctx context.Context, serverName string) error
+	Resources         []kubechainv1alpha1.MCPResource
+	Prompts           []kubechainv1alpha1.MCPPrompt
+	connected         map[string]bool
 }
 
 func (m *MockMCPServerManager) ConnectServer(ctx context.Context, mcpServer *kubechainv1alpha1.MCPServer) error {
@@ -38,37 +47,86 @@ func (m *MockMCPServerManager) ConnectServer(ctx context.Context, mcpServer *kub
 	return nil
 }
 
-func (m *MockMCPServerManager) GetTools(serverName string) ([]kubechainv1alpha1.MCPTool, bool) {
+func (m *MockMCPServerManager) GetTools(namespace, serverName string) ([]kubechainv1alpha1.MCPTool, bool) {
 	if m.GetToolsFunc != nil {
 		return m.GetToolsFunc(serverName)
 	}
 	return nil, false
 }
 
-func (m *MockMCPServerManager) GetConnection(serverName string) (*mcpmanager.MCPConnection, bool) {
+func (m *MockMCPServerManager) GetResources(namespace, serverName string) ([]kubechainv1alpha1.MCPResource, bool) {
+	return m.Resources, true
+}
+
+func (m *MockMCPServerManager) GetPrompts(namespace, serverName string) ([]kubechainv1alpha1.MCPPrompt, bool) {
+	return m.Prompts, true
+}
+
+func (m *MockMCPServerManager) GetConnection(namespace, serverName string) (*mcpmanager.MCPConnection, bool) {
+	if m.connected[serverName] {
+		return &mcpmanager.MCPConnection{ServerName: serverName}, true
+	}
 	return nil, false
 }
 
-func (m *MockMCPServerManager) DisconnectServer(serverName string) {
-	// No-op for testing
+func (m *MockMCPServerManager) DisconnectServer(namespace, serverName string) {
+	delete(m.connected, serverName)
 }
 
 func (m *MockMCPServerManager) GetToolsForAgent(agent *kubechainv1alpha1.Agent) []kubechainv1alpha1.MCPTool {
 	return nil
 }
 
-func (m *MockMCPServerManager) CallTool(ctx context.Context, serverName, toolName string, arguments map[string]interface{}) (string, error) {
+func (m *MockMCPServerManager) CallTool(ctx context.Context, namespace, serverName, toolName string, arguments map[string]interface{}) (string, error) {
 	return "", nil
 }
 
-func (m *MockMCPServerManager) FindServerForTool(fullToolName string) (serverName string, toolName string, found bool) {
+func (m *MockMCPServerManager) FindServerForTool(namespace, fullToolName string) (serverName string, toolName string, found bool) {
 	return "", "", false
 }
 
+func (m *MockMCPServerManager) CheckHealth(ctx context.Context, namespace, serverName string) error {
+	if m.CheckHealthFunc != nil {
+		return m.CheckHealthFunc(ctx, serverName)
+	}
+	return nil
+}
+
+func (m *MockMCPServerManager) ConnectionFailures() <-chan event.GenericEvent {
+	return nil
+}
+
+func (m *MockMCPServerManager) ListChanges() <-chan event.GenericEvent {
+	return nil
+}
+
 func (m *MockMCPServerManager) Close() {
 	// No-op for testing
 }
 
+var _ = Describe("reconnectBackoff", func() {
+	It("doubles up to the maximum", func() {
+		Expect(reconnectBackoff(1)).To(Equal(5 * time.Second))
+		Expect(reconnectBackoff(2)).To(Equal(10 * time.Second))
+		Expect(reconnectBackoff(4)).To(Equal(40 * time.Second))
+		Expect(reconnectBackoff(20)).To(Equal(reconnectBackoffMax))
+	})
+})
+
+var _ = Describe("untilReconnect", func() {
+	It("waits out the backoff after a failure", func() {
+		now := time.Now()
+		failedAt := metav1.NewTime(now.Add(-2 * time.Second))
+		mcpServer := &kubechainv1alpha1.MCPServer{}
+		Expect(untilReconnect(mcpServer, now)).To(BeZero())
+
+		mcpServer.Status.ConsecutiveFailures = 2
+		mcpServer.Status.LastFailureTime = &failedAt
+		Expect(untilReconnect(mcpServer, now)).To(Equal(8 * time.Second))
+		Expect(untilReconnect(mcpServer, now.Add(8*time.Second))).To(BeZero())
+	})
+})
+
 var _ = Describe("MCPServer Controller", func() {
 	const (
 		MCPServerName      = "test-mcpserver"
@@ -123,6 +181,12 @@ var _ = Describe("MCPServer Controller", func() {
 						},
 					}, true
 				},
+				Resources: []kubechainv1alpha1.MCPResource{
+					{URI: "file:///README.md", Name: "README", MimeType: "text/markdown"},
+				},
+				Prompts: []kubechainv1alpha1.MCPPrompt{
+					{Name: "summarize", Arguments: []kubechainv1alpha1.MCPPromptArgument{{Name: "topic", Required: true}}},
+				},
 			}
 
 			By("Creating a controller with the mock manager")
@@ -149,6 +213,269 @@ var _ = Describe("MCPServer Controller", func() {
 					len(createdMCPServer.Status.Tools) == 1 &&
 					createdMCPServer.Status.Status == "Ready"
 			}, time.Second*10, time.Millisecond*250).Should(BeTrue())
+			Expect(createdMCPServer.Status.Resources).To(Equal(mockManager.Resources))
+			Expect(createdMCPServer.Status.Prompts).To(Equal(mockManager.Prompts))
+		})
+
+		It("Should run a stdio server in its own Deployment", func() {
+			ctx := context.Background()
+
+			By("Creating a new MCPServer with a deployment")
+			mcpServer := &kubechainv1alpha1.MCPServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "deployed-mcpserver",
+					Namespace: MCPServerNamespace,
+				},
+				Spec: kubechainv1alpha1.MCPServerSpec{
+					Transport: "stdio",
+					Command:   "uvx",
+					Args:      []string{"mcp-server-fetch"},
+					Env: []kubechainv1alpha1.EnvVar{
+						{
+							Name: "API_KEY",
+							ValueFrom: &kubechainv1alpha1.EnvVarSource{
+								SecretKeyRef: &kubechainv1alpha1.SecretKeySelector{Name: "fetch-secret", Key: "api-key"},
+							},
+						},
+					},
+					Deployment: &kubechainv1alpha1.MCPServerDeployment{Image: "ghcr.io/astral-sh/uv:python3.12-bookworm-slim"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, mcpServer)).To(Succeed())
+			defer teardownMCPServer(ctx, mcpServer)
+
+			var connected *kubechainv1alpha1.MCPServer
+			reconciler := &MCPServerReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				recorder: record.NewFakeRecorder(10),
+				MCPManager: &MockMCPServerManager{
+					ConnectServerFunc: func(ctx context.Context, mcpServer *kubechainv1alpha1.MCPServer) error {
+						connected = mcpServer
+						return nil
+					},
+					GetToolsFunc: func(serverName string) ([]kubechainv1alpha1.MCPTool, bool) {
+						return []kubechainv1alpha1.MCPTool{{Name: "fetch"}}, true
+					},
+				},
+				BridgeImage:      "kubechain:latest",
+				ManagerNamespace: "kubechain-system",
+				ManagerPodLabels: map[string]string{"control-plane": "controller-manager"},
+			}
+			lookupKey := types.NamespacedName{Name: mcpServer.Name, Namespace: MCPServerNamespace}
+
+			By("Reconciling before the Deployment is available")
+			result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: lookupKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(5 * time.Second))
+			Expect(connected).To(BeNil())
+
+			updated := &kubechainv1alpha1.MCPServer{}
+			Expect(k8sClient.Get(ctx, lookupKey, updated)).To(Succeed())
+			Expect(updated.Status.Status).To(Equal(StatusPending))
+
+			By("Checking the Deployment and Service")
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "deployed-mcpserver-mcp", Namespace: MCPServerNamespace}, deployment)).To(Succeed())
+			Expect(metav1.IsControlledBy(deployment, updated)).To(BeTrue())
+			Expect(deployment.Spec.Template.Spec.InitContainers[0].Image).To(Equal("kubechain:latest"))
+			server := deployment.Spec.Template.Spec.Containers[0]
+			Expect(server.Image).To(Equal("ghcr.io/astral-sh/uv:python3.12-bookworm-slim"))
+			Expect(server.Command).To(Equal([]string{"/kubechain-bridge/mcp-bridge", "--listen=:3100", "--health-listen=:3101", "--", "uvx", "mcp-server-fetch"}))
+			Expect(server.ReadinessProbe.TCPSocket).To(BeNil())
+			Expect(server.ReadinessProbe.HTTPGet.Port.IntValue()).To(Equal(3101))
+			Expect(server.Env[0].ValueFrom.SecretKeyRef.Name).To(Equal("fetch-secret"))
+			Expect(server.Env[0].ValueFrom.SecretKeyRef.Key).To(Equal("api-key"))
+
+			By("Checking the bridge token")
+			tokenSecret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "deployed-mcpserver-mcp-bridge", Namespace: MCPServerNamespace}, tokenSecret)).To(Succeed())
+			Expect(metav1.IsControlledBy(tokenSecret, updated)).To(BeTrue())
+			Expect(tokenSecret.Data["token"]).To(HaveLen(64))
+			Expect(server.Env[1].Name).To(Equal("KUBECHAIN_BRIDGE_TOKEN"))
+			Expect(server.Env[1].ValueFrom.SecretKeyRef.Name).To(Equal("deployed-mcpserver-mcp-bridge"))
+			Expect(server.Env[1].ValueFrom.SecretKeyRef.Key).To(Equal("token"))
+
+			service := &corev1.Service{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "deployed-mcpserver-mcp", Namespace: MCPServerNamespace}, service)).To(Succeed())
+			Expect(service.Spec.Ports[0].Port).To(Equal(int32(3100)))
+
+			By("Checking that only the manager may connect to the bridge")
+			policy := &networkingv1.NetworkPolicy{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "deployed-mcpserver-mcp", Namespace: MCPServerNamespace}, policy)).To(Succeed())
+			Expect(policy.Spec.PodSelector.MatchLabels).To(Equal(map[string]string{"kubechain.humanlayer.dev/mcpserver": "deployed-mcpserver"}))
+			Expect(policy.Spec.Ingress[0].From).To(HaveLen(1))
+			Expect(policy.Spec.Ingress[0].From[0].NamespaceSelector.MatchLabels).To(Equal(map[string]string{"kubernetes.io/metadata.name": "kubechain-system"}))
+			Expect(policy.Spec.Ingress[0].From[0].PodSelector.MatchLabels).To(Equal(map[string]string{"control-plane": "controller-manager"}))
+			Expect(policy.Spec.Ingress[0].Ports[0].Port.IntValue()).To(Equal(3100))
+
+			By("Reconciling once the Deployment is available")
+			deployment.Status.ObservedGeneration = deployment.Generation
+			deployment.Status.Replicas = 1
+			deployment.Status.UpdatedReplicas = 1
+			deployment.Status.AvailableReplicas = 1
+			Expect(k8sClient.Status().Update(ctx, deployment)).To(Succeed())
+
+			_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: lookupKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(connected).NotTo(BeNil())
+			Expect(k8sClient.Get(ctx, lookupKey, updated)).To(Succeed())
+			Expect(updated.Status.Status).To(Equal(StatusReady))
+
+			By("Keeping the token across reconciles")
+			reconciled := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(tokenSecret), reconciled)).To(Succeed())
+			Expect(reconciled.Data["token"]).To(Equal(tokenSecret.Data["token"]))
+
+			Expect(k8sClient.Delete(ctx, deployment)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, service)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, policy)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, tokenSecret)).To(Succeed())
+		})
+
+		It("Should reconnect with a backoff when the connection is lost", func() {
+			ctx := context.Background()
+
+			mcpServer := &kubechainv1alpha1.MCPServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "crashing-mcpserver",
+					Namespace: MCPServerNamespace,
+				},
+				Spec: kubechainv1alpha1.MCPServerSpec{
+					Transport: "stdio",
+					Command:   "test-command",
+				},
+			}
+			Expect(k8sClient.Create(ctx, mcpServer)).To(Succeed())
+			defer teardownMCPServer(ctx, mcpServer)
+
+			mockManager := &MockMCPServerManager{
+				connected: map[string]bool{"crashing-mcpserver": true},
+				CheckHealthFunc: func(ctx context.Context, serverName string) error {
+					return errors.New("MCP server process exited: exit status 1")
+				},
+				GetToolsFunc: func(serverName string) ([]kubechainv1alpha1.MCPTool, bool) {
+					return []kubechainv1alpha1.MCPTool{{Name: "test-tool"}}, true
+				},
+			}
+			mockManager.ConnectServerFunc = func(ctx context.Context, mcpServer *kubechainv1alpha1.MCPServer) error {
+				mockManager.connected[mcpServer.Name] = true
+				return nil
+			}
+			reconciler := &MCPServerReconciler{
+				Client:     k8sClient,
+				Scheme:     k8sClient.Scheme(),
+				recorder:   record.NewFakeRecorder(10),
+				MCPManager: mockManager,
+			}
+			lookupKey := types.NamespacedName{Name: mcpServer.Name, Namespace: MCPServerNamespace}
+
+			By("Detecting the lost connection")
+			result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: lookupKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(5 * time.Second))
+
+			updated := &kubechainv1alpha1.MCPServer{}
+			Expect(k8sClient.Get(ctx, lookupKey, updated)).To(Succeed())
+			Expect(updated.Status.Connected).To(BeFalse())
+			Expect(updated.Status.Status).To(Equal(StatusError))
+			Expect(updated.Status.RestartCount).To(Equal(int32(1)))
+			Expect(updated.Status.ConsecutiveFailures).To(Equal(int32(1)))
+			Expect(updated.Status.LastFailure).To(ContainSubstring("process exited"))
+			Expect(updated.Status.LastFailureTime).NotTo(BeNil())
+
+			By("Not reconnecting inside the backoff window")
+			mockManager.CheckHealthFunc = nil
+			connects := 0
+			mockManager.ConnectServerFunc = func(ctx context.Context, mcpServer *kubechainv1alpha1.MCPServer) error {
+				connects++
+				mockManager.connected[mcpServer.Name] = true
+				return nil
+			}
+			result, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: lookupKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(connects).To(BeZero())
+			Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+			Expect(result.RequeueAfter).To(BeNumerically("<=", 5*time.Second))
+
+			By("Reconnecting after the backoff")
+			failedAt := metav1.NewTime(time.Now().Add(-5 * time.Second))
+			updated.Status.LastFailureTime = &failedAt
+			Expect(k8sClient.Status().Update(ctx, updated)).To(Succeed())
+			result, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: lookupKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(healthCheckInterval))
+
+			Expect(k8sClient.Get(ctx, lookupKey, updated)).To(Succeed())
+			Expect(connects).To(Equal(1))
+			Expect(updated.Status.Connected).To(BeTrue())
+			Expect(updated.Status.RestartCount).To(Equal(int32(1)))
+			Expect(updated.Status.ConsecutiveFailures).To(BeZero())
+		})
+
+		It("Should reconnect right away once the spec of a failing server changes", func() {
+			ctx := context.Background()
+
+			mcpServer := &kubechainv1alpha1.MCPServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "misconfigured-mcpserver",
+					Namespace: MCPServerNamespace,
+				},
+				Spec: kubechainv1alpha1.MCPServerSpec{
+					Transport: "stdio",
+					Command:   "test-command",
+					Args:      []string{"--port", "not-a-port"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, mcpServer)).To(Succeed())
+			defer teardownMCPServer(ctx, mcpServer)
+
+			connects := 0
+			mockManager := &MockMCPServerManager{
+				GetToolsFunc: func(serverName string) ([]kubechainv1alpha1.MCPTool, bool) {
+					return []kubechainv1alpha1.MCPTool{{Name: "test-tool"}}, true
+				},
+			}
+			mockManager.ConnectServerFunc = func(ctx context.Context, mcpServer *kubechainv1alpha1.MCPServer) error {
+				connects++
+				if mcpServer.Spec.Args[1] == "not-a-port" {
+					return errors.New("MCP server process exited: exit status 2")
+				}
+				return nil
+			}
+			reconciler := &MCPServerReconciler{
+				Client:     k8sClient,
+				Scheme:     k8sClient.Scheme(),
+				recorder:   record.NewFakeRecorder(10),
+				MCPManager: mockManager,
+			}
+			lookupKey := types.NamespacedName{Name: mcpServer.Name, Namespace: MCPServerNamespace}
+
+			By("Failing to connect")
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: lookupKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(connects).To(Equal(1))
+
+			By("Waiting out the backoff while the spec is unchanged")
+			_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: lookupKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(connects).To(Equal(1))
+
+			By("Fixing the spec")
+			updated := &kubechainv1alpha1.MCPServer{}
+			Expect(k8sClient.Get(ctx, lookupKey, updated)).To(Succeed())
+			Expect(updated.Status.ConsecutiveFailures).To(Equal(int32(1)))
+			updated.Spec.Args = []string{"--port", "8080"}
+			Expect(k8sClient.Update(ctx, updated)).To(Succeed())
+
+			result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: lookupKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(healthCheckInterval))
+			Expect(connects).To(Equal(2))
+
+			Expect(k8sClient.Get(ctx, lookupKey, updated)).To(Succeed())
+			Expect(updated.Status.Connected).To(BeTrue())
+			Expect(updated.Status.ConsecutiveFailures).To(BeZero())
 		})
 
 		It("Should handle invalid MCP server specs", func() {
@@ -279,7 +606,7 @@ var _ = Describe("MCPServer Controller", func() {
 				},
 				Spec: kubechainv1alpha1.ContactChannelSpec{
 					Type: "slack",
-					APIKeyFrom: kubechainv1alpha1.APIKeySource{
+					APIKeyFrom: &kubechainv1alpha1.APIKeySource{
 						SecretKeyRef: kubechainv1alpha1.SecretKeyRef{
 							Name: "test-secret",
 							Key:  "token",
@@ -20,6 +20,7 @@ import (
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
 	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+	"github.com/humanlayer/smallchain/kubechain/internal/mcpmanager"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -83,7 +84,7 @@ var _ = BeforeSuite(func() {
 		Client:     k8sManager.GetClient(),
 		Scheme:     k8sManager.GetScheme(),
 		recorder:   eventRecorder,
-		MCPManager: nil, // Will be set in individual tests
+		MCPManager: mcpmanager.NewMCPServerManager(), // Individual tests use their own reconcilers
 	}).SetupWithManager(k8sManager)
 	Expect(err).ToNot(HaveOccurred())
 
@@ -46,6 +46,17 @@ func (r *TaskReconciler) validateAgent(ctx context.Context, task *kubechainv1alp
 	return nil
 }
 
+// validateMessage checks that the task has either a message or an MCP prompt
+func validateMessage(task *kubechainv1alpha1.Task) error {
+	if task.Spec.Message == "" && task.Spec.Prompt == nil {
+		return fmt.Errorf("task needs a message or a prompt")
+	}
+	if task.Spec.Message != "" && task.Spec.Prompt != nil {
+		return fmt.Errorf("task can't have both a message and a prompt")
+	}
+	return nil
+}
+
 // Reconcile validates the task's agent reference and parameters
 func (r *TaskReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
@@ -67,6 +78,20 @@ func (r *TaskReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 		r.recorder.Event(&task, corev1.EventTypeNormal, "Initializing", "Starting validation")
 	}
 
+	// Validate the message, a spec change is needed to fix it
+	if err := validateMessage(&task); err != nil {
+		logger.Error(err, "Message validation failed")
+		statusUpdate.Status.Ready = false
+		statusUpdate.Status.Status = kubechainv1alpha1.TaskStatusError
+		statusUpdate.Status.StatusDetail = err.Error()
+		r.recorder.Event(&task, corev1.EventTypeWarning, "ValidationFailed", err.Error())
+		if updateErr := r.Status().Update(ctx, statusUpdate); updateErr != nil {
+			logger.Error(updateErr, "Failed to update Task status")
+			return ctrl.Result{}, updateErr
+		}
+		return ctrl.Result{}, nil
+	}
+
 	// Validate agent reference
 	if err := r.validateAgent(ctx, &task); err != nil {
 		logger.Error(err, "Agent validation failed")
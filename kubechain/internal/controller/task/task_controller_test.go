@@ -173,5 +173,49 @@ var _ = Describe("Task Controller", func() {
 			By("checking that a failure event was created")
 			utils.ExpectRecorder(eventRecorder).ToEmitEventContaining("ValidationFailed")
 		})
+
+		It("should fail validation with both a message and a prompt", func() {
+			By("creating the task with a message and an MCP prompt")
+			task := &kubechainv1alpha1.Task{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: kubechainv1alpha1.TaskSpec{
+					AgentRef: kubechainv1alpha1.LocalObjectReference{
+						Name: agentName,
+					},
+					Message: "Test input",
+					Prompt: &kubechainv1alpha1.MCPPromptRef{
+						MCPServer: "wiki",
+						Name:      "summarize",
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, task)).To(Succeed())
+
+			By("reconciling the task")
+			eventRecorder := record.NewFakeRecorder(10)
+			reconciler := &TaskReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				recorder: eventRecorder,
+			}
+
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("checking the task status")
+			updatedTask := &kubechainv1alpha1.Task{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, updatedTask)).To(Succeed())
+			Expect(updatedTask.Status.Ready).To(BeFalse())
+			Expect(updatedTask.Status.Status).To(Equal(kubechainv1alpha1.TaskStatusError))
+			Expect(updatedTask.Status.StatusDetail).To(Equal("task can't have both a message and a prompt"))
+
+			By("checking that a failure event was created")
+			utils.ExpectRecorder(eventRecorder).ToEmitEventContaining("ValidationFailed")
+		})
 	})
 })
@@ -0,0 +1,47 @@
+package taskrun
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+	"github.com/humanlayer/smallchain/kubechain/internal/llmclient"
+)
+
+// loadAttachmentData reads the data of the attachments in a TaskRun's context
+// window from their ConfigMaps. Attachments whose ConfigMap is gone are left
+// out and only described to the LLM.
+func (r *TaskRunReconciler) loadAttachmentData(ctx context.Context, taskRun *kubechainv1alpha1.TaskRun) (map[string][]byte, error) {
+	data := map[string][]byte{}
+	configMaps := map[string]*corev1.ConfigMap{}
+
+	for _, message := range taskRun.Status.ContextWindow {
+		for _, attachment := range message.Attachments {
+			if attachment.DataFrom == nil {
+				continue
+			}
+			configMap, fetched := configMaps[attachment.DataFrom.Name]
+			if !fetched {
+				configMap = &corev1.ConfigMap{}
+				err := r.Get(ctx, client.ObjectKey{Namespace: taskRun.Namespace, Name: attachment.DataFrom.Name}, configMap)
+				if apierrors.IsNotFound(err) {
+					configMap = nil
+				} else if err != nil {
+					return nil, fmt.Errorf("failed to get attachments ConfigMap %q: %w", attachment.DataFrom.Name, err)
+				}
+				configMaps[attachment.DataFrom.Name] = configMap
+			}
+			if configMap == nil {
+				continue
+			}
+			if value, ok := configMap.BinaryData[attachment.DataFrom.Key]; ok {
+				data[llmclient.AttachmentDataKey(attachment.DataFrom)] = value
+			}
+		}
+	}
+	return data, nil
+}
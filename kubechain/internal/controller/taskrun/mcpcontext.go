@@ -0,0 +1,61 @@
+package taskrun
+
+import (
+	"context"
+	"fmt"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+// usesMCPPrompt reports whether a TaskRun's messages come from its task's MCP
+// prompt rather than a plain message
+func usesMCPPrompt(taskRun *kubechainv1alpha1.TaskRun, task *kubechainv1alpha1.Task) bool {
+	return taskRun.Spec.UserMessage == "" && task != nil && task.Spec.Prompt != nil
+}
+
+// buildContextWindow assembles the first messages of a TaskRun: the agent's
+// system prompt, the task's MCP resources as context, and then the user
+// message or the messages rendered from the task's MCP prompt
+func (r *TaskRunReconciler) buildContextWindow(ctx context.Context, taskRun *kubechainv1alpha1.TaskRun, task *kubechainv1alpha1.Task, agent *kubechainv1alpha1.Agent, message string) ([]kubechainv1alpha1.Message, error) {
+	contextWindow := []kubechainv1alpha1.Message{
+		{
+			Role:    "system",
+			Content: agent.Spec.System,
+		},
+	}
+
+	needsMCP := usesMCPPrompt(taskRun, task) || (task != nil && len(task.Spec.Resources) > 0)
+	if needsMCP && r.MCPManager == nil {
+		return nil, fmt.Errorf("MCPManager is not initialized")
+	}
+
+	if task != nil {
+		for _, resource := range task.Spec.Resources {
+			content, err := r.MCPManager.ReadResource(ctx, taskRun.Namespace, resource.MCPServer, resource.URI)
+			if err != nil {
+				return nil, err
+			}
+			contextWindow = append(contextWindow, kubechainv1alpha1.Message{
+				Role:    "user",
+				Content: fmt.Sprintf("Contents of %s from MCP server %s:\n\n%s", resource.URI, resource.MCPServer, content),
+			})
+		}
+	}
+
+	if usesMCPPrompt(taskRun, task) {
+		prompt := task.Spec.Prompt
+		messages, err := r.MCPManager.GetPrompt(ctx, taskRun.Namespace, prompt.MCPServer, prompt.Name, prompt.Arguments)
+		if err != nil {
+			return nil, err
+		}
+		if len(messages) == 0 {
+			return nil, fmt.Errorf("prompt %q of MCP server %q has no messages", prompt.Name, prompt.MCPServer)
+		}
+		return append(contextWindow, messages...), nil
+	}
+
+	return append(contextWindow, kubechainv1alpha1.Message{
+		Role:    "user",
+		Content: message,
+	}), nil
+}
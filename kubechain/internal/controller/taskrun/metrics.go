@@ -0,0 +1,39 @@
+package taskrun
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// llmTokensTotal counts the tokens consumed by LLM turns
+	llmTokensTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kubechain_llm_tokens_total",
+			Help: "Number of tokens consumed by TaskRun LLM turns",
+		},
+		[]string{"namespace", "agent", "provider", "model", "direction"},
+	)
+
+	// llmEstimatedCostTotal sums the estimated cost of LLM turns
+	llmEstimatedCostTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kubechain_llm_estimated_cost_total",
+			Help: "Estimated cost of TaskRun LLM turns, based on PricingCatalogs",
+		},
+		[]string{"namespace", "agent", "provider", "model", "currency"},
+	)
+
+	// llmUnpricedTurnsTotal counts LLM turns without a known price
+	llmUnpricedTurnsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kubechain_llm_unpriced_turns_total",
+			Help: "Number of TaskRun LLM turns for which no PricingCatalog entry was found",
+		},
+		[]string{"namespace", "agent", "provider", "model"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(llmTokensTotal, llmEstimatedCostTotal, llmUnpricedTurnsTotal)
+}
@@ -0,0 +1,99 @@
+package taskrun
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+	"github.com/humanlayer/smallchain/kubechain/internal/llmclient"
+	"github.com/humanlayer/smallchain/kubechain/internal/modelrouter"
+)
+
+// selectLLM returns the name of the LLM to use for this turn. Agents without
+// a router always use their LLMRef. Routing problems are reported as events
+// and fall back to the LLMRef rather than failing the TaskRun.
+func (r *TaskRunReconciler) selectLLM(ctx context.Context, agent *kubechainv1alpha1.Agent, task *kubechainv1alpha1.Task, taskRun *kubechainv1alpha1.TaskRun, statusUpdate *kubechainv1alpha1.TaskRun, tools []llmclient.Tool) string {
+	logger := log.FromContext(ctx)
+
+	if agent.Spec.RouterRef == nil {
+		return agent.Spec.LLMRef.Name
+	}
+
+	var router kubechainv1alpha1.ModelRouter
+	if err := r.Get(ctx, client.ObjectKey{Namespace: agent.Namespace, Name: agent.Spec.RouterRef.Name}, &router); err != nil {
+		logger.Error(err, "Failed to get ModelRouter, using the agent's LLM", "router", agent.Spec.RouterRef.Name)
+		r.recorder.Event(taskRun, corev1.EventTypeWarning, "ModelRoutingFailed",
+			fmt.Sprintf("Failed to get ModelRouter %q, using LLM %q: %v", agent.Spec.RouterRef.Name, agent.Spec.LLMRef.Name, err))
+		return agent.Spec.LLMRef.Name
+	}
+
+	turn := modelrouter.Turn{
+		ContextTokens: modelrouter.EstimateTokens(taskRun.Status.ContextWindow),
+		HasTools:      len(tools) > 0,
+	}
+	if task != nil {
+		turn.Tier = task.Spec.Tier
+	}
+
+	var classify modelrouter.Classifier
+	if router.Spec.Classifier != nil {
+		classify = func() (string, error) {
+			return r.classifyTurn(ctx, agent, router.Spec.Classifier, taskRun, statusUpdate)
+		}
+	}
+
+	route, err := modelrouter.Select(&router.Spec, turn, classify)
+	if err != nil {
+		logger.Error(err, "Model routing failed, using the agent's LLM", "router", router.Name)
+		r.recorder.Event(taskRun, corev1.EventTypeWarning, "ModelRoutingFailed",
+			fmt.Sprintf("ModelRouter %q failed, using LLM %q: %v", router.Name, agent.Spec.LLMRef.Name, err))
+		return agent.Spec.LLMRef.Name
+	}
+	if route == nil {
+		logger.V(1).Info("No route matched, using the agent's LLM", "router", router.Name, "contextTokens", turn.ContextTokens)
+		return agent.Spec.LLMRef.Name
+	}
+
+	logger.Info("Routed LLM turn", "router", router.Name, "route", route.Name, "llm", route.LLMRef.Name)
+	r.recorder.Event(taskRun, corev1.EventTypeNormal, "ModelRouted",
+		fmt.Sprintf("Route %q of ModelRouter %q selected LLM %q", route.Name, router.Name, route.LLMRef.Name))
+	return route.LLMRef.Name
+}
+
+// classifyTurn asks the router's classifier LLM to label the current turn.
+// The classifier's token usage is accounted like any other LLM turn.
+func (r *TaskRunReconciler) classifyTurn(ctx context.Context, agent *kubechainv1alpha1.Agent, classifier *kubechainv1alpha1.ModelRouterClassifier, taskRun *kubechainv1alpha1.TaskRun, statusUpdate *kubechainv1alpha1.TaskRun) (string, error) {
+	var llm kubechainv1alpha1.LLM
+	if err := r.Get(ctx, client.ObjectKey{Namespace: agent.Namespace, Name: classifier.LLMRef.Name}, &llm); err != nil {
+		return "", fmt.Errorf("failed to get classifier LLM %q: %w", classifier.LLMRef.Name, err)
+	}
+
+	var secret corev1.Secret
+	if err := r.Get(ctx, client.ObjectKey{
+		Namespace: llm.Namespace,
+		Name:      llm.Spec.APIKeyFrom.SecretKeyRef.Name,
+	}, &secret); err != nil {
+		return "", fmt.Errorf("failed to get API key secret of classifier LLM %q: %w", llm.Name, err)
+	}
+	apiKey := string(secret.Data[llm.Spec.APIKeyFrom.SecretKeyRef.Key])
+	if apiKey == "" {
+		return "", fmt.Errorf("API key is empty in secret %s", secret.Name)
+	}
+
+	llmClient, err := r.newLLMClient(ctx, llm, apiKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to create classifier LLM client: %w", err)
+	}
+
+	output, err := llmClient.SendRequest(ctx, modelrouter.ClassifierPrompt(classifier, taskRun.Status.ContextWindow), nil)
+	if err != nil {
+		return "", fmt.Errorf("classifier request failed: %w", err)
+	}
+	r.recordUsage(ctx, &llm, agent, output, statusUpdate)
+
+	return modelrouter.ParseLabel(output.Content, classifier.Labels)
+}
@@ -40,8 +40,12 @@ const (
 // +kubebuilder:rbac:groups=kubechain.humanlayer.dev,resources=taskruns/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=kubechain.humanlayer.dev,resources=tasks,verbs=get;list;watch
 // +kubebuilder:rbac:groups=kubechain.humanlayer.dev,resources=agents,verbs=get;list;watch
+// +kubebuilder:rbac:groups=kubechain.humanlayer.dev,resources=agents/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=kubechain.humanlayer.dev,resources=llms,verbs=get;list;watch
+// +kubebuilder:rbac:groups=kubechain.humanlayer.dev,resources=pricingcatalogs,verbs=get;list;watch
+// +kubebuilder:rbac:groups=kubechain.humanlayer.dev,resources=modelrouters,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
 
 // TaskRunReconciler reconciles a TaskRun object
 type TaskRunReconciler struct {
@@ -205,7 +209,7 @@ func (r *TaskRunReconciler) prepareForLLM(ctx context.Context, taskRun *kubechai
 			message = task.Spec.Message
 		}
 
-		if message == "" {
+		if message == "" && !usesMCPPrompt(taskRun, task) {
 			err := fmt.Errorf("no message found in TaskRun or Task")
 			logger.Error(err, "Missing message")
 			statusUpdate.Status.Ready = false
@@ -221,16 +225,23 @@ func (r *TaskRunReconciler) prepareForLLM(ctx context.Context, taskRun *kubechai
 			return ctrl.Result{}, err
 		}
 
-		statusUpdate.Status.ContextWindow = []kubechainv1alpha1.Message{
-			{
-				Role:    "system",
-				Content: agent.Spec.System,
-			},
-			{
-				Role:    "user",
-				Content: message,
-			},
+		contextWindow, err := r.buildContextWindow(ctx, taskRun, task, agent, message)
+		if err != nil {
+			// the MCP servers the task's prompt or resources come from may not be connected yet
+			logger.Error(err, "Failed to build context window from MCP servers")
+			statusUpdate.Status.Ready = false
+			statusUpdate.Status.Status = StatusPending
+			statusUpdate.Status.Phase = kubechainv1alpha1.TaskRunPhasePending
+			statusUpdate.Status.StatusDetail = "Waiting for MCP context: " + err.Error()
+			r.recorder.Event(taskRun, corev1.EventTypeWarning, "MCPContextUnavailable", err.Error())
+			if updateErr := r.Status().Update(ctx, statusUpdate); updateErr != nil {
+				logger.Error(updateErr, "Failed to update TaskRun status")
+				return ctrl.Result{}, updateErr
+			}
+			return ctrl.Result{RequeueAfter: time.Second * 5}, nil
 		}
+
+		statusUpdate.Status.ContextWindow = contextWindow
 		statusUpdate.Status.Status = StatusReady
 		statusUpdate.Status.StatusDetail = "Ready to send to LLM"
 		statusUpdate.Status.Error = "" // Clear any previous error
@@ -278,9 +289,10 @@ func (r *TaskRunReconciler) processToolCalls(ctx context.Context, taskRun *kubec
 			break
 		}
 		toolResults = append(toolResults, kubechainv1alpha1.Message{
-			ToolCallId: tc.Spec.ToolCallId,
-			Role:       "tool",
-			Content:    tc.Status.Result,
+			ToolCallId:  tc.Spec.ToolCallId,
+			Role:        "tool",
+			Content:     tc.Status.Result,
+			Attachments: tc.Status.Attachments,
 		})
 	}
 
@@ -308,19 +320,19 @@ func (r *TaskRunReconciler) processToolCalls(ctx context.Context, taskRun *kubec
 }
 
 // getLLMAndCredentials fetches the LLM and its API key from the referenced secret
-func (r *TaskRunReconciler) getLLMAndCredentials(ctx context.Context, agent *kubechainv1alpha1.Agent, taskRun *kubechainv1alpha1.TaskRun, statusUpdate *kubechainv1alpha1.TaskRun) (kubechainv1alpha1.LLM, string, error) {
+func (r *TaskRunReconciler) getLLMAndCredentials(ctx context.Context, namespace, llmName string, taskRun *kubechainv1alpha1.TaskRun, statusUpdate *kubechainv1alpha1.TaskRun) (kubechainv1alpha1.LLM, string, error) {
 	logger := log.FromContext(ctx)
 
-	// Get the LLM referenced by the Agent
+	// Get the LLM selected for this turn
 	var llm kubechainv1alpha1.LLM
-	if err := r.Get(ctx, client.ObjectKey{Namespace: agent.Namespace, Name: agent.Spec.LLMRef.Name}, &llm); err != nil {
+	if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: llmName}, &llm); err != nil {
 		logger.Error(err, "Failed to get LLM")
 		statusUpdate.Status.Ready = false
 		statusUpdate.Status.Status = StatusError
 		statusUpdate.Status.StatusDetail = "Failed to get LLM: " + err.Error()
 		statusUpdate.Status.Error = err.Error()
 		r.recorder.Event(taskRun, corev1.EventTypeWarning, "LLMFetchFailed", err.Error())
-		if updateErr := r.Status().Update(ctx, statusUpdate); updateErr != nil {
+		if updateErr := r.saveStatus(ctx, statusUpdate); updateErr != nil {
 			logger.Error(updateErr, "Failed to update TaskRun status")
 			return llm, "", updateErr
 		}
@@ -339,7 +351,7 @@ func (r *TaskRunReconciler) getLLMAndCredentials(ctx context.Context, agent *kub
 		statusUpdate.Status.StatusDetail = "Failed to get API key secret: " + err.Error()
 		statusUpdate.Status.Error = err.Error()
 		r.recorder.Event(taskRun, corev1.EventTypeWarning, "SecretFetchFailed", err.Error())
-		if updateErr := r.Status().Update(ctx, statusUpdate); updateErr != nil {
+		if updateErr := r.saveStatus(ctx, statusUpdate); updateErr != nil {
 			logger.Error(updateErr, "Failed to update TaskRun status")
 			return llm, "", updateErr
 		}
@@ -356,7 +368,7 @@ func (r *TaskRunReconciler) getLLMAndCredentials(ctx context.Context, agent *kub
 		statusUpdate.Status.StatusDetail = "API key is empty"
 		statusUpdate.Status.Error = err.Error()
 		r.recorder.Event(taskRun, corev1.EventTypeWarning, "EmptyAPIKey", err.Error())
-		if updateErr := r.Status().Update(ctx, statusUpdate); updateErr != nil {
+		if updateErr := r.saveStatus(ctx, statusUpdate); updateErr != nil {
 			logger.Error(updateErr, "Failed to update TaskRun status")
 			return llm, "", updateErr
 		}
@@ -401,14 +413,21 @@ func (r *TaskRunReconciler) collectTools(ctx context.Context, agent *kubechainv1
 
 		for _, mcpServer := range agent.Status.ValidMCPServers {
 			// Get tools for this server
-			mcpTools, exists := r.MCPManager.GetTools(mcpServer.Name)
+			mcpTools, exists := r.MCPManager.GetTools(agent.Namespace, mcpServer.Name)
 			if !exists {
 				logger.Error(fmt.Errorf("MCP server tools not found"), "Failed to get tools for MCP server", "server", mcpServer.Name)
 				continue
 			}
 
+			// Only offer the tools the agent may use
+			serverRef, referenced := mcpmanager.AgentServerRef(agent, mcpServer.Name)
+			if !referenced {
+				continue
+			}
+			mcpTools = mcpmanager.FilterTools(serverRef, mcpTools)
+
 			// Convert MCP tools to LLM client format
-			mcpClientTools := adapters.ConvertMCPToolsToLLMClientTools(mcpTools, mcpServer.Name)
+			mcpClientTools := adapters.ConvertMCPToolsToLLMClientTools(ctx, mcpTools, mcpServer.Name)
 			tools = append(tools, mcpClientTools...)
 
 			logger.Info("Added MCP tools", "server", mcpServer.Name, "toolCount", len(mcpTools))
@@ -506,6 +525,10 @@ func (r *TaskRunReconciler) processLLMResponse(ctx context.Context, output *kube
 		statusUpdate.Status.ContextWindow = append(statusUpdate.Status.ContextWindow, kubechainv1alpha1.Message{
 			Role:      "assistant",
 			ToolCalls: adapters.CastOpenAIToolCallsToKubechain(output.ToolCalls),
+			Reasoning: output.Reasoning,
+			LLM:       output.LLM,
+			Model:     output.Model,
+			Usage:     output.Usage,
 		})
 		statusUpdate.Status.Ready = true
 		statusUpdate.Status.Status = StatusReady
@@ -514,7 +537,7 @@ func (r *TaskRunReconciler) processLLMResponse(ctx context.Context, output *kube
 		r.recorder.Event(taskRun, corev1.EventTypeNormal, "ToolCallsPending", "LLM response received, tool calls pending")
 
 		// Update the parent's status before creating tool call objects.
-		if err := r.Status().Update(ctx, statusUpdate); err != nil {
+		if err := r.saveStatus(ctx, statusUpdate); err != nil {
 			logger.Error(err, "Unable to update TaskRun status")
 			return ctrl.Result{}, err
 		}
@@ -527,8 +550,12 @@ func (r *TaskRunReconciler) processLLMResponse(ctx context.Context, output *kube
 		statusUpdate.Status.Phase = kubechainv1alpha1.TaskRunPhaseFinalAnswer
 		statusUpdate.Status.Ready = true
 		statusUpdate.Status.ContextWindow = append(statusUpdate.Status.ContextWindow, kubechainv1alpha1.Message{
-			Role:    "assistant",
-			Content: output.Content,
+			Role:      "assistant",
+			Content:   output.Content,
+			Reasoning: output.Reasoning,
+			LLM:       output.LLM,
+			Model:     output.Model,
+			Usage:     output.Usage,
 		})
 		statusUpdate.Status.Status = StatusReady
 		statusUpdate.Status.StatusDetail = "LLM final response received"
@@ -753,14 +780,22 @@ func (r *TaskRunReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		return ctrl.Result{}, nil
 	}
 
-	// Step 5: Get LLM and API credentials
-	logger.V(3).Info("Getting API credentials")
-	llm, apiKey, err := r.getLLMAndCredentials(ctx, agent, &taskRun, statusUpdate)
+	// Step 5: Collect tools from all sources
+	tools := r.collectTools(ctx, agent)
+
+	// Token usage reaches the Agent and the metrics only once the status
+	// that accounts for it is saved, see saveStatus
+	ctx = withUsageLedger(ctx)
+
+	// Step 6: Pick the LLM for this turn and get its API credentials
+	llmName := r.selectLLM(ctx, agent, task, &taskRun, statusUpdate, tools)
+	logger.V(3).Info("Getting API credentials", "llm", llmName)
+	llm, apiKey, err := r.getLLMAndCredentials(ctx, taskRun.Namespace, llmName, &taskRun, statusUpdate)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
-	// Step 6: Create LLM client
+	// Step 7: Create LLM client
 	logger.V(3).Info("Creating LLM client")
 	llmClient, err := r.newLLMClient(ctx, llm, apiKey)
 	if err != nil {
@@ -774,16 +809,13 @@ func (r *TaskRunReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 
 		// End span since we've failed with a terminal error
 		r.endTaskRunSpan(ctx, &taskRun, codes.Error, "Failed to create LLM client: "+err.Error())
-		if updateErr := r.Status().Update(ctx, statusUpdate); updateErr != nil {
+		if updateErr := r.saveStatus(ctx, statusUpdate); updateErr != nil {
 			logger.Error(updateErr, "Failed to update TaskRun status")
 			return ctrl.Result{}, updateErr
 		}
 		return ctrl.Result{}, err
 	}
 
-	// Step 7: Collect tools from all sources
-	tools := r.collectTools(ctx, agent)
-
 	r.recorder.Event(&taskRun, corev1.EventTypeNormal, "SendingContextWindowToLLM", "Sending context window to LLM")
 
 	// Create child span for LLM call
@@ -792,6 +824,14 @@ func (r *TaskRunReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		defer childSpan.End()
 	}
 
+	// Attachment data is kept in ConfigMaps, not in the context window
+	attachmentData, err := r.loadAttachmentData(ctx, &taskRun)
+	if err != nil {
+		logger.Error(err, "Failed to load attachments")
+		return ctrl.Result{}, err
+	}
+	childCtx = llmclient.WithAttachmentData(childCtx, attachmentData)
+
 	logger.V(3).Info("Sending LLM request")
 	// Step 8: Send the prompt to the LLM
 	output, err := llmClient.SendRequest(childCtx, taskRun.Status.ContextWindow, tools)
@@ -821,7 +861,7 @@ func (r *TaskRunReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 			childSpan.SetStatus(codes.Error, err.Error())
 		}
 
-		if updateErr := r.Status().Update(ctx, statusUpdate); updateErr != nil {
+		if updateErr := r.saveStatus(ctx, statusUpdate); updateErr != nil {
 			logger.Error(updateErr, "Failed to update TaskRun status after LLM error")
 			return ctrl.Result{}, updateErr
 		}
@@ -833,6 +873,13 @@ func (r *TaskRunReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		childSpan.SetStatus(codes.Ok, "LLM request succeeded")
 	}
 
+	// Record which LLM produced the response
+	output.LLM = llm.Name
+	output.Model = llm.Spec.Parameters.Model
+
+	// Account for the tokens spent on this turn
+	r.recordUsage(ctx, &llm, agent, output, statusUpdate)
+
 	logger.V(3).Info("Processing LLM response")
 	// Step 9: Process LLM response
 	var llmResult ctrl.Result
@@ -845,7 +892,7 @@ func (r *TaskRunReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		statusUpdate.Status.Error = err.Error()
 		r.recorder.Event(&taskRun, corev1.EventTypeWarning, "LLMResponseProcessingFailed", err.Error())
 
-		if updateErr := r.Status().Update(ctx, statusUpdate); updateErr != nil {
+		if updateErr := r.saveStatus(ctx, statusUpdate); updateErr != nil {
 			logger.Error(updateErr, "Failed to update TaskRun status after LLM response processing error")
 			return ctrl.Result{}, updateErr
 		}
@@ -857,7 +904,7 @@ func (r *TaskRunReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 	}
 
 	// Step 10: Update final status
-	if err := r.Status().Update(ctx, statusUpdate); err != nil {
+	if err := r.saveStatus(ctx, statusUpdate); err != nil {
 		logger.Error(err, "Unable to update TaskRun status")
 		return ctrl.Result{}, err
 	}
@@ -876,11 +923,6 @@ func (r *TaskRunReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		r.newLLMClient = llmclient.NewLLMClient
 	}
 
-	// Initialize MCPManager if not already set
-	if r.MCPManager == nil {
-		r.MCPManager = mcpmanager.NewMCPServerManager()
-	}
-
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&kubechainv1alpha1.TaskRun{}).
 		Complete(r)
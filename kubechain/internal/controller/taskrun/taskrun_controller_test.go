@@ -7,6 +7,7 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -254,6 +255,137 @@ var _ = Describe("TaskRun Controller", func() {
 			Expect(mockLLMClient.Calls[0].Messages[1].Content).To(ContainSubstring(testTask.message))
 		})
 	})
+	Context("ReadyForLLM -> LLMFinalAnswer with a ModelRouter", func() {
+		It("sends the turn to the LLM picked by the router and records it on the message", func() {
+			_, _, agent, _, teardown := setupSuiteObjects(ctx)
+			defer teardown()
+
+			By("creating a second llm and a router that always picks it")
+			routedLLM := &TestLLM{name: "test-llm-routed"}
+			routedLLM.SetupWithStatus(ctx, kubechain.LLMStatus{Status: "Ready", Ready: true})
+			defer routedLLM.Teardown(ctx)
+
+			router := &kubechain.ModelRouter{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-router", Namespace: "default"},
+				Spec: kubechain.ModelRouterSpec{
+					Routes: []kubechain.ModelRoute{{
+						Name:   "always",
+						LLMRef: kubechain.LocalObjectReference{Name: routedLLM.name},
+					}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, router)).To(Succeed())
+			defer func() { Expect(k8sClient.Delete(ctx, router)).To(Succeed()) }()
+
+			agent.Spec.RouterRef = &kubechain.LocalObjectReference{Name: router.Name}
+			Expect(k8sClient.Update(ctx, agent)).To(Succeed())
+
+			taskRun := testTaskRun.SetupWithStatus(ctx, kubechain.TaskRunStatus{
+				Phase: kubechain.TaskRunPhaseReadyForLLM,
+				ContextWindow: []kubechain.Message{
+					{
+						Role:    "system",
+						Content: testAgent.system,
+					},
+					{
+						Role:    "user",
+						Content: testTask.message,
+					},
+				},
+			})
+			defer testTaskRun.Teardown(ctx)
+
+			By("reconciling the taskrun")
+			reconciler, recorder := reconciler()
+			var usedLLM string
+			reconciler.newLLMClient = func(ctx context.Context, llm kubechain.LLM, apiKey string) (llmclient.LLMClient, error) {
+				usedLLM = llm.Name
+				return &llmclient.MockLLMClient{
+					Response: &v1alpha1.Message{
+						Role:    "assistant",
+						Content: "There is none.",
+					},
+				}, nil
+			}
+
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: testTaskRun.name, Namespace: "default"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(usedLLM).To(Equal(routedLLM.name))
+
+			By("ensuring the assistant message records the routed llm")
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: testTaskRun.name, Namespace: "default"}, taskRun)).To(Succeed())
+			Expect(taskRun.Status.Phase).To(Equal(kubechain.TaskRunPhaseFinalAnswer))
+			Expect(taskRun.Status.ContextWindow).To(HaveLen(3))
+			Expect(taskRun.Status.ContextWindow[2].LLM).To(Equal(routedLLM.name))
+			ExpectRecorder(recorder).ToEmitEventContaining("ModelRouted")
+		})
+	})
+	Context("ReadyForLLM -> LLMFinalAnswer after a conflict", func() {
+		It("accounts a turn to the agent once, after the taskrun status is saved", func() {
+			_, _, agent, _, teardown := setupSuiteObjects(ctx)
+			defer teardown()
+
+			taskRun := testTaskRun.SetupWithStatus(ctx, kubechain.TaskRunStatus{
+				Phase: kubechain.TaskRunPhaseReadyForLLM,
+				ContextWindow: []kubechain.Message{
+					{
+						Role:    "system",
+						Content: testAgent.system,
+					},
+					{
+						Role:    "user",
+						Content: testTask.message,
+					},
+				},
+			})
+			defer testTaskRun.Teardown(ctx)
+
+			reconciler, _ := reconciler()
+			conflict := true
+			reconciler.newLLMClient = func(ctx context.Context, llm kubechain.LLM, apiKey string) (llmclient.LLMClient, error) {
+				if conflict {
+					// someone else changes the taskrun while the llm is answering
+					var latest kubechain.TaskRun
+					Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(taskRun), &latest)).To(Succeed())
+					latest.Labels = map[string]string{"touched": "true"}
+					Expect(k8sClient.Update(ctx, &latest)).To(Succeed())
+				}
+				return &llmclient.MockLLMClient{
+					Response: &v1alpha1.Message{
+						Role:    "assistant",
+						Content: "There is none.",
+						Usage:   &kubechain.TokenUsage{InputTokens: 100, OutputTokens: 10},
+					},
+				}, nil
+			}
+
+			By("reconciling while the taskrun status update conflicts")
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: testTaskRun.name, Namespace: "default"},
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(agent), agent)).To(Succeed())
+			Expect(agent.Status.CostSummary).To(BeNil())
+
+			By("reconciling the turn again")
+			conflict = false
+			_, err = reconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: testTaskRun.name, Namespace: "default"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("ensuring the turn was accounted once")
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(taskRun), taskRun)).To(Succeed())
+			Expect(taskRun.Status.Phase).To(Equal(kubechain.TaskRunPhaseFinalAnswer))
+			Expect(taskRun.Status.Usage.Turns).To(Equal(int64(1)))
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(agent), agent)).To(Succeed())
+			Expect(agent.Status.CostSummary).NotTo(BeNil())
+			Expect(agent.Status.CostSummary.Turns).To(Equal(int64(1)))
+			Expect(agent.Status.CostSummary.InputTokens).To(Equal(int64(100)))
+		})
+	})
 	Context("ReadyForLLM -> Error", func() {
 		It("moves to Error state but not Failed phase on general error", func() {
 			_, _, _, _, teardown := setupSuiteObjects(ctx)
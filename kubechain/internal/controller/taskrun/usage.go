@@ -0,0 +1,132 @@
+package taskrun
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+	"github.com/humanlayer/smallchain/kubechain/internal/pricing"
+)
+
+// turnUsage is the priced token usage of one LLM turn, waiting to be added
+// to the Agent's cost summary and the metrics
+type turnUsage struct {
+	// turn is the index the turn's response takes in the context window
+	turn     int
+	agent    *kubechainv1alpha1.Agent
+	provider string
+	model    string
+	usage    kubechainv1alpha1.TokenUsage
+	price    *pricing.Price
+}
+
+// usageLedger holds the turns of a reconcile until the TaskRun status that
+// accounts for them is saved. A status update that conflicts is retried by
+// running the turn again, publishing before the save would count it twice.
+type usageLedger struct {
+	turns []turnUsage
+}
+
+type usageLedgerKey struct{}
+
+// withUsageLedger returns a context that collects the usage recorded during
+// a reconcile, see saveStatus
+func withUsageLedger(ctx context.Context) context.Context {
+	return context.WithValue(ctx, usageLedgerKey{}, &usageLedger{})
+}
+
+func usageLedgerFrom(ctx context.Context) *usageLedger {
+	ledger, _ := ctx.Value(usageLedgerKey{}).(*usageLedger)
+	return ledger
+}
+
+// recordUsage prices the token usage of an LLM turn and adds it to the
+// TaskRun's running total. The Agent's cost summary and the metrics follow
+// once the TaskRun status is saved, see saveStatus.
+// Accounting problems are logged but never fail the TaskRun.
+func (r *TaskRunReconciler) recordUsage(ctx context.Context, llm *kubechainv1alpha1.LLM, agent *kubechainv1alpha1.Agent, output *kubechainv1alpha1.Message, statusUpdate *kubechainv1alpha1.TaskRun) {
+	logger := log.FromContext(ctx)
+
+	if output.Usage == nil {
+		logger.V(1).Info("LLM response carried no token usage", "provider", llm.Spec.Provider)
+		return
+	}
+
+	model := llm.Spec.Parameters.Model
+	price, err := pricing.Lookup(ctx, r.Client, statusUpdate.Namespace, llm.Spec.Provider, model)
+	if err != nil {
+		logger.Error(err, "Failed to look up model price", "provider", llm.Spec.Provider, "model", model)
+		price = nil
+	}
+
+	usage := *output.Usage
+	if price != nil {
+		usage.EstimatedCost = pricing.FormatCost(price.Estimate(usage.InputTokens, usage.OutputTokens))
+	}
+	output.Usage = &usage
+	statusUpdate.Status.Usage = pricing.AddUsage(statusUpdate.Status.Usage, usage, price)
+
+	turn := turnUsage{
+		turn:     len(statusUpdate.Status.ContextWindow),
+		agent:    agent,
+		provider: llm.Spec.Provider,
+		model:    model,
+		usage:    usage,
+		price:    price,
+	}
+	if ledger := usageLedgerFrom(ctx); ledger != nil {
+		ledger.turns = append(ledger.turns, turn)
+		return
+	}
+	r.publishUsage(ctx, statusUpdate.Namespace, turn)
+}
+
+// saveStatus updates the TaskRun status and, once it is saved, publishes the
+// usage of the turns it accounts for
+func (r *TaskRunReconciler) saveStatus(ctx context.Context, statusUpdate *kubechainv1alpha1.TaskRun) error {
+	if err := r.Status().Update(ctx, statusUpdate); err != nil {
+		return err
+	}
+
+	if ledger := usageLedgerFrom(ctx); ledger != nil {
+		for _, turn := range ledger.turns {
+			r.publishUsage(ctx, statusUpdate.Namespace, turn)
+		}
+		ledger.turns = nil
+	}
+	return nil
+}
+
+// publishUsage adds the usage of a turn to the metrics and the Agent's cost summary
+func (r *TaskRunReconciler) publishUsage(ctx context.Context, namespace string, turn turnUsage) {
+	agent := turn.agent.Name
+	llmTokensTotal.WithLabelValues(namespace, agent, turn.provider, turn.model, "input").Add(float64(turn.usage.InputTokens))
+	llmTokensTotal.WithLabelValues(namespace, agent, turn.provider, turn.model, "output").Add(float64(turn.usage.OutputTokens))
+	if turn.price != nil {
+		cost, _ := strconv.ParseFloat(turn.usage.EstimatedCost, 64)
+		llmEstimatedCostTotal.WithLabelValues(namespace, agent, turn.provider, turn.model, turn.price.Currency).Add(cost)
+	} else {
+		llmUnpricedTurnsTotal.WithLabelValues(namespace, agent, turn.provider, turn.model).Inc()
+	}
+
+	if err := r.addAgentUsage(ctx, turn.agent, turn.usage, turn.price); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to update agent cost summary", "agent", agent, "turn", turn.turn)
+	}
+}
+
+// addAgentUsage adds the usage of a turn to the Agent's cost summary
+func (r *TaskRunReconciler) addAgentUsage(ctx context.Context, agent *kubechainv1alpha1.Agent, usage kubechainv1alpha1.TokenUsage, price *pricing.Price) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var latest kubechainv1alpha1.Agent
+		if err := r.Get(ctx, client.ObjectKeyFromObject(agent), &latest); err != nil {
+			return fmt.Errorf("failed to get agent: %w", err)
+		}
+		latest.Status.CostSummary = pricing.AddUsage(latest.Status.CostSummary, usage, price)
+		return r.Status().Update(ctx, &latest)
+	})
+}
@@ -95,7 +95,7 @@ type TestAgent struct {
 	name       string
 	llmName    string
 	system     string
-	mcpServers []kubechain.LocalObjectReference
+	mcpServers []kubechain.AgentMCPServer
 	agent      *kubechain.Agent
 }
 
@@ -139,7 +139,7 @@ var testAgent = &TestAgent{
 	name:       "test-agent",
 	llmName:    testLLM.name,
 	system:     "you are a testing assistant",
-	mcpServers: []kubechain.LocalObjectReference{},
+	mcpServers: []kubechain.AgentMCPServer{},
 }
 
 type TestTask struct {
@@ -0,0 +1,145 @@
+package taskruntoolcall
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+	"github.com/humanlayer/smallchain/kubechain/internal/approval"
+)
+
+// approvalPollInterval is how often a call waiting for approval is checked
+const approvalPollInterval = 5 * time.Second
+
+// approvalCalls are the calls that asked for approval of a tool call. Tool
+// calls that started waiting before approvals were tracked only have their
+// ExternalCallID.
+func approvalCalls(trtc *kubechainv1alpha1.TaskRunToolCall, contactChannel *kubechainv1alpha1.ContactChannel) []kubechainv1alpha1.ApprovalCall {
+	if trtc.Status.Approval != nil && len(trtc.Status.Approval.Calls) > 0 {
+		return trtc.Status.Approval.Calls
+	}
+	return []kubechainv1alpha1.ApprovalCall{{CallID: trtc.Status.ExternalCallID, ContactChannel: contactChannel.Name}}
+}
+
+// followUpApproval reminds, escalates or gives up on a tool call no one
+// answered yet, as its approval timeouts say
+func (r *TaskRunToolCallReconciler) followUpApproval(ctx context.Context, trtc *kubechainv1alpha1.TaskRunToolCall,
+	contactChannel *kubechainv1alpha1.ContactChannel, apiKey string, timeouts *kubechainv1alpha1.ApprovalTimeouts,
+) (ctrl.Result, error, bool) {
+	switch approval.NextStep(timeouts, trtc.Status.Approval, time.Now()) {
+	case approval.StepTimeOut:
+		return r.timeOutApproval(ctx, trtc, timeouts)
+
+	case approval.StepEscalate:
+		escalation := timeouts.Escalation
+		escalationChannel, err := r.getContactChannel(ctx, escalation.ContactChannel.Name, trtc.Namespace)
+		if err != nil {
+			return r.setStatusError(ctx, kubechainv1alpha1.TaskRunToolCallPhaseErrorRequestingHumanApproval,
+				"ApprovalEscalationFailed", trtc, err)
+		}
+		escalationAPIKey, err := r.contactChannelAPIKey(ctx, escalationChannel)
+		if err != nil {
+			return r.setStatusError(ctx, kubechainv1alpha1.TaskRunToolCallPhaseErrorRequestingHumanApproval,
+				"ApprovalEscalationFailed", trtc, err)
+		}
+		if err := r.askForApprovalAgain(ctx, trtc, escalationChannel, escalationAPIKey, kubechainv1alpha1.ApprovalStageEscalated); err != nil {
+			return r.setStatusError(ctx, kubechainv1alpha1.TaskRunToolCallPhaseErrorRequestingHumanApproval,
+				"ApprovalEscalationFailed", trtc, err)
+		}
+		r.recorder.Event(trtc, corev1.EventTypeNormal, "ApprovalEscalated",
+			fmt.Sprintf("No one approved the tool call within %s, asked contact channel %s",
+				escalation.After.Duration, escalationChannel.Name))
+
+	case approval.StepRemind:
+		// remind whoever was asked last, the escalation channel once escalated
+		calls := trtc.Status.Approval.Calls
+		remindChannel, remindAPIKey := contactChannel, apiKey
+		if last := calls[len(calls)-1].ContactChannel; last != contactChannel.Name {
+			var err error
+			if remindChannel, err = r.getContactChannel(ctx, last, trtc.Namespace); err != nil {
+				return ctrl.Result{}, err, true
+			}
+			if remindAPIKey, err = r.contactChannelAPIKey(ctx, remindChannel); err != nil {
+				return ctrl.Result{}, err, true
+			}
+		}
+		// a failed reminder is retried at the next poll, the request itself still stands
+		if err := r.askForApprovalAgain(ctx, trtc, remindChannel, remindAPIKey, kubechainv1alpha1.ApprovalStageReminded); err != nil {
+			r.recorder.Event(trtc, corev1.EventTypeWarning, "ApprovalReminderFailed", err.Error())
+			return ctrl.Result{RequeueAfter: approvalPollInterval}, nil, true
+		}
+		r.recorder.Event(trtc, corev1.EventTypeNormal, "ApprovalReminderSent",
+			fmt.Sprintf("Reminded contact channel %s of the tool call waiting for approval", remindChannel.Name))
+	}
+
+	return ctrl.Result{RequeueAfter: approvalPollInterval}, nil, true
+}
+
+// askForApprovalAgain sends another request for approval of a tool call, to
+// remind or escalate
+func (r *TaskRunToolCallReconciler) askForApprovalAgain(ctx context.Context, trtc *kubechainv1alpha1.TaskRunToolCall,
+	contactChannel *kubechainv1alpha1.ContactChannel, apiKey string, stage kubechainv1alpha1.ApprovalStage,
+) error {
+	functionCall, statusCode, err := r.postToHumanLayer(ctx, trtc, contactChannel, apiKey)
+	if err != nil {
+		return fmt.Errorf("HumanLayer request failed with status code %d: %w", statusCode, err)
+	}
+
+	now := metav1.Now()
+	trtc.Status.ExternalCallID = functionCall.GetCallId()
+	trtc.Status.Approval.Calls = append(trtc.Status.Approval.Calls, kubechainv1alpha1.ApprovalCall{
+		CallID:         functionCall.GetCallId(),
+		ContactChannel: contactChannel.Name,
+		RequestedAt:    now,
+	})
+	switch stage {
+	case kubechainv1alpha1.ApprovalStageEscalated:
+		trtc.Status.Approval.Stage = stage
+		trtc.Status.StatusDetail = fmt.Sprintf("Waiting for human approval via contact channel %s (escalated)", contactChannel.Name)
+	case kubechainv1alpha1.ApprovalStageReminded:
+		// reminding after escalating stays escalated
+		if trtc.Status.Approval.Stage != kubechainv1alpha1.ApprovalStageEscalated {
+			trtc.Status.Approval.Stage = stage
+		}
+		trtc.Status.Approval.Reminders++
+	}
+
+	if err := r.Status().Update(ctx, trtc); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to update TaskRunToolCall status")
+		return err
+	}
+	return nil
+}
+
+// timeOutApproval stops waiting for a tool call's approval and rejects, runs
+// or fails it
+func (r *TaskRunToolCallReconciler) timeOutApproval(ctx context.Context, trtc *kubechainv1alpha1.TaskRunToolCall,
+	timeouts *kubechainv1alpha1.ApprovalTimeouts,
+) (ctrl.Result, error, bool) {
+	trtc.Status.Approval.Stage = kubechainv1alpha1.ApprovalStageTimedOut
+	action := approval.OnTimeout(timeouts)
+	msg := fmt.Sprintf("No one approved the tool call within %s", timeouts.Timeout.Duration)
+	r.recorder.Event(trtc, corev1.EventTypeWarning, "ApprovalTimedOut", fmt.Sprintf("%s, %s", msg, action))
+
+	switch action {
+	case kubechainv1alpha1.ApprovalTimeoutActionApprove:
+		return r.updateTRTCStatus(ctx, trtc,
+			kubechainv1alpha1.TaskRunToolCallStatusTypeReady,
+			kubechainv1alpha1.TaskRunToolCallPhaseReadyToExecuteApprovedTool,
+			msg+", approved automatically", "")
+	case kubechainv1alpha1.ApprovalTimeoutActionFail:
+		return r.setStatusError(ctx, kubechainv1alpha1.TaskRunToolCallPhaseFailed,
+			"ApprovalTimedOut", trtc, fmt.Errorf("%s", msg))
+	default:
+		return r.updateTRTCStatus(ctx, trtc,
+			kubechainv1alpha1.TaskRunToolCallStatusTypeSucceeded,
+			kubechainv1alpha1.TaskRunToolCallPhaseToolCallRejected,
+			msg+", rejected automatically", "Tool call rejected: "+msg)
+	}
+}
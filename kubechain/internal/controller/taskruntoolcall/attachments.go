@@ -0,0 +1,66 @@
+package taskruntoolcall
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+	"github.com/humanlayer/smallchain/kubechain/internal/mcpmanager"
+)
+
+// maxAttachmentBytes caps the data kept for the attachments of one tool call,
+// leaving room below the 1MiB limit of a ConfigMap. Attachments past the cap
+// are only described to the LLM.
+const maxAttachmentBytes = 768 * 1024
+
+// attachmentsConfigMapName is the ConfigMap holding the attachment data of a
+// TaskRunToolCall
+func attachmentsConfigMapName(trtc *kubechainv1alpha1.TaskRunToolCall) string {
+	return trtc.Name + "-attachments"
+}
+
+// storeAttachments keeps the data of a tool result's attachments in a
+// ConfigMap owned by the TaskRunToolCall, and returns the attachments
+// pointing at it
+func (r *TaskRunToolCallReconciler) storeAttachments(ctx context.Context, trtc *kubechainv1alpha1.TaskRunToolCall, result *mcpmanager.ToolResult) ([]kubechainv1alpha1.Attachment, error) {
+	if len(result.Attachments) == 0 {
+		return nil, nil
+	}
+
+	attachments := make([]kubechainv1alpha1.Attachment, len(result.Attachments))
+	binaryData := map[string][]byte{}
+	total := 0
+	for i, attachment := range result.Attachments {
+		attachments[i] = attachment
+		data := result.Data[i]
+		if total+len(data) > maxAttachmentBytes {
+			continue
+		}
+		total += len(data)
+		key := fmt.Sprintf("attachment-%d", i)
+		binaryData[key] = data
+		attachments[i].DataFrom = &kubechainv1alpha1.ConfigMapKeySelector{
+			Name: attachmentsConfigMapName(trtc),
+			Key:  key,
+		}
+	}
+	if len(binaryData) == 0 {
+		return attachments, nil
+	}
+
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Name:      attachmentsConfigMapName(trtc),
+		Namespace: trtc.Namespace,
+	}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, configMap, func() error {
+		configMap.BinaryData = binaryData
+		return controllerutil.SetControllerReference(trtc, configMap, r.Scheme)
+	}); err != nil {
+		return nil, fmt.Errorf("failed to store attachments: %w", err)
+	}
+	return attachments, nil
+}
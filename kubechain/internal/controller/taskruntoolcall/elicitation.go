@@ -0,0 +1,350 @@
+package taskruntoolcall
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+const (
+	// elicitationPollInterval is how often a pending human contact is checked
+	elicitationPollInterval = 5 * time.Second
+
+	// elicitationTimeout is how long an elicitation waits for a human to
+	// reply, it is cancelled afterwards
+	elicitationTimeout = 30 * time.Minute
+
+	// elicitationDecline is the reply that declines an elicitation
+	elicitationDecline = "decline"
+)
+
+// elicitationSchema is the part of a requested schema a human is told about,
+// MCP limits it to an object of primitive properties
+type elicitationSchema struct {
+	Properties map[string]elicitationProperty `json:"properties"`
+	Required   []string                       `json:"required"`
+}
+
+type elicitationProperty struct {
+	Type        string   `json:"type"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Enum        []string `json:"enum"`
+}
+
+// elicitor answers the elicitation requests an MCP server makes while it runs
+// a tool call, by asking the server's elicitation contact channel
+type elicitor struct {
+	r          *TaskRunToolCallReconciler
+	trtc       *kubechainv1alpha1.TaskRunToolCall
+	serverName string
+	// callCtx is the context of the tool call, eliciting stops with the call
+	callCtx context.Context
+	// reporter writes the phase of the call while it waits for the human
+	reporter *progressReporter
+}
+
+// Elicit implements mcpmanager.Elicitor
+func (e *elicitor) Elicit(ctx context.Context, request mcp.ElicitationRequest) (result *mcp.ElicitationResult, err error) {
+	defer recoverHandler(&err, "taskRunToolCall", client.ObjectKeyFromObject(e.trtc), "mcpServer", e.serverName)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer context.AfterFunc(e.callCtx, cancel)()
+	logger := log.Log.WithValues("taskRunToolCall", client.ObjectKeyFromObject(e.trtc), "mcpServer", e.serverName)
+
+	if e.r.HLClientFactory == nil {
+		return nil, fmt.Errorf("HLClient not initialized")
+	}
+	var mcpServer kubechainv1alpha1.MCPServer
+	if err := e.r.Get(ctx, client.ObjectKey{Namespace: e.trtc.Namespace, Name: e.serverName}, &mcpServer); err != nil {
+		return nil, fmt.Errorf("failed to get MCPServer %q: %w", e.serverName, err)
+	}
+	channel := mcpServer.Spec.ElicitationChannel()
+	if channel == nil {
+		return nil, fmt.Errorf("elicitation is not enabled for MCP server %s", e.serverName)
+	}
+	contactChannel, err := e.r.getContactChannel(ctx, channel.Name, e.trtc.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	apiKey, err := e.r.contactChannelAPIKey(ctx, contactChannel)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := parseElicitationSchema(request.Params.RequestedSchema)
+	if err != nil {
+		return nil, err
+	}
+	msg := elicitationMessage(e.serverName, e.trtc.Spec.ToolRef.Name, request.Params.Message, schema)
+	humanContact, statusCode, err := e.r.requestHumanContact(ctx, contactChannel, apiKey, e.trtc.Name, msg)
+	if err != nil {
+		return nil, fmt.Errorf("HumanLayer request failed with status code %d: %v", statusCode, err)
+	}
+
+	logger.Info("Waiting for human input", "contactChannel", contactChannel.Name)
+	e.r.recorder.Event(e.trtc, corev1.EventTypeNormal, "AwaitingHumanInput",
+		fmt.Sprintf("MCP server %s requested input via contact channel %s", e.serverName, contactChannel.Name))
+	// the call ID outlives the call, see resumeElicitation
+	e.reporter.setExternalCallID(humanContact.GetCallId())
+	phase, detail := e.reporter.setPhase(kubechainv1alpha1.TaskRunToolCallPhaseAwaitingHumanInput,
+		fmt.Sprintf("Waiting for human input via contact channel %s", contactChannel.Name))
+	defer e.reporter.setPhase(phase, detail)
+
+	response, err := e.r.awaitHumanResponse(ctx, contactChannel, e.trtc.Name, humanContact.GetCallId(), apiKey)
+	if err != nil {
+		if e.callCtx.Err() != nil {
+			return nil, err
+		}
+		// an unanswered request is left to the server to handle
+		logger.Info("No human input received", "reason", err.Error())
+		e.r.recorder.Event(e.trtc, corev1.EventTypeWarning, "HumanInputTimedOut", err.Error())
+		return &mcp.ElicitationResult{ElicitationResponse: mcp.ElicitationResponse{Action: mcp.ElicitationResponseActionCancel}}, nil
+	}
+
+	if strings.EqualFold(strings.TrimSpace(response), elicitationDecline) {
+		e.r.recorder.Event(e.trtc, corev1.EventTypeNormal, "HumanInputDeclined",
+			fmt.Sprintf("Input requested by MCP server %s was declined", e.serverName))
+		return &mcp.ElicitationResult{ElicitationResponse: mcp.ElicitationResponse{Action: mcp.ElicitationResponseActionDecline}}, nil
+	}
+
+	content, err := schema.content(response)
+	if err != nil {
+		e.r.recorder.Event(e.trtc, corev1.EventTypeWarning, "InvalidHumanInput", err.Error())
+		return nil, err
+	}
+	e.r.recorder.Event(e.trtc, corev1.EventTypeNormal, "HumanInputReceived",
+		fmt.Sprintf("Input requested by MCP server %s was provided", e.serverName))
+	return &mcp.ElicitationResult{ElicitationResponse: mcp.ElicitationResponse{
+		Action:  mcp.ElicitationResponseActionAccept,
+		Content: content,
+	}}, nil
+}
+
+// awaitHumanResponse polls a human contact until a human replied to it
+func (r *TaskRunToolCallReconciler) awaitHumanResponse(ctx context.Context, contactChannel *kubechainv1alpha1.ContactChannel, runID, callID, apiKey string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, elicitationTimeout)
+	defer cancel()
+	ticker := time.NewTicker(elicitationPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("no human input received: %w", ctx.Err())
+		case <-ticker.C:
+		}
+
+		hlClient, err := r.callStatusClient(contactChannel, runID, callID, apiKey)
+		if err != nil {
+			return "", err
+		}
+		output, _, err := hlClient.GetHumanContactStatus(ctx)
+		if err != nil {
+			// a failed check is retried on the next tick
+			log.FromContext(ctx).Error(err, "Failed to check human contact")
+			continue
+		}
+		status := output.GetStatus()
+		response, ok := status.GetResponseOk()
+		if !ok || response == nil {
+			continue
+		}
+		return *response, nil
+	}
+}
+
+// resumeElicitation finishes a tool call that was cut off while it waited for
+// human input, e.g. by a restart of the operator. The call is not made again,
+// it may have had effects before it asked. Instead the reply is waited for on
+// later reconciles and handed to the agent, which may call the tool again.
+func (r *TaskRunToolCallReconciler) resumeElicitation(ctx context.Context, trtc *kubechainv1alpha1.TaskRunToolCall, serverName string) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	if r.HLClientFactory == nil {
+		result, err, _ := r.setStatusError(ctx, kubechainv1alpha1.TaskRunToolCallPhaseFailed,
+			"NoHumanLayerClient", trtc, fmt.Errorf("HLClient not initialized"))
+		return result, err
+	}
+	var mcpServer kubechainv1alpha1.MCPServer
+	if err := r.Get(ctx, client.ObjectKey{Namespace: trtc.Namespace, Name: serverName}, &mcpServer); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to get MCPServer %q: %w", serverName, err)
+	}
+	channel := mcpServer.Spec.ElicitationChannel()
+	if channel == nil {
+		result, err, _ := r.setStatusError(ctx, kubechainv1alpha1.TaskRunToolCallPhaseFailed, "NoContactChannel", trtc,
+			fmt.Errorf("tool call was interrupted while waiting for human input and elicitation is no longer enabled for MCP server %s", serverName))
+		return result, err
+	}
+	contactChannel, err := r.getContactChannel(ctx, channel.Name, trtc.Namespace)
+	if err != nil {
+		result, errStatus, _ := r.setStatusError(ctx, kubechainv1alpha1.TaskRunToolCallPhaseFailed, "NoContactChannel", trtc, err)
+		return result, errStatus
+	}
+	apiKey, err := r.contactChannelAPIKey(ctx, contactChannel)
+	if err != nil {
+		result, errStatus, _ := r.setStatusError(ctx, kubechainv1alpha1.TaskRunToolCallPhaseFailed, "NoAPIKey", trtc, err)
+		return result, errStatus
+	}
+
+	hlClient, err := r.callStatusClient(contactChannel, trtc.Name, trtc.Status.ExternalCallID, apiKey)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	humanContact, _, err := hlClient.GetHumanContactStatus(ctx)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	status := humanContact.GetStatus()
+	reply := "no human input was received"
+	if response, ok := status.GetResponseOk(); ok && response != nil {
+		reply = fmt.Sprintf("the human replied: %s", *response)
+	} else if requestedAt := status.GetRequestedAt(); requestedAt.IsZero() || time.Since(requestedAt) < elicitationTimeout {
+		return ctrl.Result{RequeueAfter: elicitationPollInterval}, nil
+	}
+
+	trtc.Status.Result = fmt.Sprintf("The tool call was interrupted while MCP server %s waited for human input and was not made again, %s",
+		serverName, reply)
+	trtc.Status.Phase = kubechainv1alpha1.TaskRunToolCallPhaseSucceeded
+	trtc.Status.Status = kubechainv1alpha1.TaskRunToolCallStatusTypeSucceeded
+	trtc.Status.StatusDetail = "MCP tool call interrupted while waiting for human input"
+	r.recorder.Event(trtc, corev1.EventTypeWarning, "ToolCallInterrupted",
+		fmt.Sprintf("MCP tool %q was interrupted while waiting for human input, %s", trtc.Spec.ToolRef.Name, reply))
+	if err := r.Status().Update(ctx, trtc); err != nil {
+		logger.Error(err, "Failed to update TaskRunToolCall status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// parseElicitationSchema reads the requested schema of an elicitation
+func parseElicitationSchema(requested any) (*elicitationSchema, error) {
+	schema := &elicitationSchema{}
+	if requested == nil {
+		return schema, nil
+	}
+	data, err := json.Marshal(requested)
+	if err != nil {
+		return nil, fmt.Errorf("invalid requested schema: %w", err)
+	}
+	if err := json.Unmarshal(data, schema); err != nil {
+		return nil, fmt.Errorf("invalid requested schema: %w", err)
+	}
+	return schema, nil
+}
+
+// names returns the property names of a schema in order
+func (s *elicitationSchema) names() []string {
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// elicitationMessage is what the human is asked: the server's message and how
+// to reply to it
+func elicitationMessage(serverName, toolName, message string, schema *elicitationSchema) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "MCP server %s needs input to run %s:\n\n%s\n\n", serverName, toolName, message)
+
+	names := schema.names()
+	switch len(names) {
+	case 0:
+		b.WriteString("Reply with anything to continue")
+	case 1:
+		fmt.Fprintf(&b, "Reply with %s", describeProperty(names[0], schema.Properties[names[0]]))
+	default:
+		b.WriteString("Reply with a JSON object of:")
+		for _, name := range names {
+			fmt.Fprintf(&b, "\n- %s", describeProperty(name, schema.Properties[name]))
+			if slices.Contains(schema.Required, name) {
+				b.WriteString(", required")
+			}
+		}
+	}
+	fmt.Fprintf(&b, "\n\nReply %q to refuse.", elicitationDecline)
+	return b.String()
+}
+
+// describeProperty describes a property of a requested schema
+func describeProperty(name string, property elicitationProperty) string {
+	description := fmt.Sprintf("%s (%s)", name, property.Type)
+	if property.Title != "" {
+		description = fmt.Sprintf("%s, %s", description, property.Title)
+	}
+	if property.Description != "" {
+		description = fmt.Sprintf("%s: %s", description, property.Description)
+	}
+	if len(property.Enum) > 0 {
+		description = fmt.Sprintf("%s, one of %s", description, strings.Join(property.Enum, ", "))
+	}
+	return description
+}
+
+// content turns a human's reply into the content of an accepted elicitation.
+// A reply to a single property is that property's value, other replies are
+// JSON objects.
+func (s *elicitationSchema) content(response string) (map[string]any, error) {
+	response = strings.TrimSpace(response)
+	names := s.names()
+
+	var content map[string]any
+	if err := json.Unmarshal([]byte(response), &content); err != nil {
+		switch len(names) {
+		case 0:
+			return map[string]any{}, nil
+		case 1:
+			value, err := s.Properties[names[0]].value(response)
+			if err != nil {
+				return nil, fmt.Errorf("invalid human input for %s: %w", names[0], err)
+			}
+			return map[string]any{names[0]: value}, nil
+		default:
+			return nil, fmt.Errorf("human input is not a JSON object: %w", err)
+		}
+	}
+
+	for _, name := range s.Required {
+		if _, ok := content[name]; !ok {
+			return nil, fmt.Errorf("human input is missing %s", name)
+		}
+	}
+	return content, nil
+}
+
+// value converts a reply to the type of a property
+func (p elicitationProperty) value(response string) (any, error) {
+	switch p.Type {
+	case "number":
+		return strconv.ParseFloat(response, 64)
+	case "integer":
+		return strconv.ParseInt(response, 10, 64)
+	case "boolean":
+		switch strings.ToLower(response) {
+		case "yes", "y":
+			return true, nil
+		case "no", "n":
+			return false, nil
+		}
+		return strconv.ParseBool(response)
+	default:
+		if len(p.Enum) > 0 && !slices.Contains(p.Enum, response) {
+			return nil, fmt.Errorf("%q is not one of %s", response, strings.Join(p.Enum, ", "))
+		}
+		return response, nil
+	}
+}
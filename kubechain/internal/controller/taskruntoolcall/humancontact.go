@@ -0,0 +1,102 @@
+package taskruntoolcall
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+// humanContactPollInterval is how often a question is checked for a reply
+const humanContactPollInterval = 5 * time.Second
+
+// processHumanContact asks a human the LLM's question and, on later
+// reconciles, waits for the reply that becomes the tool result
+func (r *TaskRunToolCallReconciler) processHumanContact(ctx context.Context, trtc *kubechainv1alpha1.TaskRunToolCall, tool *kubechainv1alpha1.Tool, args map[string]interface{}) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	if r.HLClientFactory == nil {
+		result, err, _ := r.setStatusError(ctx, kubechainv1alpha1.TaskRunToolCallPhaseFailed,
+			"NoHumanLayerClient", trtc, fmt.Errorf("HLClient not initialized"))
+		return result, err
+	}
+
+	contactChannel, err := r.getContactChannel(ctx, tool.Spec.Execute.HumanContact.ContactChannel.Name, trtc.Namespace)
+	if err != nil {
+		result, errStatus, _ := r.setStatusError(ctx, kubechainv1alpha1.TaskRunToolCallPhaseFailed,
+			"NoContactChannel", trtc, err)
+		return result, errStatus
+	}
+	apiKey, err := r.contactChannelAPIKey(ctx, contactChannel)
+	if err != nil {
+		result, errStatus, _ := r.setStatusError(ctx, kubechainv1alpha1.TaskRunToolCallPhaseFailed,
+			"NoAPIKey", trtc, err)
+		return result, errStatus
+	}
+
+	// the question was asked on an earlier reconcile
+	if trtc.Status.Phase == kubechainv1alpha1.TaskRunToolCallPhaseAwaitingHumanInput && trtc.Status.ExternalCallID != "" {
+		return r.checkHumanContact(ctx, trtc, contactChannel, apiKey)
+	}
+
+	question, _ := args["question"].(string)
+	if question == "" {
+		// models don't always name their arguments as told
+		question = trtc.Spec.Arguments
+	}
+	humanContact, statusCode, err := r.requestHumanContact(ctx, contactChannel, apiKey, trtc.Name, question)
+	if err != nil {
+		result, errStatus, _ := r.setStatusError(ctx, kubechainv1alpha1.TaskRunToolCallPhaseFailed,
+			"HumanLayerRequestFailed", trtc, fmt.Errorf("HumanLayer request failed with status code %d: %v", statusCode, err))
+		return result, errStatus
+	}
+
+	trtc.Status.Phase = kubechainv1alpha1.TaskRunToolCallPhaseAwaitingHumanInput
+	trtc.Status.StatusDetail = fmt.Sprintf("Waiting for human input via contact channel %s", contactChannel.Name)
+	trtc.Status.ExternalCallID = humanContact.GetCallId()
+	r.recorder.Event(trtc, corev1.EventTypeNormal, "AwaitingHumanInput",
+		fmt.Sprintf("Question sent via contact channel %s", contactChannel.Name))
+	if err := r.Status().Update(ctx, trtc); err != nil {
+		logger.Error(err, "Failed to update TaskRunToolCall status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: humanContactPollInterval}, nil
+}
+
+// checkHumanContact completes the tool call once the human replied
+func (r *TaskRunToolCallReconciler) checkHumanContact(ctx context.Context, trtc *kubechainv1alpha1.TaskRunToolCall,
+	contactChannel *kubechainv1alpha1.ContactChannel, apiKey string,
+) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	hlClient, err := r.callStatusClient(contactChannel, trtc.Name, trtc.Status.ExternalCallID, apiKey)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	humanContact, _, err := hlClient.GetHumanContactStatus(ctx)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	status := humanContact.GetStatus()
+	response, ok := status.GetResponseOk()
+	if !ok || response == nil {
+		return ctrl.Result{RequeueAfter: humanContactPollInterval}, nil
+	}
+
+	trtc.Status.Result = *response
+	trtc.Status.Phase = kubechainv1alpha1.TaskRunToolCallPhaseSucceeded
+	trtc.Status.Status = kubechainv1alpha1.TaskRunToolCallStatusTypeSucceeded
+	trtc.Status.StatusDetail = "Human responded"
+	r.recorder.Event(trtc, corev1.EventTypeNormal, "HumanResponded", "Human replied to the question")
+	if err := r.Status().Update(ctx, trtc); err != nil {
+		logger.Error(err, "Failed to update TaskRunToolCall status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
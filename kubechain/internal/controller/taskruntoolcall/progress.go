@@ -0,0 +1,220 @@
+package taskruntoolcall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+	"github.com/humanlayer/smallchain/kubechain/internal/mcpmanager"
+)
+
+// progressInterval is the least time between two status updates with the
+// progress of a tool call, servers may report far more often
+const progressInterval = 2 * time.Second
+
+// errCallCancelled is the cause of a tool call cancelled because its
+// TaskRunToolCall or TaskRun was deleted
+var errCallCancelled = errors.New("tool call cancelled")
+
+// runningCall is an MCP tool call in flight
+type runningCall struct {
+	taskRun string
+	cancel  context.CancelCauseFunc
+}
+
+// trackCall registers a running tool call so that deleting its
+// TaskRunToolCall or TaskRun cancels it. The returned func must be called
+// once the call returns.
+func (r *TaskRunToolCallReconciler) trackCall(ctx context.Context, trtc *kubechainv1alpha1.TaskRunToolCall) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	key := client.ObjectKeyFromObject(trtc)
+
+	r.callsMu.Lock()
+	if r.calls == nil {
+		r.calls = map[types.NamespacedName]runningCall{}
+	}
+	r.calls[key] = runningCall{taskRun: trtc.Spec.TaskRunRef.Name, cancel: cancel}
+	r.callsMu.Unlock()
+
+	return ctx, func() {
+		r.callsMu.Lock()
+		delete(r.calls, key)
+		r.callsMu.Unlock()
+		cancel(nil)
+	}
+}
+
+// cancelCalls cancels the running tool calls that match
+func (r *TaskRunToolCallReconciler) cancelCalls(match func(key types.NamespacedName, call runningCall) bool, reason string) {
+	r.callsMu.Lock()
+	defer r.callsMu.Unlock()
+	for key, call := range r.calls {
+		if match(key, call) {
+			log.Log.Info("Cancelling MCP tool call", "taskRunToolCall", key, "reason", reason)
+			call.cancel(fmt.Errorf("%w: %s", errCallCancelled, reason))
+		}
+	}
+}
+
+// cancelOnDelete cancels the running tool call of a deleted TaskRunToolCall
+func (r *TaskRunToolCallReconciler) cancelOnDelete() handler.EventHandler {
+	return handler.Funcs{
+		DeleteFunc: func(ctx context.Context, e event.DeleteEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			deleted := client.ObjectKeyFromObject(e.Object)
+			r.cancelCalls(func(key types.NamespacedName, call runningCall) bool {
+				return key == deleted
+			}, "TaskRunToolCall was deleted")
+		},
+	}
+}
+
+// cancelOnTaskRunDelete cancels the running tool calls of a deleted TaskRun,
+// without waiting for the garbage collector to delete its TaskRunToolCalls
+func (r *TaskRunToolCallReconciler) cancelOnTaskRunDelete() handler.EventHandler {
+	return handler.Funcs{
+		DeleteFunc: func(ctx context.Context, e event.DeleteEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			namespace, name := e.Object.GetNamespace(), e.Object.GetName()
+			r.cancelCalls(func(key types.NamespacedName, call runningCall) bool {
+				return key.Namespace == namespace && call.taskRun == name
+			}, "TaskRun was deleted")
+		},
+	}
+}
+
+// progressReporter writes the progress of a running tool call to the status
+// of its TaskRunToolCall, at most once per progressInterval. It also writes
+// the phases the call passes through while it waits on a human.
+type progressReporter struct {
+	r *TaskRunToolCallReconciler
+	// trtc is the TaskRunToolCall as last written by the reporter
+	trtc *kubechainv1alpha1.TaskRunToolCall
+
+	mu     sync.Mutex
+	latest *kubechainv1alpha1.ToolCallProgress
+	phase  kubechainv1alpha1.TaskRunToolCallPhase
+	detail string
+	// externalCallID is the HumanLayer call the running call waits on
+	externalCallID string
+
+	updated chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// reportProgress starts reporting the progress of a tool call. The returned
+// func stops the reporter and carries what was written over to trtc, it must
+// be called before trtc is written.
+func (r *TaskRunToolCallReconciler) reportProgress(ctx context.Context, trtc *kubechainv1alpha1.TaskRunToolCall) (context.Context, *progressReporter, func()) {
+	p := &progressReporter{
+		r:              r,
+		trtc:           trtc.DeepCopy(),
+		phase:          trtc.Status.Phase,
+		detail:         trtc.Status.StatusDetail,
+		externalCallID: trtc.Status.ExternalCallID,
+		updated:        make(chan struct{}, 1),
+		stop:           make(chan struct{}),
+		done:           make(chan struct{}),
+	}
+	go p.run(ctx)
+
+	return mcpmanager.WithProgress(ctx, p.update), p, func() {
+		close(p.stop)
+		<-p.done
+		// the status writes changed the resource version
+		trtc.ResourceVersion = p.trtc.ResourceVersion
+		p.mu.Lock()
+		trtc.Status.Progress = p.latest
+		p.mu.Unlock()
+	}
+}
+
+// setPhase writes a phase of the running call, e.g. while it waits for human
+// input. It returns the phase and detail it replaced.
+func (p *progressReporter) setPhase(phase kubechainv1alpha1.TaskRunToolCallPhase, detail string) (kubechainv1alpha1.TaskRunToolCallPhase, string) {
+	p.mu.Lock()
+	previousPhase, previousDetail := p.phase, p.detail
+	p.phase, p.detail = phase, detail
+	p.mu.Unlock()
+
+	p.notify()
+	return previousPhase, previousDetail
+}
+
+// setExternalCallID records the HumanLayer call the running call waits on, it
+// is written with the next phase
+func (p *progressReporter) setExternalCallID(callID string) {
+	p.mu.Lock()
+	p.externalCallID = callID
+	p.mu.Unlock()
+}
+
+// update records the progress a server reported
+func (p *progressReporter) update(progress mcpmanager.Progress) {
+	latest := &kubechainv1alpha1.ToolCallProgress{
+		Message:        progress.Message,
+		LastUpdateTime: metav1.Now(),
+	}
+	if progress.Total > 0 {
+		percentage := int32(math.Min(100, math.Max(0, 100*progress.Progress/progress.Total)))
+		latest.Percentage = &percentage
+	}
+
+	p.mu.Lock()
+	p.latest = latest
+	p.mu.Unlock()
+
+	p.notify()
+}
+
+// notify wakes the writer up
+func (p *progressReporter) notify() {
+	select {
+	case p.updated <- struct{}{}:
+	default:
+	}
+}
+
+// run writes the latest progress whenever there is some, until stopped
+func (p *progressReporter) run(ctx context.Context) {
+	defer close(p.done)
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-p.updated:
+		}
+
+		base := p.trtc.DeepCopy()
+		p.mu.Lock()
+		p.trtc.Status.Progress = p.latest.DeepCopy()
+		p.trtc.Status.Phase = p.phase
+		p.trtc.Status.StatusDetail = p.detail
+		p.trtc.Status.ExternalCallID = p.externalCallID
+		p.mu.Unlock()
+
+		// a merge patch has no resource version, it can't conflict with other writers
+		if err := p.r.Status().Patch(ctx, p.trtc, client.MergeFrom(base)); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to update the status of the tool call")
+			p.trtc = base
+		}
+
+		select {
+		case <-p.stop:
+			return
+		case <-time.After(progressInterval):
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package taskruntoolcall
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+	"github.com/humanlayer/smallchain/kubechain/internal/mcpmanager"
+	"github.com/humanlayer/smallchain/kubechain/internal/pricing"
+)
+
+const (
+	// samplingApprovalFunction is the function a sampling request is approved as
+	samplingApprovalFunction = "mcp_sampling"
+
+	// samplingApprovalPollInterval is how often a pending approval is checked
+	samplingApprovalPollInterval = 5 * time.Second
+
+	// samplingApprovalTimeout is how long a sampling request waits for its
+	// approval, the tool call waits as long
+	samplingApprovalTimeout = 10 * time.Minute
+)
+
+// sampler answers the sampling requests an MCP server makes while it runs a
+// tool call, with the LLM of the Agent that made the call
+type sampler struct {
+	r          *TaskRunToolCallReconciler
+	trtc       *kubechainv1alpha1.TaskRunToolCall
+	serverName string
+	// callCtx is the context of the tool call, sampling stops with the call
+	callCtx context.Context
+}
+
+// recoverHandler turns a panic of a handler mcp-go runs on its own goroutine
+// into an error for the server; unrecovered, it would take down the manager
+func recoverHandler(err *error, keysAndValues ...interface{}) {
+	if p := recover(); p != nil {
+		keysAndValues = append(keysAndValues, "stack", string(debug.Stack()))
+		log.Log.Error(fmt.Errorf("%v", p), "Recovered from a panic handling an MCP server request", keysAndValues...)
+		*err = fmt.Errorf("internal error handling the request: %v", p)
+	}
+}
+
+// CreateMessage implements mcpmanager.Sampler
+func (s *sampler) CreateMessage(ctx context.Context, request mcp.CreateMessageRequest) (result *mcp.CreateMessageResult, err error) {
+	defer recoverHandler(&err, "taskRunToolCall", client.ObjectKeyFromObject(s.trtc), "mcpServer", s.serverName)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer context.AfterFunc(s.callCtx, cancel)()
+	logger := log.Log.WithValues("taskRunToolCall", client.ObjectKeyFromObject(s.trtc), "mcpServer", s.serverName)
+
+	var mcpServer kubechainv1alpha1.MCPServer
+	if err := s.r.Get(ctx, client.ObjectKey{Namespace: s.trtc.Namespace, Name: s.serverName}, &mcpServer); err != nil {
+		return nil, fmt.Errorf("failed to get MCPServer %q: %w", s.serverName, err)
+	}
+	sampling := mcpServer.Spec.Sampling
+	if sampling == nil {
+		return nil, fmt.Errorf("sampling is not enabled for MCP server %s", s.serverName)
+	}
+
+	messages, err := mcpmanager.SamplingMessages(request)
+	if err != nil {
+		return nil, err
+	}
+
+	// the server's request is kept below the cap
+	maxTokens := request.MaxTokens
+	if sampling.MaxTokens != nil && (maxTokens <= 0 || maxTokens > *sampling.MaxTokens) {
+		maxTokens = *sampling.MaxTokens
+	}
+
+	if sampling.ApprovalContactChannel != nil {
+		if err := s.r.approveSampling(ctx, s.trtc, &mcpServer, messages, maxTokens); err != nil {
+			s.r.recorder.Event(s.trtc, corev1.EventTypeWarning, "SamplingNotApproved", err.Error())
+			return nil, err
+		}
+	}
+
+	agent, err := s.r.getAgent(ctx, s.trtc)
+	if err != nil {
+		return nil, err
+	}
+	llm, apiKey, err := s.r.getLLM(ctx, s.trtc.Namespace, agent.Spec.LLMRef.Name)
+	if err != nil {
+		return nil, err
+	}
+	if maxTokens > 0 {
+		llm.Spec.Parameters.MaxTokens = &maxTokens
+	}
+	if request.Temperature > 0 {
+		llm.Spec.Parameters.Temperature = strconv.FormatFloat(request.Temperature, 'f', -1, 64)
+	}
+
+	llmClient, err := s.r.newLLMClient(ctx, llm, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create LLM client: %w", err)
+	}
+	output, err := llmClient.SendRequest(ctx, messages, nil)
+	if err != nil {
+		return nil, fmt.Errorf("sampling request failed: %w", err)
+	}
+
+	s.r.recordSamplingUsage(ctx, s.trtc, &llm, output)
+	logger.Info("Answered MCP sampling request", "llm", llm.Name, "maxTokens", maxTokens)
+	s.r.recorder.Event(s.trtc, corev1.EventTypeNormal, "SamplingCompleted",
+		fmt.Sprintf("MCP server %s sampled LLM %s", s.serverName, llm.Name))
+
+	return &mcp.CreateMessageResult{
+		SamplingMessage: mcp.SamplingMessage{
+			Role:    mcp.RoleAssistant,
+			Content: mcp.NewTextContent(output.Content),
+		},
+		Model:      llm.Spec.Parameters.Model,
+		StopReason: "endTurn",
+	}, nil
+}
+
+// getLLM fetches an LLM and its API key
+func (r *TaskRunToolCallReconciler) getLLM(ctx context.Context, namespace, name string) (kubechainv1alpha1.LLM, string, error) {
+	var llm kubechainv1alpha1.LLM
+	if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &llm); err != nil {
+		return llm, "", fmt.Errorf("failed to get LLM %q: %w", name, err)
+	}
+	apiKey, err := r.getHumanLayerAPIKey(ctx, llm.Spec.APIKeyFrom.SecretKeyRef.Name, llm.Spec.APIKeyFrom.SecretKeyRef.Key, namespace)
+	if err != nil {
+		return llm, "", err
+	}
+	if apiKey == "" {
+		return llm, "", fmt.Errorf("API key is empty in secret %s", llm.Spec.APIKeyFrom.SecretKeyRef.Name)
+	}
+	return llm, apiKey, nil
+}
+
+// approveSampling asks the sampling contact channel of an MCP server to
+// approve a sampling request and waits for the answer
+func (r *TaskRunToolCallReconciler) approveSampling(ctx context.Context, trtc *kubechainv1alpha1.TaskRunToolCall, mcpServer *kubechainv1alpha1.MCPServer, messages []kubechainv1alpha1.Message, maxTokens int) error {
+	if r.HLClientFactory == nil {
+		return fmt.Errorf("HLClient not initialized")
+	}
+	contactChannel, err := r.getContactChannel(ctx, mcpServer.Spec.Sampling.ApprovalContactChannel.Name, trtc.Namespace)
+	if err != nil {
+		return err
+	}
+	apiKey, err := r.contactChannelAPIKey(ctx, contactChannel)
+	if err != nil {
+		return err
+	}
+
+	prompt := make([]interface{}, 0, len(messages))
+	for _, message := range messages {
+		prompt = append(prompt, map[string]interface{}{"role": message.Role, "content": message.Content})
+	}
+	args := map[string]interface{}{
+		"mcp_server": mcpServer.Name,
+		"tool_call":  trtc.Spec.ToolRef.Name,
+		"messages":   prompt,
+		"max_tokens": maxTokens,
+	}
+	functionCall, statusCode, err := r.requestApproval(ctx, contactChannel, apiKey, trtc.Name, samplingApprovalFunction, args)
+	if err != nil {
+		return fmt.Errorf("HumanLayer request failed with status code %d: %v", statusCode, err)
+	}
+	r.recorder.Event(trtc, corev1.EventTypeNormal, "AwaitingSamplingApproval",
+		fmt.Sprintf("Sampling request of MCP server %s requires approval via contact channel %s", mcpServer.Name, contactChannel.Name))
+
+	ctx, cancel := context.WithTimeout(ctx, samplingApprovalTimeout)
+	defer cancel()
+	ticker := time.NewTicker(samplingApprovalPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("sampling request was not approved: %w", ctx.Err())
+		case <-ticker.C:
+		}
+
+		hlClient, err := r.callStatusClient(contactChannel, trtc.Name, functionCall.GetCallId(), apiKey)
+		if err != nil {
+			return err
+		}
+		output, _, err := hlClient.GetFunctionCallStatus(ctx)
+		if err != nil {
+			// a failed check is retried on the next tick
+			log.FromContext(ctx).Error(err, "Failed to check sampling approval")
+			continue
+		}
+		status := output.GetStatus()
+		approved, ok := status.GetApprovedOk()
+		if !ok || approved == nil {
+			continue
+		}
+		if !*approved {
+			return fmt.Errorf("sampling request rejected: %s", status.GetComment())
+		}
+		return nil
+	}
+}
+
+// recordSamplingUsage adds the tokens of a sampling completion to the
+// TaskRun of the tool call. Like the TaskRun's own turns, accounting problems
+// are logged but never fail the request.
+func (r *TaskRunToolCallReconciler) recordSamplingUsage(ctx context.Context, trtc *kubechainv1alpha1.TaskRunToolCall, llm *kubechainv1alpha1.LLM, output *kubechainv1alpha1.Message) {
+	logger := log.FromContext(ctx)
+
+	if output.Usage == nil {
+		logger.V(1).Info("Sampling response carried no token usage", "provider", llm.Spec.Provider)
+		return
+	}
+
+	price, err := pricing.Lookup(ctx, r.Client, trtc.Namespace, llm.Spec.Provider, llm.Spec.Parameters.Model)
+	if err != nil {
+		logger.Error(err, "Failed to look up model price", "provider", llm.Spec.Provider, "model", llm.Spec.Parameters.Model)
+		price = nil
+	}
+	usage := *output.Usage
+	if price != nil {
+		usage.EstimatedCost = pricing.FormatCost(price.Estimate(usage.InputTokens, usage.OutputTokens))
+	}
+
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var taskRun kubechainv1alpha1.TaskRun
+		if err := r.Get(ctx, client.ObjectKey{Namespace: trtc.Namespace, Name: trtc.Spec.TaskRunRef.Name}, &taskRun); err != nil {
+			return err
+		}
+		taskRun.Status.Usage = pricing.AddUsage(taskRun.Status.Usage, usage, price)
+		return r.Status().Update(ctx, &taskRun)
+	}); err != nil {
+		logger.Error(err, "Failed to record sampling usage", "taskRun", trtc.Spec.TaskRunRef.Name)
+	}
+}
@@ -3,124 +3,77 @@ package taskruntoolcall
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/google/uuid"
 	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+	"github.com/humanlayer/smallchain/kubechain/internal/approval"
 	externalapi "github.com/humanlayer/smallchain/kubechain/internal/externalAPI"
 	"github.com/humanlayer/smallchain/kubechain/internal/humanlayer"
 	"github.com/humanlayer/smallchain/kubechain/internal/humanlayerapi"
+	"github.com/humanlayer/smallchain/kubechain/internal/llmclient"
 	"github.com/humanlayer/smallchain/kubechain/internal/mcpmanager"
 )
 
 const (
 	DetailToolExecutedSuccess = "Tool executed successfully"
 	DetailInvalidArgsJSON     = "Invalid arguments JSON"
+
+	// DefaultMaxConcurrentReconciles is how many TaskRunToolCalls are
+	// reconciled at once unless MaxConcurrentReconciles says otherwise
+	DefaultMaxConcurrentReconciles = 16
 )
 
 // +kubebuilder:rbac:groups=kubechain.humanlayer.dev,resources=taskruntoolcalls,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=kubechain.humanlayer.dev,resources=taskruntoolcalls/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=kubechain.humanlayer.dev,resources=tools,verbs=get;list;watch
+// +kubebuilder:rbac:groups=kubechain.humanlayer.dev,resources=taskruns;tasks;agents,verbs=get;list;watch
+// +kubebuilder:rbac:groups=kubechain.humanlayer.dev,resources=taskruns/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=kubechain.humanlayer.dev,resources=llms;pricingcatalogs,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups=kubechain.humanlayer.dev,resources=approvalrequests,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups=kubechain.humanlayer.dev,resources=approvalrequests/status,verbs=get;update;patch
 
 // TaskRunToolCallReconciler reconciles a TaskRunToolCall object.
 type TaskRunToolCallReconciler struct {
 	client.Client
 	Scheme          *runtime.Scheme
 	recorder        record.EventRecorder
-	server          *http.Server
 	MCPManager      mcpmanager.MCPManagerInterface
 	HLClientFactory humanlayer.HumanLayerClientFactory
-}
-
-func (r *TaskRunToolCallReconciler) webhookHandler(w http.ResponseWriter, req *http.Request) {
-	logger := log.FromContext(context.Background())
-	var webhook humanlayer.FunctionCall
-	if err := json.NewDecoder(req.Body).Decode(&webhook); err != nil {
-		logger.Error(err, "Failed to decode webhook payload")
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
-		return
-	}
-
-	logger.Info("Received webhook", "webhook", webhook)
+	newLLMClient    func(ctx context.Context, llm kubechainv1alpha1.LLM, apiKey string) (llmclient.LLMClient, error)
 
-	if webhook.Status != nil && webhook.Status.Approved != nil {
-		if *webhook.Status.Approved {
-			logger.Info("Email approved", "comment", webhook.Status.Comment)
-		} else {
-			logger.Info("Email request denied")
-		}
+	// MaxConcurrentReconciles is how many TaskRunToolCalls are reconciled at
+	// once, DefaultMaxConcurrentReconciles if zero. An MCP tool call holds a
+	// worker until it returns, also while the server waits for a human to
+	// approve a sampling request or to provide input.
+	MaxConcurrentReconciles int
 
-		// Update TaskRunToolCall status
-		if err := r.updateTaskRunToolCall(context.Background(), webhook); err != nil {
-			logger.Error(err, "Failed to update TaskRunToolCall status")
-			http.Error(w, "Failed to update status", http.StatusInternalServerError)
-			return
-		}
-	}
+	// webhookEvents reconciles TaskRunToolCalls a HumanLayer webhook was for
+	webhookEvents chan event.GenericEvent
 
-	w.WriteHeader(http.StatusOK)
-	if _, err := w.Write([]byte(`{"status": "ok"}`)); err != nil {
-		http.Error(w, "Failed to write response", http.StatusInternalServerError)
-		return
-	}
-}
-
-func (r *TaskRunToolCallReconciler) updateTaskRunToolCall(ctx context.Context, webhook humanlayer.FunctionCall) error {
-	logger := log.FromContext(ctx)
-	var trtc kubechainv1alpha1.TaskRunToolCall
-
-	if err := r.Get(ctx, client.ObjectKey{Namespace: "default", Name: webhook.RunID}, &trtc); err != nil {
-		return fmt.Errorf("failed to get TaskRunToolCall: %w", err)
-	}
-
-	logger.Info("Webhook received",
-		"runID", webhook.RunID,
-		"status", webhook.Status,
-		"approved", *webhook.Status.Approved,
-		"comment", webhook.Status.Comment)
-
-	if webhook.Status != nil && webhook.Status.Approved != nil {
-		// Update the TaskRunToolCall status with the webhook data
-		if *webhook.Status.Approved {
-			trtc.Status.Result = "Approved"
-			trtc.Status.Phase = kubechainv1alpha1.TaskRunToolCallPhaseSucceeded
-			trtc.Status.Status = kubechainv1alpha1.TaskRunToolCallStatusTypeSucceeded
-			trtc.Status.StatusDetail = DetailToolExecutedSuccess
-		} else {
-			trtc.Status.Result = "Rejected"
-			trtc.Status.Phase = kubechainv1alpha1.TaskRunToolCallPhaseToolCallRejected
-			trtc.Status.Status = kubechainv1alpha1.TaskRunToolCallStatusTypeSucceeded
-			trtc.Status.StatusDetail = "Tool execution rejected"
-		}
-
-		// if webhook.Status.RespondedAt != nil {
-		// 		trtc.Status.RespondedAt = &metav1.Time{Time: *webhook.Status.RespondedAt}
-		// }
-
-		// if webhook.Status.Approved != nil {
-		// 		trtc.Status.Approved = webhook.Status.Approved
-		// }
-
-		if err := r.Status().Update(ctx, &trtc); err != nil {
-			return fmt.Errorf("failed to update TaskRunToolCall status: %w", err)
-		}
-		logger.Info("TaskRunToolCall status updated", "name", trtc.Name, "phase", trtc.Status.Phase)
-	}
-
-	return nil
+	// calls are the MCP tool calls in flight, by TaskRunToolCall
+	calls   map[types.NamespacedName]runningCall
+	callsMu sync.Mutex
 }
 
 // Helper function to convert various value types to float64
@@ -147,6 +100,19 @@ func isMCPTool(toolName string) (serverName string, actualToolName string, isMCP
 	return "", toolName, false
 }
 
+// mcpTool resolves the MCP server and tool that a tool call refers to. The
+// name the LLM knows may be shortened, see mcpmanager.ToolName, so it is
+// looked up among the servers of the tool call's namespace first.
+func (r *TaskRunToolCallReconciler) mcpTool(trtc *kubechainv1alpha1.TaskRunToolCall) (serverName string, toolName string, isMCP bool) {
+	if r.MCPManager != nil {
+		if serverName, toolName, found := r.MCPManager.FindServerForTool(trtc.Namespace, trtc.Spec.ToolRef.Name); found {
+			return serverName, toolName, true
+		}
+	}
+	// a name that wasn't shortened still tells, e.g. while its server reconnects
+	return isMCPTool(trtc.Spec.ToolRef.Name)
+}
+
 // executeMCPTool executes a tool call on an MCP server
 func (r *TaskRunToolCallReconciler) executeMCPTool(ctx context.Context, trtc *kubechainv1alpha1.TaskRunToolCall, serverName, toolName string, args map[string]interface{}) error {
 	logger := log.FromContext(ctx)
@@ -155,8 +121,19 @@ func (r *TaskRunToolCallReconciler) executeMCPTool(ctx context.Context, trtc *ku
 		return fmt.Errorf("MCPManager is not initialized")
 	}
 
-	// Call the MCP tool
-	result, err := r.MCPManager.CallTool(ctx, serverName, toolName, args)
+	// Call the MCP tool, following its progress until it returns
+	callCtx, reporter, stopProgress := r.reportProgress(ctx, trtc)
+	callCtx, untrack := r.trackCall(callCtx, trtc)
+	// servers that may sample do so with the LLM of the calling Agent
+	callCtx = mcpmanager.WithSampler(callCtx, &sampler{r: r, trtc: trtc.DeepCopy(), serverName: serverName, callCtx: callCtx})
+	// servers that may elicit input get it from a human
+	callCtx = mcpmanager.WithElicitor(callCtx, &elicitor{r: r, trtc: trtc.DeepCopy(), serverName: serverName, callCtx: callCtx, reporter: reporter})
+	result, err := r.MCPManager.CallToolResult(callCtx, trtc.Namespace, serverName, toolName, args)
+	untrack()
+	stopProgress()
+	if cause := context.Cause(callCtx); errors.Is(cause, errCallCancelled) {
+		return cause
+	}
 	if err != nil {
 		logger.Error(err, "Failed to call MCP tool",
 			"serverName", serverName,
@@ -164,8 +141,16 @@ func (r *TaskRunToolCallReconciler) executeMCPTool(ctx context.Context, trtc *ku
 		return err
 	}
 
+	// Non-text contents are kept in a ConfigMap for the LLM to look at
+	attachments, err := r.storeAttachments(ctx, trtc, result)
+	if err != nil {
+		logger.Error(err, "Failed to store MCP tool attachments")
+		return err
+	}
+
 	// Update TaskRunToolCall status with the MCP tool result
-	trtc.Status.Result = result
+	trtc.Status.Result = result.Text
+	trtc.Status.Attachments = attachments
 	trtc.Status.Phase = kubechainv1alpha1.TaskRunToolCallPhaseSucceeded
 	trtc.Status.Status = kubechainv1alpha1.TaskRunToolCallStatusTypeSucceeded
 	trtc.Status.StatusDetail = "MCP tool executed successfully"
@@ -248,10 +233,21 @@ func (r *TaskRunToolCallReconciler) parseArguments(ctx context.Context, trtc *ku
 func (r *TaskRunToolCallReconciler) processMCPTool(ctx context.Context, trtc *kubechainv1alpha1.TaskRunToolCall, serverName, mcpToolName string, args map[string]interface{}) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
 
+	// the call waited for human input when it was cut off, it isn't made again
+	if trtc.Status.Phase == kubechainv1alpha1.TaskRunToolCallPhaseAwaitingHumanInput && trtc.Status.ExternalCallID != "" {
+		return r.resumeElicitation(ctx, trtc, serverName)
+	}
+
 	logger.Info("Executing MCP tool", "serverName", serverName, "toolName", mcpToolName)
 
 	// Execute the MCP tool
-	if err := r.executeMCPTool(ctx, trtc, serverName, mcpToolName, args); err != nil {
+	err := r.executeMCPTool(ctx, trtc, serverName, mcpToolName, args)
+	if errors.Is(err, errCallCancelled) {
+		// the TaskRunToolCall is gone or about to be, there is no status to write
+		logger.Info("MCP tool call cancelled", "reason", err.Error())
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
 		trtc.Status.Status = kubechainv1alpha1.TaskRunToolCallStatusTypeError
 		trtc.Status.StatusDetail = fmt.Sprintf("MCP tool execution failed: %v", err)
 		trtc.Status.Error = err.Error()
@@ -303,6 +299,8 @@ func (r *TaskRunToolCallReconciler) getTraditionalTool(ctx context.Context, trtc
 		toolType = "delegateToAgent"
 	} else if tool.Spec.Execute.ExternalAPI != nil {
 		toolType = "externalAPI"
+	} else if tool.Spec.Execute.HumanContact != nil {
+		toolType = "humanContact"
 	} else if tool.Spec.ToolType != "" {
 		toolType = tool.Spec.ToolType
 	} else {
@@ -629,7 +627,7 @@ func (r *TaskRunToolCallReconciler) getMCPServer(ctx context.Context, trtc *kube
 	logger := log.FromContext(ctx)
 
 	// Check if this is an MCP tool
-	serverName, _, isMCP := isMCPTool(trtc.Spec.ToolRef.Name)
+	serverName, _, isMCP := r.mcpTool(trtc)
 	if !isMCP {
 		return nil, false, nil
 	}
@@ -647,12 +645,65 @@ func (r *TaskRunToolCallReconciler) getMCPServer(ctx context.Context, trtc *kube
 	return &mcpServer, mcpServer.Spec.ApprovalContactChannel != nil, nil
 }
 
+// getAgent gets the Agent of the TaskRun a tool call belongs to: the TaskRun's
+// agent override, or else its Task's agent
+func (r *TaskRunToolCallReconciler) getAgent(ctx context.Context, trtc *kubechainv1alpha1.TaskRunToolCall) (*kubechainv1alpha1.Agent, error) {
+	var taskRun kubechainv1alpha1.TaskRun
+	if err := r.Get(ctx, client.ObjectKey{Namespace: trtc.Namespace, Name: trtc.Spec.TaskRunRef.Name}, &taskRun); err != nil {
+		return nil, fmt.Errorf("failed to get TaskRun %q: %w", trtc.Spec.TaskRunRef.Name, err)
+	}
+
+	var agentRef kubechainv1alpha1.LocalObjectReference
+	if taskRun.Spec.AgentRef != nil {
+		agentRef = *taskRun.Spec.AgentRef
+	} else if taskRun.Spec.TaskRef != nil {
+		var task kubechainv1alpha1.Task
+		if err := r.Get(ctx, client.ObjectKey{Namespace: trtc.Namespace, Name: taskRun.Spec.TaskRef.Name}, &task); err != nil {
+			return nil, fmt.Errorf("failed to get Task %q: %w", taskRun.Spec.TaskRef.Name, err)
+		}
+		agentRef = task.Spec.AgentRef
+	} else {
+		return nil, fmt.Errorf("TaskRun %q has no agent", taskRun.Name)
+	}
+
+	var agent kubechainv1alpha1.Agent
+	if err := r.Get(ctx, client.ObjectKey{Namespace: trtc.Namespace, Name: agentRef.Name}, &agent); err != nil {
+		return nil, fmt.Errorf("failed to get Agent %q: %w", agentRef.Name, err)
+	}
+	return &agent, nil
+}
+
+// checkMCPToolAllowed fails MCP tool calls the agent's tool filters exclude,
+// the LLM was never offered them
+func (r *TaskRunToolCallReconciler) checkMCPToolAllowed(ctx context.Context, trtc *kubechainv1alpha1.TaskRunToolCall) (result ctrl.Result, err error, handled bool) {
+	serverName, toolName, isMCP := r.mcpTool(trtc)
+	if !isMCP {
+		return ctrl.Result{}, nil, false
+	}
+
+	agent, err := r.getAgent(ctx, trtc)
+	if err != nil {
+		return ctrl.Result{}, err, true
+	}
+
+	serverRef, referenced := mcpmanager.AgentServerRef(agent, serverName)
+	if !referenced {
+		return r.setStatusError(ctx, kubechainv1alpha1.TaskRunToolCallPhaseFailed, "ToolNotAllowed", trtc,
+			fmt.Errorf("agent %q does not use MCP server %q", agent.Name, serverName))
+	}
+	if !mcpmanager.ToolAllowed(serverRef, toolName) {
+		return r.setStatusError(ctx, kubechainv1alpha1.TaskRunToolCallPhaseFailed, "ToolNotAllowed", trtc,
+			fmt.Errorf("agent %q may not use tool %q of MCP server %q", agent.Name, toolName, serverName))
+	}
+	return ctrl.Result{}, nil, false
+}
+
 // getContactChannel fetches and validates the ContactChannel resource
-func (r *TaskRunToolCallReconciler) getContactChannel(ctx context.Context, mcpServer *kubechainv1alpha1.MCPServer, trtcNamespace string) (*kubechainv1alpha1.ContactChannel, error) {
+func (r *TaskRunToolCallReconciler) getContactChannel(ctx context.Context, name string, trtcNamespace string) (*kubechainv1alpha1.ContactChannel, error) {
 	var contactChannel kubechainv1alpha1.ContactChannel
 	if err := r.Get(ctx, client.ObjectKey{
 		Namespace: trtcNamespace,
-		Name:      mcpServer.Spec.ApprovalContactChannel.Name,
+		Name:      name,
 	}, &contactChannel); err != nil {
 
 		err := fmt.Errorf("failed to get ContactChannel: %v", err)
@@ -683,6 +734,29 @@ func (r *TaskRunToolCallReconciler) getHumanLayerAPIKey(ctx context.Context, sec
 	return apiKey, nil
 }
 
+// contactChannelAPIKey returns the HumanLayer API key of a contact channel.
+// inCluster channels don't talk to HumanLayer and have none.
+func (r *TaskRunToolCallReconciler) contactChannelAPIKey(ctx context.Context, contactChannel *kubechainv1alpha1.ContactChannel) (string, error) {
+	if contactChannel.Spec.Type == kubechainv1alpha1.ContactChannelTypeInCluster {
+		return "", nil
+	}
+	if contactChannel.Spec.APIKeyFrom == nil {
+		return "", fmt.Errorf("ContactChannel %s has no apiKeyFrom", contactChannel.Name)
+	}
+
+	apiKey, err := r.getHumanLayerAPIKey(ctx,
+		contactChannel.Spec.APIKeyFrom.SecretKeyRef.Name,
+		contactChannel.Spec.APIKeyFrom.SecretKeyRef.Key,
+		contactChannel.Namespace)
+	if err != nil {
+		return "", err
+	}
+	if apiKey == "" {
+		return "", fmt.Errorf("API key is empty in secret %s", contactChannel.Spec.APIKeyFrom.SecretKeyRef.Name)
+	}
+	return apiKey, nil
+}
+
 //nolint:unparam
 func (r *TaskRunToolCallReconciler) setStatusError(ctx context.Context, trtcPhase kubechainv1alpha1.TaskRunToolCallPhase, eventType string, trtc *kubechainv1alpha1.TaskRunToolCall, err error) (ctrl.Result, error, bool) {
 	trtcDeepCopy := trtc.DeepCopy()
@@ -732,42 +806,92 @@ func (r *TaskRunToolCallReconciler) updateTRTCStatus(ctx context.Context, trtc *
 }
 
 func (r *TaskRunToolCallReconciler) postToHumanLayer(ctx context.Context, trtc *kubechainv1alpha1.TaskRunToolCall, contactChannel *kubechainv1alpha1.ContactChannel, apiKey string) (*humanlayerapi.FunctionCallOutput, int, error) {
-	client := r.HLClientFactory.NewHumanLayerClient()
+	toolName := trtc.Spec.ToolRef.Name
+	var args map[string]interface{}
+	if err := json.Unmarshal([]byte(trtc.Spec.Arguments), &args); err != nil {
+		// Set default error map if JSON parsing fails
+		args = map[string]interface{}{
+			"error": "Error reading JSON",
+		}
+	}
+
+	functionCall, statusCode, err := r.requestApproval(ctx, contactChannel, apiKey, trtc.Name, toolName, args)
+
+	if err == nil {
+		r.recorder.Event(trtc, corev1.EventTypeNormal, "HumanLayerRequestSent", "HumanLayer request sent")
+	}
+
+	return functionCall, statusCode, err
+}
 
+// setContactChannel points a HumanLayer client at a contact channel
+func setContactChannel(client humanlayer.HumanLayerClientWrapper, contactChannel *kubechainv1alpha1.ContactChannel) error {
 	switch contactChannel.Spec.Type {
 	case kubechainv1alpha1.ContactChannelTypeSlack:
 		client.SetSlackConfig(contactChannel.Spec.Slack)
 	case kubechainv1alpha1.ContactChannelTypeEmail:
 		client.SetEmailConfig(contactChannel.Spec.Email)
+	case kubechainv1alpha1.ContactChannelTypeSMS:
+		client.SetSMSConfig(contactChannel.Spec.SMS)
+	case kubechainv1alpha1.ContactChannelTypeWhatsApp:
+		client.SetWhatsAppConfig(contactChannel.Spec.WhatsApp)
+	case kubechainv1alpha1.ContactChannelTypeInCluster:
+		client.SetInClusterChannel(contactChannel.Namespace, contactChannel.Name)
 	default:
-		return nil, 0, fmt.Errorf("unsupported channel type: %s", contactChannel.Spec.Type)
+		return fmt.Errorf("unsupported channel type: %s", contactChannel.Spec.Type)
 	}
+	return nil
+}
 
-	toolName := trtc.Spec.ToolRef.Name
-	var args map[string]interface{}
-	if err := json.Unmarshal([]byte(trtc.Spec.Arguments), &args); err != nil {
-		// Set default error map if JSON parsing fails
-		args = map[string]interface{}{
-			"error": "Error reading JSON",
-		}
+// callStatusClient returns a client that checks on a call a contact channel
+// was asked, inCluster channels look the call up in their own namespace
+func (r *TaskRunToolCallReconciler) callStatusClient(contactChannel *kubechainv1alpha1.ContactChannel, runID, callID, apiKey string) (humanlayer.HumanLayerClientWrapper, error) {
+	client := r.HLClientFactory.NewHumanLayerClient()
+	if err := setContactChannel(client, contactChannel); err != nil {
+		return nil, err
+	}
+	client.SetRunID(runID)
+	client.SetCallID(callID)
+	client.SetAPIKey(apiKey)
+	return client, nil
+}
+
+// requestApproval asks a contact channel to approve a function call
+func (r *TaskRunToolCallReconciler) requestApproval(ctx context.Context, contactChannel *kubechainv1alpha1.ContactChannel, apiKey, runID, functionName string, args map[string]interface{}) (*humanlayerapi.FunctionCallOutput, int, error) {
+	client := r.HLClientFactory.NewHumanLayerClient()
+
+	if err := setContactChannel(client, contactChannel); err != nil {
+		return nil, 0, err
 	}
-	client.SetFunctionCallSpec(toolName, args)
 
+	client.SetFunctionCallSpec(functionName, args)
 	client.SetCallID("ec-" + uuid.New().String()[:7])
-	client.SetRunID(trtc.Name)
+	client.SetRunID(runID)
 	client.SetAPIKey(apiKey)
 
-	functionCall, statusCode, err := client.RequestApproval(ctx)
+	return client.RequestApproval(ctx)
+}
 
-	if err == nil {
-		r.recorder.Event(trtc, corev1.EventTypeNormal, "HumanLayerRequestSent", "HumanLayer request sent")
+// requestHumanContact sends a message to a contact channel for a human to reply to
+func (r *TaskRunToolCallReconciler) requestHumanContact(ctx context.Context, contactChannel *kubechainv1alpha1.ContactChannel, apiKey, runID, msg string) (*humanlayerapi.HumanContactOutput, int, error) {
+	client := r.HLClientFactory.NewHumanLayerClient()
+
+	if err := setContactChannel(client, contactChannel); err != nil {
+		return nil, 0, err
 	}
 
-	return functionCall, statusCode, err
+	client.SetHumanContactSpec(msg)
+	client.SetCallID("hc-" + uuid.New().String()[:7])
+	client.SetRunID(runID)
+	client.SetAPIKey(apiKey)
+
+	return client.RequestHumanContact(ctx)
 }
 
 // handlePendingApproval checks if an existing human approval is completed and updates status accordingly
-func (r *TaskRunToolCallReconciler) handlePendingApproval(ctx context.Context, trtc *kubechainv1alpha1.TaskRunToolCall, apiKey string) (ctrl.Result, error, bool) {
+func (r *TaskRunToolCallReconciler) handlePendingApproval(ctx context.Context, trtc *kubechainv1alpha1.TaskRunToolCall,
+	contactChannel *kubechainv1alpha1.ContactChannel, apiKey string, timeouts *kubechainv1alpha1.ApprovalTimeouts,
+) (ctrl.Result, error, bool) {
 	logger := log.FromContext(ctx)
 
 	// Only process if in the awaiting human approval phase
@@ -781,38 +905,55 @@ func (r *TaskRunToolCallReconciler) handlePendingApproval(ctx context.Context, t
 		return ctrl.Result{}, nil, false
 	}
 
-	client := r.HLClientFactory.NewHumanLayerClient()
-	client.SetCallID(trtc.Status.ExternalCallID)
-	client.SetAPIKey(apiKey)
-	functionCall, _, err := client.GetFunctionCallStatus(ctx)
-	if err != nil {
-		return ctrl.Result{}, err, true
-	}
-
-	status := functionCall.GetStatus()
+	// an answer to any of the calls that asked for approval counts
+	for _, call := range approvalCalls(trtc, contactChannel) {
+		callChannel, callAPIKey := contactChannel, apiKey
+		if call.ContactChannel != contactChannel.Name {
+			var err error
+			if callChannel, err = r.getContactChannel(ctx, call.ContactChannel, trtc.Namespace); err != nil {
+				return ctrl.Result{}, err, true
+			}
+			if callAPIKey, err = r.contactChannelAPIKey(ctx, callChannel); err != nil {
+				return ctrl.Result{}, err, true
+			}
+		}
 
-	approved, ok := status.GetApprovedOk()
+		client, err := r.callStatusClient(callChannel, trtc.Name, call.CallID, callAPIKey)
+		if err != nil {
+			return ctrl.Result{}, err, true
+		}
+		functionCall, _, err := client.GetFunctionCallStatus(ctx)
+		if err != nil {
+			return ctrl.Result{}, err, true
+		}
 
-	if !ok || approved == nil {
-		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil, true
-	}
+		status := functionCall.GetStatus()
+		approved, ok := status.GetApprovedOk()
+		if !ok || approved == nil {
+			continue
+		}
 
-	if *approved {
-		return r.updateTRTCStatus(ctx, trtc,
-			kubechainv1alpha1.TaskRunToolCallStatusTypeReady,
-			kubechainv1alpha1.TaskRunToolCallPhaseReadyToExecuteApprovedTool,
-			"Ready to execute approved tool", "")
-	} else {
+		if trtc.Status.Approval != nil {
+			trtc.Status.Approval.Stage = kubechainv1alpha1.ApprovalStageAnswered
+		}
+		if *approved {
+			return r.updateTRTCStatus(ctx, trtc,
+				kubechainv1alpha1.TaskRunToolCallStatusTypeReady,
+				kubechainv1alpha1.TaskRunToolCallPhaseReadyToExecuteApprovedTool,
+				"Ready to execute approved tool", "")
+		}
 		return r.updateTRTCStatus(ctx, trtc,
 			kubechainv1alpha1.TaskRunToolCallStatusTypeSucceeded,
 			kubechainv1alpha1.TaskRunToolCallPhaseToolCallRejected,
 			"Tool execution rejected", status.GetComment())
 	}
+
+	return r.followUpApproval(ctx, trtc, contactChannel, apiKey, timeouts)
 }
 
 // requestHumanApproval handles setting up a new human approval request
 func (r *TaskRunToolCallReconciler) requestHumanApproval(ctx context.Context, trtc *kubechainv1alpha1.TaskRunToolCall,
-	contactChannel *kubechainv1alpha1.ContactChannel, apiKey string, mcpServer *kubechainv1alpha1.MCPServer,
+	contactChannel *kubechainv1alpha1.ContactChannel, apiKey string,
 ) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
 
@@ -823,9 +964,9 @@ func (r *TaskRunToolCallReconciler) requestHumanApproval(ctx context.Context, tr
 
 	// Update to awaiting approval phase while maintaining current status
 	trtc.Status.Phase = kubechainv1alpha1.TaskRunToolCallPhaseAwaitingHumanApproval
-	trtc.Status.StatusDetail = fmt.Sprintf("Waiting for human approval via contact channel %s", mcpServer.Spec.ApprovalContactChannel.Name)
+	trtc.Status.StatusDetail = fmt.Sprintf("Waiting for human approval via contact channel %s", contactChannel.Name)
 	r.recorder.Event(trtc, corev1.EventTypeNormal, "AwaitingHumanApproval",
-		fmt.Sprintf("Tool execution requires approval via contact channel %s", mcpServer.Spec.ApprovalContactChannel.Name))
+		fmt.Sprintf("Tool execution requires approval via contact channel %s", contactChannel.Name))
 
 	if err := r.Status().Update(ctx, trtc); err != nil {
 		logger.Error(err, "Failed to update TaskRunToolCall status")
@@ -854,48 +995,118 @@ func (r *TaskRunToolCallReconciler) requestHumanApproval(ctx context.Context, tr
 
 	// Update with call ID and requeue
 	callId := functionCall.GetCallId()
+	now := metav1.Now()
 	trtc.Status.ExternalCallID = callId
+	trtc.Status.Approval = &kubechainv1alpha1.ToolCallApproval{
+		Stage:       kubechainv1alpha1.ApprovalStageRequested,
+		RequestedAt: now,
+		Calls:       []kubechainv1alpha1.ApprovalCall{{CallID: callId, ContactChannel: contactChannel.Name, RequestedAt: now}},
+	}
 	if err := r.Status().Update(ctx, trtc); err != nil {
 		logger.Error(err, "Failed to update TaskRunToolCall status")
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	return ctrl.Result{RequeueAfter: approvalPollInterval}, nil
 }
 
-// handleMCPApprovalFlow encapsulates the MCP approval flow logic
-func (r *TaskRunToolCallReconciler) handleMCPApprovalFlow(ctx context.Context, trtc *kubechainv1alpha1.TaskRunToolCall) (result ctrl.Result, err error, handled bool) {
-	// We've already been through the approval flow and are ready to execute the tool
-	if trtc.Status.Phase == kubechainv1alpha1.TaskRunToolCallPhaseReadyToExecuteApprovedTool {
+// approvalPolicy returns the approval policy of the tool a call is for, the
+// action for calls it has no rule for, the contact channel approvals go to
+// unless a rule names one, and the name the policy knows the tool by
+func (r *TaskRunToolCallReconciler) approvalPolicy(ctx context.Context, trtc *kubechainv1alpha1.TaskRunToolCall) (
+	policy *kubechainv1alpha1.ApprovalPolicy, defaultAction kubechainv1alpha1.ApprovalAction,
+	defaultChannel *kubechainv1alpha1.LocalObjectReference, toolName string, err error,
+) {
+	mcpServer, needsApproval, err := r.getMCPServer(ctx, trtc)
+	if err != nil {
+		return nil, "", nil, "", err
+	}
+	if mcpServer != nil {
+		_, toolName, _ := r.mcpTool(trtc)
+		defaultAction := kubechainv1alpha1.ApprovalActionApprove
+		if needsApproval {
+			defaultAction = kubechainv1alpha1.ApprovalActionRequireApproval
+		}
+		return mcpServer.Spec.ApprovalPolicy, defaultAction, mcpServer.Spec.ApprovalContactChannel, toolName, nil
+	}
+
+	var tool kubechainv1alpha1.Tool
+	if err := r.Get(ctx, client.ObjectKey{Namespace: trtc.Namespace, Name: trtc.Spec.ToolRef.Name}, &tool); err != nil {
+		// a missing tool is reported when the call is executed
+		return nil, kubechainv1alpha1.ApprovalActionApprove, nil, trtc.Spec.ToolRef.Name, client.IgnoreNotFound(err)
+	}
+	defaultAction = kubechainv1alpha1.ApprovalActionApprove
+	if toolRequiresApproval(&tool) {
+		defaultAction = kubechainv1alpha1.ApprovalActionRequireApproval
+	}
+	return tool.Spec.ApprovalPolicy, defaultAction, tool.Spec.ApprovalContactChannel, tool.Name, nil
+}
+
+// toolRequiresApproval reports whether every call of a Tool needs approval,
+// unless its policy decides otherwise
+func toolRequiresApproval(tool *kubechainv1alpha1.Tool) bool {
+	if tool.Spec.ApprovalContactChannel != nil {
+		return true
+	}
+	return tool.Spec.Execute.ExternalAPI != nil && tool.Spec.Execute.ExternalAPI.RequiresApproval
+}
+
+// handleApprovalFlow decides with the approval policy of the tool whether a
+// call runs, is denied, or waits for a human's approval
+func (r *TaskRunToolCallReconciler) handleApprovalFlow(ctx context.Context, trtc *kubechainv1alpha1.TaskRunToolCall) (result ctrl.Result, err error, handled bool) {
+	// We've already been through the approval flow and are ready to execute the tool,
+	// or are executing it and waiting for a human's reply
+	if trtc.Status.Phase == kubechainv1alpha1.TaskRunToolCallPhaseReadyToExecuteApprovedTool ||
+		trtc.Status.Phase == kubechainv1alpha1.TaskRunToolCallPhaseAwaitingHumanInput {
 		return ctrl.Result{}, nil, false
 	}
 
-	// Check if this is an MCP tool and needs approval
-	mcpServer, needsApproval, err := r.getMCPServer(ctx, trtc)
+	policy, defaultAction, defaultChannel, toolName, err := r.approvalPolicy(ctx, trtc)
 	if err != nil {
 		return ctrl.Result{}, err, true
 	}
 
-	// If not an MCP tool or no approval needed, continue with normal processing
-	if mcpServer == nil || !needsApproval {
+	var args map[string]interface{}
+	// arguments that don't parse are reported when the call is executed
+	_ = json.Unmarshal([]byte(trtc.Spec.Arguments), &args)
+	decision, err := approval.Decide(ctx, policy, defaultAction, toolName, args)
+	if err != nil {
+		return r.setStatusError(ctx, kubechainv1alpha1.TaskRunToolCallPhaseFailed, "ApprovalPolicyFailed", trtc, err)
+	}
+
+	switch decision.Action {
+	case kubechainv1alpha1.ApprovalActionApprove:
 		return ctrl.Result{}, nil, false
+	case kubechainv1alpha1.ApprovalActionDeny:
+		r.recorder.Event(trtc, corev1.EventTypeNormal, "ToolCallDenied",
+			fmt.Sprintf("Tool call denied by %s", decision.Reason))
+		return r.updateTRTCStatus(ctx, trtc,
+			kubechainv1alpha1.TaskRunToolCallStatusTypeSucceeded,
+			kubechainv1alpha1.TaskRunToolCallPhaseToolCallRejected,
+			fmt.Sprintf("Tool call denied by %s", decision.Reason), "Tool call denied by approval policy")
+	}
+
+	channel := decision.ContactChannel
+	if channel == nil {
+		channel = defaultChannel
+	}
+	if channel == nil {
+		result, errStatus, _ := r.setStatusError(ctx, kubechainv1alpha1.TaskRunToolCallPhaseErrorRequestingHumanApproval,
+			"NoContactChannel", trtc, fmt.Errorf("tool call requires approval (%s) but there is no contact channel to ask", decision.Reason))
+		return result, errStatus, true
 	}
 
 	// Get contact channel and API key information
 	trtcNamespace := trtc.Namespace
-	contactChannel, err := r.getContactChannel(ctx, mcpServer, trtcNamespace)
+	contactChannel, err := r.getContactChannel(ctx, channel.Name, trtcNamespace)
 	if err != nil {
 		result, errStatus, _ := r.setStatusError(ctx, kubechainv1alpha1.TaskRunToolCallPhaseErrorRequestingHumanApproval,
 			"NoContactChannel", trtc, err)
 		return result, errStatus, true
 	}
 
-	apiKey, err := r.getHumanLayerAPIKey(ctx,
-		contactChannel.Spec.APIKeyFrom.SecretKeyRef.Name,
-		contactChannel.Spec.APIKeyFrom.SecretKeyRef.Key,
-		trtcNamespace)
-
-	if err != nil || apiKey == "" {
+	apiKey, err := r.contactChannelAPIKey(ctx, contactChannel)
+	if err != nil {
 		result, errStatus, _ := r.setStatusError(ctx, kubechainv1alpha1.TaskRunToolCallPhaseErrorRequestingHumanApproval,
 			"NoAPIKey", trtc, err)
 		return result, errStatus, true
@@ -903,14 +1114,18 @@ func (r *TaskRunToolCallReconciler) handleMCPApprovalFlow(ctx context.Context, t
 
 	// Handle pending approval check first
 	if trtc.Status.Phase == kubechainv1alpha1.TaskRunToolCallPhaseAwaitingHumanApproval {
-		result, err, handled := r.handlePendingApproval(ctx, trtc, apiKey)
+		timeouts := contactChannel.Spec.ApprovalTimeouts
+		if policy != nil && policy.Timeouts != nil {
+			timeouts = policy.Timeouts
+		}
+		result, err, handled := r.handlePendingApproval(ctx, trtc, contactChannel, apiKey, timeouts)
 		if handled {
 			return result, err, true
 		}
 	}
 
 	// Request human approval if not already done
-	result, err = r.requestHumanApproval(ctx, trtc, contactChannel, apiKey, mcpServer)
+	result, err = r.requestHumanApproval(ctx, trtc, contactChannel, apiKey)
 	return result, err, true
 }
 
@@ -919,7 +1134,7 @@ func (r *TaskRunToolCallReconciler) dispatchToolExecution(ctx context.Context, t
 	args map[string]interface{},
 ) (ctrl.Result, error) {
 	// Check for MCP tool first
-	serverName, mcpToolName, isMCP := isMCPTool(trtc.Spec.ToolRef.Name)
+	serverName, mcpToolName, isMCP := r.mcpTool(trtc)
 	if isMCP && r.MCPManager != nil {
 		return r.processMCPTool(ctx, trtc, serverName, mcpToolName, args)
 	}
@@ -938,6 +1153,8 @@ func (r *TaskRunToolCallReconciler) dispatchToolExecution(ctx context.Context, t
 		return r.processBuiltinFunction(ctx, trtc, tool, args)
 	case "externalAPI":
 		return r.processExternalAPI(ctx, trtc, tool)
+	case "humanContact":
+		return r.processHumanContact(ctx, trtc, tool, args)
 	default:
 		return r.handleUnsupportedToolType(ctx, trtc)
 	}
@@ -1000,57 +1217,58 @@ func (r *TaskRunToolCallReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		return result, err
 	}
 
-	// 6. Handle MCP approval flow
-	result, err, handled := r.handleMCPApprovalFlow(ctx, &trtc)
+	// 6. Reject MCP tools the agent may not use
+	result, err, handled := r.checkMCPToolAllowed(ctx, &trtc)
 	if handled {
 		return result, err
 	}
 
-	// 7. Parse arguments for execution
+	// 7. Handle the approval flow
+	result, err, handled = r.handleApprovalFlow(ctx, &trtc)
+	if handled {
+		return result, err
+	}
+
+	// 8. Parse arguments for execution
 	args, err := r.parseArguments(ctx, &trtc)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
-	// 8. Execute the appropriate tool type
+	// 9. Execute the appropriate tool type
 	return r.dispatchToolExecution(ctx, &trtc, args)
 }
 
 func (r *TaskRunToolCallReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.recorder = mgr.GetEventRecorderFor("taskruntoolcall-controller")
-	r.server = &http.Server{Addr: ":8080"} // Choose a port
-	http.HandleFunc("/webhook/inbound", r.webhookHandler)
-
-	// Initialize MCPManager if it hasn't been initialized yet
-	if r.MCPManager == nil {
-		r.MCPManager = mcpmanager.NewMCPServerManagerWithClient(r.Client)
+	if r.newLLMClient == nil {
+		r.newLLMClient = llmclient.NewLLMClient
 	}
-
 	if r.HLClientFactory == nil {
 		client, err := humanlayer.NewHumanLayerClientFactory("")
 		if err != nil {
 			return err
 		}
 
-		r.HLClientFactory = client
+		r.HLClientFactory = humanlayer.NewInClusterClientFactory(mgr.GetClient(), client)
 	}
 
-	go func() {
-		if err := r.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Log.Error(err, "Failed to start HTTP server")
-		}
-	}()
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &kubechainv1alpha1.TaskRunToolCall{},
+		externalCallIDIndex, indexExternalCallID); err != nil {
+		return err
+	}
+	r.webhookEvents = make(chan event.GenericEvent, webhookEventBuffer)
+
+	maxConcurrentReconciles := r.MaxConcurrentReconciles
+	if maxConcurrentReconciles <= 0 {
+		maxConcurrentReconciles = DefaultMaxConcurrentReconciles
+	}
 
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&kubechainv1alpha1.TaskRunToolCall{}).
+		WithOptions(controller.Options{MaxConcurrentReconciles: maxConcurrentReconciles}).
+		Watches(&kubechainv1alpha1.TaskRunToolCall{}, r.cancelOnDelete()).
+		Watches(&kubechainv1alpha1.TaskRun{}, r.cancelOnTaskRunDelete()).
+		WatchesRawSource(source.Channel(r.webhookEvents, &handler.EnqueueRequestForObject{})).
 		Complete(r)
 }
-
-func (r *TaskRunToolCallReconciler) Stop() {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	if err := r.server.Shutdown(ctx); err != nil {
-		log.Log.Error(err, "Failed to shut down HTTP server")
-	}
-}
@@ -136,6 +136,255 @@ var _ = Describe("TaskRunToolCall Controller", func() {
 		})
 	})
 
+	Context("Ready:Pending -> Ready:AwaitingHumanApproval (Tool with approval channel)", func() {
+		It("waits for approval before executing a tool with an approval contact channel", func() {
+			ctx := context.Background()
+
+			testSecret.Setup(ctx)
+			defer testSecret.Teardown(ctx)
+			testContactChannel.channelType = kubechainv1alpha1.ContactChannelTypeSlack
+			testContactChannel.SetupWithStatus(ctx, kubechainv1alpha1.ContactChannelStatus{
+				Ready:  true,
+				Status: "Ready",
+			})
+			defer testContactChannel.Teardown(ctx)
+
+			approvedAddTool := &TestTool{
+				name:                   addTool.name,
+				toolType:               "function",
+				approvalContactChannel: testContactChannel.name,
+			}
+			approvedAddTool.SetupWithStatus(ctx, kubechainv1alpha1.ToolStatus{
+				Ready:  true,
+				Status: "Ready",
+			})
+			defer approvedAddTool.Teardown(ctx)
+
+			taskRunToolCall := trtcForAddTool.SetupWithStatus(ctx, kubechainv1alpha1.TaskRunToolCallStatus{
+				Phase:        kubechainv1alpha1.TaskRunToolCallPhasePending,
+				Status:       kubechainv1alpha1.TaskRunToolCallStatusTypeReady,
+				StatusDetail: "Setup complete",
+				StartTime:    &metav1.Time{Time: time.Now().Add(-1 * time.Minute)},
+			})
+			defer trtcForAddTool.Teardown(ctx)
+
+			By("reconciling the trtc")
+			reconciler, recorder := reconciler()
+			reconciler.HLClientFactory = &humanlayer.MockHumanLayerClientFactory{
+				StatusCode: 200,
+			}
+
+			result, err := reconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      taskRunToolCall.Name,
+					Namespace: taskRunToolCall.Namespace,
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(5 * time.Second))
+
+			By("checking the taskruntoolcall waits for approval instead of executing")
+			updatedTRTC := &kubechainv1alpha1.TaskRunToolCall{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      taskRunToolCall.Name,
+				Namespace: taskRunToolCall.Namespace,
+			}, updatedTRTC)).To(Succeed())
+			Expect(updatedTRTC.Status.Phase).To(Equal(kubechainv1alpha1.TaskRunToolCallPhaseAwaitingHumanApproval))
+			Expect(updatedTRTC.Status.Result).To(BeEmpty())
+			utils.ExpectRecorder(recorder).ToEmitEventContaining("AwaitingHumanApproval")
+		})
+
+		It("denies calls the tool's approval policy denies", func() {
+			ctx := context.Background()
+
+			teardown := setupTestAddTool(ctx)
+			defer teardown()
+			tool := &kubechainv1alpha1.Tool{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: addTool.name, Namespace: "default"}, tool)).To(Succeed())
+			tool.Spec.ApprovalPolicy = &kubechainv1alpha1.ApprovalPolicy{
+				Rules: []kubechainv1alpha1.ApprovalRule{
+					{Condition: "args.a > 1", Action: kubechainv1alpha1.ApprovalActionDeny},
+				},
+			}
+			Expect(k8sClient.Update(ctx, tool)).To(Succeed())
+
+			taskRunToolCall := trtcForAddTool.SetupWithStatus(ctx, kubechainv1alpha1.TaskRunToolCallStatus{
+				Phase:        kubechainv1alpha1.TaskRunToolCallPhasePending,
+				Status:       kubechainv1alpha1.TaskRunToolCallStatusTypeReady,
+				StatusDetail: "Setup complete",
+				StartTime:    &metav1.Time{Time: time.Now().Add(-1 * time.Minute)},
+			})
+			defer trtcForAddTool.Teardown(ctx)
+
+			By("reconciling the trtc")
+			reconciler, recorder := reconciler()
+
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      taskRunToolCall.Name,
+					Namespace: taskRunToolCall.Namespace,
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("checking the taskruntoolcall was rejected without executing")
+			updatedTRTC := &kubechainv1alpha1.TaskRunToolCall{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      taskRunToolCall.Name,
+				Namespace: taskRunToolCall.Namespace,
+			}, updatedTRTC)).To(Succeed())
+			Expect(updatedTRTC.Status.Phase).To(Equal(kubechainv1alpha1.TaskRunToolCallPhaseToolCallRejected))
+			Expect(updatedTRTC.Status.Result).To(Equal("Tool call denied by approval policy"))
+			utils.ExpectRecorder(recorder).ToEmitEventContaining("ToolCallDenied")
+		})
+	})
+
+	Context("Ready:Pending -> Ready:AwaitingHumanInput -> Succeeded:Succeeded (humanContact Tool)", func() {
+		It("asks a human the question and returns their reply", func() {
+			ctx := context.Background()
+
+			testSecret.Setup(ctx)
+			defer testSecret.Teardown(ctx)
+			testContactChannel.channelType = kubechainv1alpha1.ContactChannelTypeSlack
+			testContactChannel.SetupWithStatus(ctx, kubechainv1alpha1.ContactChannelStatus{
+				Ready:  true,
+				Status: "Ready",
+			})
+			defer testContactChannel.Teardown(ctx)
+
+			tool := &kubechainv1alpha1.Tool{
+				ObjectMeta: metav1.ObjectMeta{Name: "ask-oncall", Namespace: "default"},
+				Spec: kubechainv1alpha1.ToolSpec{
+					Name: "ask-oncall",
+					Execute: kubechainv1alpha1.ToolExecute{
+						HumanContact: &kubechainv1alpha1.HumanContactToolSpec{
+							ContactChannel: kubechainv1alpha1.LocalObjectReference{Name: testContactChannel.name},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, tool)).To(Succeed())
+			defer func() { _ = k8sClient.Delete(ctx, tool) }()
+
+			askOncall := &TestTaskRunToolCall{
+				name:      "test-ask-oncall-trtc",
+				toolName:  tool.Name,
+				arguments: `{"question": "Can I restart the billing service?"}`,
+			}
+			taskRunToolCall := askOncall.SetupWithStatus(ctx, kubechainv1alpha1.TaskRunToolCallStatus{
+				Phase:        kubechainv1alpha1.TaskRunToolCallPhasePending,
+				Status:       kubechainv1alpha1.TaskRunToolCallStatusTypeReady,
+				StatusDetail: "Setup complete",
+				StartTime:    &metav1.Time{Time: time.Now().Add(-1 * time.Minute)},
+			})
+			defer askOncall.Teardown(ctx)
+
+			By("reconciling the trtc to ask the question")
+			reconciler, recorder := reconciler()
+			hlFactory := &humanlayer.MockHumanLayerClientFactory{StatusCode: 200}
+			reconciler.HLClientFactory = hlFactory
+
+			request := reconcile.Request{NamespacedName: types.NamespacedName{
+				Name:      taskRunToolCall.Name,
+				Namespace: taskRunToolCall.Namespace,
+			}}
+			result, err := reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(5 * time.Second))
+			Expect(hlFactory.LastHumanContactMsg).To(Equal("Can I restart the billing service?"))
+
+			updatedTRTC := &kubechainv1alpha1.TaskRunToolCall{}
+			Expect(k8sClient.Get(ctx, request.NamespacedName, updatedTRTC)).To(Succeed())
+			Expect(updatedTRTC.Status.Phase).To(Equal(kubechainv1alpha1.TaskRunToolCallPhaseAwaitingHumanInput))
+			Expect(updatedTRTC.Status.ExternalCallID).NotTo(BeEmpty())
+			utils.ExpectRecorder(recorder).ToEmitEventContaining("AwaitingHumanInput")
+
+			By("reconciling the trtc once the human replied")
+			hlFactory.HumanContactResponse = "Yes, go ahead"
+			_, err = reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, request.NamespacedName, updatedTRTC)).To(Succeed())
+			Expect(updatedTRTC.Status.Phase).To(Equal(kubechainv1alpha1.TaskRunToolCallPhaseSucceeded))
+			Expect(updatedTRTC.Status.Status).To(Equal(kubechainv1alpha1.TaskRunToolCallStatusTypeSucceeded))
+			Expect(updatedTRTC.Status.Result).To(Equal("Yes, go ahead"))
+			utils.ExpectRecorder(recorder).ToEmitEventContaining("HumanResponded")
+		})
+	})
+
+	Context("Ready:AwaitingHumanInput -> Succeeded:Succeeded (MCP Tool interrupted while eliciting input)", func() {
+		It("waits for the reply instead of calling the tool again", func() {
+			testSecret.Setup(ctx)
+			defer testSecret.Teardown(ctx)
+			testContactChannel.channelType = kubechainv1alpha1.ContactChannelTypeSlack
+			testContactChannel.SetupWithStatus(ctx, kubechainv1alpha1.ContactChannelStatus{
+				Ready:  true,
+				Status: "Ready",
+			})
+			defer testContactChannel.Teardown(ctx)
+
+			mcpServer := &TestMCPServer{
+				name:                   "test-mcp-eliciting",
+				needsApproval:          true,
+				approvalContactChannel: testContactChannel.name,
+			}
+			mcpServer.SetupWithStatus(ctx, kubechainv1alpha1.MCPServerStatus{
+				Connected: true,
+				Status:    "Ready",
+			})
+			defer mcpServer.Teardown(ctx)
+
+			parent := &TestParentTaskRun{
+				mcpServers: []kubechainv1alpha1.AgentMCPServer{{Name: mcpServer.name}},
+			}
+			parent.Setup(ctx)
+			defer parent.Teardown(ctx)
+
+			taskRunToolCall := &TestTaskRunToolCall{
+				name:      "test-mcp-eliciting-trtc",
+				toolName:  mcpServer.name + "__deploy",
+				arguments: `{"service": "billing"}`,
+			}
+			trtc := taskRunToolCall.SetupWithStatus(ctx, kubechainv1alpha1.TaskRunToolCallStatus{
+				Phase:          kubechainv1alpha1.TaskRunToolCallPhaseAwaitingHumanInput,
+				Status:         kubechainv1alpha1.TaskRunToolCallStatusTypeReady,
+				StatusDetail:   "Waiting for human input via contact channel " + testContactChannel.name,
+				ExternalCallID: "elicitation-call",
+				StartTime:      &metav1.Time{Time: time.Now().Add(-1 * time.Minute)},
+			})
+			defer taskRunToolCall.Teardown(ctx)
+
+			By("reconciling the trtc before the human replied")
+			reconciler, recorder := reconciler()
+			mcpManager := &MockMCPManager{}
+			reconciler.MCPManager = mcpManager
+			hlFactory := &humanlayer.MockHumanLayerClientFactory{StatusCode: 200}
+			reconciler.HLClientFactory = hlFactory
+
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Name: trtc.Name, Namespace: trtc.Namespace}}
+			result, err := reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(5 * time.Second))
+			Expect(mcpManager.Calls).To(BeZero())
+
+			updatedTRTC := &kubechainv1alpha1.TaskRunToolCall{}
+			Expect(k8sClient.Get(ctx, request.NamespacedName, updatedTRTC)).To(Succeed())
+			Expect(updatedTRTC.Status.Phase).To(Equal(kubechainv1alpha1.TaskRunToolCallPhaseAwaitingHumanInput))
+
+			By("reconciling the trtc once the human replied")
+			hlFactory.HumanContactResponse = "eu-west-1"
+			_, err = reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mcpManager.Calls).To(BeZero())
+
+			Expect(k8sClient.Get(ctx, request.NamespacedName, updatedTRTC)).To(Succeed())
+			Expect(updatedTRTC.Status.Phase).To(Equal(kubechainv1alpha1.TaskRunToolCallPhaseSucceeded))
+			Expect(updatedTRTC.Status.Status).To(Equal(kubechainv1alpha1.TaskRunToolCallStatusTypeSucceeded))
+			Expect(updatedTRTC.Status.Result).To(ContainSubstring("was not made again, the human replied: eu-west-1"))
+			utils.ExpectRecorder(recorder).ToEmitEventContaining("ToolCallInterrupted")
+		})
+	})
+
 	Context("Ready:Pending -> Error:Pending", func() {
 		It("fails when arguments are invalid", func() {
 			teardown := setupTestAddTool(ctx)
@@ -204,6 +453,12 @@ var _ = Describe("TaskRunToolCall Controller", func() {
 			})
 			defer mcpServer.Teardown(ctx)
 
+			parent := &TestParentTaskRun{
+				mcpServers: []kubechainv1alpha1.AgentMCPServer{{Name: mcpServer.name}},
+			}
+			parent.Setup(ctx)
+			defer parent.Teardown(ctx)
+
 			// Setup MCP tool
 			mcpTool := &TestMCPTool{
 				name:        "test-mcp-no-approval-tool",
@@ -263,6 +518,63 @@ var _ = Describe("TaskRunToolCall Controller", func() {
 		})
 	})
 
+	Context("Ready:Pending -> Error:Failed (MCP Tool excluded by the agent)", func() {
+		It("rejects an MCP tool the agent excludes", func() {
+			mcpServer := &TestMCPServer{
+				name: "test-mcp-excluded",
+			}
+			mcpServer.SetupWithStatus(ctx, kubechainv1alpha1.MCPServerStatus{
+				Connected: true,
+				Status:    "Ready",
+			})
+			defer mcpServer.Teardown(ctx)
+
+			parent := &TestParentTaskRun{
+				mcpServers: []kubechainv1alpha1.AgentMCPServer{{
+					Name:         mcpServer.name,
+					ExcludeTools: []string{"test-*"},
+				}},
+			}
+			parent.Setup(ctx)
+			defer parent.Teardown(ctx)
+
+			taskRunToolCall := &TestTaskRunToolCall{
+				name:      "test-mcp-excluded-trtc",
+				toolName:  mcpServer.name + "__test-tool",
+				arguments: `{"a": 2, "b": 3}`,
+			}
+			trtc := taskRunToolCall.SetupWithStatus(ctx, kubechainv1alpha1.TaskRunToolCallStatus{
+				Phase:        kubechainv1alpha1.TaskRunToolCallPhasePending,
+				Status:       kubechainv1alpha1.TaskRunToolCallStatusTypeReady,
+				StatusDetail: "Setup complete",
+				StartTime:    &metav1.Time{Time: time.Now().Add(-1 * time.Minute)},
+			})
+			defer taskRunToolCall.Teardown(ctx)
+
+			By("reconciling the taskruntoolcall")
+			reconciler, recorder := reconciler()
+			reconciler.MCPManager = &MockMCPManager{}
+
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      trtc.Name,
+					Namespace: trtc.Namespace,
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("checking the tool call failed without being executed")
+			updatedTRTC := &kubechainv1alpha1.TaskRunToolCall{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: trtc.Name, Namespace: trtc.Namespace}, updatedTRTC)).To(Succeed())
+			Expect(updatedTRTC.Status.Status).To(Equal(kubechainv1alpha1.TaskRunToolCallStatusTypeError))
+			Expect(updatedTRTC.Status.Phase).To(Equal(kubechainv1alpha1.TaskRunToolCallPhaseFailed))
+			Expect(updatedTRTC.Status.Error).To(ContainSubstring("may not use tool \"test-tool\""))
+			Expect(updatedTRTC.Status.Result).To(BeEmpty())
+
+			utils.ExpectRecorder(recorder).ToEmitEventContaining("ToolNotAllowed")
+		})
+	})
+
 	// Tests for MCP tools with approval requirement
 	Context("Ready:Pending -> Ready:AwaitingHumanApproval (MCP Tool, Slack Contact Channel)", func() {
 		It("transitions to Ready:AwaitingHumanApproval when MCPServer has approval channel", func() {
@@ -482,6 +794,67 @@ var _ = Describe("TaskRunToolCall Controller", func() {
 		})
 	})
 
+	Context("Ready:AwaitingHumanApproval -> Succeeded:ToolCallRejected (approval timeout)", func() {
+		It("rejects a tool call no one approved within the contact channel's timeout", func() {
+			requestedAt := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+			trtc, teardown := setupTestApprovalResources(ctx, &SetupTestApprovalConfig{
+				TaskRunToolCallStatus: &kubechainv1alpha1.TaskRunToolCallStatus{
+					ExternalCallID: "call-tool-call-timeout-test",
+					Phase:          kubechainv1alpha1.TaskRunToolCallPhaseAwaitingHumanApproval,
+					Status:         kubechainv1alpha1.TaskRunToolCallStatusTypeReady,
+					StatusDetail:   "Waiting for human approval via contact channel",
+					StartTime:      &requestedAt,
+					Approval: &kubechainv1alpha1.ToolCallApproval{
+						Stage:       kubechainv1alpha1.ApprovalStageRequested,
+						RequestedAt: requestedAt,
+						Calls: []kubechainv1alpha1.ApprovalCall{{
+							CallID:         "call-tool-call-timeout-test",
+							ContactChannel: testContactChannel.name,
+							RequestedAt:    requestedAt,
+						}},
+					},
+				},
+			})
+			defer teardown()
+
+			By("giving the contact channel a timeout of an hour")
+			contactChannel := testContactChannel.contactChannel
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: contactChannel.Name, Namespace: contactChannel.Namespace}, contactChannel)).To(Succeed())
+			contactChannel.Spec.ApprovalTimeouts = &kubechainv1alpha1.ApprovalTimeouts{
+				Timeout: &metav1.Duration{Duration: time.Hour},
+			}
+			Expect(k8sClient.Update(ctx, contactChannel)).To(Succeed())
+
+			By("reconciling the trtc against a HumanLayer client no one answered")
+			reconciler, recorder := reconciler()
+			reconciler.MCPManager = &MockMCPManager{
+				NeedsApproval: true,
+			}
+			reconciler.HLClientFactory = &humanlayer.MockHumanLayerClientFactory{
+				StatusCode: 200,
+			}
+
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      trtc.Name,
+					Namespace: trtc.Namespace,
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("checking the taskruntoolcall was rejected")
+			updatedTRTC := &kubechainv1alpha1.TaskRunToolCall{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: trtc.Name, Namespace: trtc.Namespace}, updatedTRTC)).To(Succeed())
+			Expect(updatedTRTC.Status.Phase).To(Equal(kubechainv1alpha1.TaskRunToolCallPhaseToolCallRejected))
+			Expect(updatedTRTC.Status.Status).To(Equal(kubechainv1alpha1.TaskRunToolCallStatusTypeSucceeded))
+			Expect(updatedTRTC.Status.Approval.Stage).To(Equal(kubechainv1alpha1.ApprovalStageTimedOut))
+			Expect(updatedTRTC.Status.Result).To(ContainSubstring("No one approved the tool call within 1h0m0s"))
+
+			By("checking that a timeout event was emitted")
+			utils.ExpectRecorder(recorder).ToEmitEventContaining("ApprovalTimedOut")
+		})
+	})
+
 	Context("Ready:ReadyToExecuteApprovedTool -> Succeeded:Succeeded", func() {
 		It("transitions from Ready:ReadyToExecuteApprovedTool to Succeeded:Succeeded when a tool is executed", func() {
 			trtc, teardown := setupTestApprovalResources(ctx, &SetupTestApprovalConfig{
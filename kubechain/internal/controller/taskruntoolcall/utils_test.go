@@ -6,6 +6,7 @@ import (
 	"time"
 
 	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+	"github.com/humanlayer/smallchain/kubechain/internal/mcpmanager"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
@@ -50,9 +51,10 @@ var testSecret = &TestSecret{
 
 // TestTool represents a test Tool resource
 type TestTool struct {
-	name     string
-	toolType string
-	tool     *kubechainv1alpha1.Tool
+	name                   string
+	toolType               string
+	approvalContactChannel string
+	tool                   *kubechainv1alpha1.Tool
 }
 
 // TestSecret represents a test secret for storing API keys
@@ -78,7 +80,7 @@ func (t *TestContactChannel) Setup(ctx context.Context) *kubechainv1alpha1.Conta
 		},
 		Spec: kubechainv1alpha1.ContactChannelSpec{
 			Type: t.channelType,
-			APIKeyFrom: kubechainv1alpha1.APIKeySource{
+			APIKeyFrom: &kubechainv1alpha1.APIKeySource{
 				SecretKeyRef: kubechainv1alpha1.SecretKeyRef{
 					Name: t.secretName,
 					Key:  "api-key",
@@ -214,6 +216,9 @@ func (t *TestTool) Setup(ctx context.Context) *kubechainv1alpha1.Tool {
 			},
 		},
 	}
+	if t.approvalContactChannel != "" {
+		tool.Spec.ApprovalContactChannel = &kubechainv1alpha1.LocalObjectReference{Name: t.approvalContactChannel}
+	}
 	_ = k8sClient.Delete(ctx, tool) // Delete if exists
 	err := k8sClient.Create(ctx, tool)
 	Expect(err).NotTo(HaveOccurred())
@@ -239,6 +244,53 @@ func setupTestAddTool(ctx context.Context) func() {
 	}
 }
 
+// TestParentTaskRun represents the TaskRun and Agent a test TaskRunToolCall belongs to
+type TestParentTaskRun struct {
+	mcpServers []kubechainv1alpha1.AgentMCPServer
+	agent      *kubechainv1alpha1.Agent
+	taskRun    *kubechainv1alpha1.TaskRun
+}
+
+func (t *TestParentTaskRun) Setup(ctx context.Context) *kubechainv1alpha1.TaskRun {
+	By("creating the parent agent")
+	agent := &kubechainv1alpha1.Agent{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "parent-agent",
+			Namespace: "default",
+		},
+		Spec: kubechainv1alpha1.AgentSpec{
+			LLMRef:     kubechainv1alpha1.LocalObjectReference{Name: "test-llm"},
+			System:     "you are a testing assistant",
+			MCPServers: t.mcpServers,
+		},
+	}
+	_ = k8sClient.Delete(ctx, agent) // Delete if exists
+	Expect(k8sClient.Create(ctx, agent)).To(Succeed())
+	t.agent = agent
+
+	By("creating the parent taskrun")
+	taskRun := &kubechainv1alpha1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "parent-taskrun",
+			Namespace: "default",
+		},
+		Spec: kubechainv1alpha1.TaskRunSpec{
+			AgentRef:    &kubechainv1alpha1.LocalObjectReference{Name: agent.Name},
+			UserMessage: "what is 2 + 3?",
+		},
+	}
+	_ = k8sClient.Delete(ctx, taskRun) // Delete if exists
+	Expect(k8sClient.Create(ctx, taskRun)).To(Succeed())
+	t.taskRun = taskRun
+	return taskRun
+}
+
+func (t *TestParentTaskRun) Teardown(ctx context.Context) {
+	By("deleting the parent taskrun and agent")
+	_ = k8sClient.Delete(ctx, t.taskRun)
+	_ = k8sClient.Delete(ctx, t.agent)
+}
+
 // TestMCPServer represents a test MCPServer resource
 type TestMCPServer struct {
 	name                   string
@@ -289,23 +341,31 @@ func (t *TestMCPServer) Teardown(ctx context.Context) {
 // MockMCPManager is a struct that mocks the essential MCPServerManager functionality for testing
 type MockMCPManager struct {
 	NeedsApproval bool // Flag to control if mock MCP tools need approval
+	Calls         int  // How many tools were called
 }
 
-// CallTool implements the MCPManager.CallTool method
-func (m *MockMCPManager) CallTool(ctx context.Context, serverName, toolName string, args map[string]interface{}) (string, error) {
+// CallToolResult implements the MCPManager.CallToolResult method
+func (m *MockMCPManager) CallToolResult(ctx context.Context, namespace, serverName, toolName string, args map[string]interface{}) (*mcpmanager.ToolResult, error) {
+	m.Calls++
+
 	// If we're testing the approval flow, return an error to prevent direct execution
 	if m.NeedsApproval {
-		return "", fmt.Errorf("tool requires approval")
+		return nil, fmt.Errorf("tool requires approval")
 	}
 
 	// For non-approval tests, pretend to add the numbers
 	if a, ok := args["a"].(float64); ok {
 		if b, ok := args["b"].(float64); ok {
-			return fmt.Sprintf("%v", a+b), nil
+			return &mcpmanager.ToolResult{Text: fmt.Sprintf("%v", a+b)}, nil
 		}
 	}
 
-	return "5", nil // Default result
+	return &mcpmanager.ToolResult{Text: "5"}, nil // Default result
+}
+
+// FindServerForTool implements the MCPManager.FindServerForTool method
+func (m *MockMCPManager) FindServerForTool(namespace, fullToolName string) (serverName string, toolName string, found bool) {
+	return isMCPTool(fullToolName)
 }
 
 // TestMCPTool represents a test Tool resource for MCP
@@ -410,6 +470,10 @@ func setupTestApprovalResources(ctx context.Context, config *SetupTestApprovalCo
 		Connected: true,
 		Status:    "Ready",
 	})
+	parent := &TestParentTaskRun{
+		mcpServers: []kubechainv1alpha1.AgentMCPServer{{Name: testMCPServer.name}},
+	}
+	parent.Setup(ctx)
 	By("creating the MCP tool")
 	mcpTool := testMCPTool.SetupWithStatus(ctx, kubechainv1alpha1.ToolStatus{
 		Ready:  true,
@@ -448,6 +512,7 @@ func setupTestApprovalResources(ctx context.Context, config *SetupTestApprovalCo
 
 	return trtc, func() {
 		testMCPTool.Teardown(ctx)
+		parent.Teardown(ctx)
 		testMCPServer.Teardown(ctx)
 		testContactChannel.Teardown(ctx)
 		testSecret.Teardown(ctx)
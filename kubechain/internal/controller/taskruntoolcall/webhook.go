@@ -0,0 +1,59 @@
+package taskruntoolcall
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+const (
+	// externalCallIDIndex indexes TaskRunToolCalls by the HumanLayer calls they wait on
+	externalCallIDIndex = "status.externalCallID"
+
+	webhookEventBuffer = 64
+)
+
+// indexExternalCallID indexes the current call and, as an answer to any of
+// them counts, all earlier calls that asked for approval
+func indexExternalCallID(obj client.Object) []string {
+	trtc := obj.(*kubechainv1alpha1.TaskRunToolCall)
+	var callIDs []string
+	if trtc.Status.ExternalCallID != "" {
+		callIDs = append(callIDs, trtc.Status.ExternalCallID)
+	}
+	if trtc.Status.Approval != nil {
+		for _, call := range trtc.Status.Approval.Calls {
+			if call.CallID != trtc.Status.ExternalCallID {
+				callIDs = append(callIDs, call.CallID)
+			}
+		}
+	}
+	return callIDs
+}
+
+// RouteWebhook reconciles the TaskRunToolCall waiting on a HumanLayer call
+// right away instead of at its next poll, which then fetches the answer. It
+// reports whether any TaskRunToolCall waits on the call.
+func (r *TaskRunToolCallReconciler) RouteWebhook(ctx context.Context, callID string) (bool, error) {
+	if r.webhookEvents == nil {
+		return false, fmt.Errorf("TaskRunToolCall controller is not set up")
+	}
+
+	var trtcs kubechainv1alpha1.TaskRunToolCallList
+	if err := r.List(ctx, &trtcs, client.MatchingFields{externalCallIDIndex: callID}); err != nil {
+		return false, fmt.Errorf("failed to list TaskRunToolCalls: %w", err)
+	}
+
+	for i := range trtcs.Items {
+		select {
+		case r.webhookEvents <- event.GenericEvent{Object: &trtcs.Items[i]}:
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+	return len(trtcs.Items) > 0, nil
+}
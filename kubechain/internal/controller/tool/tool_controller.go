@@ -13,11 +13,13 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+	"github.com/humanlayer/smallchain/kubechain/internal/approval"
 	"github.com/openai/openai-go"
 )
 
 // +kubebuilder:rbac:groups=kubechain.humanlayer.dev,resources=tools,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=kubechain.humanlayer.dev,resources=tools/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=kubechain.humanlayer.dev,resources=contactchannels,verbs=get;list;watch
 
 // ToolReconciler reconciles a Tool object
 type ToolReconciler struct {
@@ -63,6 +65,32 @@ func (r *ToolReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 		}
 	}
 
+	// Validate the contact channels
+	if err := r.validateContactChannels(ctx, &tool); err != nil {
+		statusUpdate.Status.Ready = false
+		statusUpdate.Status.Status = "Error"
+		statusUpdate.Status.StatusDetail = err.Error()
+		r.recorder.Event(&tool, corev1.EventTypeWarning, "ValidationFailed", err.Error())
+		if err := r.Status().Update(ctx, statusUpdate); err != nil {
+			logger.Error(err, "Unable to update Tool status")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Validate the approval policy
+	if err := approval.Validate(tool.Spec.ApprovalPolicy); err != nil {
+		statusUpdate.Status.Ready = false
+		statusUpdate.Status.Status = "Error"
+		statusUpdate.Status.StatusDetail = fmt.Sprintf("Invalid approval policy: %v", err)
+		r.recorder.Event(&tool, corev1.EventTypeWarning, "ValidationFailed", fmt.Sprintf("Invalid approval policy: %v", err))
+		if err := r.Status().Update(ctx, statusUpdate); err != nil {
+			logger.Error(err, "Unable to update Tool status")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, err
+	}
+
 	// All validations passed
 	statusUpdate.Status.Ready = true
 	statusUpdate.Status.Status = "Ready"
@@ -79,6 +107,33 @@ func (r *ToolReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 	return ctrl.Result{}, nil
 }
 
+// validateContactChannels checks that the contact channels a tool asks humans
+// through exist: the one that approves its calls, if it requires approval, the
+// one its approval policy escalates to, and the one a humanContact tool asks
+// its questions in
+func (r *ToolReconciler) validateContactChannels(ctx context.Context, tool *kubechainv1alpha1.Tool) error {
+	var refs []kubechainv1alpha1.LocalObjectReference
+	if ref := tool.Spec.ApprovalContactChannel; ref != nil {
+		refs = append(refs, *ref)
+	} else if externalAPI := tool.Spec.Execute.ExternalAPI; externalAPI != nil && externalAPI.RequiresApproval {
+		return fmt.Errorf("requiresApproval needs an approvalContactChannel")
+	}
+	if policy := tool.Spec.ApprovalPolicy; policy != nil && policy.Timeouts != nil && policy.Timeouts.Escalation != nil {
+		refs = append(refs, policy.Timeouts.Escalation.ContactChannel)
+	}
+	if humanContact := tool.Spec.Execute.HumanContact; humanContact != nil {
+		refs = append(refs, humanContact.ContactChannel)
+	}
+
+	for _, ref := range refs {
+		var contactChannel kubechainv1alpha1.ContactChannel
+		if err := r.Get(ctx, client.ObjectKey{Namespace: tool.Namespace, Name: ref.Name}, &contactChannel); err != nil {
+			return fmt.Errorf("ContactChannel %q not found: %w", ref.Name, err)
+		}
+	}
+	return nil
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *ToolReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.recorder = mgr.GetEventRecorderFor("tool-controller")
@@ -4,7 +4,9 @@ package humanlayer
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
 	"os"
 
@@ -51,16 +53,33 @@ func NewHumanLayerClientFactory(optionalApiBase string) (HumanLayerClientFactory
 	return &RealHumanLayerClientFactory{client: client}, nil
 }
 
+// responseStatusCode is the status code of a response, 0 when the request
+// failed before there was one
+func responseStatusCode(resp *http.Response) int {
+	if resp == nil {
+		return 0
+	}
+	return resp.StatusCode
+}
+
+var errInClusterChannel = errors.New("inCluster contact channels are not served by the HumanLayer API")
+
 type HumanLayerClientWrapper interface {
 	SetSlackConfig(slackConfig *kubechainv1alpha1.SlackChannelConfig)
 	SetEmailConfig(emailConfig *kubechainv1alpha1.EmailChannelConfig)
+	SetSMSConfig(smsConfig *kubechainv1alpha1.SMSChannelConfig)
+	SetWhatsAppConfig(whatsAppConfig *kubechainv1alpha1.WhatsAppChannelConfig)
+	SetInClusterChannel(namespace, name string)
 	SetFunctionCallSpec(functionName string, args map[string]interface{})
+	SetHumanContactSpec(msg string)
 	SetCallID(callID string)
 	SetRunID(runID string)
 	SetAPIKey(apiKey string)
 
 	RequestApproval(ctx context.Context) (functionCall *humanlayerapi.FunctionCallOutput, statusCode int, err error)
 	GetFunctionCallStatus(ctx context.Context) (functionCall *humanlayerapi.FunctionCallOutput, statusCode int, err error)
+	RequestHumanContact(ctx context.Context) (humanContact *humanlayerapi.HumanContactOutput, statusCode int, err error)
+	GetHumanContactStatus(ctx context.Context) (humanContact *humanlayerapi.HumanContactOutput, statusCode int, err error)
 }
 
 type HumanLayerClientFactory interface {
@@ -71,7 +90,11 @@ type RealHumanLayerClientWrapper struct {
 	client                *humanlayerapi.APIClient
 	slackChannelInput     *humanlayerapi.SlackContactChannelInput
 	emailContactChannel   *humanlayerapi.EmailContactChannel
+	smsContactChannel     *humanlayerapi.SMSContactChannel
+	whatsAppChannel       *humanlayerapi.WhatsAppContactChannel
 	functionCallSpecInput *humanlayerapi.FunctionCallSpecInput
+	humanContactSpecInput *humanlayerapi.HumanContactSpecInput
+	inCluster             bool
 	callID                string
 	runID                 string
 	apiKey                string
@@ -107,6 +130,32 @@ func (h *RealHumanLayerClientWrapper) SetEmailConfig(emailConfig *kubechainv1alp
 	h.emailContactChannel = emailContactChannel
 }
 
+func (h *RealHumanLayerClientWrapper) SetSMSConfig(smsConfig *kubechainv1alpha1.SMSChannelConfig) {
+	smsContactChannel := humanlayerapi.NewSMSContactChannel(smsConfig.PhoneNumber)
+
+	if smsConfig.ContextAboutUser != "" {
+		smsContactChannel.SetContextAboutUser(smsConfig.ContextAboutUser)
+	}
+
+	h.smsContactChannel = smsContactChannel
+}
+
+func (h *RealHumanLayerClientWrapper) SetWhatsAppConfig(whatsAppConfig *kubechainv1alpha1.WhatsAppChannelConfig) {
+	whatsAppChannel := humanlayerapi.NewWhatsAppContactChannel(whatsAppConfig.PhoneNumber)
+
+	if whatsAppConfig.ContextAboutUser != "" {
+		whatsAppChannel.SetContextAboutUser(whatsAppConfig.ContextAboutUser)
+	}
+
+	h.whatsAppChannel = whatsAppChannel
+}
+
+// SetInClusterChannel marks the request for an inCluster contact channel,
+// which only an InClusterClientFactory can serve
+func (h *RealHumanLayerClientWrapper) SetInClusterChannel(namespace, name string) {
+	h.inCluster = true
+}
+
 func (h *RealHumanLayerClientWrapper) SetFunctionCallSpec(functionName string, args map[string]interface{}) {
 	// Create the function call input with required parameters
 	functionCallSpecInput := humanlayerapi.NewFunctionCallSpecInput(functionName, args)
@@ -114,6 +163,10 @@ func (h *RealHumanLayerClientWrapper) SetFunctionCallSpec(functionName string, a
 	h.functionCallSpecInput = functionCallSpecInput
 }
 
+func (h *RealHumanLayerClientWrapper) SetHumanContactSpec(msg string) {
+	h.humanContactSpecInput = humanlayerapi.NewHumanContactSpecInput(msg)
+}
+
 func (h *RealHumanLayerClientWrapper) SetCallID(callID string) {
 	h.callID = callID
 }
@@ -126,7 +179,9 @@ func (h *RealHumanLayerClientWrapper) SetAPIKey(apiKey string) {
 	h.apiKey = apiKey
 }
 
-func (h *RealHumanLayerClientWrapper) RequestApproval(ctx context.Context) (functionCall *humanlayerapi.FunctionCallOutput, statusCode int, err error) {
+// contactChannel is the channel set with SetSlackConfig, SetEmailConfig,
+// SetSMSConfig or SetWhatsAppConfig
+func (h *RealHumanLayerClientWrapper) contactChannel() *humanlayerapi.ContactChannelInput {
 	channel := humanlayerapi.NewContactChannelInput()
 
 	if h.slackChannelInput != nil {
@@ -137,7 +192,22 @@ func (h *RealHumanLayerClientWrapper) RequestApproval(ctx context.Context) (func
 		channel.SetEmail(*h.emailContactChannel)
 	}
 
-	h.functionCallSpecInput.SetChannel(*channel)
+	if h.smsContactChannel != nil {
+		channel.SetSms(*h.smsContactChannel)
+	}
+
+	if h.whatsAppChannel != nil {
+		channel.SetWhatsapp(*h.whatsAppChannel)
+	}
+
+	return channel
+}
+
+func (h *RealHumanLayerClientWrapper) RequestApproval(ctx context.Context) (functionCall *humanlayerapi.FunctionCallOutput, statusCode int, err error) {
+	if h.inCluster {
+		return nil, 0, errInClusterChannel
+	}
+	h.functionCallSpecInput.SetChannel(*h.contactChannel())
 	functionCallInput := humanlayerapi.NewFunctionCallInput(h.runID, h.callID, *h.functionCallSpecInput)
 
 	functionCall, resp, err := h.client.DefaultAPI.RequestApproval(ctx).
@@ -145,7 +215,7 @@ func (h *RealHumanLayerClientWrapper) RequestApproval(ctx context.Context) (func
 		FunctionCallInput(*functionCallInput).
 		Execute()
 
-	return functionCall, resp.StatusCode, err
+	return functionCall, responseStatusCode(resp), err
 }
 
 func (h *RealHumanLayerClientWrapper) GetFunctionCallStatus(ctx context.Context) (functionCall *humanlayerapi.FunctionCallOutput, statusCode int, err error) {
@@ -153,5 +223,28 @@ func (h *RealHumanLayerClientWrapper) GetFunctionCallStatus(ctx context.Context)
 		Authorization("Bearer " + h.apiKey).
 		Execute()
 
-	return functionCall, resp.StatusCode, err
+	return functionCall, responseStatusCode(resp), err
+}
+
+func (h *RealHumanLayerClientWrapper) RequestHumanContact(ctx context.Context) (humanContact *humanlayerapi.HumanContactOutput, statusCode int, err error) {
+	if h.inCluster {
+		return nil, 0, errInClusterChannel
+	}
+	h.humanContactSpecInput.SetChannel(*h.contactChannel())
+	humanContactInput := humanlayerapi.NewHumanContactInput(h.runID, h.callID, *h.humanContactSpecInput)
+
+	humanContact, resp, err := h.client.DefaultAPI.RequestHumanContact(ctx).
+		Authorization("Bearer " + h.apiKey).
+		HumanContactInput(*humanContactInput).
+		Execute()
+
+	return humanContact, responseStatusCode(resp), err
+}
+
+func (h *RealHumanLayerClientWrapper) GetHumanContactStatus(ctx context.Context) (humanContact *humanlayerapi.HumanContactOutput, statusCode int, err error) {
+	humanContact, resp, err := h.client.DefaultAPI.GetHumanContactStatus(ctx, h.callID).
+		Authorization("Bearer " + h.apiKey).
+		Execute()
+
+	return humanContact, responseStatusCode(resp), err
 }
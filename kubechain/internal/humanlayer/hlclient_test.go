@@ -0,0 +1,111 @@
+package humanlayer
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+var _ = Describe("RealHumanLayerClientWrapper", func() {
+	var (
+		server  *httptest.Server
+		factory HumanLayerClientFactory
+		body    map[string]interface{}
+	)
+
+	BeforeEach(func() {
+		body = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			data, err := io.ReadAll(req.Body)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(json.Unmarshal(data, &body)).To(Succeed())
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"run_id": "run-1", "call_id": "call-1", "spec": {"fn": "delete_file", "kwargs": {}}}`))
+		}))
+		DeferCleanup(server.Close)
+
+		var err error
+		factory, err = NewHumanLayerClientFactory(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	// requestedChannel requests approval through client and returns the
+	// channel HumanLayer was asked to use
+	requestedChannel := func(client HumanLayerClientWrapper) map[string]interface{} {
+		client.SetFunctionCallSpec("delete_file", map[string]interface{}{"path": "/tmp/x"})
+		client.SetCallID("call-1")
+		client.SetRunID("run-1")
+		client.SetAPIKey("hl-key")
+
+		_, statusCode, err := client.RequestApproval(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(statusCode).To(Equal(http.StatusOK))
+		return body["spec"].(map[string]interface{})["channel"].(map[string]interface{})
+	}
+
+	It("sends approvals to a phone number via SMS", func() {
+		client := factory.NewHumanLayerClient()
+		client.SetSMSConfig(&kubechainv1alpha1.SMSChannelConfig{
+			PhoneNumber:      "+14155552671",
+			ContextAboutUser: "the on-call engineer",
+		})
+
+		Expect(requestedChannel(client)).To(HaveKeyWithValue("sms", map[string]interface{}{
+			"phone_number":       "+14155552671",
+			"context_about_user": "the on-call engineer",
+		}))
+	})
+
+	It("sends approvals to a phone number via WhatsApp", func() {
+		client := factory.NewHumanLayerClient()
+		client.SetWhatsAppConfig(&kubechainv1alpha1.WhatsAppChannelConfig{
+			PhoneNumber: "+447911123456",
+		})
+
+		channel := requestedChannel(client)
+		Expect(channel).To(HaveKeyWithValue("whatsapp", map[string]interface{}{
+			"phone_number": "+447911123456",
+		}))
+		Expect(channel).NotTo(HaveKey("sms"))
+	})
+
+	It("reports approvals that fail before there is a response", func() {
+		server.Close()
+		client := factory.NewHumanLayerClient()
+		client.SetSlackConfig(&kubechainv1alpha1.SlackChannelConfig{ChannelOrUserID: "C12345678"})
+		client.SetFunctionCallSpec("delete_file", nil)
+		client.SetCallID("call-1")
+
+		_, statusCode, err := client.RequestApproval(context.Background())
+		Expect(err).To(HaveOccurred())
+		Expect(statusCode).To(BeZero())
+
+		_, statusCode, err = client.GetFunctionCallStatus(context.Background())
+		Expect(err).To(HaveOccurred())
+		Expect(statusCode).To(BeZero())
+	})
+
+	It("reports human contacts that fail before there is a response", func() {
+		server.Close()
+		client := factory.NewHumanLayerClient()
+		client.SetSlackConfig(&kubechainv1alpha1.SlackChannelConfig{ChannelOrUserID: "C12345678"})
+		client.SetHumanContactSpec("Which environment?")
+		client.SetCallID("call-1")
+
+		_, statusCode, err := client.RequestHumanContact(context.Background())
+		Expect(err).To(HaveOccurred())
+		Expect(statusCode).To(BeZero())
+
+		_, statusCode, err = client.GetHumanContactStatus(context.Background())
+		Expect(err).To(HaveOccurred())
+		Expect(statusCode).To(BeZero())
+	})
+})
@@ -0,0 +1,256 @@
+package humanlayer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+	humanlayerapi "github.com/humanlayer/smallchain/kubechain/internal/humanlayerapi"
+)
+
+// InClusterClientFactory serves inCluster contact channels from ApprovalRequest
+// objects, which humans answer with kubectl or the approval API, and hands
+// every other channel to the HumanLayer API
+type InClusterClientFactory struct {
+	client   client.Client
+	fallback HumanLayerClientFactory
+}
+
+// NewInClusterClientFactory wraps fallback, typically the factory returned by
+// NewHumanLayerClientFactory, so that inCluster channels never leave the cluster
+func NewInClusterClientFactory(c client.Client, fallback HumanLayerClientFactory) *InClusterClientFactory {
+	return &InClusterClientFactory{client: c, fallback: fallback}
+}
+
+func (f *InClusterClientFactory) NewHumanLayerClient() HumanLayerClientWrapper {
+	return &InClusterClientWrapper{
+		HumanLayerClientWrapper: f.fallback.NewHumanLayerClient(),
+		client:                  f.client,
+	}
+}
+
+// InClusterClientWrapper keeps what it is set up with, and passes it on to the
+// HumanLayer client it wraps for requests that are not for an inCluster channel
+type InClusterClientWrapper struct {
+	HumanLayerClientWrapper
+
+	client       client.Client
+	namespace    string
+	channel      string
+	functionName string
+	args         map[string]interface{}
+	message      string
+	callID       string
+	runID        string
+}
+
+func (h *InClusterClientWrapper) SetInClusterChannel(namespace, name string) {
+	h.namespace = namespace
+	h.channel = name
+}
+
+func (h *InClusterClientWrapper) SetFunctionCallSpec(functionName string, args map[string]interface{}) {
+	h.functionName = functionName
+	h.args = args
+	h.HumanLayerClientWrapper.SetFunctionCallSpec(functionName, args)
+}
+
+func (h *InClusterClientWrapper) SetHumanContactSpec(msg string) {
+	h.message = msg
+	h.HumanLayerClientWrapper.SetHumanContactSpec(msg)
+}
+
+func (h *InClusterClientWrapper) SetCallID(callID string) {
+	h.callID = callID
+	h.HumanLayerClientWrapper.SetCallID(callID)
+}
+
+func (h *InClusterClientWrapper) SetRunID(runID string) {
+	h.runID = runID
+	h.HumanLayerClientWrapper.SetRunID(runID)
+}
+
+func (h *InClusterClientWrapper) RequestApproval(ctx context.Context) (*humanlayerapi.FunctionCallOutput, int, error) {
+	if h.channel == "" {
+		return h.HumanLayerClientWrapper.RequestApproval(ctx)
+	}
+
+	arguments, err := json.Marshal(h.args)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to marshal function arguments: %w", err)
+	}
+	request, err := h.createApprovalRequest(ctx, kubechainv1alpha1.ApprovalRequestSpec{
+		Type:         kubechainv1alpha1.ApprovalRequestTypeFunctionCall,
+		FunctionName: h.functionName,
+		Arguments:    string(arguments),
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return functionCallOutput(request), http.StatusCreated, nil
+}
+
+func (h *InClusterClientWrapper) RequestHumanContact(ctx context.Context) (*humanlayerapi.HumanContactOutput, int, error) {
+	if h.channel == "" {
+		return h.HumanLayerClientWrapper.RequestHumanContact(ctx)
+	}
+
+	request, err := h.createApprovalRequest(ctx, kubechainv1alpha1.ApprovalRequestSpec{
+		Type:    kubechainv1alpha1.ApprovalRequestTypeHumanContact,
+		Message: h.message,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return humanContactOutput(request), http.StatusCreated, nil
+}
+
+func (h *InClusterClientWrapper) GetFunctionCallStatus(ctx context.Context) (*humanlayerapi.FunctionCallOutput, int, error) {
+	if h.channel == "" {
+		return h.HumanLayerClientWrapper.GetFunctionCallStatus(ctx)
+	}
+
+	request, err := h.getApprovalRequest(ctx)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	return functionCallOutput(request), http.StatusOK, nil
+}
+
+func (h *InClusterClientWrapper) GetHumanContactStatus(ctx context.Context) (*humanlayerapi.HumanContactOutput, int, error) {
+	if h.channel == "" {
+		return h.HumanLayerClientWrapper.GetHumanContactStatus(ctx)
+	}
+
+	request, err := h.getApprovalRequest(ctx)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	return humanContactOutput(request), http.StatusOK, nil
+}
+
+// createApprovalRequest stores a request named after its call ID. Run IDs are
+// TaskRunToolCall names, so the request is owned by and deleted with its
+// TaskRunToolCall when there is one.
+func (h *InClusterClientWrapper) createApprovalRequest(ctx context.Context, spec kubechainv1alpha1.ApprovalRequestSpec) (*kubechainv1alpha1.ApprovalRequest, error) {
+	spec.ContactChannelRef = kubechainv1alpha1.LocalObjectReference{Name: h.channel}
+	spec.CallID = h.callID
+	spec.RunID = h.runID
+
+	request := &kubechainv1alpha1.ApprovalRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      h.callID,
+			Namespace: h.namespace,
+			Labels:    map[string]string{kubechainv1alpha1.ApprovalRequestCallIDLabel: h.callID},
+		},
+		Spec: spec,
+	}
+
+	var trtc kubechainv1alpha1.TaskRunToolCall
+	err := h.client.Get(ctx, client.ObjectKey{Namespace: h.namespace, Name: h.runID}, &trtc)
+	switch {
+	case err == nil:
+		if err := controllerutil.SetOwnerReference(&trtc, request, h.client.Scheme()); err != nil {
+			return nil, fmt.Errorf("failed to set owner reference: %w", err)
+		}
+	case !apierrors.IsNotFound(err):
+		return nil, fmt.Errorf("failed to get TaskRunToolCall %s: %w", h.runID, err)
+	}
+
+	// a retry after the status update failed finds the request created already
+	err = h.client.Create(ctx, request)
+	if apierrors.IsAlreadyExists(err) {
+		if request, err = h.getApprovalRequest(ctx); err != nil {
+			return nil, err
+		}
+		if request.Status.Phase != "" {
+			return request, nil
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to create ApprovalRequest: %w", err)
+	}
+
+	now := metav1.Now()
+	request.Status.Phase = kubechainv1alpha1.ApprovalRequestPhasePending
+	request.Status.RequestedAt = &now
+	if err := h.client.Status().Update(ctx, request); err != nil {
+		return nil, fmt.Errorf("failed to update ApprovalRequest status: %w", err)
+	}
+
+	return request, nil
+}
+
+// getApprovalRequest gets the request for the call ID from the channel's
+// namespace, and checks that it was made for the call, the run and the channel
+// set, so that a request can't answer a call it wasn't made for
+func (h *InClusterClientWrapper) getApprovalRequest(ctx context.Context) (*kubechainv1alpha1.ApprovalRequest, error) {
+	var request kubechainv1alpha1.ApprovalRequest
+	if err := h.client.Get(ctx, client.ObjectKey{Namespace: h.namespace, Name: h.callID}, &request); err != nil {
+		return nil, fmt.Errorf("failed to get ApprovalRequest %s: %w", h.callID, err)
+	}
+
+	if request.Spec.CallID != h.callID || request.Spec.ContactChannelRef.Name != h.channel ||
+		(h.runID != "" && request.Spec.RunID != h.runID) {
+		return nil, fmt.Errorf("ApprovalRequest %s was not made for call %s of %s via contact channel %s",
+			request.Name, h.callID, h.runID, h.channel)
+	}
+
+	return &request, nil
+}
+
+func functionCallOutput(request *kubechainv1alpha1.ApprovalRequest) *humanlayerapi.FunctionCallOutput {
+	var args map[string]interface{}
+	_ = json.Unmarshal([]byte(request.Spec.Arguments), &args)
+	output := humanlayerapi.NewFunctionCallOutput(request.Spec.RunID, request.Spec.CallID,
+		*humanlayerapi.NewFunctionCallSpecOutput(request.Spec.FunctionName, args))
+
+	status := humanlayerapi.NewFunctionCallStatus()
+	if request.Status.RequestedAt != nil {
+		status.SetRequestedAt(request.Status.RequestedAt.Time)
+	}
+	if request.Status.RespondedAt != nil {
+		status.SetRespondedAt(request.Status.RespondedAt.Time)
+	}
+	switch request.Status.Phase {
+	case kubechainv1alpha1.ApprovalRequestPhaseApproved:
+		status.SetApproved(true)
+	case kubechainv1alpha1.ApprovalRequestPhaseRejected:
+		status.SetApproved(false)
+	}
+	if request.Status.Comment != "" {
+		status.SetComment(request.Status.Comment)
+	}
+	output.SetStatus(*status)
+
+	return output
+}
+
+func humanContactOutput(request *kubechainv1alpha1.ApprovalRequest) *humanlayerapi.HumanContactOutput {
+	output := humanlayerapi.NewHumanContactOutput(request.Spec.RunID, request.Spec.CallID,
+		*humanlayerapi.NewHumanContactSpecOutput(request.Spec.Message))
+
+	status := humanlayerapi.NewHumanContactStatus()
+	if request.Status.RequestedAt != nil {
+		status.SetRequestedAt(request.Status.RequestedAt.Time)
+	}
+	if request.Status.RespondedAt != nil {
+		status.SetRespondedAt(request.Status.RespondedAt.Time)
+	}
+	if request.Status.Phase == kubechainv1alpha1.ApprovalRequestPhaseResponded {
+		status.SetResponse(request.Status.Response)
+	}
+	output.SetStatus(*status)
+
+	return output
+}
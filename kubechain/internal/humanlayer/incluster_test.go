@@ -0,0 +1,183 @@
+package humanlayer
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+var _ = Describe("InClusterClientFactory", func() {
+	var (
+		ctx      context.Context
+		k8s      client.Client
+		fallback *MockHumanLayerClientFactory
+		factory  *InClusterClientFactory
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme := runtime.NewScheme()
+		Expect(kubechainv1alpha1.AddToScheme(scheme)).To(Succeed())
+		k8s = fake.NewClientBuilder().WithScheme(scheme).
+			WithStatusSubresource(&kubechainv1alpha1.ApprovalRequest{}).Build()
+		fallback = &MockHumanLayerClientFactory{StatusCode: 200}
+		factory = NewInClusterClientFactory(k8s, fallback)
+	})
+
+	answer := func(callID string, status kubechainv1alpha1.ApprovalRequestStatus) {
+		var request kubechainv1alpha1.ApprovalRequest
+		Expect(k8s.Get(ctx, client.ObjectKey{Namespace: "default", Name: callID}, &request)).To(Succeed())
+		request.Status = status
+		Expect(k8s.Status().Update(ctx, &request)).To(Succeed())
+	}
+
+	It("stores approvals for inCluster channels as ApprovalRequests", func() {
+		hlClient := factory.NewHumanLayerClient()
+		hlClient.SetInClusterChannel("default", "approvers")
+		hlClient.SetFunctionCallSpec("delete_file", map[string]interface{}{"path": "/tmp/x"})
+		hlClient.SetCallID("ec-1234567")
+		hlClient.SetRunID("my-trtc")
+		functionCall, statusCode, err := hlClient.RequestApproval(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(statusCode).To(Equal(201))
+		Expect(functionCall.GetCallId()).To(Equal("ec-1234567"))
+		Expect(fallback.LastCallID).To(BeEmpty())
+
+		var request kubechainv1alpha1.ApprovalRequest
+		Expect(k8s.Get(ctx, client.ObjectKey{Namespace: "default", Name: "ec-1234567"}, &request)).To(Succeed())
+		Expect(request.Spec.Type).To(Equal(kubechainv1alpha1.ApprovalRequestTypeFunctionCall))
+		Expect(request.Spec.ContactChannelRef.Name).To(Equal("approvers"))
+		Expect(request.Spec.FunctionName).To(Equal("delete_file"))
+		Expect(request.Spec.Arguments).To(MatchJSON(`{"path": "/tmp/x"}`))
+		Expect(request.Status.Phase).To(Equal(kubechainv1alpha1.ApprovalRequestPhasePending))
+
+		By("polling before anyone answered")
+		poll := factory.NewHumanLayerClient()
+		poll.SetInClusterChannel("default", "approvers")
+		poll.SetRunID("my-trtc")
+		poll.SetCallID("ec-1234567")
+		functionCall, _, err = poll.GetFunctionCallStatus(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		status := functionCall.GetStatus()
+		_, ok := status.GetApprovedOk()
+		Expect(ok).To(BeFalse())
+
+		By("polling once rejected")
+		answer("ec-1234567", kubechainv1alpha1.ApprovalRequestStatus{
+			Phase:   kubechainv1alpha1.ApprovalRequestPhaseRejected,
+			Comment: "not today",
+		})
+		functionCall, _, err = poll.GetFunctionCallStatus(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		status = functionCall.GetStatus()
+		Expect(status.GetApproved()).To(BeFalse())
+		Expect(status.GetComment()).To(Equal("not today"))
+	})
+
+	It("returns the response to human contacts", func() {
+		hlClient := factory.NewHumanLayerClient()
+		hlClient.SetInClusterChannel("default", "oncall")
+		hlClient.SetHumanContactSpec("Can I restart the billing service?")
+		hlClient.SetCallID("hc-1234567")
+		_, _, err := hlClient.RequestHumanContact(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		answer("hc-1234567", kubechainv1alpha1.ApprovalRequestStatus{
+			Phase:    kubechainv1alpha1.ApprovalRequestPhaseResponded,
+			Response: "Yes, go ahead",
+		})
+		poll := factory.NewHumanLayerClient()
+		poll.SetInClusterChannel("default", "oncall")
+		poll.SetCallID("hc-1234567")
+		humanContact, _, err := poll.GetHumanContactStatus(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		status := humanContact.GetStatus()
+		Expect(status.GetResponse()).To(Equal("Yes, go ahead"))
+	})
+
+	It("only answers calls with requests made for them in the channel's namespace", func() {
+		By("creating a look-alike request in another namespace")
+		Expect(k8s.Create(ctx, &kubechainv1alpha1.ApprovalRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "ec-1234567", Namespace: "attacker"},
+			Spec: kubechainv1alpha1.ApprovalRequestSpec{
+				Type:              kubechainv1alpha1.ApprovalRequestTypeFunctionCall,
+				ContactChannelRef: kubechainv1alpha1.LocalObjectReference{Name: "approvers"},
+				CallID:            "ec-1234567",
+				RunID:             "my-trtc",
+			},
+		})).To(Succeed())
+		var lookAlike kubechainv1alpha1.ApprovalRequest
+		Expect(k8s.Get(ctx, client.ObjectKey{Namespace: "attacker", Name: "ec-1234567"}, &lookAlike)).To(Succeed())
+		lookAlike.Status = kubechainv1alpha1.ApprovalRequestStatus{Phase: kubechainv1alpha1.ApprovalRequestPhaseApproved}
+		Expect(k8s.Status().Update(ctx, &lookAlike)).To(Succeed())
+
+		poll := factory.NewHumanLayerClient()
+		poll.SetInClusterChannel("default", "approvers")
+		poll.SetRunID("my-trtc")
+		poll.SetCallID("ec-1234567")
+		_, _, err := poll.GetFunctionCallStatus(ctx)
+		Expect(err).To(MatchError(ContainSubstring("not found")))
+
+		By("creating the request of another run in the channel's namespace")
+		other := lookAlike.DeepCopy()
+		other.ObjectMeta = metav1.ObjectMeta{Name: "ec-1234567", Namespace: "default"}
+		other.Spec.RunID = "other-trtc"
+		Expect(k8s.Create(ctx, other)).To(Succeed())
+		_, _, err = poll.GetFunctionCallStatus(ctx)
+		Expect(err).To(MatchError(ContainSubstring("was not made for call ec-1234567 of my-trtc")))
+	})
+
+	It("finishes creating a request whose status was not written", func() {
+		Expect(k8s.Create(ctx, &kubechainv1alpha1.ApprovalRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "ec-1234567", Namespace: "default"},
+			Spec: kubechainv1alpha1.ApprovalRequestSpec{
+				Type:              kubechainv1alpha1.ApprovalRequestTypeFunctionCall,
+				ContactChannelRef: kubechainv1alpha1.LocalObjectReference{Name: "approvers"},
+				CallID:            "ec-1234567",
+				RunID:             "my-trtc",
+			},
+		})).To(Succeed())
+
+		hlClient := factory.NewHumanLayerClient()
+		hlClient.SetInClusterChannel("default", "approvers")
+		hlClient.SetFunctionCallSpec("delete_file", nil)
+		hlClient.SetCallID("ec-1234567")
+		hlClient.SetRunID("my-trtc")
+		functionCall, _, err := hlClient.RequestApproval(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(functionCall.GetCallId()).To(Equal("ec-1234567"))
+
+		var request kubechainv1alpha1.ApprovalRequest
+		Expect(k8s.Get(ctx, client.ObjectKey{Namespace: "default", Name: "ec-1234567"}, &request)).To(Succeed())
+		Expect(request.Status.Phase).To(Equal(kubechainv1alpha1.ApprovalRequestPhasePending))
+		Expect(request.Status.RequestedAt).NotTo(BeNil())
+	})
+
+	It("hands other channels to the HumanLayer client", func() {
+		hlClient := factory.NewHumanLayerClient()
+		hlClient.SetSlackConfig(&kubechainv1alpha1.SlackChannelConfig{ChannelOrUserID: "C123"})
+		hlClient.SetFunctionCallSpec("delete_file", nil)
+		hlClient.SetCallID("ec-7654321")
+		_, _, err := hlClient.RequestApproval(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fallback.LastCallID).To(Equal("ec-7654321"))
+		Expect(fallback.LastFunction).To(Equal("delete_file"))
+
+		var requests kubechainv1alpha1.ApprovalRequestList
+		Expect(k8s.List(ctx, &requests)).To(Succeed())
+		Expect(requests.Items).To(BeEmpty())
+	})
+})
+
+func TestHumanLayer(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "HumanLayer Suite")
+}
@@ -21,6 +21,9 @@ type MockHumanLayerClientFactory struct {
 	LastFunction          string
 	LastArguments         map[string]interface{}
 	StatusComment         string
+	LastHumanContactMsg   string
+	HumanContactResponse  string
+	LastInClusterChannel  string
 }
 
 // MockHumanLayerClientWrapper implements HumanLayerClientWrapper for testing
@@ -28,8 +31,12 @@ type MockHumanLayerClientWrapper struct {
 	parent       *MockHumanLayerClientFactory
 	slackConfig  *kubechainv1alpha1.SlackChannelConfig
 	emailConfig  *kubechainv1alpha1.EmailChannelConfig
+	smsConfig    *kubechainv1alpha1.SMSChannelConfig
+	whatsApp     *kubechainv1alpha1.WhatsAppChannelConfig
+	inCluster    string
 	functionName string
 	functionArgs map[string]interface{}
+	humanContact string
 	callID       string
 	runID        string
 	apiKey       string
@@ -61,12 +68,32 @@ func (m *MockHumanLayerClientWrapper) SetEmailConfig(emailConfig *kubechainv1alp
 	m.emailConfig = emailConfig
 }
 
+// SetSMSConfig implements HumanLayerClientWrapper
+func (m *MockHumanLayerClientWrapper) SetSMSConfig(smsConfig *kubechainv1alpha1.SMSChannelConfig) {
+	m.smsConfig = smsConfig
+}
+
+// SetWhatsAppConfig implements HumanLayerClientWrapper
+func (m *MockHumanLayerClientWrapper) SetWhatsAppConfig(whatsAppConfig *kubechainv1alpha1.WhatsAppChannelConfig) {
+	m.whatsApp = whatsAppConfig
+}
+
+// SetInClusterChannel implements HumanLayerClientWrapper
+func (m *MockHumanLayerClientWrapper) SetInClusterChannel(namespace, name string) {
+	m.inCluster = namespace + "/" + name
+}
+
 // SetFunctionCallSpec implements HumanLayerClientWrapper
 func (m *MockHumanLayerClientWrapper) SetFunctionCallSpec(functionName string, args map[string]interface{}) {
 	m.functionName = functionName
 	m.functionArgs = args
 }
 
+// SetHumanContactSpec implements HumanLayerClientWrapper
+func (m *MockHumanLayerClientWrapper) SetHumanContactSpec(msg string) {
+	m.humanContact = msg
+}
+
 // SetCallID implements HumanLayerClientWrapper
 func (m *MockHumanLayerClientWrapper) SetCallID(callID string) {
 	m.callID = callID
@@ -123,11 +150,46 @@ func (m *MockHumanLayerClientWrapper) RequestApproval(ctx context.Context) (*hum
 	m.parent.LastRunID = m.runID
 	m.parent.LastFunction = m.functionName
 	m.parent.LastArguments = m.functionArgs
+	m.parent.LastInClusterChannel = m.inCluster
 
 	if m.parent.ShouldFail {
 		return nil, m.parent.StatusCode, m.parent.ReturnError
 	}
 
 	// Return a successful mock response
-	return &humanlayerapi.FunctionCallOutput{}, m.parent.StatusCode, nil
+	return &humanlayerapi.FunctionCallOutput{CallId: m.callID}, m.parent.StatusCode, nil
+}
+
+// RequestHumanContact implements HumanLayerClientWrapper
+func (m *MockHumanLayerClientWrapper) RequestHumanContact(ctx context.Context) (*humanlayerapi.HumanContactOutput, int, error) {
+	// Store the values in the parent for test verification
+	m.parent.LastAPIKey = m.apiKey
+	m.parent.LastCallID = m.callID
+	m.parent.LastRunID = m.runID
+	m.parent.LastHumanContactMsg = m.humanContact
+	m.parent.LastInClusterChannel = m.inCluster
+
+	if m.parent.ShouldFail {
+		return nil, m.parent.StatusCode, m.parent.ReturnError
+	}
+
+	// Return a successful mock response
+	return &humanlayerapi.HumanContactOutput{CallId: m.callID}, m.parent.StatusCode, nil
+}
+
+// GetHumanContactStatus implements HumanLayerClientWrapper
+func (m *MockHumanLayerClientWrapper) GetHumanContactStatus(ctx context.Context) (*humanlayerapi.HumanContactOutput, int, error) {
+	if m.parent.HumanContactResponse != "" {
+		now := time.Now()
+		status := humanlayerapi.NewNullableHumanContactStatus(&humanlayerapi.HumanContactStatus{
+			RequestedAt: *humanlayerapi.NewNullableTime(&now),
+			RespondedAt: *humanlayerapi.NewNullableTime(&now),
+			Response:    *humanlayerapi.NewNullableString(&m.parent.HumanContactResponse),
+		})
+		return &humanlayerapi.HumanContactOutput{
+			Status: *status,
+		}, 200, nil
+	}
+
+	return nil, m.parent.StatusCode, m.parent.ReturnError
 }
@@ -0,0 +1,208 @@
+package humanlayer
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// WebhookSignatureHeader holds the hex encoded HMAC-SHA256 of
+	// "<timestamp>.<body>" keyed with the webhook secret, optionally
+	// prefixed with "sha256="
+	WebhookSignatureHeader = "X-HumanLayer-Signature"
+	// WebhookTimestampHeader holds the unix time the webhook was signed at
+	WebhookTimestampHeader = "X-HumanLayer-Timestamp"
+
+	defaultWebhookTolerance = 5 * time.Minute
+	maxWebhookBodyBytes     = 1 << 20
+)
+
+// WebhookServer receives the webhooks HumanLayer sends when a function call
+// or human contact is answered. It rejects payloads that are not signed with
+// Secret, and signed payloads that are stale or were delivered before, then
+// hands the call ID to Route.
+type WebhookServer struct {
+	// Addr is the address to listen on
+	Addr string
+	// TLSConfig serves HTTPS when set, plain HTTP otherwise
+	TLSConfig *tls.Config
+	// Secret is the key payloads are signed with
+	Secret []byte
+	// Tolerance is how far the signing time may be off, 5 minutes by default
+	Tolerance time.Duration
+	// Route hands a call ID to whatever waits for it, and reports whether
+	// anything did
+	Route func(ctx context.Context, callID string) (bool, error)
+
+	mu   sync.Mutex
+	seen map[string]time.Time
+	now  func() time.Time
+}
+
+// webhookPayload is a function call or human contact, either as is or
+// wrapped in an event
+type webhookPayload struct {
+	CallID string `json:"call_id"`
+	Event  *struct {
+		CallID string `json:"call_id"`
+	} `json:"event"`
+}
+
+func (p webhookPayload) callID() string {
+	if p.Event != nil && p.Event.CallID != "" {
+		return p.Event.CallID
+	}
+	return p.CallID
+}
+
+// Start implements manager.Runnable
+func (s *WebhookServer) Start(ctx context.Context) error {
+	if len(s.Secret) == 0 {
+		return errors.New("the HumanLayer webhook server needs a secret to verify payloads with")
+	}
+
+	server := &http.Server{
+		Addr:              s.Addr,
+		Handler:           s.Handler(),
+		TLSConfig:         s.TLSConfig,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		log.FromContext(ctx).Info("Starting HumanLayer webhook server", "addr", s.Addr, "tls", s.TLSConfig != nil)
+		if s.TLSConfig != nil {
+			errs <- server.ListenAndServeTLS("", "")
+		} else {
+			errs <- server.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return server.Shutdown(shutdownCtx)
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Payloads are
+// handed to controllers, which only run on the leader.
+func (s *WebhookServer) NeedLeaderElection() bool {
+	return true
+}
+
+// Handler serves POST /webhook/inbound
+func (s *WebhookServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /webhook/inbound", s.inbound)
+	return mux
+}
+
+func (s *WebhookServer) inbound(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	logger := log.FromContext(ctx)
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, maxWebhookBodyBytes))
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.verify(req.Header, body); err != nil {
+		logger.Info("Rejected HumanLayer webhook", "reason", err.Error())
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var payload webhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil || payload.callID() == "" {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	routed, err := s.Route(ctx, payload.callID())
+	if err != nil {
+		logger.Error(err, "Failed to route HumanLayer webhook", "callID", payload.callID())
+		http.Error(w, "Failed to route webhook", http.StatusInternalServerError)
+		return
+	}
+	logger.Info("Received HumanLayer webhook", "callID", payload.callID(), "routed", routed)
+
+	w.Header().Set("Content-Type", "application/json")
+	if routed {
+		_, _ = w.Write([]byte(`{"status": "ok"}`))
+	} else {
+		_, _ = w.Write([]byte(`{"status": "ignored"}`))
+	}
+}
+
+// verify checks the signature and freshness of a payload, and remembers its
+// signature so the payload is only accepted once. Signatures are remembered
+// decoded, a payload can't be replayed with another spelling of the same one.
+func (s *WebhookServer) verify(header http.Header, body []byte) error {
+	timestamp := header.Get(WebhookTimestampHeader)
+	signature := strings.TrimPrefix(header.Get(WebhookSignatureHeader), "sha256=")
+	if timestamp == "" || signature == "" {
+		return errors.New("missing signature")
+	}
+
+	mac := hmac.New(sha256.New, s.Secret)
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	given, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(given, mac.Sum(nil)) {
+		return errors.New("invalid signature")
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("invalid timestamp")
+	}
+	now := time.Now()
+	if s.now != nil {
+		now = s.now()
+	}
+	tolerance := s.Tolerance
+	if tolerance == 0 {
+		tolerance = defaultWebhookTolerance
+	}
+	signedAt := time.Unix(unix, 0)
+	if signedAt.Before(now.Add(-tolerance)) || signedAt.After(now.Add(tolerance)) {
+		return errors.New("stale timestamp")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.seen == nil {
+		s.seen = make(map[string]time.Time)
+	}
+	// anything older than the tolerance is rejected as stale already
+	for seen, at := range s.seen {
+		if at.Before(now.Add(-tolerance)) {
+			delete(s.seen, seen)
+		}
+	}
+	key := hex.EncodeToString(given)
+	if _, ok := s.seen[key]; ok {
+		return errors.New("replayed payload")
+	}
+	s.seen[key] = signedAt
+
+	return nil
+}
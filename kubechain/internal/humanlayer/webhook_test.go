@@ -0,0 +1,101 @@
+package humanlayer
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WebhookServer", func() {
+	var (
+		server *WebhookServer
+		routed []string
+		now    time.Time
+	)
+
+	BeforeEach(func() {
+		routed = nil
+		now = time.Unix(1_750_000_000, 0)
+		server = &WebhookServer{
+			Secret: []byte("s3cret"),
+			Route: func(ctx context.Context, callID string) (bool, error) {
+				routed = append(routed, callID)
+				return callID != "unknown", nil
+			},
+			now: func() time.Time { return now },
+		}
+	})
+
+	sign := func(secret string, signedAt time.Time, body string) http.Header {
+		timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(timestamp + "." + body))
+		header := http.Header{}
+		header.Set(WebhookTimestampHeader, timestamp)
+		header.Set(WebhookSignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		return header
+	}
+
+	deliver := func(header http.Header, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/webhook/inbound", strings.NewReader(body))
+		req.Header = header
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	It("routes signed payloads by call ID", func() {
+		body := `{"run_id": "my-trtc", "call_id": "ec-1234567", "status": {"approved": true}}`
+		rec := deliver(sign("s3cret", now, body), body)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(ContainSubstring("ok"))
+		Expect(routed).To(Equal([]string{"ec-1234567"}))
+
+		body = `{"type": "human_contact.completed", "event": {"call_id": "hc-1234567"}}`
+		rec = deliver(sign("s3cret", now, body), body)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(routed).To(Equal([]string{"ec-1234567", "hc-1234567"}))
+	})
+
+	It("ignores payloads nothing waits for", func() {
+		body := `{"call_id": "unknown"}`
+		rec := deliver(sign("s3cret", now, body), body)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(ContainSubstring("ignored"))
+	})
+
+	It("rejects unsigned and wrongly signed payloads", func() {
+		body := `{"call_id": "ec-1234567"}`
+		Expect(deliver(http.Header{}, body).Code).To(Equal(http.StatusUnauthorized))
+		Expect(deliver(sign("wrong", now, body), body).Code).To(Equal(http.StatusUnauthorized))
+
+		Expect(deliver(sign("s3cret", now, body), `{"call_id": "ec-7654321"}`).Code).To(Equal(http.StatusUnauthorized))
+		Expect(routed).To(BeEmpty())
+	})
+
+	It("rejects stale and replayed payloads", func() {
+		body := `{"call_id": "ec-1234567"}`
+		Expect(deliver(sign("s3cret", now.Add(-10*time.Minute), body), body).Code).To(Equal(http.StatusUnauthorized))
+
+		header := sign("s3cret", now, body)
+		Expect(deliver(header, body).Code).To(Equal(http.StatusOK))
+		Expect(deliver(header, body).Code).To(Equal(http.StatusUnauthorized))
+
+		By("spelling the signature differently")
+		signature := strings.TrimPrefix(header.Get(WebhookSignatureHeader), "sha256=")
+		for _, respelled := range []string{signature, strings.ToUpper(signature), "sha256=" + strings.ToUpper(signature)} {
+			header.Set(WebhookSignatureHeader, respelled)
+			Expect(deliver(header, body).Code).To(Equal(http.StatusUnauthorized))
+		}
+		Expect(routed).To(Equal([]string{"ec-1234567"}))
+	})
+})
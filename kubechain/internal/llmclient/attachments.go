@@ -0,0 +1,136 @@
+package llmclient
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+	"github.com/humanlayer/smallchain/kubechain/internal/mcpmanager"
+)
+
+// attachmentDataKey is the context key of the attachment data of a request
+type attachmentDataKey struct{}
+
+// WithAttachmentData passes the data of the context window's attachments to
+// SendRequest, keyed by AttachmentDataKey
+func WithAttachmentData(ctx context.Context, data map[string][]byte) context.Context {
+	return context.WithValue(ctx, attachmentDataKey{}, data)
+}
+
+// attachmentDataFrom returns the attachment data passed with WithAttachmentData
+func attachmentDataFrom(ctx context.Context) map[string][]byte {
+	data, _ := ctx.Value(attachmentDataKey{}).(map[string][]byte)
+	return data
+}
+
+// AttachmentDataKey identifies the data of an attachment
+func AttachmentDataKey(selector *kubechainv1alpha1.ConfigMapKeySelector) string {
+	return selector.Name + "/" + selector.Key
+}
+
+// supportsAttachment reports whether a provider takes the data of an
+// attachment as input. Through langchaingo, OpenAI takes images, Google and
+// Vertex take images, audio and PDFs, and the others only take text.
+func supportsAttachment(provider string, attachment kubechainv1alpha1.Attachment) bool {
+	switch provider {
+	case "openai":
+		return strings.HasPrefix(attachment.MimeType, "image/")
+	case "google", "vertex":
+		return strings.HasPrefix(attachment.MimeType, "image/") ||
+			strings.HasPrefix(attachment.MimeType, "audio/") ||
+			attachment.MimeType == "application/pdf"
+	default:
+		return false
+	}
+}
+
+// attachmentPart converts an attachment the provider supports to a message part
+func attachmentPart(provider string, attachment kubechainv1alpha1.Attachment, data []byte) llms.ContentPart {
+	if provider == "openai" {
+		// OpenAI takes images by URL only
+		return llms.ImageURLContent{
+			URL: fmt.Sprintf("data:%s;base64,%s", attachment.MimeType, base64.StdEncoding.EncodeToString(data)),
+		}
+	}
+	return llms.BinaryContent{MIMEType: attachment.MimeType, Data: data}
+}
+
+// addAttachments adds the attachments of messages to their converted form.
+// Attachments the provider can't take, or whose data is missing, are
+// described in text. Tool messages hold a single tool response, so the
+// attachments of a run of tool messages follow it in a user message.
+func addAttachments(converted []llms.MessageContent, messages []kubechainv1alpha1.Message, provider string, data map[string][]byte) []llms.MessageContent {
+	result := make([]llms.MessageContent, 0, len(converted))
+	var pending []llms.ContentPart
+
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		parts := append([]llms.ContentPart{llms.TextContent{Text: "Attachments of the tool results above:"}}, pending...)
+		result = append(result, llms.MessageContent{Role: llms.ChatMessageTypeHuman, Parts: parts})
+		pending = nil
+	}
+
+	for i, message := range converted {
+		source := messages[i]
+		if message.Role != llms.ChatMessageTypeTool {
+			flush()
+		}
+
+		// only user and tool messages carry data
+		carriesData := message.Role == llms.ChatMessageTypeHuman || message.Role == llms.ChatMessageTypeTool
+
+		var parts []llms.ContentPart
+		var described []string
+		for _, attachment := range source.Attachments {
+			var attachmentData []byte
+			if attachment.DataFrom != nil {
+				attachmentData = data[AttachmentDataKey(attachment.DataFrom)]
+			}
+			if !carriesData || attachmentData == nil || !supportsAttachment(provider, attachment) {
+				described = append(described, mcpmanager.DescribeAttachment(attachment)+" (not shown)")
+				continue
+			}
+			parts = append(parts, attachmentPart(provider, attachment, attachmentData))
+		}
+
+		if len(described) > 0 {
+			message.Parts = appendText(message.Parts, strings.Join(described, "\n"))
+		}
+		if message.Role == llms.ChatMessageTypeTool {
+			pending = append(pending, parts...)
+		} else {
+			message.Parts = append(message.Parts, parts...)
+		}
+		result = append(result, message)
+	}
+	flush()
+
+	return result
+}
+
+// appendText adds text to a message, to the content of its tool response if
+// it has one
+func appendText(parts []llms.ContentPart, text string) []llms.ContentPart {
+	for i, part := range parts {
+		if response, ok := part.(llms.ToolCallResponse); ok {
+			response.Content = joinText(response.Content, text)
+			parts[i] = response
+			return parts
+		}
+	}
+	return append(parts, llms.TextContent{Text: text})
+}
+
+// joinText joins two pieces of text with a newline
+func joinText(a, b string) string {
+	if a == "" {
+		return b
+	}
+	return a + "\n" + b
+}
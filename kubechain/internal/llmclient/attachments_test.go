@@ -0,0 +1,59 @@
+package llmclient
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/tmc/langchaingo/llms"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+var _ = Describe("addAttachments", func() {
+	image := kubechainv1alpha1.Attachment{
+		Type: "image", MimeType: "image/png", Size: 3,
+		DataFrom: &kubechainv1alpha1.ConfigMapKeySelector{Name: "call-1-attachments", Key: "attachment-0"},
+	}
+	audio := kubechainv1alpha1.Attachment{
+		Type: "audio", MimeType: "audio/wav", Size: 2,
+		DataFrom: &kubechainv1alpha1.ConfigMapKeySelector{Name: "call-1-attachments", Key: "attachment-1"},
+	}
+	data := map[string][]byte{
+		"call-1-attachments/attachment-0": []byte("png"),
+		"call-1-attachments/attachment-1": []byte("wa"),
+	}
+	messages := []kubechainv1alpha1.Message{
+		{Role: "user", Content: "Plot it"},
+		{Role: "tool", ToolCallId: "call-1", Content: "Here is the chart", Attachments: []kubechainv1alpha1.Attachment{image, audio}},
+	}
+
+	It("sends images to OpenAI in a user message after the tool results", func() {
+		converted := addAttachments(convertToLangchainMessages(messages), messages, "openai", data)
+		Expect(converted).To(HaveLen(3))
+		Expect(converted[1].Parts).To(Equal([]llms.ContentPart{llms.ToolCallResponse{
+			ToolCallID: "call-1",
+			Content:    "Here is the chart\n[audio/wav audio, 2 bytes] (not shown)",
+		}}))
+		Expect(converted[2].Role).To(Equal(llms.ChatMessageTypeHuman))
+		Expect(converted[2].Parts[1:]).To(Equal([]llms.ContentPart{
+			llms.ImageURLContent{URL: "data:image/png;base64,cG5n"},
+		}))
+	})
+
+	It("sends images and audio to Google as binary content", func() {
+		converted := addAttachments(convertToLangchainMessages(messages), messages, "google", data)
+		Expect(converted).To(HaveLen(3))
+		Expect(converted[2].Parts[1:]).To(Equal([]llms.ContentPart{
+			llms.BinaryContent{MIMEType: "image/png", Data: []byte("png")},
+			llms.BinaryContent{MIMEType: "audio/wav", Data: []byte("wa")},
+		}))
+	})
+
+	It("describes attachments to text-only providers and when data is missing", func() {
+		converted := addAttachments(convertToLangchainMessages(messages), messages, "anthropic", data)
+		Expect(converted).To(HaveLen(2))
+		Expect(converted[1].Parts[0].(llms.ToolCallResponse).Content).To(ContainSubstring("[image/png image, 3 bytes] (not shown)"))
+
+		converted = addAttachments(convertToLangchainMessages(messages), messages, "openai", nil)
+		Expect(converted).To(HaveLen(2))
+	})
+})
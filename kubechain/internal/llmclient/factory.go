@@ -8,5 +8,9 @@ import (
 
 // NewLLMClient creates a new LLM client based on the LLM configuration
 func NewLLMClient(ctx context.Context, llm kubechainv1alpha1.LLM, apiKey string) (LLMClient, error) {
-	return NewLangchainClient(ctx, llm.Spec.Provider, apiKey, llm.Spec.Parameters)
+	client, err := newLangchainClient(ctx, llm.Name, llm.Spec.Provider, apiKey, llm.Spec.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
 }
@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 
+	sdk "github.com/gage-technologies/mistral-go"
 	"github.com/tmc/langchaingo/llms"
 	"github.com/tmc/langchaingo/llms/anthropic"
 	"github.com/tmc/langchaingo/llms/googleai"
@@ -18,13 +20,27 @@ import (
 
 // LangchainClient implements the LLMClient interface using langchaingo
 type LangchainClient struct {
-	model llms.Model
+	model    llms.Model
+	provider string
+	// llm and modelName identify the LLM this client calls, only reasoning
+	// that LLM produced is passed back to it
+	llm       string
+	modelName string
+	// reasoning is set when the provider's HTTP client exchanges reasoning,
+	// i.e. extended thinking on anthropic and reasoning_content on openai
+	reasoning bool
 }
 
 // NewLangchainClient creates a new client using the specified provider and credentials
 func NewLangchainClient(ctx context.Context, provider string, apiKey string, modelConfig kubechainv1alpha1.BaseConfig) (LLMClient, error) {
+	return newLangchainClient(ctx, "", provider, apiKey, modelConfig)
+}
+
+// newLangchainClient creates a client for the named LLM
+func newLangchainClient(ctx context.Context, llmName string, provider string, apiKey string, modelConfig kubechainv1alpha1.BaseConfig) (*LangchainClient, error) {
 	var model llms.Model
 	var err error
+	reasoning := false
 
 	switch provider {
 	case "openai":
@@ -35,6 +51,8 @@ func NewLangchainClient(ctx context.Context, provider string, apiKey string, mod
 		if modelConfig.BaseURL != "" {
 			opts = append(opts, openai.WithBaseURL(modelConfig.BaseURL))
 		}
+		opts = append(opts, openai.WithHTTPClient(&openaiReasoning{client: http.DefaultClient}))
+		reasoning = true
 		model, err = openai.New(opts...)
 	case "anthropic":
 		opts := []anthropic.Option{anthropic.WithToken(apiKey)}
@@ -44,6 +62,13 @@ func NewLangchainClient(ctx context.Context, provider string, apiKey string, mod
 		if modelConfig.BaseURL != "" {
 			opts = append(opts, anthropic.WithBaseURL(modelConfig.BaseURL))
 		}
+		if modelConfig.Reasoning != nil {
+			opts = append(opts, anthropic.WithHTTPClient(&anthropicThinking{
+				client:       http.DefaultClient,
+				budgetTokens: modelConfig.Reasoning.BudgetTokens,
+			}))
+			reasoning = true
+		}
 		model, err = anthropic.New(opts...)
 	case "mistral":
 		opts := []mistral.Option{mistral.WithAPIKey(apiKey)}
@@ -74,7 +99,7 @@ func NewLangchainClient(ctx context.Context, provider string, apiKey string, mod
 		return nil, fmt.Errorf("failed to initialize %s client: %w", provider, err)
 	}
 
-	return &LangchainClient{model: model}, nil
+	return &LangchainClient{model: model, provider: provider, llm: llmName, modelName: modelConfig.Model, reasoning: reasoning}, nil
 }
 
 // SendRequest implements the LLMClient interface
@@ -83,9 +108,13 @@ func (c *LangchainClient) SendRequest(ctx context.Context, messages []kubechainv
 
 	// Convert messages to langchaingo format
 	langchainMessages := convertToLangchainMessages(messages)
+	langchainMessages = addAttachments(langchainMessages, messages, c.provider, attachmentDataFrom(ctx))
 
 	// Convert tools to langchaingo format
-	langchainTools := convertToLangchainTools(tools)
+	langchainTools, err := convertToLangchainTools(tools, c.provider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert tools: %w", err)
+	}
 
 	// Prepare options
 	options := []llms.CallOption{}
@@ -96,6 +125,13 @@ func (c *LangchainClient) SendRequest(ctx context.Context, messages []kubechainv
 			"toolCount", len(langchainTools))
 	}
 
+	// Pass earlier reasoning to the provider and collect the new one
+	var exchange *reasoningExchange
+	if c.reasoning {
+		exchange = newReasoningExchange(messages, c.llm, c.modelName)
+		ctx = withReasoningExchange(ctx, exchange)
+	}
+
 	// Make the API call
 	response, err := c.model.GenerateContent(ctx, langchainMessages, options...)
 	if err != nil {
@@ -109,7 +145,11 @@ func (c *LangchainClient) SendRequest(ctx context.Context, messages []kubechainv
 	}
 
 	// Convert response back to Kubechain format
-	return convertFromLangchainResponse(response), nil
+	message := convertFromLangchainResponse(response)
+	if exchange != nil && len(exchange.response) > 0 {
+		message.Reasoning = exchange.response
+	}
+	return message, nil
 }
 
 // convertToLangchainMessages converts Kubechain messages to langchaingo format
@@ -182,22 +222,42 @@ func convertToLangchainMessages(messages []kubechainv1alpha1.Message) []llms.Mes
 	return langchainMessages
 }
 
-// convertToLangchainTools converts Kubechain tools to langchaingo format
-func convertToLangchainTools(tools []Tool) []llms.Tool {
+// convertToLangchainTools converts Kubechain tools to langchaingo format.
+// The full parameter schema is passed through; for providers that can't
+// follow $ref/$defs the references are inlined first, and the schema is
+// handed over in generic map form.
+func convertToLangchainTools(tools []Tool, provider string) ([]llms.Tool, error) {
 	langchainTools := make([]llms.Tool, 0, len(tools))
 
 	for _, tool := range tools {
+		var parameters any = tool.Function.Parameters
+		if !providersWithSchemaRefs[provider] {
+			resolved, err := ResolveSchemaRefs(tool.Function.Parameters)
+			if err != nil {
+				return nil, fmt.Errorf("tool %s: %w", tool.Function.Name, err)
+			}
+			schemaMap, err := SchemaToMap(resolved)
+			if err != nil {
+				return nil, fmt.Errorf("tool %s: %w", tool.Function.Name, err)
+			}
+			// some providers insist on a properties map for object schemas
+			if _, ok := schemaMap["properties"]; !ok {
+				schemaMap["properties"] = map[string]any{}
+			}
+			parameters = schemaMap
+		}
+
 		langchainTools = append(langchainTools, llms.Tool{
 			Type: tool.Type,
 			Function: &llms.FunctionDefinition{
 				Name:        tool.Function.Name,
 				Description: tool.Function.Description,
-				Parameters:  tool.Function.Parameters,
+				Parameters:  parameters,
 			},
 		})
 	}
 
-	return langchainTools
+	return langchainTools, nil
 }
 
 // convertFromLangchainResponse converts a langchaingo response to Kubechain format.
@@ -224,6 +284,25 @@ func convertFromLangchainResponse(response *llms.ContentResponse) *kubechainv1al
 	var contentText string
 	var hasContent bool
 
+	// Providers report the usage of the whole request on each choice, take the first
+	for _, choice := range response.Choices {
+		if usage := extractUsage(choice.GenerationInfo); usage != nil {
+			message.Usage = usage
+			break
+		}
+	}
+
+	// Keep reasoning returned by OpenAI-compatible APIs apart from the content
+	for _, choice := range response.Choices {
+		if choice.ReasoningContent != "" {
+			message.Reasoning = []kubechainv1alpha1.ReasoningBlock{{
+				Type: reasoningTypeContent,
+				Text: choice.ReasoningContent,
+			}}
+			break
+		}
+	}
+
 	// Process all choices to collect content and tool calls
 	for i, choice := range response.Choices {
 		// Extract content from the first non-empty choice
@@ -279,6 +358,59 @@ func convertFromLangchainResponse(response *llms.ContentResponse) *kubechainv1al
 	return message
 }
 
+// usageKeys are the GenerationInfo keys langchaingo providers use for token counts
+var usageKeys = []struct{ input, output string }{
+	{"PromptTokens", "CompletionTokens"}, // openai
+	{"InputTokens", "OutputTokens"},      // anthropic
+	{"input_tokens", "output_tokens"},    // google, vertex
+}
+
+// extractUsage reads the token usage from a choice's generation info
+func extractUsage(info map[string]any) *kubechainv1alpha1.TokenUsage {
+	if info == nil {
+		return nil
+	}
+
+	for _, keys := range usageKeys {
+		input, okIn := toInt64(info[keys.input])
+		output, okOut := toInt64(info[keys.output])
+		if okIn || okOut {
+			usage := &kubechainv1alpha1.TokenUsage{InputTokens: input, OutputTokens: output}
+			// openai reports the reasoning part of the output tokens
+			if reasoning, ok := toInt64(info["ReasoningTokens"]); ok {
+				usage.ReasoningTokens = reasoning
+			}
+			return usage
+		}
+	}
+
+	// mistral reports a usage struct
+	if usage, ok := info["usage"].(sdk.UsageInfo); ok {
+		return &kubechainv1alpha1.TokenUsage{
+			InputTokens:  int64(usage.PromptTokens),
+			OutputTokens: int64(usage.CompletionTokens),
+		}
+	}
+
+	return nil
+}
+
+// toInt64 converts the numeric types found in generation info
+func toInt64(v any) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case float64:
+		return int64(n), true
+	default:
+		return 0, false
+	}
+}
+
 // truncateString truncates a string to the specified length if needed
 func truncateString(s string, maxLength int) string {
 	if len(s) <= maxLength {
@@ -305,6 +437,15 @@ func FromKubechainTool(tool kubechainv1alpha1.Tool) *Tool {
 			return nil
 		}
 		clientTool.Function.Parameters = params
+	} else if tool.Spec.Execute.HumanContact != nil {
+		// humans are asked a question
+		clientTool.Function.Parameters = ToolFunctionParameters{
+			Type: "object",
+			Properties: map[string]ToolFunctionParameter{
+				"question": {Type: "string", Description: "The question to ask the human"},
+			},
+			Required: []string{"question"},
+		}
 	} else {
 		// Default to a simple object schema if none provided
 		clientTool.Function.Parameters = ToolFunctionParameters{
@@ -0,0 +1,41 @@
+package llmclient
+
+import (
+	sdk "github.com/gage-technologies/mistral-go"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/tmc/langchaingo/llms"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+var _ = Describe("convertFromLangchainResponse", func() {
+	It("keeps reasoning content apart from the answer", func() {
+		message := convertFromLangchainResponse(&llms.ContentResponse{
+			Choices: []*llms.ContentChoice{{Content: "42", ReasoningContent: "6 times 7"}},
+		})
+		Expect(message.Content).To(Equal("42"))
+		Expect(message.Reasoning).To(Equal([]kubechainv1alpha1.ReasoningBlock{{Type: "reasoning_content", Text: "6 times 7"}}))
+	})
+
+	DescribeTable("extracts token usage from the generation info",
+		func(info map[string]any, expected *kubechainv1alpha1.TokenUsage) {
+			message := convertFromLangchainResponse(&llms.ContentResponse{
+				Choices: []*llms.ContentChoice{{Content: "hi", GenerationInfo: info}},
+			})
+			Expect(message.Content).To(Equal("hi"))
+			Expect(message.Usage).To(Equal(expected))
+		},
+		Entry("openai", map[string]any{"PromptTokens": 12, "CompletionTokens": 3},
+			&kubechainv1alpha1.TokenUsage{InputTokens: 12, OutputTokens: 3}),
+		Entry("openai with reasoning", map[string]any{"PromptTokens": 12, "CompletionTokens": 30, "ReasoningTokens": 20},
+			&kubechainv1alpha1.TokenUsage{InputTokens: 12, OutputTokens: 30, ReasoningTokens: 20}),
+		Entry("anthropic", map[string]any{"InputTokens": 20, "OutputTokens": 5},
+			&kubechainv1alpha1.TokenUsage{InputTokens: 20, OutputTokens: 5}),
+		Entry("google", map[string]any{"input_tokens": int32(7), "output_tokens": int32(2)},
+			&kubechainv1alpha1.TokenUsage{InputTokens: 7, OutputTokens: 2}),
+		Entry("mistral", map[string]any{"usage": sdk.UsageInfo{PromptTokens: 9, CompletionTokens: 4}},
+			&kubechainv1alpha1.TokenUsage{InputTokens: 9, OutputTokens: 4}),
+		Entry("no usage", map[string]any{"model": "x"}, nil),
+	)
+})
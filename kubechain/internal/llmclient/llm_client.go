@@ -2,6 +2,7 @@ package llmclient
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
@@ -42,14 +43,29 @@ type ToolFunction struct {
 	Parameters  ToolFunctionParameters `json:"parameters"`
 }
 
-// ToolFunctionParameter defines a parameter type
+// ToolFunctionParameter is a JSON Schema node describing a parameter. The
+// commonly used keywords are exposed as fields; any other keyword (minimum,
+// pattern, title, ...) is kept in Extra so the schema reaches the provider intact.
 type ToolFunctionParameter struct {
-	Type string `json:"type"`
+	Type                 string                           `json:"type,omitempty"`
+	Description          string                           `json:"description,omitempty"`
+	Enum                 []interface{}                    `json:"enum,omitempty"`
+	Default              interface{}                      `json:"default,omitempty"`
+	Format               string                           `json:"format,omitempty"`
+	Items                *ToolFunctionParameter           `json:"items,omitempty"`
+	Properties           map[string]ToolFunctionParameter `json:"properties,omitempty"`
+	Required             []string                         `json:"required,omitempty"`
+	AdditionalProperties interface{}                      `json:"additionalProperties,omitempty"`
+	AnyOf                []ToolFunctionParameter          `json:"anyOf,omitempty"`
+	OneOf                []ToolFunctionParameter          `json:"oneOf,omitempty"`
+	AllOf                []ToolFunctionParameter          `json:"allOf,omitempty"`
+	Ref                  string                           `json:"$ref,omitempty"`
+	Defs                 map[string]ToolFunctionParameter `json:"$defs,omitempty"`
+	Definitions          map[string]ToolFunctionParameter `json:"definitions,omitempty"`
+
+	// Extra holds schema keywords that have no dedicated field
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // ToolFunctionParameters defines the schema for the function parameters
-type ToolFunctionParameters struct {
-	Type       string                           `json:"type"`
-	Properties map[string]ToolFunctionParameter `json:"properties"`
-	Required   []string                         `json:"required,omitempty"`
-}
+type ToolFunctionParameters = ToolFunctionParameter
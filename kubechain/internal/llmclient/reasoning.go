@@ -0,0 +1,294 @@
+package llmclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+// Anthropic reasoning block types
+const (
+	reasoningTypeThinking         = "thinking"
+	reasoningTypeRedactedThinking = "redacted_thinking"
+	// reasoningTypeContent marks the reasoning_content returned by OpenAI-compatible APIs
+	reasoningTypeContent = "reasoning_content"
+)
+
+// thinkingResponseTokens is added on top of the thinking budget when the
+// request's max_tokens would leave no room for the answer
+const thinkingResponseTokens = 2048
+
+// reasoningExchangeKey is the context key of the reasoningExchange of a request
+type reasoningExchangeKey struct{}
+
+// reasoningExchange carries reasoning between SendRequest and the HTTP layer
+// for a single request
+type reasoningExchange struct {
+	// history holds the reasoning of each assistant message sent, in order
+	history [][]kubechainv1alpha1.ReasoningBlock
+	// response receives the reasoning of the response
+	response []kubechainv1alpha1.ReasoningBlock
+}
+
+// newReasoningExchange collects the reasoning of the assistant messages that
+// the provider needs to see again. Signatures only verify for the model that
+// made them, so the turns of other LLMs, e.g. picked by a ModelRouter, keep
+// their place in the history without reasoning.
+func newReasoningExchange(messages []kubechainv1alpha1.Message, llm, model string) *reasoningExchange {
+	exchange := &reasoningExchange{}
+	for _, message := range messages {
+		if message.Role != "assistant" {
+			continue
+		}
+		var blocks []kubechainv1alpha1.ReasoningBlock
+		if message.LLM != llm || message.Model != model {
+			exchange.history = append(exchange.history, blocks)
+			continue
+		}
+		for _, block := range message.Reasoning {
+			if block.Type == reasoningTypeThinking || block.Type == reasoningTypeRedactedThinking {
+				blocks = append(blocks, block)
+			}
+		}
+		exchange.history = append(exchange.history, blocks)
+	}
+	return exchange
+}
+
+// anthropicThinking is an HTTP client for langchaingo's anthropic provider
+// that enables extended thinking, which langchaingo can't express. It adds the
+// thinking configuration and the reasoning of earlier assistant turns to
+// Messages API requests, and lifts the thinking blocks out of the response
+// before langchaingo parses it.
+type anthropicThinking struct {
+	client       *http.Client
+	budgetTokens int
+}
+
+// Do implements anthropicclient.Doer
+func (t *anthropicThinking) Do(req *http.Request) (*http.Response, error) {
+	exchange, _ := req.Context().Value(reasoningExchangeKey{}).(*reasoningExchange)
+	if exchange == nil || req.Method != http.MethodPost || !strings.HasSuffix(req.URL.Path, "/messages") {
+		return t.client.Do(req)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	body, err = addThinking(body, t.budgetTokens, exchange.history)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	respBody, exchange.response, err = liftThinking(respBody)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	resp.ContentLength = int64(len(respBody))
+	resp.Header.Del("Content-Length")
+	return resp, nil
+}
+
+// addThinking enables thinking on a Messages API request body and prepends
+// the recorded reasoning to the assistant messages
+func addThinking(body []byte, budgetTokens int, history [][]kubechainv1alpha1.ReasoningBlock) ([]byte, error) {
+	var payload map[string]json.RawMessage
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("failed to parse request body: %w", err)
+	}
+
+	thinking, _ := json.Marshal(map[string]any{"type": "enabled", "budget_tokens": budgetTokens})
+	payload["thinking"] = thinking
+	// thinking requires the default temperature
+	payload["temperature"] = json.RawMessage("1")
+
+	maxTokens, _ := strconv.Atoi(string(payload["max_tokens"]))
+	if maxTokens <= budgetTokens {
+		payload["max_tokens"] = json.RawMessage(strconv.Itoa(budgetTokens + thinkingResponseTokens))
+	}
+
+	var messages []map[string]json.RawMessage
+	if err := json.Unmarshal(payload["messages"], &messages); err != nil {
+		return nil, fmt.Errorf("failed to parse request messages: %w", err)
+	}
+	assistantIndex := 0
+	for _, message := range messages {
+		var role string
+		_ = json.Unmarshal(message["role"], &role)
+		if role != "assistant" {
+			continue
+		}
+		if assistantIndex < len(history) && len(history[assistantIndex]) > 0 {
+			content, err := prependReasoning(message["content"], history[assistantIndex])
+			if err != nil {
+				return nil, err
+			}
+			message["content"] = content
+		}
+		assistantIndex++
+	}
+	rawMessages, err := json.Marshal(messages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request messages: %w", err)
+	}
+	payload["messages"] = rawMessages
+
+	return json.Marshal(payload)
+}
+
+// prependReasoning puts reasoning blocks in front of a message's content,
+// as Anthropic expects them
+func prependReasoning(content json.RawMessage, blocks []kubechainv1alpha1.ReasoningBlock) (json.RawMessage, error) {
+	var parts []json.RawMessage
+	var text string
+	if err := json.Unmarshal(content, &text); err == nil {
+		textPart, _ := json.Marshal(map[string]string{"type": "text", "text": text})
+		parts = []json.RawMessage{textPart}
+	} else if err := json.Unmarshal(content, &parts); err != nil {
+		return nil, fmt.Errorf("failed to parse message content: %w", err)
+	}
+
+	withReasoning := make([]any, 0, len(blocks)+len(parts))
+	for _, block := range blocks {
+		if block.Type == reasoningTypeRedactedThinking {
+			withReasoning = append(withReasoning, map[string]string{"type": block.Type, "data": block.Data})
+		} else {
+			withReasoning = append(withReasoning, map[string]string{"type": block.Type, "thinking": block.Text, "signature": block.Signature})
+		}
+	}
+	for _, part := range parts {
+		withReasoning = append(withReasoning, part)
+	}
+	return json.Marshal(withReasoning)
+}
+
+// liftThinking removes the thinking blocks from a Messages API response body
+// and returns them as reasoning
+func liftThinking(body []byte) ([]byte, []kubechainv1alpha1.ReasoningBlock, error) {
+	var payload map[string]json.RawMessage
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse response body: %w", err)
+	}
+	var content []json.RawMessage
+	if err := json.Unmarshal(payload["content"], &content); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse response content: %w", err)
+	}
+
+	var reasoning []kubechainv1alpha1.ReasoningBlock
+	kept := make([]json.RawMessage, 0, len(content))
+	for _, part := range content {
+		var block struct {
+			Type      string `json:"type"`
+			Thinking  string `json:"thinking"`
+			Signature string `json:"signature"`
+			Data      string `json:"data"`
+		}
+		if err := json.Unmarshal(part, &block); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse response content block: %w", err)
+		}
+		switch block.Type {
+		case reasoningTypeThinking, reasoningTypeRedactedThinking:
+			reasoning = append(reasoning, kubechainv1alpha1.ReasoningBlock{
+				Type:      block.Type,
+				Text:      block.Thinking,
+				Signature: block.Signature,
+				Data:      block.Data,
+			})
+		default:
+			kept = append(kept, part)
+		}
+	}
+
+	rawContent, err := json.Marshal(kept)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode response content: %w", err)
+	}
+	payload["content"] = rawContent
+	body, err = json.Marshal(payload)
+	return body, reasoning, err
+}
+
+// openaiReasoning is an HTTP client for langchaingo's openai provider that
+// lifts the reasoning_content of OpenAI-compatible APIs, e.g. DeepSeek, out
+// of chat completion responses, langchaingo drops it. The reasoning is only
+// recorded, these APIs reject reasoning_content in the messages of a request.
+type openaiReasoning struct {
+	client *http.Client
+}
+
+// Do implements openaiclient.Doer
+func (t *openaiReasoning) Do(req *http.Request) (*http.Response, error) {
+	exchange, _ := req.Context().Value(reasoningExchangeKey{}).(*reasoningExchange)
+	if exchange == nil || req.Method != http.MethodPost || !strings.HasSuffix(req.URL.Path, "/chat/completions") {
+		return t.client.Do(req)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	exchange.response = liftReasoningContent(body)
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// liftReasoningContent returns the reasoning_content of the first choice
+// that has one. Bodies it can't parse carry no reasoning, langchaingo
+// reports them.
+func liftReasoningContent(body []byte) []kubechainv1alpha1.ReasoningBlock {
+	var payload struct {
+		Choices []struct {
+			Message struct {
+				ReasoningContent string `json:"reasoning_content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil
+	}
+	for _, choice := range payload.Choices {
+		if choice.Message.ReasoningContent != "" {
+			return []kubechainv1alpha1.ReasoningBlock{{
+				Type: reasoningTypeContent,
+				Text: choice.Message.ReasoningContent,
+			}}
+		}
+	}
+	return nil
+}
+
+// withReasoningExchange attaches a reasoning exchange to a request context
+func withReasoningExchange(ctx context.Context, exchange *reasoningExchange) context.Context {
+	return context.WithValue(ctx, reasoningExchangeKey{}, exchange)
+}
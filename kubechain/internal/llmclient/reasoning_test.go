@@ -0,0 +1,200 @@
+package llmclient
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+var _ = Describe("Anthropic extended thinking", func() {
+	var server *httptest.Server
+	var requests []map[string]any
+
+	BeforeEach(func() {
+		requests = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			var request map[string]any
+			_ = json.Unmarshal(body, &request)
+			requests = append(requests, request)
+
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{
+				"id": "msg_1", "type": "message", "role": "assistant", "model": "claude-3-7-sonnet",
+				"stop_reason": "tool_use",
+				"content": [
+					{"type": "thinking", "thinking": "The user wants the weather, I should call the tool.", "signature": "sig-1"},
+					{"type": "redacted_thinking", "data": "encrypted"},
+					{"type": "tool_use", "id": "toolu_1", "name": "get_weather", "input": {"city": "Paris"}}
+				],
+				"usage": {"input_tokens": 50, "output_tokens": 120}
+			}`))
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	newClient := func() LLMClient {
+		client, err := NewLangchainClient(context.Background(), "anthropic", "test-key", kubechainv1alpha1.BaseConfig{
+			Model:     "claude-3-7-sonnet",
+			BaseURL:   server.URL,
+			Reasoning: &kubechainv1alpha1.ReasoningConfig{BudgetTokens: 4000},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		return client
+	}
+
+	It("enables thinking and keeps the reasoning apart from the answer", func() {
+		message, err := newClient().SendRequest(context.Background(), []kubechainv1alpha1.Message{
+			{Role: "system", Content: "you are helpful"},
+			{Role: "user", Content: "weather in Paris?"},
+		}, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0]["thinking"]).To(Equal(map[string]any{"type": "enabled", "budget_tokens": float64(4000)}))
+		Expect(requests[0]["temperature"]).To(Equal(float64(1)))
+		Expect(requests[0]["max_tokens"]).To(BeNumerically(">", 4000))
+
+		Expect(message.ToolCalls).To(HaveLen(1))
+		Expect(message.ToolCalls[0].Function.Name).To(Equal("get_weather"))
+		Expect(message.Content).To(BeEmpty())
+		Expect(message.Reasoning).To(Equal([]kubechainv1alpha1.ReasoningBlock{
+			{Type: "thinking", Text: "The user wants the weather, I should call the tool.", Signature: "sig-1"},
+			{Type: "redacted_thinking", Data: "encrypted"},
+		}))
+	})
+
+	It("passes the reasoning of earlier turns back", func() {
+		_, err := newClient().SendRequest(context.Background(), []kubechainv1alpha1.Message{
+			{Role: "system", Content: "you are helpful"},
+			{Role: "user", Content: "weather in Paris?"},
+			{
+				Role: "assistant",
+				ToolCalls: []kubechainv1alpha1.ToolCall{{
+					ID: "toolu_1", Type: "function",
+					Function: kubechainv1alpha1.ToolCallFunction{Name: "get_weather", Arguments: `{"city":"Paris"}`},
+				}},
+				Reasoning: []kubechainv1alpha1.ReasoningBlock{
+					{Type: "thinking", Text: "call the tool", Signature: "sig-0"},
+				},
+				Model: "claude-3-7-sonnet",
+			},
+			{Role: "tool", ToolCallId: "toolu_1", Content: "sunny"},
+		}, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(requests).To(HaveLen(1))
+		messages := requests[0]["messages"].([]any)
+		Expect(messages).To(HaveLen(3))
+		assistant := messages[1].(map[string]any)
+		Expect(assistant["role"]).To(Equal("assistant"))
+		content := assistant["content"].([]any)
+		Expect(content).To(HaveLen(2))
+		Expect(content[0]).To(Equal(map[string]any{"type": "thinking", "thinking": "call the tool", "signature": "sig-0"}))
+		Expect(content[1].(map[string]any)["type"]).To(Equal("tool_use"))
+	})
+
+	It("passes back only the reasoning of the LLM being called", func() {
+		client, err := newLangchainClient(context.Background(), "sonnet", "anthropic", "test-key", kubechainv1alpha1.BaseConfig{
+			Model:     "claude-3-7-sonnet",
+			BaseURL:   server.URL,
+			Reasoning: &kubechainv1alpha1.ReasoningConfig{BudgetTokens: 4000},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = client.SendRequest(context.Background(), []kubechainv1alpha1.Message{
+			{Role: "system", Content: "you are helpful"},
+			{Role: "user", Content: "weather in Paris?"},
+			{
+				Role:    "assistant",
+				Content: "Let me think about Paris.",
+				Reasoning: []kubechainv1alpha1.ReasoningBlock{
+					{Type: "thinking", Text: "signed by another model", Signature: "sig-opus"},
+				},
+				LLM:   "opus",
+				Model: "claude-opus-4",
+			},
+			{Role: "user", Content: "and in Lyon?"},
+			{
+				Role:    "assistant",
+				Content: "Let me think about Lyon.",
+				Reasoning: []kubechainv1alpha1.ReasoningBlock{
+					{Type: "thinking", Text: "signed by this llm", Signature: "sig-sonnet"},
+				},
+				LLM:   "sonnet",
+				Model: "claude-3-7-sonnet",
+			},
+			{Role: "user", Content: "and in Nice?"},
+		}, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(requests).To(HaveLen(1))
+		messages := requests[0]["messages"].([]any)
+		Expect(messages).To(HaveLen(5))
+		Expect(messages[1].(map[string]any)["content"]).To(Equal([]any{
+			map[string]any{"type": "text", "text": "Let me think about Paris."},
+		}))
+		Expect(messages[3].(map[string]any)["content"]).To(Equal([]any{
+			map[string]any{"type": "thinking", "thinking": "signed by this llm", "signature": "sig-sonnet"},
+			map[string]any{"type": "text", "text": "Let me think about Lyon."},
+		}))
+	})
+})
+
+var _ = Describe("OpenAI-compatible reasoning", func() {
+	It("records reasoning_content without passing it back", func() {
+		var requests []map[string]any
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			var request map[string]any
+			_ = json.Unmarshal(body, &request)
+			requests = append(requests, request)
+
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{
+				"id": "chatcmpl-1", "object": "chat.completion", "model": "deepseek-reasoner",
+				"choices": [{
+					"index": 0, "finish_reason": "stop",
+					"message": {"role": "assistant", "content": "It is sunny.", "reasoning_content": "Paris is usually sunny in June."}
+				}],
+				"usage": {"prompt_tokens": 20, "completion_tokens": 30, "total_tokens": 50}
+			}`))
+		}))
+		defer server.Close()
+
+		client, err := newLangchainClient(context.Background(), "deepseek", "openai", "test-key", kubechainv1alpha1.BaseConfig{
+			Model:   "deepseek-reasoner",
+			BaseURL: server.URL,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		history := []kubechainv1alpha1.Message{{Role: "user", Content: "weather in Paris?"}}
+		answer, err := client.SendRequest(context.Background(), history, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(answer.Content).To(Equal("It is sunny."))
+		Expect(answer.Reasoning).To(Equal([]kubechainv1alpha1.ReasoningBlock{
+			{Type: "reasoning_content", Text: "Paris is usually sunny in June."},
+		}))
+
+		answer.LLM, answer.Model = "deepseek", "deepseek-reasoner"
+		history = append(history, *answer, kubechainv1alpha1.Message{Role: "user", Content: "and in Lyon?"})
+		_, err = client.SendRequest(context.Background(), history, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(requests).To(HaveLen(2))
+		messages := requests[1]["messages"].([]any)
+		Expect(messages).To(HaveLen(3))
+		Expect(messages[1]).To(HaveKeyWithValue("role", "assistant"))
+		Expect(messages[1]).NotTo(HaveKey("reasoning_content"))
+	})
+})
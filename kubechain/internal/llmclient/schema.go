@@ -0,0 +1,286 @@
+package llmclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// providersWithSchemaRefs lists the providers that accept $ref/$defs in tool
+// parameter schemas. Every other provider gets the references inlined.
+var providersWithSchemaRefs = map[string]bool{
+	"openai":    true,
+	"anthropic": true,
+}
+
+// schemaMapKeywords hold a map of name -> subschema
+var schemaMapKeywords = map[string]bool{
+	"properties":        true,
+	"patternProperties": true,
+	"$defs":             true,
+	"definitions":       true,
+}
+
+// schemaListKeywords hold a list of subschemas
+var schemaListKeywords = map[string]bool{
+	"anyOf":       true,
+	"oneOf":       true,
+	"allOf":       true,
+	"prefixItems": true,
+}
+
+// schemaKeywords hold a single subschema (or a boolean)
+var schemaKeywords = map[string]bool{
+	"items":                true,
+	"additionalProperties": true,
+	"not":                  true,
+	"contains":             true,
+}
+
+// UnmarshalJSON decodes a schema node, keeping keywords that don't fit a
+// dedicated field (or that have an unexpected shape, e.g. "type": ["string", "null"])
+// in Extra. A field that fails to decode is cleared again, a partly decoded
+// value would hide the raw one from MarshalJSON.
+func (p *ToolFunctionParameter) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*p = ToolFunctionParameter{}
+	fields := map[string]interface{}{
+		"type":                 &p.Type,
+		"description":          &p.Description,
+		"enum":                 &p.Enum,
+		"default":              &p.Default,
+		"format":               &p.Format,
+		"items":                &p.Items,
+		"properties":           &p.Properties,
+		"required":             &p.Required,
+		"additionalProperties": &p.AdditionalProperties,
+		"anyOf":                &p.AnyOf,
+		"oneOf":                &p.OneOf,
+		"allOf":                &p.AllOf,
+		"$ref":                 &p.Ref,
+		"$defs":                &p.Defs,
+		"definitions":          &p.Definitions,
+	}
+
+	for key, value := range raw {
+		if field, ok := fields[key]; ok {
+			if err := json.Unmarshal(value, field); err == nil {
+				continue
+			}
+			target := reflect.ValueOf(field).Elem()
+			target.Set(reflect.Zero(target.Type()))
+		}
+		if p.Extra == nil {
+			p.Extra = map[string]json.RawMessage{}
+		}
+		p.Extra[key] = value
+	}
+
+	return nil
+}
+
+// MarshalJSON encodes the schema node including any Extra keywords
+func (p ToolFunctionParameter) MarshalJSON() ([]byte, error) {
+	type plain ToolFunctionParameter
+	data, err := json.Marshal(plain(p))
+	if err != nil {
+		return nil, err
+	}
+
+	var out map[string]json.RawMessage
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+
+	// an explicitly empty properties map is meaningful for object schemas
+	if p.Properties != nil {
+		if _, ok := out["properties"]; !ok {
+			out["properties"] = json.RawMessage("{}")
+		}
+	}
+
+	for key, value := range p.Extra {
+		if _, ok := out[key]; !ok {
+			out[key] = value
+		}
+	}
+
+	return json.Marshal(out)
+}
+
+// ResolveSchemaRefs returns a copy of the schema with every local $ref
+// ("#/$defs/...", "#/definitions/...", or any other JSON pointer into the
+// schema) inlined and the definitions removed. Recursive references are cut
+// off and replaced by a plain object schema.
+func ResolveSchemaRefs(schema ToolFunctionParameters) (ToolFunctionParameters, error) {
+	root, err := SchemaToMap(schema)
+	if err != nil {
+		return ToolFunctionParameters{}, err
+	}
+
+	resolved, err := resolveSchemaNode(root, root, map[string]bool{})
+	if err != nil {
+		return ToolFunctionParameters{}, err
+	}
+
+	data, err := json.Marshal(resolved)
+	if err != nil {
+		return ToolFunctionParameters{}, fmt.Errorf("failed to encode resolved schema: %w", err)
+	}
+
+	var result ToolFunctionParameters
+	if err := json.Unmarshal(data, &result); err != nil {
+		return ToolFunctionParameters{}, fmt.Errorf("failed to decode resolved schema: %w", err)
+	}
+	return result, nil
+}
+
+// SchemaToMap converts a schema to its generic map form
+func SchemaToMap(schema ToolFunctionParameters) (map[string]interface{}, error) {
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode schema: %w", err)
+	}
+
+	var out map[string]interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("failed to decode schema: %w", err)
+	}
+	return out, nil
+}
+
+// resolveSchemaNode inlines references in a node that sits in schema position
+func resolveSchemaNode(root map[string]interface{}, node interface{}, inProgress map[string]bool) (interface{}, error) {
+	schema, ok := node.(map[string]interface{})
+	if !ok {
+		// boolean schemas and anything unexpected pass through untouched
+		return node, nil
+	}
+
+	out := map[string]interface{}{}
+
+	if ref, ok := schema["$ref"].(string); ok {
+		if inProgress[ref] {
+			out["type"] = "object"
+		} else {
+			target, err := lookupSchemaPointer(root, ref)
+			if err != nil {
+				return nil, err
+			}
+
+			inProgress[ref] = true
+			resolved, err := resolveSchemaNode(root, target, inProgress)
+			delete(inProgress, ref)
+			if err != nil {
+				return nil, err
+			}
+
+			if resolvedMap, ok := resolved.(map[string]interface{}); ok {
+				for key, value := range resolvedMap {
+					out[key] = value
+				}
+			}
+		}
+	}
+
+	for key, value := range schema {
+		var err error
+		switch {
+		case key == "$ref", key == "$defs", key == "definitions":
+			continue
+		case schemaMapKeywords[key]:
+			out[key], err = resolveSchemaMap(root, value, inProgress)
+		case schemaListKeywords[key]:
+			out[key], err = resolveSchemaList(root, value, inProgress)
+		case schemaKeywords[key]:
+			out[key], err = resolveSchemaNode(root, value, inProgress)
+		default:
+			// sibling keywords of a $ref override the referenced schema
+			out[key] = value
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+// resolveSchemaMap inlines references in a name -> subschema map
+func resolveSchemaMap(root map[string]interface{}, node interface{}, inProgress map[string]bool) (interface{}, error) {
+	schemas, ok := node.(map[string]interface{})
+	if !ok {
+		return node, nil
+	}
+
+	out := make(map[string]interface{}, len(schemas))
+	for name, schema := range schemas {
+		resolved, err := resolveSchemaNode(root, schema, inProgress)
+		if err != nil {
+			return nil, err
+		}
+		out[name] = resolved
+	}
+	return out, nil
+}
+
+// resolveSchemaList inlines references in a list of subschemas
+func resolveSchemaList(root map[string]interface{}, node interface{}, inProgress map[string]bool) (interface{}, error) {
+	schemas, ok := node.([]interface{})
+	if !ok {
+		return node, nil
+	}
+
+	out := make([]interface{}, 0, len(schemas))
+	for _, schema := range schemas {
+		resolved, err := resolveSchemaNode(root, schema, inProgress)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, resolved)
+	}
+	return out, nil
+}
+
+// lookupSchemaPointer follows a local JSON pointer reference such as "#/$defs/address"
+func lookupSchemaPointer(root map[string]interface{}, ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("unsupported $ref %q: only local references are supported", ref)
+	}
+
+	pointer := strings.TrimPrefix(ref, "#")
+	if pointer == "" {
+		return root, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("unsupported $ref %q: expected a JSON pointer", ref)
+	}
+
+	var current interface{} = root
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch node := current.(type) {
+		case map[string]interface{}:
+			next, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("unresolvable $ref %q: %q not found", ref, token)
+			}
+			current = next
+		case []interface{}:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, fmt.Errorf("unresolvable $ref %q: invalid index %q", ref, token)
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+	}
+	return current, nil
+}
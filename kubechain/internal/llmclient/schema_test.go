@@ -0,0 +1,189 @@
+package llmclient
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+var _ = Describe("Tool parameter schemas", func() {
+	Context("FromKubechainTool", func() {
+		It("preserves the complete schema", func() {
+			raw := `{
+				"type": "object",
+				"properties": {
+					"unit": {"type": "string", "description": "temperature unit", "enum": ["c", "f"], "default": "c"},
+					"days": {"type": "integer", "minimum": 1, "maximum": 14},
+					"tags": {"type": "array", "items": {"type": "string"}},
+					"nickname": {"type": ["string", "null"]},
+					"options": {
+						"type": "object",
+						"properties": {"verbose": {"type": "boolean"}},
+						"additionalProperties": false
+					}
+				},
+				"required": ["unit"]
+			}`
+			tool := FromKubechainTool(kubechainv1alpha1.Tool{
+				Spec: kubechainv1alpha1.ToolSpec{
+					Name:       "forecast",
+					Parameters: runtime.RawExtension{Raw: []byte(raw)},
+				},
+			})
+			Expect(tool).NotTo(BeNil())
+
+			params := tool.Function.Parameters
+			Expect(params.Required).To(Equal([]string{"unit"}))
+			Expect(params.Properties["unit"].Description).To(Equal("temperature unit"))
+			Expect(params.Properties["unit"].Enum).To(Equal([]interface{}{"c", "f"}))
+			Expect(params.Properties["tags"].Items.Type).To(Equal("string"))
+			Expect(params.Properties["options"].AdditionalProperties).To(Equal(false))
+
+			encoded, err := json.Marshal(params)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(encoded).To(MatchJSON(raw))
+		})
+
+		It("keeps boolean subschemas that don't fit the typed fields", func() {
+			raw := `{
+				"type": "object",
+				"properties": {"name": {"type": "string"}, "anything": true},
+				"anyOf": [{"required": ["name"]}, false],
+				"items": true
+			}`
+			var params ToolFunctionParameters
+			Expect(json.Unmarshal([]byte(raw), &params)).To(Succeed())
+			Expect(params.Properties).To(BeNil())
+			Expect(params.AnyOf).To(BeNil())
+			Expect(params.Items).To(BeNil())
+
+			encoded, err := json.Marshal(params)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(encoded).To(MatchJSON(raw))
+		})
+
+		It("keeps an empty properties map on the default schema", func() {
+			tool := FromKubechainTool(kubechainv1alpha1.Tool{
+				Spec: kubechainv1alpha1.ToolSpec{Name: "noop"},
+			})
+			encoded, err := json.Marshal(tool.Function.Parameters)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(encoded).To(MatchJSON(`{"type": "object", "properties": {}}`))
+		})
+
+		It("asks humanContact tools for a question", func() {
+			tool := FromKubechainTool(kubechainv1alpha1.Tool{
+				Spec: kubechainv1alpha1.ToolSpec{
+					Name: "ask_oncall",
+					Execute: kubechainv1alpha1.ToolExecute{
+						HumanContact: &kubechainv1alpha1.HumanContactToolSpec{
+							ContactChannel: kubechainv1alpha1.LocalObjectReference{Name: "oncall"},
+						},
+					},
+				},
+			})
+			encoded, err := json.Marshal(tool.Function.Parameters)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(encoded).To(MatchJSON(`{
+				"type": "object",
+				"properties": {"question": {"type": "string", "description": "The question to ask the human"}},
+				"required": ["question"]
+			}`))
+		})
+	})
+
+	Context("ResolveSchemaRefs", func() {
+		decode := func(raw string) ToolFunctionParameters {
+			var params ToolFunctionParameters
+			Expect(json.Unmarshal([]byte(raw), &params)).To(Succeed())
+			return params
+		}
+
+		It("inlines $defs and definitions references", func() {
+			params := decode(`{
+				"type": "object",
+				"properties": {
+					"home": {"$ref": "#/$defs/address", "description": "where you live"},
+					"work": {"$ref": "#/definitions/address"},
+					"definitions": {"type": "string"}
+				},
+				"$defs": {"address": {"type": "object", "properties": {"city": {"type": "string"}}}},
+				"definitions": {"address": {"type": "object", "properties": {"zip": {"type": "string"}}}}
+			}`)
+
+			resolved, err := ResolveSchemaRefs(params)
+			Expect(err).NotTo(HaveOccurred())
+
+			encoded, err := json.Marshal(resolved)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(encoded).To(MatchJSON(`{
+				"type": "object",
+				"properties": {
+					"home": {"type": "object", "description": "where you live", "properties": {"city": {"type": "string"}}},
+					"work": {"type": "object", "properties": {"zip": {"type": "string"}}},
+					"definitions": {"type": "string"}
+				}
+			}`))
+		})
+
+		It("cuts off recursive references", func() {
+			params := decode(`{
+				"type": "object",
+				"properties": {"root": {"$ref": "#/$defs/node"}},
+				"$defs": {"node": {"type": "object", "properties": {"child": {"$ref": "#/$defs/node"}}}}
+			}`)
+
+			resolved, err := ResolveSchemaRefs(params)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resolved.Properties["root"].Properties["child"].Type).To(Equal("object"))
+			Expect(resolved.Properties["root"].Properties["child"].Properties).To(BeNil())
+		})
+
+		It("rejects unresolvable and remote references", func() {
+			_, err := ResolveSchemaRefs(decode(`{"type": "object", "properties": {"a": {"$ref": "#/$defs/missing"}}}`))
+			Expect(err).To(MatchError(ContainSubstring("not found")))
+
+			_, err = ResolveSchemaRefs(decode(`{"type": "object", "properties": {"a": {"$ref": "https://example.com/schema.json"}}}`))
+			Expect(err).To(MatchError(ContainSubstring("only local references")))
+		})
+	})
+
+	Context("convertToLangchainTools", func() {
+		tools := []Tool{{
+			Type: "function",
+			Function: ToolFunction{
+				Name: "lookup",
+				Parameters: ToolFunctionParameters{
+					Type:       "object",
+					Properties: map[string]ToolFunctionParameter{"id": {Ref: "#/$defs/id"}},
+					Defs:       map[string]ToolFunctionParameter{"id": {Type: "string"}},
+				},
+			},
+		}}
+
+		It("passes references through for providers that support them", func() {
+			converted, err := convertToLangchainTools(tools, "openai")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(converted[0].Function.Parameters).To(Equal(tools[0].Function.Parameters))
+		})
+
+		It("inlines references for providers that don't", func() {
+			converted, err := convertToLangchainTools(tools, "google")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(converted[0].Function.Parameters).To(Equal(map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"id": map[string]any{"type": "string"}},
+			}))
+		})
+	})
+})
+
+func TestLLMClient(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "LLM Client Suite")
+}
@@ -0,0 +1,314 @@
+// Package mcpbridge exposes a stdio MCP server over TCP so that servers
+// running in their own Pods can be reached by the operator. Each session
+// gets a fresh server process, so every client starts with a clean MCP session.
+//
+// A client opens a session by sending the bridge's token on a line of its
+// own. The bridge answers "ok" and starts the server, or answers with an
+// "error: " line and closes the connection.
+package mcpbridge
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+const (
+	// Port is the TCP port the bridge listens on
+	Port = 3100
+
+	// HealthPort serves the bridge's health checks. Probes must not connect to
+	// Port, connections there need the token and only one session runs at a time.
+	HealthPort = 3101
+
+	// HealthPath is the HTTP path of the health check
+	HealthPath = "/healthz"
+
+	// BinaryPath is where the bridge binary is placed in MCP server Pods
+	BinaryPath = "/kubechain-bridge/mcp-bridge"
+
+	// VolumeMountPath is the shared volume the bridge binary is copied to
+	VolumeMountPath = "/kubechain-bridge"
+
+	// TokenEnv is the environment variable the bridge reads its token from.
+	// The server process doesn't inherit it.
+	TokenEnv = "KUBECHAIN_BRIDGE_TOKEN"
+
+	// TokenKey is the key of the token in an MCPServer's bridge token Secret
+	TokenKey = "token"
+)
+
+const (
+	// handshakeTimeout bounds sending the token and reading the answer
+	handshakeTimeout = 10 * time.Second
+
+	// defaultHandoverTimeout is the default of Bridge.HandoverTimeout
+	defaultHandoverTimeout = 10 * time.Second
+
+	// stopTimeout is how long a server may take to exit once its client is gone
+	stopTimeout = 5 * time.Second
+
+	// maxTokenLength bounds the handshake line
+	maxTokenLength = 1024
+)
+
+var (
+	errUnauthorized  = errors.New("unauthorized")
+	errSessionActive = errors.New("another session is active")
+)
+
+// ResourceName is the name of the Deployment and Service of an MCPServer
+func ResourceName(mcpServer *kubechainv1alpha1.MCPServer) string {
+	return mcpServer.Name + "-mcp"
+}
+
+// TokenSecretName is the name of the Secret holding an MCPServer's bridge token
+func TokenSecretName(mcpServer *kubechainv1alpha1.MCPServer) string {
+	return ResourceName(mcpServer) + "-bridge"
+}
+
+// Address is the in-cluster address of an MCPServer's bridge
+func Address(mcpServer *kubechainv1alpha1.MCPServer) string {
+	return fmt.Sprintf("%s.%s.svc:%d", ResourceName(mcpServer), mcpServer.Namespace, Port)
+}
+
+// Bridge serves a stdio command over TCP
+type Bridge struct {
+	// Command and Args start the stdio server
+	Command string
+	Args    []string
+	// Token authenticates clients, connections without it are refused
+	Token string
+	// HandoverTimeout is how long a new session waits for the active one to
+	// end, e.g. when the operator reconnects right after closing its
+	// connection. Defaults to 10s.
+	HandoverTimeout time.Duration
+	// Stderr receives the server's stderr, defaults to os.Stderr
+	Stderr io.Writer
+
+	mu     sync.Mutex
+	active *session
+}
+
+// session is a connection and the server process attached to it
+type session struct {
+	conn net.Conn
+	cmd  *exec.Cmd
+	done chan struct{}
+}
+
+// Serve accepts connections until the context is canceled. Only one session
+// is active at a time: a new connection waits a little for the previous
+// session to end and is refused if it doesn't, so that nobody can take over
+// the operator's session.
+func (b *Bridge) Serve(ctx context.Context, listener net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		_ = listener.Close()
+	}()
+	defer b.stopActive()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+		// a slow handshake must not hold up other connections
+		go b.handle(ctx, conn)
+	}
+}
+
+// handle authenticates a connection and starts a session for it
+func (b *Bridge) handle(ctx context.Context, conn net.Conn) {
+	if err := b.authenticate(conn); err != nil {
+		refuse(conn, err)
+		return
+	}
+	if err := b.claim(ctx, conn); err != nil {
+		refuse(conn, err)
+	}
+}
+
+// authenticate reads the client's token
+func (b *Bridge) authenticate(conn net.Conn) error {
+	_ = conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	given, err := readLine(conn)
+	if err != nil {
+		return fmt.Errorf("failed to read token: %w", err)
+	}
+	if b.Token == "" || subtle.ConstantTimeCompare([]byte(given), []byte(b.Token)) != 1 {
+		return errUnauthorized
+	}
+	return nil
+}
+
+// claim starts a session for the connection once no other one is active
+func (b *Bridge) claim(ctx context.Context, conn net.Conn) error {
+	timeout := b.HandoverTimeout
+	if timeout == 0 {
+		timeout = defaultHandoverTimeout
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		b.mu.Lock()
+		active := b.active
+		if active == nil {
+			err := b.start(ctx, conn)
+			b.mu.Unlock()
+			return err
+		}
+		b.mu.Unlock()
+
+		select {
+		case <-active.done:
+		case <-timer.C:
+			return errSessionActive
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// refuse tells the client why its connection is closed
+func refuse(conn net.Conn, err error) {
+	_ = conn.SetWriteDeadline(time.Now().Add(handshakeTimeout))
+	_, _ = fmt.Fprintf(conn, "error: %v\n", err)
+	_ = conn.Close()
+}
+
+// HealthHandler answers health checks on HealthPath, without touching the
+// active session
+func (b *Bridge) HealthHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+HealthPath, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+	return mux
+}
+
+// start launches a server process wired to the connection. The caller
+// holds b.mu.
+func (b *Bridge) start(ctx context.Context, conn net.Conn) error {
+	stderr := b.Stderr
+	if stderr == nil {
+		stderr = os.Stderr
+	}
+
+	cmd := exec.CommandContext(ctx, b.Command, b.Args...)
+	cmd.Stdout = conn
+	cmd.Stderr = stderr
+	// copy stdin ourselves so that waiting for the process doesn't wait for the client
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+
+	// answer before the server can write to the connection
+	if _, err := io.WriteString(conn, "ok\n"); err != nil {
+		return fmt.Errorf("failed to answer handshake: %w", err)
+	}
+	_ = conn.SetDeadline(time.Time{})
+	if err := cmd.Start(); err != nil {
+		_, _ = fmt.Fprintf(stderr, "mcp-bridge: failed to start %s: %v\n", b.Command, err)
+		_ = conn.Close()
+		return nil
+	}
+
+	s := &session{conn: conn, cmd: cmd, done: make(chan struct{})}
+	go func() {
+		_, _ = io.Copy(stdin, conn)
+		_ = stdin.Close()
+		// the client is gone, a server that doesn't exit on EOF must not keep the bridge busy
+		select {
+		case <-s.done:
+		case <-time.After(stopTimeout):
+			_ = cmd.Process.Kill()
+		}
+	}()
+	go func() {
+		err := cmd.Wait()
+		var exitErr *exec.ExitError
+		if err != nil && !errors.As(err, &exitErr) {
+			_, _ = fmt.Fprintf(stderr, "mcp-bridge: %s failed: %v\n", b.Command, err)
+		}
+		// the client sees the server exit as a closed connection
+		_ = conn.Close()
+		b.mu.Lock()
+		if b.active == s {
+			b.active = nil
+		}
+		b.mu.Unlock()
+		close(s.done)
+	}()
+
+	b.active = s
+	return nil
+}
+
+// stopActive ends the active session, if any, and waits for its process to exit
+func (b *Bridge) stopActive() {
+	b.mu.Lock()
+	s := b.active
+	b.active = nil
+	b.mu.Unlock()
+
+	if s == nil {
+		return
+	}
+	_ = s.conn.Close()
+	if s.cmd.Process != nil {
+		_ = s.cmd.Process.Kill()
+	}
+	<-s.done
+}
+
+// Authenticate opens a session on a connection to a bridge
+func Authenticate(conn net.Conn, token string) error {
+	_ = conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	defer func() { _ = conn.SetDeadline(time.Time{}) }()
+
+	if _, err := io.WriteString(conn, token+"\n"); err != nil {
+		return fmt.Errorf("failed to send token: %w", err)
+	}
+	answer, err := readLine(conn)
+	if err != nil {
+		return fmt.Errorf("failed to read the bridge's answer: %w", err)
+	}
+	if answer != "ok" {
+		return fmt.Errorf("bridge refused the connection: %s", strings.TrimPrefix(answer, "error: "))
+	}
+	return nil
+}
+
+// readLine reads a handshake line. It reads byte by byte, what follows the
+// line belongs to the MCP session.
+func readLine(r io.Reader) (string, error) {
+	var line []byte
+	buf := make([]byte, 1)
+	for len(line) <= maxTokenLength {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return "", err
+		}
+		if buf[0] == '\n' {
+			return strings.TrimSuffix(string(line), "\r"), nil
+		}
+		line = append(line, buf[0])
+	}
+	return "", errors.New("line too long")
+}
@@ -0,0 +1,114 @@
+package mcpbridge
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Bridge", func() {
+	var listener net.Listener
+	var cancel context.CancelFunc
+	var served chan error
+	var bridge *Bridge
+
+	BeforeEach(func() {
+		var err error
+		listener, err = net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+		served = make(chan error, 1)
+		bridge = &Bridge{Command: "cat", Token: "bridge-token", HandoverTimeout: time.Second, Stderr: GinkgoWriter}
+		go func() { served <- bridge.Serve(ctx, listener) }()
+	})
+
+	AfterEach(func() {
+		cancel()
+		Eventually(served).Should(Receive(BeNil()))
+	})
+
+	roundTrip := func(conn net.Conn, line string) string {
+		_, err := conn.Write([]byte(line + "\n"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(conn.SetReadDeadline(time.Now().Add(5 * time.Second))).To(Succeed())
+		reply, err := bufio.NewReader(conn).ReadString('\n')
+		Expect(err).NotTo(HaveOccurred())
+		return reply
+	}
+
+	// connect opens a connection and authenticates with token
+	connect := func(token string) (net.Conn, error) {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(func() { _ = conn.Close() })
+		return conn, Authenticate(conn, token)
+	}
+
+	It("pipes a connection through the stdio process", func() {
+		conn, err := connect("bridge-token")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(roundTrip(conn, `{"jsonrpc":"2.0","id":1,"method":"ping"}`)).To(Equal(`{"jsonrpc":"2.0","id":1,"method":"ping"}` + "\n"))
+	})
+
+	It("refuses connections without the token", func() {
+		_, err := connect("guessed")
+		Expect(err).To(MatchError(ContainSubstring("unauthorized")))
+
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		Expect(err).NotTo(HaveOccurred())
+		defer conn.Close()
+		Expect(roundTrip(conn, `{"jsonrpc":"2.0","id":1,"method":"ping"}`)).To(Equal("error: unauthorized\n"))
+	})
+
+	It("refuses a second session while one is active", func() {
+		first, err := connect("bridge-token")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(roundTrip(first, "one")).To(Equal("one\n"))
+
+		_, err = connect("bridge-token")
+		Expect(err).To(MatchError(ContainSubstring("another session is active")))
+
+		Expect(roundTrip(first, "two")).To(Equal("two\n"))
+	})
+
+	It("starts a new session once the client of the previous one is gone", func() {
+		first, err := connect("bridge-token")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(roundTrip(first, "one")).To(Equal("one\n"))
+		Expect(first.Close()).To(Succeed())
+
+		second, err := connect("bridge-token")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(roundTrip(second, "two")).To(Equal("two\n"))
+	})
+
+	It("answers health checks without ending the session", func() {
+		conn, err := connect("bridge-token")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(roundTrip(conn, "one")).To(Equal("one\n"))
+
+		health := httptest.NewServer(bridge.HealthHandler())
+		defer health.Close()
+		resp, err := http.Get(health.URL + HealthPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Body.Close()).To(Succeed())
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+		Expect(roundTrip(conn, "two")).To(Equal("two\n"))
+	})
+})
+
+func TestMCPBridge(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "MCPBridge Suite")
+}
@@ -0,0 +1,101 @@
+package mcpmanager
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+// ReferencedSecrets returns the names of the Secrets an MCPServer's
+// connection depends on
+func ReferencedSecrets(mcpServer *kubechainv1alpha1.MCPServer) []string {
+	names := map[string]bool{}
+	for _, env := range mcpServer.Spec.Env {
+		if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+			names[env.ValueFrom.SecretKeyRef.Name] = true
+		}
+	}
+	for _, header := range mcpServer.Spec.Headers {
+		if header.ValueFrom != nil && header.ValueFrom.SecretKeyRef != nil {
+			names[header.ValueFrom.SecretKeyRef.Name] = true
+		}
+	}
+	if auth := mcpServer.Spec.Auth; auth != nil {
+		if auth.BearerTokenFrom != nil {
+			names[auth.BearerTokenFrom.Name] = true
+		}
+		if auth.OAuth != nil {
+			names[auth.OAuth.ClientSecretFrom.Name] = true
+		}
+	}
+	if tls := mcpServer.Spec.TLS; tls != nil {
+		if tls.CABundleFrom != nil {
+			names[tls.CABundleFrom.Name] = true
+		}
+		if tls.ClientCertificateSecret != nil {
+			names[tls.ClientCertificateSecret.Name] = true
+		}
+	}
+
+	result := make([]string, 0, len(names))
+	for name := range names {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// ConfigHash hashes everything an MCPServer's connection is built from: the
+// connection settings of its spec and the data of the Secrets it references.
+// A connection whose hash no longer matches has to be replaced. Secrets that
+// don't exist are left out, connecting reports them.
+func ConfigHash(ctx context.Context, c ctrlclient.Client, mcpServer *kubechainv1alpha1.MCPServer) (string, error) {
+	spec := mcpServer.Spec.DeepCopy()
+	// approvals don't affect the connection, whether elicitation is offered does
+	elicits := spec.ElicitationChannel() != nil
+	spec.ApprovalContactChannel = nil
+	spec.ApprovalPolicy = nil
+	spec.ElicitationContactChannel = nil
+	if elicits {
+		spec.ElicitationContactChannel = &kubechainv1alpha1.LocalObjectReference{}
+	}
+	if spec.Sampling != nil {
+		// only whether sampling is offered does, its limits are read per request
+		spec.Sampling = &kubechainv1alpha1.MCPServerSampling{}
+	}
+
+	secrets := map[string]map[string][]byte{}
+	if c != nil {
+		for _, name := range ReferencedSecrets(mcpServer) {
+			var secret corev1.Secret
+			if err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: mcpServer.Namespace}, &secret); err != nil {
+				if apierrors.IsNotFound(err) {
+					continue
+				}
+				return "", fmt.Errorf("failed to get secret %s: %w", name, err)
+			}
+			secrets[name] = secret.Data
+		}
+	}
+
+	// map keys are marshaled in sorted order, so the encoding is stable
+	data, err := json.Marshal(struct {
+		Spec    *kubechainv1alpha1.MCPServerSpec `json:"spec"`
+		Secrets map[string]map[string][]byte     `json:"secrets"`
+	}{spec, secrets})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode MCP server config: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
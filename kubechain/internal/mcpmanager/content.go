@@ -0,0 +1,92 @@
+package mcpmanager
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+// ToolResult is the outcome of a tool call
+type ToolResult struct {
+	// Text is the text of the result, embedded text resources included
+	Text string
+	// Attachments describe the non-text contents of the result. Storing
+	// their data is up to the caller, so DataFrom is unset.
+	Attachments []kubechainv1alpha1.Attachment
+	// Data holds the data of each attachment
+	Data [][]byte
+}
+
+// String returns the text of the result with the attachments described
+func (r *ToolResult) String() string {
+	parts := []string{}
+	if r.Text != "" {
+		parts = append(parts, r.Text)
+	}
+	for _, attachment := range r.Attachments {
+		parts = append(parts, DescribeAttachment(attachment))
+	}
+	return strings.Join(parts, "\n")
+}
+
+// DescribeAttachment describes an attachment in text, for places that can't
+// show its data
+func DescribeAttachment(attachment kubechainv1alpha1.Attachment) string {
+	if attachment.URI != "" {
+		return fmt.Sprintf("[%s %s %s, %d bytes]", attachment.MimeType, attachment.Type, attachment.URI, attachment.Size)
+	}
+	return fmt.Sprintf("[%s %s, %d bytes]", attachment.MimeType, attachment.Type, attachment.Size)
+}
+
+// convertContents splits MCP contents into text and attachments
+func convertContents(contents []mcp.Content) (*ToolResult, error) {
+	result := &ToolResult{}
+	for _, content := range contents {
+		switch c := content.(type) {
+		case mcp.TextContent:
+			result.Text += c.Text
+		case mcp.ImageContent:
+			if err := result.attachBase64("image", c.MIMEType, "", c.Data); err != nil {
+				return nil, err
+			}
+		case mcp.AudioContent:
+			if err := result.attachBase64("audio", c.MIMEType, "", c.Data); err != nil {
+				return nil, err
+			}
+		case mcp.EmbeddedResource:
+			switch resource := c.Resource.(type) {
+			case mcp.TextResourceContents:
+				result.Text += resource.Text
+			case mcp.BlobResourceContents:
+				if err := result.attachBase64("resource", resource.MIMEType, resource.URI, resource.Blob); err != nil {
+					return nil, err
+				}
+			}
+		case mcp.ResourceLink:
+			result.Text += fmt.Sprintf("[Resource link: %s]", c.URI)
+		default:
+			result.Text += "[Non-text content]"
+		}
+	}
+	return result, nil
+}
+
+// attachBase64 adds an attachment from base64 encoded data
+func (r *ToolResult) attachBase64(contentType, mimeType, uri, encoded string) error {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("invalid %s data: %w", contentType, err)
+	}
+	r.Attachments = append(r.Attachments, kubechainv1alpha1.Attachment{
+		Type:     contentType,
+		MimeType: mimeType,
+		URI:      uri,
+		Size:     int64(len(data)),
+	})
+	r.Data = append(r.Data, data)
+	return nil
+}
@@ -0,0 +1,78 @@
+package mcpmanager
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http/httptest"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+var _ = Describe("CallToolResult with non-text contents", func() {
+	var httpServer *httptest.Server
+	var manager *MCPServerManager
+	png := []byte("\x89PNG fake image")
+
+	BeforeEach(func() {
+		mcpServer := server.NewMCPServer("charts", "1.0.0")
+		mcpServer.AddTool(mcp.NewTool("plot"),
+			func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				return &mcp.CallToolResult{Content: []mcp.Content{
+					mcp.NewTextContent("Here is the chart"),
+					mcp.NewImageContent(base64.StdEncoding.EncodeToString(png), "image/png"),
+					mcp.NewEmbeddedResource(mcp.BlobResourceContents{
+						URI: "charts://data.csv.gz", MIMEType: "application/gzip", Blob: base64.StdEncoding.EncodeToString([]byte("gz")),
+					}),
+				}}, nil
+			})
+		mcpServer.AddTool(mcp.NewTool("broken"),
+			func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				return &mcp.CallToolResult{Content: []mcp.Content{
+					mcp.NewImageContent("not base64!", "image/png"),
+				}}, nil
+			})
+		httpServer = httptest.NewServer(server.NewStreamableHTTPServer(mcpServer))
+
+		manager = NewMCPServerManager()
+		Expect(manager.ConnectServer(context.Background(), &kubechainv1alpha1.MCPServer{
+			ObjectMeta: metav1.ObjectMeta{Name: "charts", Namespace: "default"},
+			Spec: kubechainv1alpha1.MCPServerSpec{
+				Transport: "streamable-http",
+				URL:       httpServer.URL + "/mcp",
+			},
+		})).To(Succeed())
+	})
+
+	AfterEach(func() {
+		manager.Close()
+		httpServer.Close()
+	})
+
+	It("keeps images and blobs as attachments with their data", func() {
+		result, err := manager.CallToolResult(context.Background(), "default", "charts", "plot", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Text).To(Equal("Here is the chart"))
+		Expect(result.Attachments).To(Equal([]kubechainv1alpha1.Attachment{
+			{Type: "image", MimeType: "image/png", Size: int64(len(png))},
+			{Type: "resource", MimeType: "application/gzip", URI: "charts://data.csv.gz", Size: 2},
+		}))
+		Expect(result.Data).To(Equal([][]byte{png, []byte("gz")}))
+	})
+
+	It("describes attachments in the text of CallTool", func() {
+		content, err := manager.CallTool(context.Background(), "default", "charts", "plot", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(content).To(Equal("Here is the chart\n[image/png image, 15 bytes]\n[application/gzip resource charts://data.csv.gz, 2 bytes]"))
+	})
+
+	It("fails on invalid data", func() {
+		_, err := manager.CallToolResult(context.Background(), "default", "charts", "broken", nil)
+		Expect(err).To(MatchError(ContainSubstring("invalid image data")))
+	})
+})
@@ -0,0 +1,54 @@
+package mcpmanager
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Elicitor answers the elicitation requests an MCP server makes while it runs
+// a tool call, i.e. asks a human for the input the server needs
+type Elicitor interface {
+	Elicit(ctx context.Context, request mcp.ElicitationRequest) (*mcp.ElicitationResult, error)
+}
+
+type elicitorKey struct{}
+
+// WithElicitor answers the elicitation requests made during a tool call with
+// e. They are only made by servers whose MCPServer has a contact channel to
+// elicit input through.
+func WithElicitor(ctx context.Context, e Elicitor) context.Context {
+	return context.WithValue(ctx, elicitorKey{}, e)
+}
+
+// elicitorFrom returns the Elicitor of a context, if any
+func elicitorFrom(ctx context.Context) Elicitor {
+	e, _ := ctx.Value(elicitorKey{}).(Elicitor)
+	return e
+}
+
+// elicitationHandler receives the elicitation requests of a connection
+type elicitationHandler struct {
+	conn *MCPConnection
+}
+
+// Elicit passes an elicitation request on to the Elicitor of the tool call it
+// is made for. Like sampling, the request is only answered while a single
+// call with an Elicitor is running on the connection.
+func (h elicitationHandler) Elicit(ctx context.Context, request mcp.ElicitationRequest) (*mcp.ElicitationResult, error) {
+	var elicitors []Elicitor
+	h.conn.elicitors.Range(func(_, value any) bool {
+		elicitors = append(elicitors, value.(Elicitor))
+		return true
+	})
+
+	switch len(elicitors) {
+	case 0:
+		return nil, fmt.Errorf("elicitation is only available while a tool call is running")
+	case 1:
+		return elicitors[0].Elicit(ctx, request)
+	default:
+		return nil, fmt.Errorf("elicitation request can't be attributed to one of %d running tool calls", len(elicitors))
+	}
+}
@@ -0,0 +1,113 @@
+package mcpmanager
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+// fakeElicitor accepts elicitation requests with a fixed content and records them
+type fakeElicitor struct {
+	requests []mcp.ElicitationRequest
+}
+
+func (e *fakeElicitor) Elicit(ctx context.Context, request mcp.ElicitationRequest) (*mcp.ElicitationResult, error) {
+	e.requests = append(e.requests, request)
+	return &mcp.ElicitationResult{ElicitationResponse: mcp.ElicitationResponse{
+		Action:  mcp.ElicitationResponseActionAccept,
+		Content: map[string]any{"environment": "staging"},
+	}}, nil
+}
+
+var _ = Describe("Elicitation", func() {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	var manager *MCPServerManager
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+
+		manager = NewMCPServerManager()
+		// a deployed stdio server, its bridge connection leads to an in-process server
+		manager.dialBridge = func(ctx context.Context, _ *kubechainv1alpha1.MCPServer) (net.Conn, error) {
+			mcpServer := server.NewMCPServer("deployer", "1.0.0", server.WithElicitation())
+			mcpServer.AddTool(mcp.NewTool("deploy"),
+				func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+					result, err := mcpServer.RequestElicitation(ctx, mcp.ElicitationRequest{
+						Params: mcp.ElicitationParams{
+							Message: "Which environment should be deployed to?",
+							RequestedSchema: map[string]any{
+								"type": "object",
+								"properties": map[string]any{
+									"environment": map[string]any{"type": "string"},
+								},
+								"required": []string{"environment"},
+							},
+						},
+					})
+					if err != nil {
+						return mcp.NewToolResultError(err.Error()), nil
+					}
+					return mcp.NewToolResultText(fmt.Sprintf("%s: %v", result.Action, result.Content.(map[string]any)["environment"])), nil
+				})
+			clientConn, serverConn := net.Pipe()
+			go func() {
+				_ = server.NewStdioServer(mcpServer).Listen(ctx, serverConn, serverConn)
+			}()
+			return clientConn, nil
+		}
+	})
+
+	AfterEach(func() {
+		manager.Close()
+		cancel()
+	})
+
+	connect := func(contactChannel *kubechainv1alpha1.LocalObjectReference) {
+		Expect(manager.ConnectServer(ctx, &kubechainv1alpha1.MCPServer{
+			ObjectMeta: metav1.ObjectMeta{Name: "deployer", Namespace: "default"},
+			Spec: kubechainv1alpha1.MCPServerSpec{
+				Transport:                 "stdio",
+				Command:                   "deployer-server",
+				Deployment:                &kubechainv1alpha1.MCPServerDeployment{Image: "deployer:latest"},
+				ElicitationContactChannel: contactChannel,
+			},
+		})).To(Succeed())
+	}
+
+	It("answers the elicitation requests of a call with its elicitor", func() {
+		connect(&kubechainv1alpha1.LocalObjectReference{Name: "ops"})
+
+		elicitor := &fakeElicitor{}
+		result, err := manager.CallTool(WithElicitor(ctx, elicitor), "default", "deployer", "deploy", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal("accept: staging"))
+
+		Expect(elicitor.requests).To(HaveLen(1))
+		Expect(elicitor.requests[0].Params.Message).To(Equal("Which environment should be deployed to?"))
+	})
+
+	It("rejects elicitation requests of calls without an elicitor", func() {
+		connect(&kubechainv1alpha1.LocalObjectReference{Name: "ops"})
+
+		_, err := manager.CallTool(ctx, "default", "deployer", "deploy", nil)
+		Expect(err).To(MatchError(ContainSubstring("only available while a tool call is running")))
+	})
+
+	It("doesn't offer elicitation to servers without a contact channel", func() {
+		connect(nil)
+
+		elicitor := &fakeElicitor{}
+		_, err := manager.CallTool(WithElicitor(ctx, elicitor), "default", "deployer", "deploy", nil)
+		Expect(err).To(HaveOccurred())
+		Expect(elicitor.requests).To(BeEmpty())
+	})
+})
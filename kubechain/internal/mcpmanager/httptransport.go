@@ -0,0 +1,144 @@
+package mcpmanager
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+// httpConfig resolves the headers and HTTP client used to talk to an HTTP
+// MCP server, reading any referenced secrets
+func (m *MCPServerManager) httpConfig(ctx context.Context, mcpServer *kubechainv1alpha1.MCPServer) (map[string]string, *http.Client, error) {
+	headers := make(map[string]string, len(mcpServer.Spec.Headers))
+	for _, header := range mcpServer.Spec.Headers {
+		value := header.Value
+		if header.ValueFrom != nil && header.ValueFrom.SecretKeyRef != nil {
+			secretValue, err := m.secretValue(ctx, mcpServer.Namespace, header.ValueFrom.SecretKeyRef)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to resolve header %s: %w", header.Name, err)
+			}
+			value = string(secretValue)
+		}
+		headers[header.Name] = value
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if mcpServer.Spec.TLS != nil {
+		tlsConfig, err := m.tlsConfig(ctx, mcpServer.Namespace, mcpServer.Spec.TLS)
+		if err != nil {
+			return nil, nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+	httpClient := &http.Client{Transport: transport}
+
+	auth := mcpServer.Spec.Auth
+	if auth == nil {
+		return headers, httpClient, nil
+	}
+
+	if auth.BearerTokenFrom != nil {
+		token, err := m.secretValue(ctx, mcpServer.Namespace, auth.BearerTokenFrom)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to resolve bearer token: %w", err)
+		}
+		headers["Authorization"] = "Bearer " + string(token)
+	}
+
+	if auth.OAuth != nil {
+		clientSecret, err := m.secretValue(ctx, mcpServer.Namespace, &auth.OAuth.ClientSecretFrom)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to resolve OAuth client secret: %w", err)
+		}
+		config := clientcredentials.Config{
+			ClientID:     auth.OAuth.ClientID,
+			ClientSecret: string(clientSecret),
+			TokenURL:     auth.OAuth.TokenURL,
+			Scopes:       auth.OAuth.Scopes,
+		}
+		if auth.OAuth.Audience != "" {
+			config.EndpointParams = url.Values{"audience": {auth.OAuth.Audience}}
+		}
+		// tokens are refreshed for as long as the connection lives, not just this reconcile,
+		// and are fetched with the same TLS settings as the server itself
+		tokenCtx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: transport})
+		httpClient = &http.Client{Transport: &oauth2.Transport{
+			Source: oauth2.ReuseTokenSource(nil, config.TokenSource(tokenCtx)),
+			Base:   transport,
+		}}
+	}
+
+	return headers, httpClient, nil
+}
+
+// tlsConfig builds the TLS configuration of an HTTP MCP server
+func (m *MCPServerManager) tlsConfig(ctx context.Context, namespace string, spec *kubechainv1alpha1.MCPServerTLS) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: spec.InsecureSkipVerify, //nolint:gosec // explicitly requested in the MCPServer spec
+	}
+
+	if spec.CABundleFrom != nil {
+		caBundle, err := m.secretValue(ctx, namespace, spec.CABundleFrom)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(caBundle) {
+			return nil, fmt.Errorf("CA bundle in secret %s contains no PEM certificates", spec.CABundleFrom.Name)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if spec.ClientCertificateSecret != nil {
+		secret, err := m.getSecret(ctx, namespace, spec.ClientCertificateSecret.Name)
+		if err != nil {
+			return nil, err
+		}
+		cert, err := tls.X509KeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate in secret %s: %w", spec.ClientCertificateSecret.Name, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// secretValue reads a single key of a secret
+func (m *MCPServerManager) secretValue(ctx context.Context, namespace string, ref *kubechainv1alpha1.SecretKeySelector) ([]byte, error) {
+	secret, err := m.getSecret(ctx, namespace, ref.Name)
+	if err != nil {
+		return nil, err
+	}
+	value, exists := secret.Data[ref.Key]
+	if !exists {
+		return nil, fmt.Errorf("key %s not found in secret %s", ref.Key, ref.Name)
+	}
+	return value, nil
+}
+
+// getSecret fetches a secret from the MCPServer's namespace
+func (m *MCPServerManager) getSecret(ctx context.Context, namespace, name string) (*corev1.Secret, error) {
+	if m.client == nil {
+		return nil, fmt.Errorf("cannot read secret %s: no Kubernetes client available", name)
+	}
+	var secret corev1.Secret
+	if err := m.client.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, &secret); err != nil {
+		return nil, fmt.Errorf("failed to get secret %s: %w", name, err)
+	}
+	return &secret, nil
+}
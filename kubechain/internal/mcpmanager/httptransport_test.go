@@ -0,0 +1,160 @@
+package mcpmanager
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+var _ = Describe("ConnectServer with an authenticating HTTP server", func() {
+	var httpServer *httptest.Server
+	var tokenRequests int
+	var manager *MCPServerManager
+
+	BeforeEach(func() {
+		tokenRequests = 0
+
+		mcpServer := server.NewMCPServer("gateway", "1.0.0")
+		mcpServer.AddTool(mcp.NewTool("search", mcp.WithDescription("Searches the wiki")),
+			func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				return mcp.NewToolResultText("found"), nil
+			})
+		mcpHandler := server.NewStreamableHTTPServer(mcpServer)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+			tokenRequests++
+			Expect(r.ParseForm()).To(Succeed())
+			if r.Form.Get("grant_type") != "client_credentials" || r.Form.Get("audience") != "mcp-gateway" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			if id, secret, _ := r.BasicAuth(); id != "kubechain" || secret != "client-secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"access_token":"oauth-token","token_type":"Bearer","expires_in":3600}`))
+		})
+		mux.HandleFunc("/mcp", func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer oauth-token" || r.Header.Get("X-Tenant") != "acme" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			mcpHandler.ServeHTTP(w, r)
+		})
+		httpServer = httptest.NewTLSServer(mux)
+
+		caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: httpServer.Certificate().Raw})
+		k8sClient := fake.NewClientBuilder().WithObjects(
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "gateway", Namespace: "default"},
+				Data: map[string][]byte{
+					"ca.crt":        caBundle,
+					"client-secret": []byte("client-secret"),
+					"tenant":        []byte("acme"),
+				},
+			},
+		).Build()
+		manager = NewMCPServerManagerWithClient(k8sClient)
+	})
+
+	AfterEach(func() {
+		manager.Close()
+		httpServer.Close()
+	})
+
+	It("sends headers and OAuth tokens over TLS with a custom CA", func() {
+		err := manager.ConnectServer(context.Background(), &kubechainv1alpha1.MCPServer{
+			ObjectMeta: metav1.ObjectMeta{Name: "wiki", Namespace: "default"},
+			Spec: kubechainv1alpha1.MCPServerSpec{
+				Transport: "streamable-http",
+				URL:       httpServer.URL + "/mcp",
+				Headers: []kubechainv1alpha1.HTTPHeader{{
+					Name: "X-Tenant",
+					ValueFrom: &kubechainv1alpha1.EnvVarSource{
+						SecretKeyRef: &kubechainv1alpha1.SecretKeySelector{Name: "gateway", Key: "tenant"},
+					},
+				}},
+				Auth: &kubechainv1alpha1.MCPServerAuth{
+					OAuth: &kubechainv1alpha1.OAuthClientCredentials{
+						TokenURL:         httpServer.URL + "/token",
+						ClientID:         "kubechain",
+						ClientSecretFrom: kubechainv1alpha1.SecretKeySelector{Name: "gateway", Key: "client-secret"},
+						Audience:         "mcp-gateway",
+					},
+				},
+				TLS: &kubechainv1alpha1.MCPServerTLS{
+					CABundleFrom: &kubechainv1alpha1.SecretKeySelector{Name: "gateway", Key: "ca.crt"},
+				},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		tools, exists := manager.GetTools("default", "wiki")
+		Expect(exists).To(BeTrue())
+		Expect(tools).To(HaveLen(1))
+
+		result, err := manager.CallTool(context.Background(), "default", "wiki", "search", map[string]interface{}{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal("found"))
+		// the token is reused across requests
+		Expect(tokenRequests).To(Equal(1))
+	})
+
+	It("fails without the CA bundle", func() {
+		err := manager.ConnectServer(context.Background(), &kubechainv1alpha1.MCPServer{
+			ObjectMeta: metav1.ObjectMeta{Name: "wiki", Namespace: "default"},
+			Spec: kubechainv1alpha1.MCPServerSpec{
+				Transport: "streamable-http",
+				URL:       httpServer.URL + "/mcp",
+			},
+		})
+		Expect(err).To(MatchError(ContainSubstring("certificate")))
+	})
+})
+
+var _ = Describe("ConnectServer with an SSE server", func() {
+	It("keeps the stream after the connecting context ends", func() {
+		mcpServer := server.NewMCPServer("wiki", "1.0.0")
+		mcpServer.AddTool(mcp.NewTool("search", mcp.WithDescription("Searches the wiki")),
+			func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				return mcp.NewToolResultText("found"), nil
+			})
+		httpServer := server.NewTestServer(mcpServer)
+		DeferCleanup(httpServer.Close)
+
+		manager := NewMCPServerManagerWithClient(fake.NewClientBuilder().Build())
+		DeferCleanup(manager.Close)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		err := manager.ConnectServer(ctx, &kubechainv1alpha1.MCPServer{
+			ObjectMeta: metav1.ObjectMeta{Name: "wiki", Namespace: "default"},
+			Spec: kubechainv1alpha1.MCPServerSpec{
+				Transport: "http",
+				URL:       httpServer.URL + "/sse",
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		// the reconcile that connected is over
+		cancel()
+
+		callCtx, cancelCall := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancelCall()
+		result, err := manager.CallTool(callCtx, "default", "wiki", "search", map[string]interface{}{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal("found"))
+	})
+})
@@ -0,0 +1,162 @@
+package mcpmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	mcpclient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+// listTimeout bounds re-listing a server's tools, resources and prompts
+// after it notified a change
+const listTimeout = 30 * time.Second
+
+// notificationSource is implemented by MCP clients that deliver the
+// notifications of the server
+type notificationSource interface {
+	OnNotification(handler func(notification mcp.JSONRPCNotification))
+}
+
+// serverLists is what an MCP server provides
+type serverLists struct {
+	tools     []kubechainv1alpha1.MCPTool
+	resources []kubechainv1alpha1.MCPResource
+	prompts   []kubechainv1alpha1.MCPPrompt
+	// readsResources is set when ReadResourceTool was generated for the resources
+	readsResources bool
+}
+
+// listServer lists the tools, resources and prompts of a server
+func listServer(ctx context.Context, c mcpclient.MCPClient, capabilities mcp.ServerCapabilities) (*serverLists, error) {
+	tools, err := listTools(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	resources, err := listResources(ctx, c, capabilities)
+	if err != nil {
+		return nil, err
+	}
+	prompts, err := listPrompts(ctx, c, capabilities)
+	if err != nil {
+		return nil, err
+	}
+
+	lists := &serverLists{tools: tools, resources: resources, prompts: prompts}
+	// Let agents read the server's resources through a tool
+	if len(resources) > 0 && !hasTool(tools, ReadResourceTool) {
+		lists.tools = append(lists.tools, readResourceTool(resources))
+		lists.readsResources = true
+	}
+	return lists, nil
+}
+
+// listTools lists the tools of a server
+func listTools(ctx context.Context, c mcpclient.MCPClient) ([]kubechainv1alpha1.MCPTool, error) {
+	toolsResp, err := c.ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tools: %w", err)
+	}
+
+	// Convert tools to kubechain format
+	tools := make([]kubechainv1alpha1.MCPTool, 0, len(toolsResp.Tools))
+	for _, tool := range toolsResp.Tools {
+		// Handle the InputSchema properly
+		var inputSchemaBytes []byte
+		var err error
+
+		if len(tool.RawInputSchema) > 0 {
+			// Use RawInputSchema if available (preferred)
+			inputSchemaBytes = tool.RawInputSchema
+		} else {
+			// Otherwise, use the structured InputSchema and ensure required is an array
+			schema := tool.InputSchema
+
+			// Ensure required is not null
+			if schema.Required == nil {
+				schema.Required = []string{}
+			}
+
+			inputSchemaBytes, err = json.Marshal(schema)
+			if err != nil {
+				// the tool can't be called right without its schema
+				log.FromContext(ctx).Error(err, "Skipping MCP tool with an invalid input schema", "tool", tool.Name)
+				continue
+			}
+		}
+
+		tools = append(tools, kubechainv1alpha1.MCPTool{
+			Name:        tool.Name,
+			Description: tool.Description,
+			InputSchema: runtime.RawExtension{Raw: inputSchemaBytes},
+		})
+	}
+	return tools, nil
+}
+
+// setLists stores what the server provides on the connection. A connection
+// in the map must only be changed with the manager's lock held.
+func (c *MCPConnection) setLists(lists *serverLists) {
+	c.Tools = lists.tools
+	c.Resources = lists.resources
+	c.Prompts = lists.prompts
+	c.readsResources.Store(lists.readsResources)
+}
+
+// ListChanges delivers an event for the MCPServer of every connection whose
+// tools, resources or prompts changed, so that its controller can update the
+// status without waiting for the next health check
+func (m *MCPServerManager) ListChanges() <-chan event.GenericEvent {
+	return m.listChanges
+}
+
+// handleNotification re-lists what a server provides when it notifies a
+// change, and delivers the progress of tool calls
+func (m *MCPServerManager) handleNotification(conn *MCPConnection, notification mcp.JSONRPCNotification) {
+	switch notification.Method {
+	case mcp.MethodNotificationToolsListChanged,
+		mcp.MethodNotificationResourcesListChanged,
+		mcp.MethodNotificationPromptsListChanged:
+		// not on the transport's goroutine, which has to deliver the responses
+		go m.refreshLists(conn)
+	case methodNotificationProgress:
+		conn.handleProgress(notification)
+	}
+}
+
+// refreshLists re-lists what the server of a connection provides and reports
+// the change to the controller
+func (m *MCPServerManager) refreshLists(conn *MCPConnection) {
+	ctx, cancel := context.WithTimeout(context.Background(), listTimeout)
+	defer cancel()
+
+	lists, err := listServer(ctx, conn.Client, conn.capabilities)
+	if err != nil {
+		// the next health check or notification tries again
+		fmt.Printf("Error refreshing the lists of MCP server %s/%s: %v\n", conn.Namespace, conn.ServerName, err)
+		return
+	}
+
+	m.mu.Lock()
+	current := m.connections[serverKey(conn.Namespace, conn.ServerName)] == conn
+	if current {
+		conn.setLists(lists)
+	}
+	m.mu.Unlock()
+	if !current {
+		return
+	}
+
+	select {
+	case m.listChanges <- serverEvent(conn):
+	default:
+		// the controller is behind, the periodic health check catches up
+	}
+}
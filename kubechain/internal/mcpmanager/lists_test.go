@@ -0,0 +1,73 @@
+package mcpmanager
+
+import (
+	"context"
+	"net/http/httptest"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+var _ = Describe("Tool list changes", func() {
+	var httpServer *httptest.Server
+	var mcpServer *server.MCPServer
+	var manager *MCPServerManager
+
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ok"), nil
+	}
+
+	BeforeEach(func() {
+		mcpServer = server.NewMCPServer("tickets", "1.0.0", server.WithToolCapabilities(true))
+		mcpServer.AddTool(mcp.NewTool("list_tickets"), handler)
+		httpServer = httptest.NewServer(server.NewStreamableHTTPServer(mcpServer))
+
+		manager = NewMCPServerManager()
+		Expect(manager.ConnectServer(context.Background(), &kubechainv1alpha1.MCPServer{
+			ObjectMeta: metav1.ObjectMeta{Name: "tickets", Namespace: "default"},
+			Spec: kubechainv1alpha1.MCPServerSpec{
+				Transport: "streamable-http",
+				URL:       httpServer.URL + "/mcp",
+			},
+		})).To(Succeed())
+	})
+
+	AfterEach(func() {
+		manager.Close()
+		httpServer.Close()
+	})
+
+	toolNames := func() []string {
+		tools, _ := manager.GetTools("default", "tickets")
+		names := []string{}
+		for _, tool := range tools {
+			names = append(names, tool.Name)
+		}
+		return names
+	}
+
+	It("re-lists the tools and reports the change when the server notifies it", func() {
+		Expect(toolNames()).To(ConsistOf("list_tickets"))
+
+		// the client listens for notifications once initialized, give it a moment
+		Eventually(func() []string {
+			mcpServer.AddTool(mcp.NewTool("close_ticket"), handler)
+			return toolNames()
+		}, 5*time.Second, 200*time.Millisecond).Should(ConsistOf("list_tickets", "close_ticket"))
+
+		var changed event.GenericEvent
+		Eventually(manager.ListChanges()).Should(Receive(&changed))
+		Expect(changed.Object.GetNamespace()).To(Equal("default"))
+		Expect(changed.Object.GetName()).To(Equal("tickets"))
+
+		mcpServer.DeleteTools("list_tickets")
+		Eventually(toolNames, 5*time.Second).Should(ConsistOf("close_ticket"))
+	})
+})
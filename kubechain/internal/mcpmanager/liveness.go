@@ -0,0 +1,177 @@
+package mcpmanager
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+const (
+	// pingTimeout bounds the MCP ping of a health check
+	pingTimeout = 10 * time.Second
+
+	// processExitGracePeriod is how long a stdio server may take to exit
+	// after its stdin is closed before it is killed
+	processExitGracePeriod = 5 * time.Second
+)
+
+// CheckHealth reports whether the connection to an MCP server is alive: it
+// fails if the connection was lost, the stdio process exited, or the server
+// doesn't answer an MCP ping
+func (m *MCPServerManager) CheckHealth(ctx context.Context, namespace, serverName string) error {
+	m.mu.RLock()
+	conn, exists := m.connections[serverKey(namespace, serverName)]
+	m.mu.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("MCP server not connected: %s/%s", namespace, serverName)
+	}
+	if err := conn.Lost(); err != nil {
+		return err
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	if err := conn.Client.Ping(pingCtx); err != nil {
+		return fmt.Errorf("ping failed: %w", err)
+	}
+	return nil
+}
+
+// ConnectionFailures delivers an event for the MCPServer of every connection
+// that is lost, so that its controller can reconnect without waiting for the
+// next health check
+func (m *MCPServerManager) ConnectionFailures() <-chan event.GenericEvent {
+	return m.failures
+}
+
+// connectionLost records why a connection died and notifies the controller
+func (m *MCPServerManager) connectionLost(conn *MCPConnection, err error) {
+	if !conn.markLost(err) {
+		return
+	}
+	select {
+	case m.failures <- serverEvent(conn):
+	default:
+		// the controller is behind, the periodic health check catches up
+	}
+}
+
+// serverEvent is an event for the MCPServer of a connection
+func serverEvent(conn *MCPConnection) event.GenericEvent {
+	return event.GenericEvent{Object: &kubechainv1alpha1.MCPServer{
+		ObjectMeta: metav1.ObjectMeta{Name: conn.ServerName, Namespace: conn.Namespace},
+	}}
+}
+
+// Lost returns why the connection was lost, or nil if it is still up
+func (c *MCPConnection) Lost() error {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	return c.lostErr
+}
+
+// markLost records the first failure of a connection that wasn't closed on
+// purpose and reports whether it did
+func (c *MCPConnection) markLost(err error) bool {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	if c.closed || c.lostErr != nil {
+		return false
+	}
+	c.lostErr = err
+	return true
+}
+
+// close shuts the connection down and stops its stdio process, if any
+func (c *MCPConnection) close() {
+	c.stateMu.Lock()
+	c.closed = true
+	c.stateMu.Unlock()
+
+	if c.Client != nil {
+		if err := c.Client.Close(); err != nil {
+			fmt.Printf("Error closing MCP client connection: %v\n", err)
+		}
+	}
+
+	if c.Command != nil && c.exited != nil {
+		// closing stdin asks the server to exit, give it a moment before killing it
+		select {
+		case <-c.exited:
+		case <-time.After(processExitGracePeriod):
+			_ = c.Command.Process.Kill()
+		}
+	}
+}
+
+// startProcess starts a stdio MCP server and reports its exit as a lost connection.
+// Its stderr goes to the operator's stderr.
+func (m *MCPServerManager) startProcess(conn *MCPConnection, command string, env []string, args []string) (io.Reader, io.WriteCloser, error) {
+	cmd := exec.Command(command, args...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+	// a pipe of our own, unlike StdoutPipe it isn't closed by Wait before we read everything
+	stdout, stdoutWriter, err := os.Pipe()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	cmd.Stdout = stdoutWriter
+
+	if err := cmd.Start(); err != nil {
+		_ = stdout.Close()
+		_ = stdoutWriter.Close()
+		return nil, nil, fmt.Errorf("failed to start command: %w", err)
+	}
+	_ = stdoutWriter.Close()
+
+	conn.Command = cmd
+	conn.exited = make(chan struct{})
+	go func() {
+		err := cmd.Wait()
+		close(conn.exited)
+		if err == nil {
+			err = fmt.Errorf("MCP server process exited")
+		} else {
+			err = fmt.Errorf("MCP server process exited: %w", err)
+		}
+		m.connectionLost(conn, err)
+	}()
+
+	return stdout, stdin, nil
+}
+
+// lostOnEOF reports a lost connection once the server's output ends, and
+// closes the output then
+type lostOnEOF struct {
+	io.Reader
+	onLost func(error)
+}
+
+func (r *lostOnEOF) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err != nil {
+		if closer, ok := r.Reader.(io.Closer); ok {
+			_ = closer.Close()
+		}
+		if err == io.EOF {
+			r.onLost(fmt.Errorf("MCP server closed the connection"))
+		} else {
+			r.onLost(fmt.Errorf("MCP server connection failed: %w", err))
+		}
+	}
+	return n, err
+}
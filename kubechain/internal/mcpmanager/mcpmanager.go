@@ -2,38 +2,68 @@ package mcpmanager
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"io"
+	"net"
 	"os/exec"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	mcpclient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
 	"github.com/mark3labs/mcp-go/mcp"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 
 	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+	"github.com/humanlayer/smallchain/kubechain/internal/mcpbridge"
 )
 
+// bridgeDialTimeout bounds connecting to the bridge of a deployed stdio server
+const bridgeDialTimeout = 10 * time.Second
+
+// drainTimeout bounds how long a replaced connection waits for in-flight
+// tool calls before it is closed
+const drainTimeout = 2 * time.Minute
+
+// failureBuffer is how many lost connections can wait for the controller
+const failureBuffer = 64
+
 // MCPServerManager manages MCP server connections and tools
 type MCPServerManager struct {
-	connections map[string]*MCPConnection
+	// connections are keyed by the namespace and name of their MCPServer
+	connections map[types.NamespacedName]*MCPConnection
 	mu          sync.RWMutex
 	client      ctrlclient.Client // Kubernetes client for accessing resources
+	// dialBridge opens a session on the bridge of a stdio server running in its own Deployment
+	dialBridge func(ctx context.Context, mcpServer *kubechainv1alpha1.MCPServer) (net.Conn, error)
+	// failures receives an event for every lost connection
+	failures chan event.GenericEvent
+	// listChanges receives an event for every connection whose tools,
+	// resources or prompts changed
+	listChanges chan event.GenericEvent
+	// started and stopped are set by SetupWithManager, they are closed when
+	// the controller manager starts and stops the manager
+	started chan struct{}
+	stopped chan struct{}
 }
 
 type MCPManagerInterface interface {
-	CallTool(ctx context.Context, serverName, toolName string, args map[string]interface{}) (string, error)
+	CallToolResult(ctx context.Context, namespace, serverName, toolName string, args map[string]interface{}) (*ToolResult, error)
+	FindServerForTool(namespace, fullToolName string) (serverName string, toolName string, found bool)
 }
 
 // MCPConnection represents a connection to an MCP server
 type MCPConnection struct {
 	// ServerName is the name of the MCPServer resource
 	ServerName string
-	// ServerType is "stdio" or "http"
+	// Namespace is the namespace of the MCPServer resource
+	Namespace string
+	// ServerType is "stdio", "http" or "streamable-http"
 	ServerType string
 	// Command is the stdio process (if ServerType is "stdio")
 	Command *exec.Cmd
@@ -41,30 +71,88 @@ type MCPConnection struct {
 	Client mcpclient.MCPClient
 	// Tools is the list of tools provided by this server
 	Tools []kubechainv1alpha1.MCPTool
+	// Resources is the list of resources provided by this server
+	Resources []kubechainv1alpha1.MCPResource
+	// Prompts is the list of prompts provided by this server
+	Prompts []kubechainv1alpha1.MCPPrompt
+	// ConfigHash identifies the spec and secrets the connection was built from
+	ConfigHash string
+
+	// readsResources is set when ReadResourceTool is generated rather than
+	// the server's own, it changes when the server's lists do
+	readsResources atomic.Bool
+	// capabilities are what the server advertised when initialized
+	capabilities mcp.ServerCapabilities
+
+	// inflight counts the tool calls running on this connection
+	inflight sync.WaitGroup
+	// callIDs numbers the tool calls sent on this connection
+	callIDs atomic.Int64
+	// progress holds the ProgressFunc of running tool calls by progress token
+	progress sync.Map
+	// samplers holds the Sampler of running tool calls by request ID
+	samplers sync.Map
+	// elicitors holds the Elicitor of running tool calls by request ID
+	elicitors sync.Map
+
+	// stateMu guards closed and lostErr
+	stateMu sync.Mutex
+	// closed is set once the connection is closed on purpose
+	closed bool
+	// lostErr is why the connection died, if it did
+	lostErr error
+	// exited is closed when the stdio process exits
+	exited chan struct{}
 }
 
 // NewMCPServerManager creates a new MCPServerManager
 func NewMCPServerManager() *MCPServerManager {
-	return &MCPServerManager{
-		connections: make(map[string]*MCPConnection),
+	m := &MCPServerManager{
+		connections: make(map[types.NamespacedName]*MCPConnection),
 		mu:          sync.RWMutex{},
+		failures:    make(chan event.GenericEvent, failureBuffer),
+		listChanges: make(chan event.GenericEvent, failureBuffer),
 	}
+	m.dialBridge = m.openBridgeSession
+	return m
 }
 
 // NewMCPServerManagerWithClient creates a new MCPServerManager with a Kubernetes client
 func NewMCPServerManagerWithClient(c ctrlclient.Client) *MCPServerManager {
-	return &MCPServerManager{
-		connections: make(map[string]*MCPConnection),
-		mu:          sync.RWMutex{},
-		client:      c,
+	m := NewMCPServerManager()
+	m.client = c
+	return m
+}
+
+// openBridgeSession connects to an MCPServer's bridge and authenticates with
+// the token the MCPServer controller keeps in a Secret
+func (m *MCPServerManager) openBridgeSession(ctx context.Context, mcpServer *kubechainv1alpha1.MCPServer) (net.Conn, error) {
+	secret, err := m.getSecret(ctx, mcpServer.Namespace, mcpbridge.TokenSecretName(mcpServer))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bridge token: %w", err)
 	}
+	token, exists := secret.Data[mcpbridge.TokenKey]
+	if !exists {
+		return nil, fmt.Errorf("key %s not found in secret %s", mcpbridge.TokenKey, secret.Name)
+	}
+
+	dialer := &net.Dialer{Timeout: bridgeDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", mcpbridge.Address(mcpServer))
+	if err != nil {
+		return nil, err
+	}
+	if err := mcpbridge.Authenticate(conn, string(token)); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return conn, nil
 }
 
-// GetConnection returns the MCPConnection for the given server name
-func (m *MCPServerManager) GetConnection(serverName string) (*MCPConnection, bool) {
+// GetConnection returns the MCPConnection for the given server
+func (m *MCPServerManager) GetConnection(namespace, serverName string) (*MCPConnection, bool) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	conn, exists := m.connections[serverName]
+	conn, exists := m.connections[serverKey(namespace, serverName)]
 	return conn, exists
 }
 
@@ -111,199 +199,268 @@ func (m *MCPServerManager) convertEnvVars(ctx context.Context, envVars []kubecha
 
 // ConnectServer establishes a connection to an MCP server
 func (m *MCPServerManager) ConnectServer(ctx context.Context, mcpServer *kubechainv1alpha1.MCPServer) error {
+	if err := m.waitForStart(ctx); err != nil {
+		return err
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	// Check if we already have a connection for this server
-	if conn, exists := m.connections[mcpServer.Name]; exists {
-		// If the server exists and the specs are the same, reuse the connection
-		// TODO: Add logic to detect if specs changed and reconnect if needed
-		if conn.ServerType == mcpServer.Spec.Transport {
+	if m.isStoppedLocked() {
+		return fmt.Errorf("MCP manager is stopped")
+	}
+
+	configHash, err := ConfigHash(ctx, m.client, mcpServer)
+	if err != nil {
+		return err
+	}
+
+	// Reuse the existing connection unless the spec or its secrets changed.
+	// A changed connection keeps serving until its replacement is ready.
+	key := serverKey(mcpServer.Namespace, mcpServer.Name)
+	if conn, exists := m.connections[key]; exists {
+		if conn.ConfigHash == configHash {
 			return nil
 		}
+		defer m.replaceLocked(key, conn)
+	}
+
+	newConn := &MCPConnection{
+		ServerName: mcpServer.Name,
+		Namespace:  mcpServer.Namespace,
+		ServerType: mcpServer.Spec.Transport,
+		ConfigHash: configHash,
+	}
+	onLost := func(err error) { m.connectionLost(newConn, err) }
 
-		// Clean up existing connection
-		m.disconnectServerLocked(mcpServer.Name)
+	var clientOptions []mcpclient.ClientOption
+	if mcpServer.Spec.Sampling != nil {
+		// advertises sampling to the server
+		clientOptions = append(clientOptions, mcpclient.WithSamplingHandler(samplingHandler{conn: newConn}))
+	}
+	if mcpServer.Spec.ElicitationChannel() != nil {
+		// advertises elicitation to the server
+		clientOptions = append(clientOptions, mcpclient.WithElicitationHandler(elicitationHandler{conn: newConn}))
 	}
 
 	var mcpClient mcpclient.MCPClient
-	var err error
 
-	if mcpServer.Spec.Transport == "stdio" {
+	if mcpServer.Spec.Transport == "stdio" && mcpServer.Spec.Deployment != nil {
+		// The server runs in its own Deployment; talk to it through its bridge
+		conn, err := m.dialBridge(ctx, mcpServer)
+		if err != nil {
+			return fmt.Errorf("failed to connect to MCP server deployment: %w", err)
+		}
+		// the bridge has no separate log stream, the server's stderr goes to the Pod logs
+		stdio := transport.NewIO(&lostOnEOF{Reader: conn, onLost: onLost}, conn, io.NopCloser(strings.NewReader("")))
+		bridgeClient := mcpclient.NewClient(stdio, clientOptions...)
+		// starting the client rather than the transport lets it receive the server's messages
+		if err := bridgeClient.Start(context.Background()); err != nil {
+			_ = conn.Close()
+			return fmt.Errorf("failed to start MCP bridge transport: %w", err)
+		}
+		mcpClient = bridgeClient
+	} else if mcpServer.Spec.Transport == "stdio" {
 		// Convert environment variables, resolving any secret references
 		envVars, err := m.convertEnvVars(ctx, mcpServer.Spec.Env, mcpServer.Namespace)
 		if err != nil {
 			return fmt.Errorf("failed to process environment variables: %w", err)
 		}
 
-		// Create a stdio-based MCP client
-		mcpClient, err = mcpclient.NewStdioMCPClient(mcpServer.Spec.Command, envVars, mcpServer.Spec.Args...)
+		// Start the server process ourselves so that we notice when it exits
+		stdout, stdin, err := m.startProcess(newConn, mcpServer.Spec.Command, envVars, mcpServer.Spec.Args)
 		if err != nil {
 			return fmt.Errorf("failed to create stdio MCP client: %w", err)
 		}
+		stdio := transport.NewIO(&lostOnEOF{Reader: stdout, onLost: onLost}, stdin, io.NopCloser(strings.NewReader("")))
+		stdioClient := mcpclient.NewClient(stdio, clientOptions...)
+		if err := stdioClient.Start(context.Background()); err != nil {
+			newConn.close()
+			return fmt.Errorf("failed to start stdio MCP client: %w", err)
+		}
+		mcpClient = stdioClient
 	} else if mcpServer.Spec.Transport == "http" {
+		headers, httpClient, err := m.httpConfig(ctx, mcpServer)
+		if err != nil {
+			return err
+		}
 		// Create an SSE-based MCP client for HTTP connections
-		mcpClient, err = mcpclient.NewSSEMCPClient(mcpServer.Spec.URL)
+		sse, err := transport.NewSSE(mcpServer.Spec.URL,
+			transport.WithHeaders(headers), transport.WithHTTPClient(httpClient))
 		if err != nil {
 			return fmt.Errorf("failed to create SSE MCP client: %w", err)
 		}
+		sseClient := mcpclient.NewClient(sse, clientOptions...)
+		// Unlike stdio, the SSE transport has to be started explicitly, its
+		// stream outlives the reconcile that connects
+		if err := sseClient.Start(context.Background()); err != nil {
+			return fmt.Errorf("failed to start SSE MCP client: %w", err)
+		}
+		mcpClient = sseClient
+	} else if mcpServer.Spec.Transport == "streamable-http" {
+		headers, httpClient, err := m.httpConfig(ctx, mcpServer)
+		if err != nil {
+			return err
+		}
+		// listening continuously lets the server notify us of changed lists
+		streamable, err := transport.NewStreamableHTTP(mcpServer.Spec.URL,
+			transport.WithHTTPHeaders(headers), transport.WithHTTPBasicClient(httpClient),
+			transport.WithContinuousListening())
+		if err != nil {
+			return fmt.Errorf("failed to create streamable HTTP MCP client: %w", err)
+		}
+		httpMCPClient := mcpclient.NewClient(streamable, clientOptions...)
+		// the listening stream outlives the reconcile that connects
+		if err := httpMCPClient.Start(context.Background()); err != nil {
+			return fmt.Errorf("failed to start streamable HTTP MCP client: %w", err)
+		}
+		mcpClient = httpMCPClient
 	} else {
 		return fmt.Errorf("unsupported MCP server transport: %s", mcpServer.Spec.Transport)
 	}
 
+	// Follow list changes and the progress of tool calls
+	if notifier, ok := mcpClient.(notificationSource); ok {
+		notifier.OnNotification(func(notification mcp.JSONRPCNotification) {
+			m.handleNotification(newConn, notification)
+		})
+	}
+
 	// Initialize the client
-	_, err = mcpClient.Initialize(ctx, mcp.InitializeRequest{})
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcp.Implementation{Name: "kubechain", Version: "v1alpha1"}
+	newConn.Client = mcpClient
+	initResult, err := mcpClient.Initialize(ctx, initRequest)
 	if err != nil {
-		if closeErr := mcpClient.Close(); closeErr != nil {
-			fmt.Printf("Error closing mcpClient: %v\n", closeErr)
-		} // Clean up on error
+		newConn.close() // Clean up on error
 		return fmt.Errorf("failed to initialize MCP client: %w", err)
 	}
+	newConn.capabilities = initResult.Capabilities
 
-	// Get the list of tools
-	toolsResp, err := mcpClient.ListTools(ctx, mcp.ListToolsRequest{})
+	lists, err := listServer(ctx, mcpClient, newConn.capabilities)
 	if err != nil {
-		if closeErr := mcpClient.Close(); closeErr != nil {
-			fmt.Printf("Error closing mcpClient: %v\n", closeErr)
-		} // Clean up on error
-		return fmt.Errorf("failed to list tools: %w", err)
+		newConn.close()
+		return err
 	}
+	newConn.setLists(lists)
 
-	// Convert tools to kubechain format
-	tools := make([]kubechainv1alpha1.MCPTool, 0, len(toolsResp.Tools))
-	for _, tool := range toolsResp.Tools {
-		// Handle the InputSchema properly
-		var inputSchemaBytes []byte
-		var err error
-
-		if len(tool.RawInputSchema) > 0 {
-			// Use RawInputSchema if available (preferred)
-			inputSchemaBytes = tool.RawInputSchema
-		} else {
-			// Otherwise, use the structured InputSchema and ensure required is an array
-			schema := tool.InputSchema
-
-			// Ensure required is not null
-			if schema.Required == nil {
-				schema.Required = []string{}
-			}
+	// Store the connection
+	m.connections[key] = newConn
 
-			inputSchemaBytes, err = json.Marshal(schema)
-			if err != nil {
-				// Log the error but continue
-				fmt.Printf("Error marshaling input schema for tool %s: %v\n", tool.Name, err)
-				// Use a minimal valid schema as fallback
-				inputSchemaBytes = []byte(`{"type":"object","properties":{},"required":[]}`)
-			}
-		}
+	return nil
+}
 
-		tools = append(tools, kubechainv1alpha1.MCPTool{
-			Name:        tool.Name,
-			Description: tool.Description,
-			InputSchema: runtime.RawExtension{Raw: inputSchemaBytes},
-		})
+// replaceLocked retires a connection that is out of date. Whether or not a
+// replacement was stored, the old connection is removed from the map and
+// closed once its in-flight tool calls have finished. Assumes the lock is held.
+func (m *MCPServerManager) replaceLocked(key types.NamespacedName, old *MCPConnection) {
+	if m.connections[key] == old {
+		delete(m.connections, key)
 	}
+	go drain(old)
+}
 
-	// Store the connection
-	m.connections[mcpServer.Name] = &MCPConnection{
-		ServerName: mcpServer.Name,
-		ServerType: mcpServer.Spec.Transport,
-		Client:     mcpClient,
-		Tools:      tools,
+// drain closes a connection after its in-flight tool calls have finished
+func drain(conn *MCPConnection) {
+	done := make(chan struct{})
+	go func() {
+		conn.inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(drainTimeout):
+		fmt.Printf("Closing MCP connection to %s with tool calls still running\n", conn.ServerName)
 	}
-
-	return nil
+	conn.close()
 }
 
 // DisconnectServer closes the connection to an MCP server
-func (m *MCPServerManager) DisconnectServer(serverName string) {
+func (m *MCPServerManager) DisconnectServer(namespace, serverName string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.disconnectServerLocked(serverName)
+	m.disconnectServerLocked(serverKey(namespace, serverName))
 }
 
 // disconnectServerLocked is the internal implementation of DisconnectServer
 // that assumes the lock is already held
-func (m *MCPServerManager) disconnectServerLocked(serverName string) {
-	conn, exists := m.connections[serverName]
+func (m *MCPServerManager) disconnectServerLocked(key types.NamespacedName) {
+	conn, exists := m.connections[key]
 	if !exists {
 		return
 	}
 
 	// Close the connection
-	if conn.Client != nil {
-		if err := conn.Client.Close(); err != nil {
-			fmt.Printf("Error closing MCP client connection: %v\n", err)
-		}
-	}
+	conn.close()
 
 	// Remove the connection from the map
-	delete(m.connections, serverName)
+	delete(m.connections, key)
 }
 
 // GetTools returns the tools for the given server
-func (m *MCPServerManager) GetTools(serverName string) ([]kubechainv1alpha1.MCPTool, bool) {
+func (m *MCPServerManager) GetTools(namespace, serverName string) ([]kubechainv1alpha1.MCPTool, bool) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	conn, exists := m.connections[serverName]
+	conn, exists := m.connections[serverKey(namespace, serverName)]
 	if !exists {
 		return nil, false
 	}
 	return conn.Tools, true
 }
 
-// GetToolsForAgent returns all tools from the MCP servers referenced by the agent
+// GetToolsForAgent returns the tools the agent may use from the MCP servers it references
 func (m *MCPServerManager) GetToolsForAgent(agent *kubechainv1alpha1.Agent) []kubechainv1alpha1.MCPTool {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	var allTools []kubechainv1alpha1.MCPTool
 	for _, serverRef := range agent.Spec.MCPServers {
-		conn, exists := m.connections[serverRef.Name]
+		conn, exists := m.connections[serverKey(agent.Namespace, serverRef.Name)]
 		if !exists {
 			continue
 		}
-		allTools = append(allTools, conn.Tools...)
+		allTools = append(allTools, FilterTools(serverRef, conn.Tools)...)
 	}
 	return allTools
 }
 
-// CallTool calls a tool on an MCP server
-func (m *MCPServerManager) CallTool(ctx context.Context, serverName, toolName string, arguments map[string]interface{}) (string, error) {
-	m.mu.RLock()
-	conn, exists := m.connections[serverName]
-	m.mu.RUnlock()
+// CallTool calls a tool on an MCP server and returns its text, with
+// non-text contents described
+func (m *MCPServerManager) CallTool(ctx context.Context, namespace, serverName, toolName string, arguments map[string]interface{}) (string, error) {
+	result, err := m.CallToolResult(ctx, namespace, serverName, toolName, arguments)
+	if result == nil {
+		return "", err
+	}
+	return result.String(), err
+}
 
-	if !exists {
-		return "", fmt.Errorf("MCP server not found: %s", serverName)
+// CallToolResult calls a tool on an MCP server and returns its text and
+// non-text contents
+func (m *MCPServerManager) CallToolResult(ctx context.Context, namespace, serverName, toolName string, arguments map[string]interface{}) (*ToolResult, error) {
+	conn, err := m.acquire(namespace, serverName)
+	if err != nil {
+		return nil, err
 	}
+	defer conn.inflight.Done()
 
-	result, err := conn.Client.CallTool(ctx, mcp.CallToolRequest{
-		Params: struct {
-			Name      string                 `json:"name"`
-			Arguments map[string]interface{} `json:"arguments,omitempty"`
-			Meta      *struct {
-				ProgressToken mcp.ProgressToken `json:"progressToken,omitempty"`
-			} `json:"_meta,omitempty"`
-		}{
-			Name:      toolName,
-			Arguments: arguments,
-		},
-	})
+	if toolName == ReadResourceTool && conn.readsResources.Load() {
+		uri, err := readResourceArguments(arguments)
+		if err != nil {
+			return nil, err
+		}
+		return readResource(ctx, conn, uri)
+	}
 
+	result, err := callTool(ctx, conn, toolName, arguments)
 	if err != nil {
-		return "", fmt.Errorf("error calling tool %s on server %s: %w", toolName, serverName, err)
+		return nil, fmt.Errorf("error calling tool %s on server %s: %w", toolName, serverName, err)
 	}
 
 	// Process the result
-	var output string
-	for _, content := range result.Content {
-		if textContent, ok := content.(mcp.TextContent); ok {
-			output += textContent.Text
-		} else {
-			// Handle other content types as needed
-			output += "[Non-text content]"
-		}
+	output, err := convertContents(result.Content)
+	if err != nil {
+		return nil, fmt.Errorf("error reading result of tool %s on server %s: %w", toolName, serverName, err)
 	}
 
 	if result.IsError {
@@ -313,31 +470,20 @@ func (m *MCPServerManager) CallTool(ctx context.Context, serverName, toolName st
 	return output, nil
 }
 
-// FindServerForTool finds which MCP server provides a given tool
-// Format of the tool name is expected to be "serverName__toolName"
-func (m *MCPServerManager) FindServerForTool(fullToolName string) (serverName string, toolName string, found bool) {
-	// In our implementation, we'll use serverName__toolName as the format
-	parts := strings.SplitN(fullToolName, "__", 2)
-	if len(parts) != 2 {
-		return "", "", false
-	}
-
-	serverName = parts[0]
-	toolName = parts[1]
-
+// FindServerForTool finds the MCP server and tool in a namespace that an
+// LLM-facing tool name, as built by ToolName, refers to
+func (m *MCPServerManager) FindServerForTool(namespace, fullToolName string) (serverName string, toolName string, found bool) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	// Check if the server exists
-	conn, exists := m.connections[serverName]
-	if !exists {
-		return "", "", false
-	}
-
-	// Check if the tool exists on this server
-	for _, tool := range conn.Tools {
-		if tool.Name == toolName {
-			return serverName, toolName, true
+	for key, conn := range m.connections {
+		if key.Namespace != namespace {
+			continue
+		}
+		for _, tool := range conn.Tools {
+			if ToolName(key.Name, tool.Name) == fullToolName {
+				return key.Name, tool.Name, true
+			}
 		}
 	}
 
@@ -349,7 +495,7 @@ func (m *MCPServerManager) Close() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	for serverName := range m.connections {
-		m.disconnectServerLocked(serverName)
+	for key := range m.connections {
+		m.disconnectServerLocked(key)
 	}
 }
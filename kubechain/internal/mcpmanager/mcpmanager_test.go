@@ -3,16 +3,22 @@ package mcpmanager
 import (
 	"context"
 	"errors"
+	"net"
 	"testing"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+	"github.com/humanlayer/smallchain/kubechain/internal/mcpbridge"
 	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 )
 
 // MockMCPClient mocks the mcpclient.MCPClient interface for testing
@@ -68,6 +74,11 @@ func (m *MockMCPClient) Initialize(ctx context.Context, req mcp.InitializeReques
 	return m.initResult, m.initError
 }
 
+// ListToolsByPage implements mcpclient.MCPClient
+func (m *MockMCPClient) ListToolsByPage(ctx context.Context, req mcp.ListToolsRequest) (*mcp.ListToolsResult, error) {
+	return m.ListTools(ctx, req)
+}
+
 // ListTools implements mcpclient.MCPClient
 func (m *MockMCPClient) ListTools(ctx context.Context, req mcp.ListToolsRequest) (*mcp.ListToolsResult, error) {
 	m.toolsCallCount++
@@ -90,9 +101,15 @@ func (m *MockMCPClient) Close() error {
 // Additional methods required by the interface
 // These are stubs to satisfy the interface but aren't used in our tests
 func (m *MockMCPClient) Ping(ctx context.Context) error { return nil }
+func (m *MockMCPClient) ListResourcesByPage(ctx context.Context, req mcp.ListResourcesRequest) (*mcp.ListResourcesResult, error) {
+	return nil, nil
+}
 func (m *MockMCPClient) ListResources(ctx context.Context, req mcp.ListResourcesRequest) (*mcp.ListResourcesResult, error) {
 	return nil, nil
 }
+func (m *MockMCPClient) ListResourceTemplatesByPage(ctx context.Context, req mcp.ListResourceTemplatesRequest) (*mcp.ListResourceTemplatesResult, error) {
+	return nil, nil
+}
 func (m *MockMCPClient) ListResourceTemplates(ctx context.Context, req mcp.ListResourceTemplatesRequest) (*mcp.ListResourceTemplatesResult, error) {
 	return nil, nil
 }
@@ -103,6 +120,9 @@ func (m *MockMCPClient) Subscribe(ctx context.Context, req mcp.SubscribeRequest)
 func (m *MockMCPClient) Unsubscribe(ctx context.Context, req mcp.UnsubscribeRequest) error {
 	return nil
 }
+func (m *MockMCPClient) ListPromptsByPage(ctx context.Context, req mcp.ListPromptsRequest) (*mcp.ListPromptsResult, error) {
+	return nil, nil
+}
 func (m *MockMCPClient) ListPrompts(ctx context.Context, req mcp.ListPromptsRequest) (*mcp.ListPromptsResult, error) {
 	return nil, nil
 }
@@ -181,8 +201,9 @@ var _ = Describe("MCPServerManager", func() {
 		manager = NewMCPServerManager()
 
 		// Add a test server directly to the connections map
-		manager.connections["test-server"] = &MCPConnection{
+		manager.connections[serverKey("default", "test-server")] = &MCPConnection{
 			ServerName: "test-server",
+			Namespace:  "default",
 			ServerType: "stdio",
 			Client:     mockClient,
 			Tools: []kubechainv1alpha1.MCPTool{
@@ -225,14 +246,14 @@ var _ = Describe("MCPServerManager", func() {
 
 	Describe("GetConnection", func() {
 		It("should return an existing connection", func() {
-			conn, exists := manager.GetConnection("test-server")
+			conn, exists := manager.GetConnection("default", "test-server")
 			Expect(exists).To(BeTrue())
 			Expect(conn).NotTo(BeNil())
 			Expect(conn.ServerName).To(Equal("test-server"))
 		})
 
 		It("should return false for non-existent connections", func() {
-			conn, exists := manager.GetConnection("non-existent")
+			conn, exists := manager.GetConnection("default", "non-existent")
 			Expect(exists).To(BeFalse())
 			Expect(conn).To(BeNil())
 		})
@@ -240,14 +261,14 @@ var _ = Describe("MCPServerManager", func() {
 
 	Describe("GetTools", func() {
 		It("should return tools for an existing server", func() {
-			tools, exists := manager.GetTools("test-server")
+			tools, exists := manager.GetTools("default", "test-server")
 			Expect(exists).To(BeTrue())
 			Expect(tools).To(HaveLen(1))
 			Expect(tools[0].Name).To(Equal("test_tool"))
 		})
 
 		It("should return false for non-existent servers", func() {
-			tools, exists := manager.GetTools("non-existent")
+			tools, exists := manager.GetTools("default", "non-existent")
 			Expect(exists).To(BeFalse())
 			Expect(tools).To(BeNil())
 		})
@@ -257,8 +278,9 @@ var _ = Describe("MCPServerManager", func() {
 		It("should return tools from all referenced servers", func() {
 			// Add another server
 			anotherMock := NewMockMCPClient()
-			manager.connections["another-server"] = &MCPConnection{
+			manager.connections[serverKey("default", "another-server")] = &MCPConnection{
 				ServerName: "another-server",
+				Namespace:  "default",
 				ServerType: "stdio",
 				Client:     anotherMock,
 				Tools: []kubechainv1alpha1.MCPTool{
@@ -272,8 +294,9 @@ var _ = Describe("MCPServerManager", func() {
 
 			// Create a test agent that references both servers
 			agent := &kubechainv1alpha1.Agent{
+				ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "default"},
 				Spec: kubechainv1alpha1.AgentSpec{
-					MCPServers: []kubechainv1alpha1.LocalObjectReference{
+					MCPServers: []kubechainv1alpha1.AgentMCPServer{
 						{Name: "test-server"},
 						{Name: "another-server"},
 					},
@@ -295,8 +318,9 @@ var _ = Describe("MCPServerManager", func() {
 
 		It("should ignore references to non-existent servers", func() {
 			agent := &kubechainv1alpha1.Agent{
+				ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "default"},
 				Spec: kubechainv1alpha1.AgentSpec{
-					MCPServers: []kubechainv1alpha1.LocalObjectReference{
+					MCPServers: []kubechainv1alpha1.AgentMCPServer{
 						{Name: "test-server"},
 						{Name: "non-existent"},
 					},
@@ -307,6 +331,51 @@ var _ = Describe("MCPServerManager", func() {
 			Expect(tools).To(HaveLen(1))
 			Expect(tools[0].Name).To(Equal("test_tool"))
 		})
+
+		It("should leave out tools the agent excludes", func() {
+			agent := &kubechainv1alpha1.Agent{
+				ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "default"},
+				Spec: kubechainv1alpha1.AgentSpec{
+					MCPServers: []kubechainv1alpha1.AgentMCPServer{
+						{Name: "test-server", ExcludeTools: []string{"test_*"}},
+					},
+				},
+			}
+
+			Expect(manager.GetToolsForAgent(agent)).To(BeEmpty())
+		})
+	})
+
+	Describe("ToolAllowed", func() {
+		It("allows every tool without filters", func() {
+			Expect(ToolAllowed(kubechainv1alpha1.AgentMCPServer{Name: "github"}, "create_issue")).To(BeTrue())
+		})
+
+		It("allows only included tools, by name or glob", func() {
+			ref := kubechainv1alpha1.AgentMCPServer{Name: "github", IncludeTools: []string{"get_*", "search_code"}}
+			Expect(ToolAllowed(ref, "get_issue")).To(BeTrue())
+			Expect(ToolAllowed(ref, "search_code")).To(BeTrue())
+			Expect(ToolAllowed(ref, "search_issues")).To(BeFalse())
+			Expect(ToolAllowed(ref, "create_issue")).To(BeFalse())
+		})
+
+		It("lets exclusions win over inclusions", func() {
+			ref := kubechainv1alpha1.AgentMCPServer{
+				Name:         "github",
+				IncludeTools: []string{"*_issue"},
+				ExcludeTools: []string{"create_*", "update_*"},
+			}
+			Expect(ToolAllowed(ref, "get_issue")).To(BeTrue())
+			Expect(ToolAllowed(ref, "create_issue")).To(BeFalse())
+			Expect(ToolAllowed(ref, "update_issue")).To(BeFalse())
+		})
+
+		It("rejects invalid patterns", func() {
+			Expect(ValidateToolFilter(kubechainv1alpha1.AgentMCPServer{Name: "github", ExcludeTools: []string{"create_["}})).
+				To(MatchError(ContainSubstring("create_[")))
+			Expect(ValidateToolFilter(kubechainv1alpha1.AgentMCPServer{Name: "github", IncludeTools: []string{"get_*"}})).
+				To(Succeed())
+		})
 	})
 
 	Describe("CallTool", func() {
@@ -323,7 +392,7 @@ var _ = Describe("MCPServerManager", func() {
 			})
 
 			// Call the tool
-			result, err := manager.CallTool(ctx, "test-server", "test_tool", map[string]interface{}{
+			result, err := manager.CallTool(ctx, "default", "test-server", "test_tool", map[string]interface{}{
 				"param1": "value1",
 			})
 
@@ -339,7 +408,7 @@ var _ = Describe("MCPServerManager", func() {
 		})
 
 		It("should return an error when the server doesn't exist", func() {
-			_, err := manager.CallTool(ctx, "non-existent", "tool", nil)
+			_, err := manager.CallTool(ctx, "default", "non-existent", "tool", nil)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("MCP server not found"))
 		})
@@ -347,7 +416,7 @@ var _ = Describe("MCPServerManager", func() {
 		It("should return an error when the tool call fails", func() {
 			mockClient.SetCallToolError(errors.New("call failed"))
 
-			_, err := manager.CallTool(ctx, "test-server", "test_tool", nil)
+			_, err := manager.CallTool(ctx, "default", "test-server", "test_tool", nil)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("call failed"))
 		})
@@ -363,7 +432,7 @@ var _ = Describe("MCPServerManager", func() {
 				IsError: true,
 			})
 
-			_, err := manager.CallTool(ctx, "test-server", "test_tool", nil)
+			_, err := manager.CallTool(ctx, "default", "test-server", "test_tool", nil)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("Error message"))
 		})
@@ -371,39 +440,94 @@ var _ = Describe("MCPServerManager", func() {
 
 	Describe("FindServerForTool", func() {
 		It("should find the server and tool for a valid formatted name", func() {
-			serverName, toolName, found := manager.FindServerForTool("test-server__test_tool")
+			serverName, toolName, found := manager.FindServerForTool("default", "test-server__test_tool")
 			Expect(found).To(BeTrue())
 			Expect(serverName).To(Equal("test-server"))
 			Expect(toolName).To(Equal("test_tool"))
 		})
 
 		It("should return false for an invalid format", func() {
-			_, _, found := manager.FindServerForTool("invalid-format")
+			_, _, found := manager.FindServerForTool("default", "invalid-format")
 			Expect(found).To(BeFalse())
 		})
 
 		It("should return false for a non-existent server", func() {
-			_, _, found := manager.FindServerForTool("non-existent__tool")
+			_, _, found := manager.FindServerForTool("default", "non-existent__tool")
 			Expect(found).To(BeFalse())
 		})
 
 		It("should return false for a non-existent tool", func() {
-			_, _, found := manager.FindServerForTool("test-server__non-existent")
+			_, _, found := manager.FindServerForTool("default", "test-server__non-existent")
 			Expect(found).To(BeFalse())
 		})
+
+		It("should only find servers of the given namespace", func() {
+			manager.connections[serverKey("team-b", "test-server")] = &MCPConnection{
+				ServerName: "test-server",
+				Namespace:  "team-b",
+				ServerType: "stdio",
+				Client:     NewMockMCPClient(),
+				Tools:      []kubechainv1alpha1.MCPTool{{Name: "other_tool"}},
+			}
+
+			_, _, found := manager.FindServerForTool("team-b", "test-server__test_tool")
+			Expect(found).To(BeFalse())
+			_, toolName, found := manager.FindServerForTool("team-b", "test-server__other_tool")
+			Expect(found).To(BeTrue())
+			Expect(toolName).To(Equal("other_tool"))
+
+			tools, _ := manager.GetTools("default", "test-server")
+			Expect(tools).To(HaveLen(1))
+			Expect(tools[0].Name).To(Equal("test_tool"))
+		})
+
+		It("should find tools by their shortened names", func() {
+			longTool := "create_or_update_a_very_long_named_repository_file_in_a_branch"
+			manager.connections[serverKey("default", "github")] = &MCPConnection{
+				ServerName: "github",
+				Namespace:  "default",
+				ServerType: "stdio",
+				Client:     NewMockMCPClient(),
+				Tools:      []kubechainv1alpha1.MCPTool{{Name: longTool}},
+			}
+
+			serverName, toolName, found := manager.FindServerForTool("default", ToolName("github", longTool))
+			Expect(found).To(BeTrue())
+			Expect(serverName).To(Equal("github"))
+			Expect(toolName).To(Equal(longTool))
+		})
+	})
+
+	Describe("ToolName", func() {
+		It("joins the server and tool names", func() {
+			Expect(ToolName("fetch", "fetch")).To(Equal("fetch__fetch"))
+		})
+
+		It("shortens long names to a stable name ending with a hash", func() {
+			longTool := "create_or_update_a_very_long_named_repository_file_in_a_branch"
+			name := ToolName("github", longTool)
+			Expect(len(name)).To(Equal(64))
+			Expect(name).To(HavePrefix("github__create_or_update"))
+			Expect(ToolName("github", longTool)).To(Equal(name))
+			Expect(ToolName("github", longTool+"s")).NotTo(Equal(name))
+		})
+
+		It("replaces characters providers reject", func() {
+			Expect(ToolName("docs.internal", "search")).To(MatchRegexp(`^docs_internal__search_[0-9a-f]{8}$`))
+		})
 	})
 
 	Describe("DisconnectServer", func() {
 		It("should remove the server from connections", func() {
 			// Verify connection exists
-			_, exists := manager.GetConnection("test-server")
+			_, exists := manager.GetConnection("default", "test-server")
 			Expect(exists).To(BeTrue())
 
 			// Disconnect server
-			manager.DisconnectServer("test-server")
+			manager.DisconnectServer("default", "test-server")
 
 			// Verify connection is removed
-			_, exists = manager.GetConnection("test-server")
+			_, exists = manager.GetConnection("default", "test-server")
 			Expect(exists).To(BeFalse())
 
 			// Verify Close was called on client
@@ -412,7 +536,7 @@ var _ = Describe("MCPServerManager", func() {
 
 		It("should do nothing for non-existent servers", func() {
 			// This shouldn't panic
-			manager.DisconnectServer("non-existent")
+			manager.DisconnectServer("default", "non-existent")
 		})
 	})
 
@@ -420,8 +544,9 @@ var _ = Describe("MCPServerManager", func() {
 		It("should close all connections", func() {
 			// Add another connection
 			anotherMock := NewMockMCPClient()
-			manager.connections["another-server"] = &MCPConnection{
+			manager.connections[serverKey("default", "another-server")] = &MCPConnection{
 				ServerName: "another-server",
+				Namespace:  "default",
 				ServerType: "stdio",
 				Client:     anotherMock,
 			}
@@ -447,3 +572,133 @@ var _ = Describe("MCPServerManager", func() {
 	// and NewSSEMCPClient, which would require refactoring the production code
 	// to allow dependency injection
 })
+
+var _ = Describe("ConnectServer with a deployed stdio server", func() {
+	var (
+		ctx     context.Context
+		cancel  context.CancelFunc
+		manager *MCPServerManager
+		dialed  []string
+		servers []net.Conn
+	)
+
+	newDeployedServer := func(args ...string) *kubechainv1alpha1.MCPServer {
+		return &kubechainv1alpha1.MCPServer{
+			ObjectMeta: metav1.ObjectMeta{Name: "fetch", Namespace: "default"},
+			Spec: kubechainv1alpha1.MCPServerSpec{
+				Transport:  "stdio",
+				Command:    "uvx",
+				Args:       args,
+				Deployment: &kubechainv1alpha1.MCPServerDeployment{Image: "python:3.12-slim"},
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+		dialed = nil
+		servers = nil
+
+		manager = NewMCPServerManager()
+		manager.dialBridge = func(ctx context.Context, deployed *kubechainv1alpha1.MCPServer) (net.Conn, error) {
+			dialed = append(dialed, mcpbridge.Address(deployed))
+			// like the bridge, every connection gets a fresh server
+			mcpServer := server.NewMCPServer("test", "1.0.0")
+			mcpServer.AddTool(mcp.NewTool("fetch", mcp.WithDescription("Fetches a URL")),
+				func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+					return mcp.NewToolResultText("fetched"), nil
+				})
+			clientConn, serverConn := net.Pipe()
+			servers = append(servers, serverConn)
+			go func() {
+				_ = server.NewStdioServer(mcpServer).Listen(ctx, serverConn, serverConn)
+			}()
+			return clientConn, nil
+		}
+	})
+
+	AfterEach(func() {
+		manager.Close()
+		cancel()
+	})
+
+	It("connects through the server's bridge", func() {
+		err := manager.ConnectServer(ctx, newDeployedServer("mcp-server-fetch"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(dialed).To(Equal([]string{"fetch-mcp.default.svc:3100"}))
+
+		tools, exists := manager.GetTools("default", "fetch")
+		Expect(exists).To(BeTrue())
+		Expect(tools).To(HaveLen(1))
+		Expect(tools[0].Name).To(Equal("fetch"))
+
+		result, err := manager.CallTool(ctx, "default", "fetch", "fetch", map[string]interface{}{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal("fetched"))
+	})
+
+	It("reuses the connection until the spec changes", func() {
+		Expect(manager.ConnectServer(ctx, newDeployedServer("mcp-server-fetch"))).To(Succeed())
+		first, _ := manager.GetConnection("default", "fetch")
+
+		Expect(manager.ConnectServer(ctx, newDeployedServer("mcp-server-fetch"))).To(Succeed())
+		Expect(dialed).To(HaveLen(1))
+
+		Expect(manager.ConnectServer(ctx, newDeployedServer("mcp-server-fetch", "--ignore-robots-txt"))).To(Succeed())
+		Expect(dialed).To(HaveLen(2))
+		second, _ := manager.GetConnection("default", "fetch")
+		Expect(second).NotTo(BeIdenticalTo(first))
+		Expect(second.ConfigHash).NotTo(Equal(first.ConfigHash))
+
+		result, err := manager.CallTool(ctx, "default", "fetch", "fetch", map[string]interface{}{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal("fetched"))
+	})
+
+	It("reports a lost connection", func() {
+		Expect(manager.ConnectServer(ctx, newDeployedServer("mcp-server-fetch"))).To(Succeed())
+		Expect(manager.CheckHealth(ctx, "default", "fetch")).To(Succeed())
+
+		// the server Pod goes away
+		Expect(servers[0].Close()).To(Succeed())
+
+		var failure event.GenericEvent
+		Eventually(manager.ConnectionFailures()).Should(Receive(&failure))
+		Expect(failure.Object.GetName()).To(Equal("fetch"))
+		Expect(failure.Object.GetNamespace()).To(Equal("default"))
+		Expect(manager.CheckHealth(ctx, "default", "fetch")).To(MatchError(ContainSubstring("closed the connection")))
+	})
+
+	It("needs the bridge token to connect", func() {
+		clientManager := NewMCPServerManagerWithClient(fake.NewClientBuilder().Build())
+		defer clientManager.Close()
+
+		err := clientManager.ConnectServer(ctx, newDeployedServer("mcp-server-fetch"))
+		Expect(err).To(MatchError(ContainSubstring("failed to read bridge token")))
+		Expect(err).To(MatchError(ContainSubstring("fetch-mcp-bridge")))
+	})
+})
+
+var _ = Describe("ConnectServer with a stdio process", func() {
+	It("reports the process exiting", func() {
+		manager := NewMCPServerManager()
+		defer manager.Close()
+
+		// answers initialize and tools/list, then exits
+		script := `id() { echo "$1" | sed 's/.*"id":\([0-9]*\).*/\1/'; }
+read line; echo '{"jsonrpc":"2.0","id":'$(id "$line")',"result":{"protocolVersion":"2024-11-05","capabilities":{"tools":{}},"serverInfo":{"name":"sh","version":"1"}}}'
+read line; read line; echo '{"jsonrpc":"2.0","id":'$(id "$line")',"result":{"tools":[]}}'`
+		err := manager.ConnectServer(context.Background(), &kubechainv1alpha1.MCPServer{
+			ObjectMeta: metav1.ObjectMeta{Name: "sh", Namespace: "default"},
+			Spec: kubechainv1alpha1.MCPServerSpec{
+				Transport: "stdio",
+				Command:   "sh",
+				Args:      []string{"-c", script},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(manager.ConnectionFailures()).Should(Receive())
+		Expect(manager.CheckHealth(context.Background(), "default", "sh")).To(HaveOccurred())
+	})
+})
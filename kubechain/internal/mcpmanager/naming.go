@@ -0,0 +1,43 @@
+package mcpmanager
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// maxToolNameLength is the longest tool name LLM providers accept
+const maxToolNameLength = 64
+
+// toolNameHashLength is how many hex digits of the hash end a shortened tool name
+const toolNameHashLength = 8
+
+// invalidToolNameChars matches what LLM providers don't accept in tool names
+var invalidToolNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// serverKey identifies the connection to an MCPServer
+func serverKey(namespace, serverName string) types.NamespacedName {
+	return types.NamespacedName{Namespace: namespace, Name: serverName}
+}
+
+// ToolName is the name the LLM knows a tool of an MCP server by,
+// "serverName__toolName". The namespace is left out since agents only use
+// servers of their own namespace. Names that are too long or have characters
+// providers reject are shortened and end with a hash of the full name, so
+// that they stay stable and distinct. FindServerForTool resolves them.
+func ToolName(serverName, toolName string) string {
+	name := serverName + "__" + toolName
+	safe := invalidToolNameChars.ReplaceAllString(name, "_")
+	if safe == name && len(name) <= maxToolNameLength {
+		return name
+	}
+
+	sum := sha256.Sum256([]byte(name))
+	hash := hex.EncodeToString(sum[:])[:toolNameHashLength]
+	if len(safe) > maxToolNameLength-toolNameHashLength-1 {
+		safe = safe[:maxToolNameLength-toolNameHashLength-1]
+	}
+	return safe + "_" + hash
+}
@@ -0,0 +1,160 @@
+package mcpmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// cancelTimeout bounds telling a server that a tool call was cancelled
+const cancelTimeout = 5 * time.Second
+
+// MCP notifications that mcp-go has no constants for
+const (
+	methodNotificationProgress  = "notifications/progress"
+	methodNotificationCancelled = "notifications/cancelled"
+)
+
+// Progress is a progress update a server sent for a running tool call
+type Progress struct {
+	// Progress increases as the tool works
+	Progress float64
+	// Total is what Progress reaches when the tool is done, 0 if unknown
+	Total float64
+	// Message describes what the tool is doing
+	Message string
+}
+
+// ProgressFunc receives the progress of a tool call. It is called on the
+// connection's transport, so it must not block.
+type ProgressFunc func(Progress)
+
+type progressKey struct{}
+
+// WithProgress asks the server of a tool call for progress updates and
+// delivers them to fn
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// progressFrom returns the ProgressFunc of a context, if any
+func progressFrom(ctx context.Context) ProgressFunc {
+	fn, _ := ctx.Value(progressKey{}).(ProgressFunc)
+	return fn
+}
+
+// RPCError is the JSON-RPC error a server answered a tool call with
+type RPCError struct {
+	Code    int
+	Message string
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("%s (JSON-RPC error %d)", e.Message, e.Code)
+}
+
+// requester is implemented by MCP clients whose transport can be used
+// directly. Tool calls are sent over it with request IDs of our own, so that
+// a cancelled call can be named in a notifications/cancelled.
+type requester interface {
+	GetTransport() transport.Interface
+}
+
+// callTool calls a tool over a connection the caller holds. If ctx is done
+// before the server answers, the server is told to stop working on the call.
+func callTool(ctx context.Context, conn *MCPConnection, toolName string, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	// The client numbers its own requests on the transport with integers,
+	// string IDs never equal those: request IDs match by type and value
+	id := fmt.Sprintf("kubechain-%d", conn.callIDs.Add(1))
+	requestID := mcp.NewRequestId(id)
+	params := mcp.CallToolParams{
+		Name:      toolName,
+		Arguments: arguments,
+	}
+	if s := samplerFrom(ctx); s != nil {
+		conn.samplers.Store(id, s)
+		defer conn.samplers.Delete(id)
+	}
+	if e := elicitorFrom(ctx); e != nil {
+		conn.elicitors.Store(id, e)
+		defer conn.elicitors.Delete(id)
+	}
+	if fn := progressFrom(ctx); fn != nil {
+		// the request ID is unique on the connection, it serves as the progress token too
+		params.Meta = &mcp.Meta{ProgressToken: id}
+		conn.progress.Store(id, fn)
+		defer conn.progress.Delete(id)
+	}
+
+	r, ok := conn.Client.(requester)
+	if !ok {
+		return conn.Client.CallTool(ctx, mcp.CallToolRequest{Params: params})
+	}
+
+	response, err := r.GetTransport().SendRequest(ctx, transport.JSONRPCRequest{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      requestID,
+		Method:  string(mcp.MethodToolsCall),
+		Params:  params,
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			sendCancelled(r.GetTransport(), requestID, context.Cause(ctx))
+		}
+		return nil, err
+	}
+	if response.Error != nil {
+		return nil, &RPCError{Code: response.Error.Code, Message: response.Error.Message}
+	}
+	return mcp.ParseCallToolResult(&response.Result)
+}
+
+// sendCancelled tells a server that the client no longer waits for a request
+func sendCancelled(t transport.Interface, requestID mcp.RequestId, cause error) {
+	ctx, cancel := context.WithTimeout(context.Background(), cancelTimeout)
+	defer cancel()
+
+	notification := mcp.JSONRPCNotification{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		Notification: mcp.Notification{
+			Method: methodNotificationCancelled,
+			Params: mcp.NotificationParams{AdditionalFields: map[string]any{
+				"requestId": requestID,
+				"reason":    cause.Error(),
+			}},
+		},
+	}
+	if err := t.SendNotification(ctx, notification); err != nil {
+		fmt.Printf("Error sending cancellation of MCP request %s: %v\n", requestID.String(), err)
+	}
+}
+
+// handleProgress delivers a progress notification to the call it is for
+func (c *MCPConnection) handleProgress(notification mcp.JSONRPCNotification) {
+	raw, err := json.Marshal(notification.Params)
+	if err != nil {
+		return
+	}
+	var params mcp.ProgressNotificationParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return
+	}
+	token, ok := params.ProgressToken.(string)
+	if !ok {
+		return
+	}
+	fn, ok := c.progress.Load(token)
+	if !ok {
+		// the call already returned
+		return
+	}
+	fn.(ProgressFunc)(Progress{
+		Progress: params.Progress,
+		Total:    params.Total,
+		Message:  params.Message,
+	})
+}
@@ -0,0 +1,146 @@
+package mcpmanager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+var _ = Describe("Long running tool calls", func() {
+	var httpServer *httptest.Server
+	var manager *MCPServerManager
+	var release chan struct{}
+
+	var mu sync.Mutex
+	var cancellations []map[string]interface{}
+
+	BeforeEach(func() {
+		release = make(chan struct{})
+		cancellations = nil
+
+		mcpServer := server.NewMCPServer("exports", "1.0.0")
+		mcpServer.AddTool(mcp.NewTool("export"),
+			func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				if request.Params.Meta != nil && request.Params.Meta.ProgressToken != nil {
+					_ = server.ServerFromContext(ctx).SendNotificationToClient(ctx, "notifications/progress", map[string]any{
+						"progressToken": request.Params.Meta.ProgressToken,
+						"progress":      1,
+						"total":         2,
+						"message":       "exported 1 of 2 tables",
+					})
+				}
+				select {
+				case <-release:
+					return mcp.NewToolResultText("exported"), nil
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+			})
+		streamable := server.NewStreamableHTTPServer(mcpServer)
+
+		// record the cancellations the client sends
+		httpServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				body, _ := io.ReadAll(r.Body)
+				r.Body = io.NopCloser(bytes.NewReader(body))
+				var message struct {
+					Method string                 `json:"method"`
+					Params map[string]interface{} `json:"params"`
+				}
+				if json.Unmarshal(body, &message) == nil && message.Method == "notifications/cancelled" {
+					mu.Lock()
+					cancellations = append(cancellations, message.Params)
+					mu.Unlock()
+				}
+			}
+			streamable.ServeHTTP(w, r)
+		}))
+
+		manager = NewMCPServerManager()
+		Expect(manager.ConnectServer(context.Background(), &kubechainv1alpha1.MCPServer{
+			ObjectMeta: metav1.ObjectMeta{Name: "exports", Namespace: "default"},
+			Spec: kubechainv1alpha1.MCPServerSpec{
+				Transport: "streamable-http",
+				URL:       httpServer.URL + "/mcp",
+			},
+		})).To(Succeed())
+	})
+
+	AfterEach(func() {
+		manager.Close()
+		httpServer.Close()
+	})
+
+	It("delivers the progress of a call", func() {
+		updates := make(chan Progress, 10)
+		ctx := WithProgress(context.Background(), func(progress Progress) {
+			updates <- progress
+		})
+
+		done := make(chan string)
+		go func() {
+			defer GinkgoRecover()
+			result, err := manager.CallTool(ctx, "default", "exports", "export", nil)
+			Expect(err).NotTo(HaveOccurred())
+			done <- result
+		}()
+
+		var update Progress
+		Eventually(updates, 5*time.Second).Should(Receive(&update))
+		Expect(update).To(Equal(Progress{Progress: 1, Total: 2, Message: "exported 1 of 2 tables"}))
+
+		close(release)
+		Eventually(done, 5*time.Second).Should(Receive(Equal("exported")))
+	})
+
+	It("keeps the JSON-RPC error code of a failed call", func() {
+		_, err := manager.CallTool(context.Background(), "default", "exports", "import", nil)
+
+		var rpcErr *RPCError
+		Expect(errors.As(err, &rpcErr)).To(BeTrue())
+		Expect(rpcErr.Code).To(Equal(mcp.INVALID_PARAMS))
+		Expect(err).To(MatchError(ContainSubstring("JSON-RPC error -32602")))
+	})
+
+	It("tells the server when a call is cancelled", func() {
+		ctx, cancel := context.WithCancelCause(context.Background())
+		started := make(chan struct{})
+		ctx = WithProgress(ctx, func(Progress) { close(started) })
+
+		done := make(chan error)
+		go func() {
+			_, err := manager.CallTool(ctx, "default", "exports", "export", nil)
+			done <- err
+		}()
+
+		Eventually(started, 5*time.Second).Should(BeClosed())
+		cancel(errors.New("TaskRunToolCall was deleted"))
+
+		var err error
+		Eventually(done, 5*time.Second).Should(Receive(&err))
+		Expect(err).To(HaveOccurred())
+
+		Eventually(func() []map[string]interface{} {
+			mu.Lock()
+			defer mu.Unlock()
+			return cancellations
+		}, 5*time.Second).Should(ConsistOf(And(
+			HaveKeyWithValue("requestId", HavePrefix("kubechain-")),
+			HaveKeyWithValue("reason", "TaskRunToolCall was deleted"),
+		)))
+	})
+})
@@ -0,0 +1,239 @@
+package mcpmanager
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	mcpclient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+// ReadResourceTool is the tool generated for servers that provide resources,
+// it lets agents read them. A tool of the server with the same name wins.
+const ReadResourceTool = "read_resource"
+
+const (
+	// maxResourcePages bounds the pages of resources listed, so a server
+	// that keeps returning cursors can't keep the manager listing forever
+	maxResourcePages = 100
+
+	// maxDescribedResources is how many resources the description of the
+	// generated tool lists, every LLM turn pays for the description
+	maxDescribedResources = 50
+)
+
+// listResources lists the resources of a server that advertises them,
+// following the server's pagination
+func listResources(ctx context.Context, c mcpclient.MCPClient, capabilities mcp.ServerCapabilities) ([]kubechainv1alpha1.MCPResource, error) {
+	if capabilities.Resources == nil {
+		return nil, nil
+	}
+
+	var resources []kubechainv1alpha1.MCPResource
+	request := mcp.ListResourcesRequest{}
+	seen := map[mcp.Cursor]bool{}
+	for page := 0; page < maxResourcePages; page++ {
+		result, err := c.ListResourcesByPage(ctx, request)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list resources: %w", err)
+		}
+		for _, resource := range result.Resources {
+			resources = append(resources, kubechainv1alpha1.MCPResource{
+				URI:         resource.URI,
+				Name:        resource.Name,
+				Description: resource.Description,
+				MimeType:    resource.MIMEType,
+			})
+		}
+		if result.NextCursor == "" || seen[result.NextCursor] {
+			return resources, nil
+		}
+		seen[result.NextCursor] = true
+		request.Params.Cursor = result.NextCursor
+	}
+	log.FromContext(ctx).Info("Stopped listing MCP server resources", "pages", maxResourcePages, "resources", len(resources))
+	return resources, nil
+}
+
+// listPrompts lists the prompts of a server that advertises them
+func listPrompts(ctx context.Context, c mcpclient.MCPClient, capabilities mcp.ServerCapabilities) ([]kubechainv1alpha1.MCPPrompt, error) {
+	if capabilities.Prompts == nil {
+		return nil, nil
+	}
+	result, err := c.ListPrompts(ctx, mcp.ListPromptsRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prompts: %w", err)
+	}
+	prompts := make([]kubechainv1alpha1.MCPPrompt, 0, len(result.Prompts))
+	for _, prompt := range result.Prompts {
+		arguments := make([]kubechainv1alpha1.MCPPromptArgument, 0, len(prompt.Arguments))
+		for _, argument := range prompt.Arguments {
+			arguments = append(arguments, kubechainv1alpha1.MCPPromptArgument{
+				Name:        argument.Name,
+				Description: argument.Description,
+				Required:    argument.Required,
+			})
+		}
+		prompts = append(prompts, kubechainv1alpha1.MCPPrompt{
+			Name:        prompt.Name,
+			Description: prompt.Description,
+			Arguments:   arguments,
+		})
+	}
+	return prompts, nil
+}
+
+// readResourceTool describes the generated tool that reads a server's
+// resources, the first of them are listed in its description
+func readResourceTool(resources []kubechainv1alpha1.MCPResource) kubechainv1alpha1.MCPTool {
+	var description strings.Builder
+	if len(resources) > maxDescribedResources {
+		fmt.Fprintf(&description, "Reads a resource of this server. Available resources (the first %d of %d, the others can be read by URI too):",
+			maxDescribedResources, len(resources))
+	} else {
+		fmt.Fprintf(&description, "Reads a resource of this server. Available resources (%d):", len(resources))
+	}
+	for _, resource := range resources[:min(len(resources), maxDescribedResources)] {
+		fmt.Fprintf(&description, "\n- %s", resource.URI)
+		if resource.Name != "" {
+			fmt.Fprintf(&description, " (%s)", resource.Name)
+		}
+		if resource.Description != "" {
+			fmt.Fprintf(&description, ": %s", resource.Description)
+		}
+	}
+
+	schema := []byte(`{"type":"object","properties":{"uri":{"type":"string","description":"URI of the resource to read"}},"required":["uri"]}`)
+	return kubechainv1alpha1.MCPTool{
+		Name:        ReadResourceTool,
+		Description: description.String(),
+		InputSchema: runtime.RawExtension{Raw: schema},
+	}
+}
+
+// hasTool reports whether a tool with the given name is in the list
+func hasTool(tools []kubechainv1alpha1.MCPTool, name string) bool {
+	for _, tool := range tools {
+		if tool.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// GetResources returns the resources of the given server
+func (m *MCPServerManager) GetResources(namespace, serverName string) ([]kubechainv1alpha1.MCPResource, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	conn, exists := m.connections[serverKey(namespace, serverName)]
+	if !exists {
+		return nil, false
+	}
+	return conn.Resources, true
+}
+
+// GetPrompts returns the prompts of the given server
+func (m *MCPServerManager) GetPrompts(namespace, serverName string) ([]kubechainv1alpha1.MCPPrompt, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	conn, exists := m.connections[serverKey(namespace, serverName)]
+	if !exists {
+		return nil, false
+	}
+	return conn.Prompts, true
+}
+
+// ReadResource reads a resource of an MCP server and returns its text.
+// Binary contents are described rather than returned.
+func (m *MCPServerManager) ReadResource(ctx context.Context, namespace, serverName, uri string) (string, error) {
+	conn, err := m.acquire(namespace, serverName)
+	if err != nil {
+		return "", err
+	}
+	defer conn.inflight.Done()
+
+	result, err := readResource(ctx, conn, uri)
+	if err != nil {
+		return "", err
+	}
+	return result.String(), nil
+}
+
+// readResource reads a resource over a connection the caller holds
+func readResource(ctx context.Context, conn *MCPConnection, uri string) (*ToolResult, error) {
+	request := mcp.ReadResourceRequest{}
+	request.Params.URI = uri
+	result, err := conn.Client.ReadResource(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("error reading resource %s on server %s: %w", uri, conn.ServerName, err)
+	}
+
+	contents := make([]mcp.Content, 0, len(result.Contents))
+	for _, resource := range result.Contents {
+		contents = append(contents, mcp.NewEmbeddedResource(resource))
+	}
+	output, err := convertContents(contents)
+	if err != nil {
+		return nil, fmt.Errorf("error reading resource %s on server %s: %w", uri, conn.ServerName, err)
+	}
+	return output, nil
+}
+
+// GetPrompt renders a prompt of an MCP server into messages
+func (m *MCPServerManager) GetPrompt(ctx context.Context, namespace, serverName, promptName string, arguments map[string]string) ([]kubechainv1alpha1.Message, error) {
+	conn, err := m.acquire(namespace, serverName)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.inflight.Done()
+
+	request := mcp.GetPromptRequest{}
+	request.Params.Name = promptName
+	request.Params.Arguments = arguments
+	result, err := conn.Client.GetPrompt(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("error getting prompt %s on server %s: %w", promptName, serverName, err)
+	}
+
+	messages := make([]kubechainv1alpha1.Message, 0, len(result.Messages))
+	for _, message := range result.Messages {
+		// prompts become plain messages, their non-text contents are described
+		content, err := convertContents([]mcp.Content{message.Content})
+		if err != nil {
+			return nil, fmt.Errorf("error getting prompt %s on server %s: %w", promptName, serverName, err)
+		}
+		messages = append(messages, kubechainv1alpha1.Message{
+			Role:    string(message.Role),
+			Content: content.String(),
+		})
+	}
+	return messages, nil
+}
+
+// acquire returns the connection to a server with a call registered on it,
+// the caller must call inflight.Done on it
+func (m *MCPServerManager) acquire(namespace, serverName string) (*MCPConnection, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	conn, exists := m.connections[serverKey(namespace, serverName)]
+	if !exists {
+		return nil, fmt.Errorf("MCP server not found: %s/%s", namespace, serverName)
+	}
+	// registered under the lock so a replacement can't close the connection mid-call
+	conn.inflight.Add(1)
+	return conn, nil
+}
+
+// readResourceArguments gets the uri argument of a read_resource call
+func readResourceArguments(arguments map[string]interface{}) (string, error) {
+	uri, ok := arguments["uri"].(string)
+	if !ok || uri == "" {
+		return "", fmt.Errorf("%s needs a uri argument", ReadResourceTool)
+	}
+	return uri, nil
+}
@@ -0,0 +1,140 @@
+package mcpmanager
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+var _ = Describe("ConnectServer with a server providing resources and prompts", func() {
+	var httpServer *httptest.Server
+	var manager *MCPServerManager
+
+	BeforeEach(func() {
+		mcpServer := server.NewMCPServer("wiki", "1.0.0",
+			server.WithResourceCapabilities(false, false),
+			server.WithPromptCapabilities(false))
+		mcpServer.AddTool(mcp.NewTool("search", mcp.WithDescription("Searches the wiki")),
+			func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				return mcp.NewToolResultText("found"), nil
+			})
+		mcpServer.AddResource(mcp.NewResource("wiki://onboarding", "Onboarding",
+			mcp.WithResourceDescription("How to get started"), mcp.WithMIMEType("text/markdown")),
+			func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+				return []mcp.ResourceContents{mcp.TextResourceContents{
+					URI: request.Params.URI, MIMEType: "text/markdown", Text: "# Welcome",
+				}}, nil
+			})
+		mcpServer.AddPrompt(mcp.NewPrompt("summarize",
+			mcp.WithPromptDescription("Summarizes a page"),
+			mcp.WithArgument("page", mcp.RequiredArgument())),
+			func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+				return mcp.NewGetPromptResult("Summarize a page", []mcp.PromptMessage{
+					mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent("Summarize "+request.Params.Arguments["page"])),
+				}), nil
+			})
+		httpServer = httptest.NewServer(server.NewStreamableHTTPServer(mcpServer))
+
+		manager = NewMCPServerManager()
+		Expect(manager.ConnectServer(context.Background(), &kubechainv1alpha1.MCPServer{
+			ObjectMeta: metav1.ObjectMeta{Name: "wiki", Namespace: "default"},
+			Spec: kubechainv1alpha1.MCPServerSpec{
+				Transport: "streamable-http",
+				URL:       httpServer.URL + "/mcp",
+			},
+		})).To(Succeed())
+	})
+
+	AfterEach(func() {
+		manager.Close()
+		httpServer.Close()
+	})
+
+	It("lists resources and prompts", func() {
+		resources, exists := manager.GetResources("default", "wiki")
+		Expect(exists).To(BeTrue())
+		Expect(resources).To(Equal([]kubechainv1alpha1.MCPResource{{
+			URI: "wiki://onboarding", Name: "Onboarding", Description: "How to get started", MimeType: "text/markdown",
+		}}))
+
+		prompts, exists := manager.GetPrompts("default", "wiki")
+		Expect(exists).To(BeTrue())
+		Expect(prompts).To(Equal([]kubechainv1alpha1.MCPPrompt{{
+			Name:        "summarize",
+			Description: "Summarizes a page",
+			Arguments:   []kubechainv1alpha1.MCPPromptArgument{{Name: "page", Required: true}},
+		}}))
+	})
+
+	It("reads resources directly and through the generated tool", func() {
+		content, err := manager.ReadResource(context.Background(), "default", "wiki", "wiki://onboarding")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(content).To(Equal("# Welcome"))
+
+		tools, _ := manager.GetTools("default", "wiki")
+		Expect(tools).To(HaveLen(2))
+		Expect(tools[1].Name).To(Equal(ReadResourceTool))
+		Expect(tools[1].Description).To(ContainSubstring("wiki://onboarding (Onboarding): How to get started"))
+
+		content, err = manager.CallTool(context.Background(), "default", "wiki", ReadResourceTool, map[string]interface{}{"uri": "wiki://onboarding"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(content).To(Equal("# Welcome"))
+
+		_, err = manager.CallTool(context.Background(), "default", "wiki", ReadResourceTool, map[string]interface{}{})
+		Expect(err).To(MatchError(ContainSubstring("needs a uri argument")))
+	})
+
+	It("renders prompts into messages", func() {
+		messages, err := manager.GetPrompt(context.Background(), "default", "wiki", "summarize", map[string]string{"page": "onboarding"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(messages).To(Equal([]kubechainv1alpha1.Message{{Role: "user", Content: "Summarize onboarding"}}))
+	})
+})
+
+var _ = Describe("ConnectServer with a server paginating many resources", func() {
+	It("lists every page and describes the first resources only", func() {
+		mcpServer := server.NewMCPServer("archive", "1.0.0",
+			server.WithResourceCapabilities(false, false),
+			server.WithPaginationLimit(20))
+		mcpServer.AddTool(mcp.NewTool("search"),
+			func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				return mcp.NewToolResultText("found"), nil
+			})
+		for i := 0; i < maxDescribedResources+10; i++ {
+			mcpServer.AddResource(mcp.NewResource(fmt.Sprintf("archive://doc-%03d", i), fmt.Sprintf("Doc %d", i)),
+				func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+					return []mcp.ResourceContents{mcp.TextResourceContents{URI: request.Params.URI, Text: "old news"}}, nil
+				})
+		}
+		httpServer := httptest.NewServer(server.NewStreamableHTTPServer(mcpServer))
+		DeferCleanup(httpServer.Close)
+
+		manager := NewMCPServerManager()
+		DeferCleanup(manager.Close)
+		Expect(manager.ConnectServer(context.Background(), &kubechainv1alpha1.MCPServer{
+			ObjectMeta: metav1.ObjectMeta{Name: "archive", Namespace: "default"},
+			Spec: kubechainv1alpha1.MCPServerSpec{
+				Transport: "streamable-http",
+				URL:       httpServer.URL + "/mcp",
+			},
+		})).To(Succeed())
+
+		resources, _ := manager.GetResources("default", "archive")
+		Expect(resources).To(HaveLen(maxDescribedResources + 10))
+
+		tools, _ := manager.GetTools("default", "archive")
+		Expect(tools).To(HaveLen(2))
+		description := tools[1].Description
+		Expect(description).To(ContainSubstring(fmt.Sprintf("the first %d of %d", maxDescribedResources, maxDescribedResources+10)))
+		Expect(strings.Count(description, "archive://")).To(Equal(maxDescribedResources))
+	})
+})
@@ -0,0 +1,64 @@
+package mcpmanager
+
+import (
+	"context"
+	"fmt"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// SetupWithManager registers the manager as a Runnable of the controller
+// manager. It then only opens connections once started, which happens on the
+// elected leader, so replicas don't each spawn every stdio server. Its
+// connections are closed when the controller manager stops.
+func (m *MCPServerManager) SetupWithManager(mgr ctrl.Manager) error {
+	m.started = make(chan struct{})
+	m.stopped = make(chan struct{})
+	return mgr.Add(m)
+}
+
+// Start implements manager.Runnable
+func (m *MCPServerManager) Start(ctx context.Context) error {
+	close(m.started)
+	<-ctx.Done()
+
+	m.mu.Lock()
+	close(m.stopped)
+	m.mu.Unlock()
+	m.Close()
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, connections
+// are only held by the leader
+func (m *MCPServerManager) NeedLeaderElection() bool {
+	return true
+}
+
+// waitForStart blocks until a manager registered with SetupWithManager is
+// started. Managers used on their own can connect right away.
+func (m *MCPServerManager) waitForStart(ctx context.Context) error {
+	if m.started == nil {
+		return nil
+	}
+	select {
+	case <-m.started:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("MCP manager not started: %w", ctx.Err())
+	}
+}
+
+// isStoppedLocked reports whether the controller manager stopped the manager,
+// the caller must hold mu
+func (m *MCPServerManager) isStoppedLocked() bool {
+	if m.stopped == nil {
+		return false
+	}
+	select {
+	case <-m.stopped:
+		return true
+	default:
+		return false
+	}
+}
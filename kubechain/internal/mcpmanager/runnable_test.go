@@ -0,0 +1,76 @@
+package mcpmanager
+
+import (
+	"context"
+	"net/http/httptest"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+var _ = Describe("MCPServerManager as a Runnable", func() {
+	var httpServer *httptest.Server
+	var manager *MCPServerManager
+	var mcpServer *kubechainv1alpha1.MCPServer
+
+	BeforeEach(func() {
+		s := server.NewMCPServer("echo", "1.0.0")
+		s.AddTool(mcp.NewTool("echo"),
+			func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				return mcp.NewToolResultText("echo"), nil
+			})
+		httpServer = httptest.NewServer(server.NewStreamableHTTPServer(s))
+		mcpServer = &kubechainv1alpha1.MCPServer{
+			ObjectMeta: metav1.ObjectMeta{Name: "echo", Namespace: "default"},
+			Spec: kubechainv1alpha1.MCPServerSpec{
+				Transport: "streamable-http",
+				URL:       httpServer.URL + "/mcp",
+			},
+		}
+
+		// what SetupWithManager sets up, without a controller manager
+		manager = NewMCPServerManager()
+		manager.started = make(chan struct{})
+		manager.stopped = make(chan struct{})
+	})
+
+	AfterEach(func() {
+		manager.Close()
+		httpServer.Close()
+	})
+
+	It("needs leader election", func() {
+		Expect(manager.NeedLeaderElection()).To(BeTrue())
+	})
+
+	It("only connects once started and disconnects when stopped", func() {
+		waitCtx, cancelWait := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancelWait()
+		Expect(manager.ConnectServer(waitCtx, mcpServer)).To(MatchError(ContainSubstring("MCP manager not started")))
+		_, connected := manager.GetConnection("default", "echo")
+		Expect(connected).To(BeFalse())
+
+		runCtx, stop := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			Expect(manager.Start(runCtx)).To(Succeed())
+		}()
+
+		Expect(manager.ConnectServer(context.Background(), mcpServer)).To(Succeed())
+		_, connected = manager.GetConnection("default", "echo")
+		Expect(connected).To(BeTrue())
+
+		stop()
+		Eventually(done).Should(BeClosed())
+		_, connected = manager.GetConnection("default", "echo")
+		Expect(connected).To(BeFalse())
+		Expect(manager.ConnectServer(context.Background(), mcpServer)).To(MatchError("MCP manager is stopped"))
+	})
+})
@@ -0,0 +1,89 @@
+package mcpmanager
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+// Sampler answers the sampling requests an MCP server makes while it runs a
+// tool call, i.e. creates LLM messages for it
+type Sampler interface {
+	CreateMessage(ctx context.Context, request mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error)
+}
+
+type samplerKey struct{}
+
+// WithSampler answers the sampling requests made during a tool call with s.
+// They are only made by servers whose MCPServer enables sampling.
+func WithSampler(ctx context.Context, s Sampler) context.Context {
+	return context.WithValue(ctx, samplerKey{}, s)
+}
+
+// samplerFrom returns the Sampler of a context, if any
+func samplerFrom(ctx context.Context) Sampler {
+	s, _ := ctx.Value(samplerKey{}).(Sampler)
+	return s
+}
+
+// samplingHandler receives the sampling requests of a connection
+type samplingHandler struct {
+	conn *MCPConnection
+}
+
+// CreateMessage passes a sampling request on to the Sampler of the tool call
+// it is made for. MCP doesn't say which call that is, so the request is only
+// answered while a single call with a Sampler is running on the connection.
+func (h samplingHandler) CreateMessage(ctx context.Context, request mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+	var samplers []Sampler
+	h.conn.samplers.Range(func(_, value any) bool {
+		samplers = append(samplers, value.(Sampler))
+		return true
+	})
+
+	switch len(samplers) {
+	case 0:
+		return nil, fmt.Errorf("sampling is only available while a tool call is running")
+	case 1:
+		return samplers[0].CreateMessage(ctx, request)
+	default:
+		return nil, fmt.Errorf("sampling request can't be attributed to one of %d running tool calls", len(samplers))
+	}
+}
+
+// SamplingMessages converts the messages of a sampling request to LLM
+// messages, like prompts their non-text contents are described
+func SamplingMessages(request mcp.CreateMessageRequest) ([]kubechainv1alpha1.Message, error) {
+	messages := make([]kubechainv1alpha1.Message, 0, len(request.Messages)+1)
+	if request.SystemPrompt != "" {
+		messages = append(messages, kubechainv1alpha1.Message{Role: "system", Content: request.SystemPrompt})
+	}
+	for _, message := range request.Messages {
+		var content mcp.Content
+		switch c := message.Content.(type) {
+		case mcp.Content:
+			content = c
+		case map[string]any:
+			// a request read off the wire
+			parsed, err := mcp.ParseContent(c)
+			if err != nil {
+				return nil, fmt.Errorf("invalid sampling message: %w", err)
+			}
+			content = parsed
+		default:
+			return nil, fmt.Errorf("invalid sampling message content %T", message.Content)
+		}
+		result, err := convertContents([]mcp.Content{content})
+		if err != nil {
+			return nil, fmt.Errorf("invalid sampling message: %w", err)
+		}
+		messages = append(messages, kubechainv1alpha1.Message{
+			Role:    string(message.Role),
+			Content: result.String(),
+		})
+	}
+	return messages, nil
+}
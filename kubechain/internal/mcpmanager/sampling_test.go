@@ -0,0 +1,117 @@
+package mcpmanager
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+// fakeSampler answers sampling requests with a fixed text and records them
+type fakeSampler struct {
+	requests []mcp.CreateMessageRequest
+}
+
+func (s *fakeSampler) CreateMessage(ctx context.Context, request mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+	s.requests = append(s.requests, request)
+	return &mcp.CreateMessageResult{
+		SamplingMessage: mcp.SamplingMessage{Role: mcp.RoleAssistant, Content: mcp.NewTextContent("a short summary")},
+		Model:           "test-model",
+	}, nil
+}
+
+var _ = Describe("Sampling", func() {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	var manager *MCPServerManager
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+
+		manager = NewMCPServerManager()
+		// a deployed stdio server, its bridge connection leads to an in-process server
+		manager.dialBridge = func(ctx context.Context, _ *kubechainv1alpha1.MCPServer) (net.Conn, error) {
+			mcpServer := server.NewMCPServer("notes", "1.0.0")
+			mcpServer.EnableSampling()
+			mcpServer.AddTool(mcp.NewTool("summarize"),
+				func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+					result, err := mcpServer.RequestSampling(ctx, mcp.CreateMessageRequest{
+						CreateMessageParams: mcp.CreateMessageParams{
+							SystemPrompt: "You summarize notes",
+							Messages: []mcp.SamplingMessage{
+								{Role: mcp.RoleUser, Content: mcp.NewTextContent("Summarize: the meeting moved to Friday")},
+							},
+							MaxTokens: 100,
+						},
+					})
+					if err != nil {
+						return mcp.NewToolResultError(err.Error()), nil
+					}
+					return mcp.NewToolResultText(fmt.Sprintf("%s (%s)", mcp.GetTextFromContent(result.Content), result.Model)), nil
+				})
+			clientConn, serverConn := net.Pipe()
+			go func() {
+				_ = server.NewStdioServer(mcpServer).Listen(ctx, serverConn, serverConn)
+			}()
+			return clientConn, nil
+		}
+	})
+
+	AfterEach(func() {
+		manager.Close()
+		cancel()
+	})
+
+	connect := func(sampling *kubechainv1alpha1.MCPServerSampling) {
+		Expect(manager.ConnectServer(ctx, &kubechainv1alpha1.MCPServer{
+			ObjectMeta: metav1.ObjectMeta{Name: "notes", Namespace: "default"},
+			Spec: kubechainv1alpha1.MCPServerSpec{
+				Transport:  "stdio",
+				Command:    "notes-server",
+				Deployment: &kubechainv1alpha1.MCPServerDeployment{Image: "notes:latest"},
+				Sampling:   sampling,
+			},
+		})).To(Succeed())
+	}
+
+	It("answers the sampling requests of a call with its sampler", func() {
+		connect(&kubechainv1alpha1.MCPServerSampling{})
+
+		sampler := &fakeSampler{}
+		result, err := manager.CallTool(WithSampler(ctx, sampler), "default", "notes", "summarize", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal("a short summary (test-model)"))
+
+		Expect(sampler.requests).To(HaveLen(1))
+		messages, err := SamplingMessages(sampler.requests[0])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(messages).To(Equal([]kubechainv1alpha1.Message{
+			{Role: "system", Content: "You summarize notes"},
+			{Role: "user", Content: "Summarize: the meeting moved to Friday"},
+		}))
+		Expect(sampler.requests[0].MaxTokens).To(Equal(100))
+	})
+
+	It("rejects sampling requests of calls without a sampler", func() {
+		connect(&kubechainv1alpha1.MCPServerSampling{})
+
+		_, err := manager.CallTool(ctx, "default", "notes", "summarize", nil)
+		Expect(err).To(MatchError(ContainSubstring("only available while a tool call is running")))
+	})
+
+	It("doesn't offer sampling to servers that don't enable it", func() {
+		connect(nil)
+
+		sampler := &fakeSampler{}
+		_, err := manager.CallTool(WithSampler(ctx, sampler), "default", "notes", "summarize", nil)
+		Expect(err).To(HaveOccurred())
+		Expect(sampler.requests).To(BeEmpty())
+	})
+})
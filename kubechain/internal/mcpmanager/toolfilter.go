@@ -0,0 +1,69 @@
+package mcpmanager
+
+import (
+	"fmt"
+	"path"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+// ValidateToolFilter checks that the include and exclude patterns of an
+// agent's MCP server reference are valid globs
+func ValidateToolFilter(ref kubechainv1alpha1.AgentMCPServer) error {
+	if err := ValidateToolPatterns(ref.IncludeTools); err != nil {
+		return err
+	}
+	return ValidateToolPatterns(ref.ExcludeTools)
+}
+
+// ValidateToolPatterns checks that tool name patterns are valid globs
+func ValidateToolPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid tool pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// ToolAllowed reports whether an agent's MCP server reference lets it use a
+// tool: the tool must match an include pattern, if there are any, and no
+// exclude pattern
+func ToolAllowed(ref kubechainv1alpha1.AgentMCPServer, toolName string) bool {
+	if len(ref.IncludeTools) > 0 && !MatchesAny(ref.IncludeTools, toolName) {
+		return false
+	}
+	return !MatchesAny(ref.ExcludeTools, toolName)
+}
+
+// AgentServerRef returns the agent's reference to an MCP server, if it has one
+func AgentServerRef(agent *kubechainv1alpha1.Agent, serverName string) (kubechainv1alpha1.AgentMCPServer, bool) {
+	for _, ref := range agent.Spec.MCPServers {
+		if ref.Name == serverName {
+			return ref, true
+		}
+	}
+	return kubechainv1alpha1.AgentMCPServer{}, false
+}
+
+// FilterTools returns the tools an agent's MCP server reference allows
+func FilterTools(ref kubechainv1alpha1.AgentMCPServer, tools []kubechainv1alpha1.MCPTool) []kubechainv1alpha1.MCPTool {
+	filtered := make([]kubechainv1alpha1.MCPTool, 0, len(tools))
+	for _, tool := range tools {
+		if ToolAllowed(ref, tool.Name) {
+			filtered = append(filtered, tool)
+		}
+	}
+	return filtered
+}
+
+// MatchesAny reports whether a tool name matches one of the patterns, invalid
+// patterns match nothing
+func MatchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package modelrouter
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+// charsPerToken is the rough ratio used to estimate the size of a context window
+// without a provider-specific tokenizer
+const charsPerToken = 4
+
+// DefaultInstructions is the classification prompt used when a router doesn't set one
+const DefaultInstructions = "You route requests to language models. Classify how demanding it is to produce the next response in the conversation below."
+
+// Turn describes an LLM turn for route matching
+type Turn struct {
+	// ContextTokens is the estimated size of the context window
+	ContextTokens int64
+	// HasTools is whether tools are offered to the LLM
+	HasTools bool
+	// Tier is the tier declared by the Task, if any
+	Tier string
+}
+
+// Classifier labels a turn with one of the router's classifier labels
+type Classifier func() (string, error)
+
+// EstimateTokens estimates the token count of a context window
+func EstimateTokens(messages []kubechainv1alpha1.Message) int64 {
+	var chars int
+	for _, message := range messages {
+		chars += len(message.Content)
+		for _, toolCall := range message.ToolCalls {
+			chars += len(toolCall.Function.Name) + len(toolCall.Function.Arguments)
+		}
+	}
+	return int64((chars + charsPerToken - 1) / charsPerToken)
+}
+
+// Select returns the first route of the router that matches the turn, or nil
+// if none does. The classifier is called at most once, and only when a route
+// with ClassifierLabels is reached.
+func Select(spec *kubechainv1alpha1.ModelRouterSpec, turn Turn, classify Classifier) (*kubechainv1alpha1.ModelRoute, error) {
+	var label string
+	classified := false
+
+	for i := range spec.Routes {
+		route := &spec.Routes[i]
+		match := route.Match
+
+		if match.MinContextTokens != nil && turn.ContextTokens < *match.MinContextTokens {
+			continue
+		}
+		if match.MaxContextTokens != nil && turn.ContextTokens > *match.MaxContextTokens {
+			continue
+		}
+		if match.HasTools != nil && turn.HasTools != *match.HasTools {
+			continue
+		}
+		if len(match.Tiers) > 0 && !slices.Contains(match.Tiers, turn.Tier) {
+			continue
+		}
+		if len(match.ClassifierLabels) > 0 {
+			if !classified {
+				if classify == nil {
+					return nil, fmt.Errorf("route %q matches on classifier labels but the router has no classifier", route.Name)
+				}
+				var err error
+				if label, err = classify(); err != nil {
+					return nil, fmt.Errorf("failed to classify turn: %w", err)
+				}
+				classified = true
+			}
+			if !slices.Contains(match.ClassifierLabels, label) {
+				continue
+			}
+		}
+		return route, nil
+	}
+
+	return nil, nil
+}
+
+// ClassifierPrompt builds the context window sent to the classifier LLM.
+// Only the system prompt and the latest user message are included to keep
+// the call cheap.
+func ClassifierPrompt(classifier *kubechainv1alpha1.ModelRouterClassifier, messages []kubechainv1alpha1.Message) []kubechainv1alpha1.Message {
+	instructions := classifier.Instructions
+	if instructions == "" {
+		instructions = DefaultInstructions
+	}
+	instructions += fmt.Sprintf("\n\nRespond with exactly one of these labels and nothing else: %s", strings.Join(classifier.Labels, ", "))
+
+	var conversation strings.Builder
+	for _, message := range messages {
+		if message.Role == "system" && message.Content != "" {
+			fmt.Fprintf(&conversation, "System prompt of the assistant:\n%s\n\n", message.Content)
+			break
+		}
+	}
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" {
+			fmt.Fprintf(&conversation, "Latest user message:\n%s\n\n", messages[i].Content)
+			break
+		}
+	}
+	fmt.Fprintf(&conversation, "Messages so far: %d", len(messages))
+
+	return []kubechainv1alpha1.Message{
+		{Role: "system", Content: instructions},
+		{Role: "user", Content: conversation.String()},
+	}
+}
+
+// ParseLabel maps the classifier's answer to one of its labels. The answer
+// may differ in case or carry surrounding text; the earliest label mentioned wins.
+func ParseLabel(answer string, labels []string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(answer))
+	normalized = strings.Trim(normalized, "\"'`.")
+
+	for _, label := range labels {
+		if strings.ToLower(label) == normalized {
+			return label, nil
+		}
+	}
+
+	best, bestIndex := "", -1
+	for _, label := range labels {
+		index := strings.Index(normalized, strings.ToLower(label))
+		if index >= 0 && (bestIndex < 0 || index < bestIndex || (index == bestIndex && len(label) > len(best))) {
+			best, bestIndex = label, index
+		}
+	}
+	if bestIndex < 0 {
+		return "", fmt.Errorf("classifier answered %q, which is none of %v", answer, labels)
+	}
+	return best, nil
+}
@@ -0,0 +1,116 @@
+package modelrouter
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+func route(name string, match kubechainv1alpha1.ModelRouteMatch) kubechainv1alpha1.ModelRoute {
+	return kubechainv1alpha1.ModelRoute{
+		Name:   name,
+		LLMRef: kubechainv1alpha1.LocalObjectReference{Name: name + "-llm"},
+		Match:  match,
+	}
+}
+
+var _ = Describe("ModelRouter", func() {
+	Context("Select", func() {
+		spec := &kubechainv1alpha1.ModelRouterSpec{
+			Routes: []kubechainv1alpha1.ModelRoute{
+				route("long", kubechainv1alpha1.ModelRouteMatch{MinContextTokens: ptr.To[int64](10000)}),
+				route("complex", kubechainv1alpha1.ModelRouteMatch{Tiers: []string{"complex"}}),
+				route("hard", kubechainv1alpha1.ModelRouteMatch{ClassifierLabels: []string{"hard"}}),
+				route("tools", kubechainv1alpha1.ModelRouteMatch{HasTools: ptr.To(true), MaxContextTokens: ptr.To[int64](2000)}),
+			},
+		}
+
+		It("picks the first matching route", func() {
+			selected, err := Select(spec, Turn{ContextTokens: 20000, Tier: "complex"}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(selected.Name).To(Equal("long"))
+
+			selected, err = Select(spec, Turn{ContextTokens: 100, Tier: "complex"}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(selected.Name).To(Equal("complex"))
+		})
+
+		It("calls the classifier once, only when needed", func() {
+			calls := 0
+			classify := func() (string, error) {
+				calls++
+				return "easy", nil
+			}
+
+			selected, err := Select(spec, Turn{ContextTokens: 100, HasTools: true}, classify)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(selected.Name).To(Equal("tools"))
+			Expect(calls).To(Equal(1))
+
+			selected, err = Select(spec, Turn{ContextTokens: 3000, HasTools: true}, classify)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(selected).To(BeNil())
+			Expect(calls).To(Equal(2))
+		})
+
+		It("returns classifier failures", func() {
+			_, err := Select(spec, Turn{}, func() (string, error) { return "", errors.New("boom") })
+			Expect(err).To(MatchError(ContainSubstring("boom")))
+
+			_, err = Select(spec, Turn{}, nil)
+			Expect(err).To(MatchError(ContainSubstring("has no classifier")))
+		})
+	})
+
+	Context("ParseLabel", func() {
+		labels := []string{"easy", "hard", "very-hard"}
+
+		DescribeTable("maps answers to labels",
+			func(answer, expected string) {
+				Expect(ParseLabel(answer, labels)).To(Equal(expected))
+			},
+			Entry("exact", "hard", "hard"),
+			Entry("case and punctuation", " Easy.\n", "easy"),
+			Entry("longest label at the same position", "very-hard", "very-hard"),
+			Entry("surrounding text", "I would say this is hard, not easy", "hard"),
+		)
+
+		It("fails on unknown answers", func() {
+			_, err := ParseLabel("medium", labels)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	It("estimates tokens from content and tool calls", func() {
+		Expect(EstimateTokens([]kubechainv1alpha1.Message{
+			{Role: "user", Content: "12345678"},
+			{Role: "assistant", ToolCalls: []kubechainv1alpha1.ToolCall{
+				{Function: kubechainv1alpha1.ToolCallFunction{Name: "ab", Arguments: "{}"}},
+			}},
+		})).To(Equal(int64(3)))
+	})
+
+	It("builds a compact classifier prompt", func() {
+		prompt := ClassifierPrompt(&kubechainv1alpha1.ModelRouterClassifier{Labels: []string{"easy", "hard"}},
+			[]kubechainv1alpha1.Message{
+				{Role: "system", Content: "be helpful"},
+				{Role: "user", Content: "first"},
+				{Role: "assistant", Content: "answer"},
+				{Role: "user", Content: "second"},
+			})
+		Expect(prompt).To(HaveLen(2))
+		Expect(prompt[0].Content).To(ContainSubstring("easy, hard"))
+		Expect(prompt[1].Content).To(ContainSubstring("second"))
+		Expect(prompt[1].Content).NotTo(ContainSubstring("first"))
+	})
+})
+
+func TestModelRouter(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ModelRouter Suite")
+}
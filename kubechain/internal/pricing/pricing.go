@@ -0,0 +1,130 @@
+package pricing
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+// DefaultCurrency is used for catalogs that don't set one
+const DefaultCurrency = "USD"
+
+// Price is the resolved token price of a model
+type Price struct {
+	// Catalog is the name of the PricingCatalog the price came from
+	Catalog          string
+	Currency         string
+	InputPerMillion  float64
+	OutputPerMillion float64
+}
+
+// Estimate returns the cost of the given token counts
+func (p *Price) Estimate(inputTokens, outputTokens int64) float64 {
+	return (float64(inputTokens)*p.InputPerMillion + float64(outputTokens)*p.OutputPerMillion) / 1_000_000
+}
+
+// Lookup finds the price for a provider and model across all PricingCatalogs
+// in the namespace. An exact model match wins over a glob pattern, and among
+// patterns the longest (most specific) one wins. Returns nil if no price is known.
+func Lookup(ctx context.Context, c client.Reader, namespace, provider, model string) (*Price, error) {
+	var catalogs kubechainv1alpha1.PricingCatalogList
+	if err := c.List(ctx, &catalogs, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list pricing catalogs: %w", err)
+	}
+
+	// keep the result stable regardless of list order
+	sort.Slice(catalogs.Items, func(i, j int) bool {
+		return catalogs.Items[i].Name < catalogs.Items[j].Name
+	})
+
+	var best *kubechainv1alpha1.ModelPrice
+	var bestCatalog *kubechainv1alpha1.PricingCatalog
+	bestExact := false
+	for i := range catalogs.Items {
+		catalog := &catalogs.Items[i]
+		for j := range catalog.Spec.Prices {
+			price := &catalog.Spec.Prices[j]
+			if price.Provider != provider {
+				continue
+			}
+
+			exact := price.Model == model
+			if !exact {
+				matched, err := path.Match(price.Model, model)
+				if err != nil || !matched {
+					continue
+				}
+			}
+
+			switch {
+			case best == nil,
+				exact && !bestExact,
+				!exact && !bestExact && len(price.Model) > len(best.Model):
+				best, bestCatalog, bestExact = price, catalog, exact
+			}
+		}
+	}
+
+	if best == nil {
+		return nil, nil
+	}
+
+	input, err := strconv.ParseFloat(best.InputPerMillion, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid input price for %s/%s in catalog %s: %w", best.Provider, best.Model, bestCatalog.Name, err)
+	}
+	output, err := strconv.ParseFloat(best.OutputPerMillion, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output price for %s/%s in catalog %s: %w", best.Provider, best.Model, bestCatalog.Name, err)
+	}
+
+	currency := bestCatalog.Spec.Currency
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+
+	return &Price{
+		Catalog:          bestCatalog.Name,
+		Currency:         currency,
+		InputPerMillion:  input,
+		OutputPerMillion: output,
+	}, nil
+}
+
+// FormatCost renders a cost the way it is stored in status fields
+func FormatCost(cost float64) string {
+	return strconv.FormatFloat(cost, 'f', 6, 64)
+}
+
+// AddUsage folds the usage of a single turn into a summary. The cost is only
+// added if the turn was priced in the summary's currency; other turns are
+// counted as unpriced. Returns the updated summary.
+func AddUsage(summary *kubechainv1alpha1.UsageSummary, usage kubechainv1alpha1.TokenUsage, price *Price) *kubechainv1alpha1.UsageSummary {
+	if summary == nil {
+		summary = &kubechainv1alpha1.UsageSummary{}
+	}
+	summary.Turns++
+	summary.InputTokens += usage.InputTokens
+	summary.OutputTokens += usage.OutputTokens
+
+	if price == nil || (summary.Currency != "" && summary.Currency != price.Currency) {
+		summary.UnpricedTurns++
+		return summary
+	}
+
+	total := 0.0
+	if summary.EstimatedCost != "" {
+		// a malformed total is reset rather than blocking further accounting
+		total, _ = strconv.ParseFloat(summary.EstimatedCost, 64)
+	}
+	total += price.Estimate(usage.InputTokens, usage.OutputTokens)
+	summary.EstimatedCost = FormatCost(total)
+	summary.Currency = price.Currency
+	return summary
+}
@@ -0,0 +1,98 @@
+package pricing
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kubechainv1alpha1 "github.com/humanlayer/smallchain/kubechain/api/v1alpha1"
+)
+
+func newCatalog(name, currency string, prices ...kubechainv1alpha1.ModelPrice) *kubechainv1alpha1.PricingCatalog {
+	return &kubechainv1alpha1.PricingCatalog{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       kubechainv1alpha1.PricingCatalogSpec{Currency: currency, Prices: prices},
+	}
+}
+
+var _ = Describe("Pricing", func() {
+	ctx := context.Background()
+
+	Context("Lookup", func() {
+		var reader *fake.ClientBuilder
+
+		BeforeEach(func() {
+			scheme := runtime.NewScheme()
+			Expect(kubechainv1alpha1.AddToScheme(scheme)).To(Succeed())
+			reader = fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				newCatalog("a-catalog", "",
+					kubechainv1alpha1.ModelPrice{Provider: "openai", Model: "*", InputPerMillion: "1", OutputPerMillion: "1"},
+					kubechainv1alpha1.ModelPrice{Provider: "openai", Model: "gpt-4o*", InputPerMillion: "0.15", OutputPerMillion: "0.6"},
+				),
+				newCatalog("b-catalog", "EUR",
+					kubechainv1alpha1.ModelPrice{Provider: "openai", Model: "gpt-4o", InputPerMillion: "2.5", OutputPerMillion: "10"},
+				),
+			)
+		})
+
+		It("prefers an exact match over patterns", func() {
+			price, err := Lookup(ctx, reader.Build(), "default", "openai", "gpt-4o")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(price).To(Equal(&Price{Catalog: "b-catalog", Currency: "EUR", InputPerMillion: 2.5, OutputPerMillion: 10}))
+		})
+
+		It("prefers the most specific pattern", func() {
+			price, err := Lookup(ctx, reader.Build(), "default", "openai", "gpt-4o-mini")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(price.InputPerMillion).To(Equal(0.15))
+			Expect(price.Currency).To(Equal(DefaultCurrency))
+		})
+
+		It("returns nil when nothing matches", func() {
+			price, err := Lookup(ctx, reader.Build(), "default", "anthropic", "claude-3-5-sonnet")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(price).To(BeNil())
+
+			price, err = Lookup(ctx, reader.Build(), "other", "openai", "gpt-4o")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(price).To(BeNil())
+		})
+	})
+
+	Context("AddUsage", func() {
+		usd := &Price{Currency: "USD", InputPerMillion: 2, OutputPerMillion: 10}
+
+		It("accumulates tokens and cost", func() {
+			summary := AddUsage(nil, kubechainv1alpha1.TokenUsage{InputTokens: 1000, OutputTokens: 100}, usd)
+			summary = AddUsage(summary, kubechainv1alpha1.TokenUsage{InputTokens: 500, OutputTokens: 50}, usd)
+
+			Expect(summary.Turns).To(Equal(int64(2)))
+			Expect(summary.InputTokens).To(Equal(int64(1500)))
+			Expect(summary.OutputTokens).To(Equal(int64(150)))
+			Expect(summary.EstimatedCost).To(Equal("0.004500"))
+			Expect(summary.Currency).To(Equal("USD"))
+			Expect(summary.UnpricedTurns).To(BeZero())
+		})
+
+		It("counts turns without a price or in another currency as unpriced", func() {
+			summary := AddUsage(nil, kubechainv1alpha1.TokenUsage{InputTokens: 1000}, usd)
+			summary = AddUsage(summary, kubechainv1alpha1.TokenUsage{InputTokens: 1000}, nil)
+			summary = AddUsage(summary, kubechainv1alpha1.TokenUsage{InputTokens: 1000}, &Price{Currency: "EUR", InputPerMillion: 1})
+
+			Expect(summary.Turns).To(Equal(int64(3)))
+			Expect(summary.InputTokens).To(Equal(int64(3000)))
+			Expect(summary.EstimatedCost).To(Equal("0.002000"))
+			Expect(summary.UnpricedTurns).To(Equal(int64(2)))
+		})
+	})
+})
+
+func TestPricing(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Pricing Suite")
+}